@@ -0,0 +1,176 @@
+package main
+
+import (
+	"J2PGo/internal"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func bindOptions(flags *flag.FlagSet, options *internal.Options) {
+	flags.BoolVar(&options.ExtractCommon, "common", options.ExtractCommon, "in bundle mode, move definitions shared by several schemas with the same name and shape into common.proto")
+	flags.StringVar(&options.CommonPackage, "common-package", options.CommonPackage, "proto package of the extracted common.proto")
+	flags.StringVar(&options.EnumZeroValue, "enum-zero", options.EnumZeroValue, "name of the zero value prepended to every enum (empty to disable)")
+	flags.BoolVar(&options.EnumAllowAlias, "enum-alias", options.EnumAllowAlias, "alias enum values whose sanitized names collide instead of suffixing them")
+	flags.StringVar(&options.OneofNameTemplate, "oneof-name", options.OneofNameTemplate, "template for oneof group names (_$NAME$_ is the property name)")
+	flags.StringVar(&options.OneofMemberNameTemplate, "oneof-member-name", options.OneofMemberNameTemplate, "template for oneof member names (_$NAME$_ is the property name, _$TYPE$_ the member type)")
+	flags.Var(&choice[internal.InputFormat]{&options.InputFormat, []internal.InputFormat{internal.AUTO_INPUT, internal.JSON_INPUT, internal.YAML_INPUT, internal.AVRO_INPUT, internal.JTD_INPUT, internal.GRAPHQL_INPUT}}, "input-format", "format of the input schemas: auto (avro for .avsc files, json type definition for .jtd.json and .jtd files, graphql sdl for .graphql, .graphqls and .gql files, yaml for .yaml and .yml files or documents not starting with { or [), json, yaml, avro, jtd or graphql")
+	flags.Var(&choice[internal.Draft]{&options.Draft, []internal.Draft{internal.AUTO_DRAFT, internal.DRAFT_04, internal.DRAFT_06, internal.DRAFT_07, internal.DRAFT_2019_09, internal.DRAFT_2020_12}}, "draft", "json schema draft used to read id, exclusiveMinimum/exclusiveMaximum and tuple items: auto (from $schema or structural hints), draft-04, draft-06, draft-07, 2019-09 or 2020-12")
+	flags.Var(&choice[internal.Target]{&options.Target, []internal.Target{internal.PROTO_TARGET, internal.GO_TARGET, internal.AVRO_TARGET, internal.THRIFT_TARGET, internal.FBS_TARGET, internal.CAPNP_TARGET, internal.BIGQUERY_TARGET, internal.PARQUET_TARGET, internal.SQL_TARGET, internal.CONNECT_TARGET, internal.CUE_TARGET, internal.TS_TARGET}}, "target", "generated output: proto (a proto3 file), go (go structs with json tags), avro (an avro schema), thrift (a thrift idl), fbs (a flatbuffers schema), capnp (a cap'n proto schema), bigquery (a bigquery table schema), parquet (a parquet message type), sql (create table statements), connect (a kafka connect schema), cue (cue definitions) or ts (typescript declarations)")
+	flags.StringVar(&options.GoPackage, "go-package", options.GoPackage, "package clause of the generated go file (defaults to the last proto package component, joined with the previous one when it is a version)")
+	flags.StringVar(&options.GoImportPath, "go-import-path", options.GoImportPath, "go import path of the .pb.go files generated with -go-out (defaults to the proto package with dots replaced by slashes)")
+	flags.BoolVar(&options.GoValidateTags, "go-validate", options.GoValidateTags, "add validate:\"required\" tags to go struct fields of required properties")
+	flags.Var(&choice[internal.SqlDialect]{&options.SqlDialect, []internal.SqlDialect{internal.POSTGRES_DIALECT, internal.MYSQL_DIALECT}}, "sql-dialect", "dialect of the statements generated with -target sql: postgres or mysql")
+	flags.Var(&choice[internal.SqlNesting]{&options.SqlNesting, []internal.SqlNesting{internal.JSON_NESTING, internal.TABLE_NESTING}}, "sql-nesting", "how -target sql stores nested objects, arrays and maps: json (json columns of the root tables) or table (a table per object with foreign keys and child tables for arrays and maps)")
+	flags.Var(&choice[internal.InlineNaming]{&options.InlineNaming, []internal.InlineNaming{internal.PROPERTY_NAMING, internal.PATH_NAMING}}, "inline-naming", "naming of messages and enums synthesized from inline schemas: property (the property name) or path (the parent message name followed by the property name)")
+	flags.Var(&choice[internal.Disambiguation]{&options.Disambiguation, []internal.Disambiguation{internal.SUFFIX_DISAMBIGUATION, internal.HASH_DISAMBIGUATION}}, "disambiguation", "how colliding type names that remain after parent prefixing are made unique: suffix (a numeric suffix) or hash (a stable hash of the schema pointer)")
+	flags.Var(&choice[internal.FieldOrder]{&options.FieldOrder, []internal.FieldOrder{internal.LENGTH_ORDER, internal.SCHEMA_ORDER, internal.ALPHABETICAL_ORDER, internal.REQUIRED_FIRST_ORDER}}, "field-order", "field numbering order: length (shortest name first, ties alphabetical), schema (order of appearance in the schema), alphabetical, or required (required properties first, each group in schema order)")
+	flags.Var(&stringList{&options.TemplateFiles}, "template", "template file overriding one or more of the file, header, message, oneof, field and enum templates (repeatable)")
+	flags.Var(&choice[internal.IndentStyle]{&options.IndentStyle, []internal.IndentStyle{internal.SPACE_INDENT, internal.TAB_INDENT}}, "indent", "indentation style: space or tab")
+	flags.IntVar(&options.IndentWidth, "indent-width", options.IndentWidth, "number of spaces per indentation level when -indent is space")
+	flags.Var(&choice[internal.NewlineStyle]{&options.Newline, []internal.NewlineStyle{internal.LF_NEWLINE, internal.CRLF_NEWLINE}}, "newline", "newline style: lf or crlf")
+	flags.BoolVar(&options.Metadata, "metadata", options.Metadata, "emit a header comment with the generator version, source and schema hash")
+	flags.BoolVar(&options.MetadataTimestamp, "metadata-timestamp", options.MetadataTimestamp, "include the generation time in the metadata header")
+	flags.StringVar(&options.Source, "source", options.Source, "schema path or URL recorded in the metadata header (defaults to -in)")
+	flags.StringVar(&options.MergeFile, "merge", options.MergeFile, "existing proto file whose field numbers and reserved ranges are preserved")
+	flags.Var(&choice[internal.Numbering]{&options.Numbering, []internal.Numbering{internal.SEQUENTIAL_NUMBERING, internal.HASH_NUMBERING}}, "numbering", "field number assignment: sequential or hash (derived from a stable hash of the field name)")
+	flags.Var(&rangeList{&options.ReservedRanges}, "reserved", "field number range reserved in every message, e.g. 1000-1999 or 5000-max (repeatable)")
+	flags.Var(&stringList{&options.Roots}, "root", "definition name or json pointer (e.g. #/definitions/Order, #/properties/item or # for the document) used as an entry point instead of the document root (repeatable)")
+	flags.BoolVar(&options.Prune, "prune", options.Prune, "omit definitions that are not transitively referenced from the root messages")
+	flags.BoolVar(&options.OpenApiServices, "openapi-services", options.OpenApiServices, "generate a service from the paths of openapi documents with one rpc per operation, a request message from its parameters and body and a response message from its 2xx schema")
+	flags.BoolVar(&options.AsyncApiServices, "asyncapi-services", options.AsyncApiServices, "generate a service from the channels of asyncapi documents with a server-streaming rpc per subscribe or send operation and a client-streaming rpc per publish or receive operation")
+	flags.BoolVar(&options.FieldBehavior, "field-behavior", options.FieldBehavior, "annotate fields with google.api.field_behavior derived from required, readOnly and writeOnly")
+	flags.Var(&choice[internal.OmitMode]{&options.Omit, []internal.OmitMode{internal.NO_OMIT, internal.READ_ONLY_OMIT, internal.WRITE_ONLY_OMIT}}, "omit", "leave out readOnly or writeOnly properties, reserving their field numbers and names, to generate request-only or response-only messages: none, readOnly or writeOnly")
+	flags.Var(&choice[internal.Validation]{&options.Validation, []internal.Validation{internal.NO_VALIDATION, internal.BUF_VALIDATION, internal.PGV_VALIDATION}}, "validate", "emit validation rules derived from schema keywords as field options: none, buf (buf.validate) or pgv (protoc-gen-validate)")
+	flags.Var(&choice[internal.DefaultMode]{&options.Defaults, []internal.DefaultMode{internal.NO_DEFAULTS, internal.COMMENT_DEFAULTS, internal.OPTION_DEFAULTS}}, "defaults", "how schema default values are carried into the proto: none, comment or option (a (j2p.json_default) field option declared in j2p/options.proto); proto3 has no field defaults, so the value is never applied as one")
+	flags.IntVar(&options.ExampleComments, "example-comments", options.ExampleComments, "append the examples of a property or definition to its comment, truncated to this many characters; 0 disables them")
+	flags.BoolVar(&options.WellKnownTypes, "well-known-types", options.WellKnownTypes, "map refs to well-known schemas (json schema meta-schemas, schema.org dates and times, json-rpc objects, geojson) onto well-known proto types")
+	flags.Var(&importMap{&options.ImportMap}, "import-map", "reuse an existing proto type for a $ref, e.g. #/definitions/Money=google.type.Money:google/type/money.proto (repeatable)")
+	flags.Var(&stringList{&options.UpdateRequests}, "update-request", "definition name or json pointer of a resource for which an Update<Name>Request message with a google.protobuf.FieldMask is generated (repeatable)")
+	flags.StringVar(&options.ImportPrefix, "import-prefix", options.ImportPrefix, "path prefix applied to the imports of generated files, e.g. proto/vendor/j2p")
+	flags.BoolVar(&options.SplitEnums, "split-enums", options.SplitEnums, "place generated enums in a separate enums.proto imported by the message files")
+	flags.StringVar(&options.Envelope, "envelope", options.Envelope, "name of a generated wrapper message with a oneof over every top-level message")
+	flags.BoolVar(&options.Provenance, "provenance", options.Provenance, "append a trailing comment with the source json pointer and original property name to every field")
+}
+
+type choice[T ~string] struct {
+	value   *T
+	choices []T
+}
+
+func (choice *choice[T]) String() string {
+	if choice.value == nil {
+		return ""
+	}
+	return string(*choice.value)
+}
+
+func (choice *choice[T]) Set(value string) error {
+	for _, _choice := range choice.choices {
+		if string(_choice) == value {
+			*choice.value = _choice
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown value %s", value)
+}
+
+type stringList struct {
+	values *[]string
+}
+
+func (list *stringList) String() string {
+	if list.values == nil {
+		return ""
+	}
+	return strings.Join(*list.values, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list.values = append(*list.values, value)
+	return nil
+}
+
+type rangeList struct {
+	values *[]internal.ProtoRange
+}
+
+func (list *rangeList) String() string {
+	if list.values == nil {
+		return ""
+	}
+	output := make([]string, 0)
+	for _, value := range *list.values {
+		output = append(output, value.String())
+	}
+	return strings.Join(output, ",")
+}
+
+func (list *rangeList) Set(value string) error {
+	bounds := strings.SplitN(value, "-", 2)
+	start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return err
+	}
+	end := start
+	if len(bounds) == 2 {
+		if strings.TrimSpace(bounds[1]) == "max" {
+			end = internal.MAX_FIELD_NUMBER
+		} else {
+			end, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if start < 1 || end < start || end > internal.MAX_FIELD_NUMBER {
+		return fmt.Errorf("invalid range %s", value)
+	}
+	*list.values = append(*list.values, internal.ProtoRange{Start: start, End: end})
+	return nil
+}
+
+type importMap struct {
+	values *map[string]internal.ImportMapping
+}
+
+func (importMap *importMap) String() string {
+	if importMap.values == nil {
+		return ""
+	}
+	output := make([]string, 0)
+	for key, value := range *importMap.values {
+		output = append(output, fmt.Sprintf("%s=%s:%s", key, value.Type, value.Import))
+	}
+	return strings.Join(output, ",")
+}
+
+func (importMap *importMap) Set(value string) error {
+	ref, target, ok := strings.Cut(value, "=")
+	if !ok || len(ref) == 0 || len(target) == 0 {
+		return fmt.Errorf("invalid import mapping %s", value)
+	}
+	mapping := internal.ImportMapping{Type: target}
+	if index := strings.LastIndex(target, ":"); index >= 0 {
+		mapping = internal.ImportMapping{Type: target[:index], Import: target[index+1:]}
+	}
+	if *importMap.values == nil {
+		*importMap.values = make(map[string]internal.ImportMapping)
+	}
+	(*importMap.values)[ref] = mapping
+	return nil
+}
+
+func configPath(args []string) string {
+	for index, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name == "config" && index+1 < len(args) {
+			return args[index+1]
+		}
+		if strings.HasPrefix(name, "config=") {
+			return strings.TrimPrefix(name, "config=")
+		}
+	}
+	return ""
+}
@@ -2,28 +2,1091 @@ package main
 
 import (
 	"J2PGo/internal"
-	"bytes"
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
 func main() {
-	file, err := os.ReadFile("test.json")
+	if len(os.Args) > 1 && os.Args[1] == "graph" {
+		writeGraph(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "infer" {
+		writeInferred(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		writeLint(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		writeDiff(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "registry" {
+		writeRegistry(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "push" {
+		writePush(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "nats" {
+		serveNats(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "crd" {
+		writeCrd(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "helm" {
+		writeHelm(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serveHttp(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reverse" {
+		writeReversed(os.Args[2:])
+		return
+	}
+	options := internal.DefaultOptions()
+	if path := configPath(os.Args[1:]); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flag.String("config", "", "json file with generator options; command line flags take precedence")
+	bindOptions(flag.CommandLine, &options)
+	input := flag.String("in", "test.json", "path to the json schema")
+	output := flag.String("out", "test.proto", "path to the generated proto file")
+	packageName := flag.String("package", "test", "proto package name")
+	outputDirectory := flag.String("out-dir", ".", "output directory for bundle mode, where every schema passed as an argument is converted into a package derived from its $id")
+	vendor := flag.Bool("vendor", false, "download every remote $ref once, inline it under $defs and convert the vendored schema")
+	vendorOutput := flag.String("vendor-out", "", "path of the vendored schema written in -vendor mode (defaults to <in>.vendored.json)")
+	lockPath := flag.String("lock", "j2p.lock.json", "lock file recording the source urls and hashes of vendored schemas")
+	manifestPath := flag.String("manifest", "", "path of a json manifest listing every generated type with its schema pointer and every collision rename")
+	sourceMap := flag.Bool("source-map", false, "write a .map.json next to every generated proto linking its messages and fields to the schema pointers they came from")
+	bundle := flag.Bool("bundle", false, "inline every file and remote $ref into a single self-contained proto file")
+	compileCheck := flag.Bool("check", false, "compile the generated proto in-process and exit with an error, reported against the schema pointers of the offending lines, when it does not compile")
+	verify := flag.Bool("verify", false, "compile the generated proto and check that the schema examples and every -verify-sample document map onto its messages through the proto3 json mapping")
+	verifySamples := []string{}
+	flag.Var(&stringList{&verifySamples}, "verify-sample", "json document verified against the root message in -verify mode (repeatable, implies -verify)")
+	goOutput := flag.String("go-out", "", "directory where protoc-gen-go, which must be on PATH, writes the .pb.go files of the generated proto (and protoc-gen-go-grpc the grpc stubs when it is on PATH)")
+	protoPaths := []string{}
+	flag.Var(&stringList{&protoPaths}, "proto-path", "directory searched for the imports of the generated proto when compiling it for -check, -verify or -go-out (repeatable)")
+	embedOutput := flag.String("embed-out", "", "path of a go file embedding the compiled descriptor set of the generated proto and registering it at init time (package from -go-package)")
+	examplesOutput := flag.String("examples-out", "", "directory where a .textproto sample of every message whose schema carries examples, example or default values is written")
+	plugins := []string{}
+	flag.Var(&stringList{&plugins}, "plugin", "name of a j2p-gen-<name> executable on PATH, or path of an executable, that receives the parsed schema as json on stdin and returns the files it generates; name:parameter passes a parameter (repeatable)")
+	combine := flag.Bool("combine", false, "merge every schema passed as an argument into the single -package written to -out, deduplicating identical definitions")
+	flag.Parse()
+	if len(options.Source) == 0 {
+		options.Source = *input
+	}
+
+	if flag.NArg() != 0 && *combine {
+		writeCombined(flag.Args(), *output, *packageName, options)
+		return
+	}
+	if flag.NArg() != 0 {
+		writeBundle(flag.Args(), *outputDirectory, *packageName, *sourceMap, options)
+		return
+	}
+
+	file, documentPackage, err := readSchema(*input, options)
+	if err != nil {
+		panic(err)
+	}
+	if len(documentPackage) != 0 && !isFlagSet(flag.CommandLine, "package") {
+		*packageName = documentPackage
+	}
+	isJsonSchema := !internal.IsAvro(*input, options.InputFormat) && !internal.IsJtd(*input, options.InputFormat) && !internal.IsGraphql(*input, options.InputFormat)
+	if (*vendor || *bundle) && isJsonSchema {
+		lock := &internal.VendorLock{Sources: make(map[string]string)}
+		if *vendor {
+			lock = internal.ReadVendorLock(*lockPath)
+		}
+		file = internal.Vendor(file, *input, *bundle, lock, internal.DefaultFetcher, func(diagnostic internal.Diagnostic) {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		})
+		if *vendor {
+			lock.Write(*lockPath)
+			if len(*vendorOutput) == 0 {
+				*vendorOutput = strings.TrimSuffix(*input, filepath.Ext(*input)) + ".vendored.json"
+			}
+			err = os.WriteFile(*vendorOutput, file, 0644)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	parser, err := newParser(*input, file, options)
+	if err != nil {
+		panic(err)
+	}
+	built := parser.Build(*packageName)
+	if options.SplitEnums {
+		enums := &internal.ProtoFile{Metadata: built.Metadata, Package: built.Package}
+		internal.SplitEnums(built, enums, options.ImportPath(internal.ENUMS_PROTO), func(diagnostic internal.Diagnostic) {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		})
+		if len(enums.Definitions) != 0 {
+			content := internal.Render(internal.NewTemplate(options), enums, options)
+			err = os.WriteFile(filepath.Join(filepath.Dir(*output), internal.ENUMS_PROTO), []byte(content), 0644)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	parsed := internal.RenderTarget(built, options)
+	for _, diagnostic := range parser.Diagnostics() {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	err = os.WriteFile(*output, []byte(parsed), 0644)
+	if err != nil {
+		panic(err)
+	}
+	if internal.UsesJ2pOptions(built) {
+		writeJ2pOptions(filepath.Dir(*output))
+	}
+	if *compileCheck && options.Target == internal.PROTO_TARGET {
+		sourceMap := internal.NewSourceMap(built, parsed, filepath.Base(*output), options.Source)
+		err = internal.CheckProto(filepath.Base(*output), append([]string{filepath.Dir(*output)}, protoPaths...), sourceMap, func(diagnostic internal.Diagnostic) {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if (*verify || len(verifySamples) != 0) && options.Target == internal.PROTO_TARGET {
+		files, err := internal.CompileProto(filepath.Base(*output), append([]string{filepath.Dir(*output)}, protoPaths...))
+		if err != nil {
+			panic(err)
+		}
+		samples := make([]internal.VerifySample, 0)
+		if isJsonSchema {
+			samples = append(samples, internal.SchemaSamples(built, file)...)
+		}
+		if len(verifySamples) != 0 {
+			_samples, err := internal.FileSamples(built, verifySamples)
+			if err != nil {
+				panic(err)
+			}
+			samples = append(samples, _samples...)
+		}
+		failures := internal.VerifyProto(files, samples, func(diagnostic internal.Diagnostic) {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		})
+		if failures != 0 {
+			fmt.Fprintf(os.Stderr, "%d properties of %d samples do not map onto %s\n", failures, len(samples), *output)
+			os.Exit(1)
+		}
+	}
+	if len(*goOutput) != 0 {
+		err = internal.GeneratePbGo(filepath.Base(*output), append([]string{filepath.Dir(*output)}, protoPaths...), *goOutput, options, func(diagnostic internal.Diagnostic) {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(*embedOutput) != 0 && options.Target == internal.PROTO_TARGET {
+		files, err := internal.CompileProto(filepath.Base(*output), append([]string{filepath.Dir(*output)}, protoPaths...))
+		if err != nil {
+			panic(err)
+		}
+		content, err := internal.RenderEmbed(files, built, options)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(*embedOutput, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(*examplesOutput) != 0 && isJsonSchema {
+		err = internal.WriteTextprotos(built, file, filepath.Base(*output), *examplesOutput, options, func(diagnostic internal.Diagnostic) {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	for _, plugin := range plugins {
+		response, err := internal.RunEmitterPlugin(plugin, built, options)
+		if err != nil {
+			panic(err)
+		}
+		for _, diagnostic := range response.Diagnostics {
+			fmt.Fprintln(os.Stderr, diagnostic.String())
+		}
+		err = internal.WritePluginFiles(response, filepath.Dir(*output))
+		if err != nil {
+			panic(err)
+		}
+	}
+	if _parser, ok := parser.(internal.DefaultJsonSchemaParser); ok && len(*manifestPath) != 0 {
+		err = os.WriteFile(*manifestPath, []byte(_parser.Manifest().String()), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if *sourceMap {
+		content := internal.NewSourceMap(built, parsed, filepath.Base(*output), options.Source).String()
+		err = os.WriteFile(internal.SourceMapPath(*output), []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func writeGraph(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	output := flags.String("out", "-", "path of the generated graph (- for standard output)")
+	packageName := flags.String("package", "test", "proto package name")
+	format := internal.DOT_GRAPH
+	flags.Var(&choice[internal.GraphFormat]{&format, []internal.GraphFormat{internal.DOT_GRAPH, internal.JSON_GRAPH}}, "format", "graph format: dot or json")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: j2p graph [flags] schema.json")
+		os.Exit(2)
+	}
+	file, documentPackage, err := readSchema(flags.Arg(0), options)
+	if err != nil {
+		panic(err)
+	}
+	if len(documentPackage) != 0 && !isFlagSet(flags, "package") {
+		*packageName = documentPackage
+	}
+	parser := internal.NewWithOptions(file, options)
+	graph := internal.NewGraph(parser.Build(*packageName))
+	for _, diagnostic := range parser.Diagnostics() {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	content := []byte(graph.DOT())
+	if format == internal.JSON_GRAPH {
+		content = append(internal.EncodeJson(graph), '\n')
+	}
+	if *output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+	err = os.WriteFile(*output, content, 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeInferred(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("infer", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	output := flags.String("out", "test.proto", "path to the generated proto file")
+	packageName := flags.String("package", "test", "proto package name")
+	title := flags.String("title", "", "name of the root message (defaults to the sample file name)")
+	schemaOutput := flags.String("schema-out", "", "path where the inferred json schema is written")
+	ndjson := flags.Bool("ndjson", false, "read the samples as newline-delimited json records (implied by .ndjson and .jsonl files)")
+	limit := flags.Int("limit", 1000, "maximum number of records sampled from each newline-delimited json input; 0 reads all")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: j2p infer [flags] sample.json...")
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+	if len(*title) == 0 && path != "-" {
+		*title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if len(*title) == 0 {
+		*title = "Root"
+	}
+	if len(options.Source) == 0 {
+		options.Source = path
+	}
+	inferrer := internal.NewInferrer(func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	})
+	for _, path := range flags.Args() {
+		if *ndjson || path == "-" || filepath.Ext(path) == ".ndjson" || filepath.Ext(path) == ".jsonl" {
+			err := observeStream(inferrer, path, *limit)
+			if err != nil {
+				panic(err)
+			}
+			continue
+		}
+		sample, err := os.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		err = inferrer.Observe(sample)
+		if err != nil {
+			panic(err)
+		}
+	}
+	schema := inferrer.Schema(*title)
+	if len(*schemaOutput) != 0 {
+		err := os.WriteFile(*schemaOutput, schema, 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	parser := internal.NewWithOptions(schema, options)
+	parsed := internal.Render(internal.NewTemplate(options), parser.Build(*packageName), options)
+	for _, diagnostic := range parser.Diagnostics() {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	err := os.WriteFile(*output, []byte(parsed), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeLint(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	enumLimit := flags.Int("max-enum-values", 256, "number of enum values above which an enum is reported as too large; 0 disables the check")
+	strict := flags.Bool("strict", false, "exit with an error when warnings are reported, not only errors")
+	fix := flags.Bool("fix", false, "apply the safe rewrites for the reported findings to a copy of every schema")
+	fixOutput := flags.String("fix-out", "", "path of the rewritten schema in -fix mode (defaults to <schema>.fixed.json, only valid with a single schema)")
+	format := internal.TEXT_LINT
+	flags.Var(&choice[internal.LintFormat]{&format, []internal.LintFormat{internal.TEXT_LINT, internal.JSON_LINT}}, "format", "report format: text or json")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: j2p lint [flags] schema.json...")
+		os.Exit(2)
+	}
+	failed := false
+	diagnostics := make([]internal.Diagnostic, 0)
+	for _, path := range flags.Args() {
+		if internal.IsAvro(path, options.InputFormat) || internal.IsJtd(path, options.InputFormat) || internal.IsGraphql(path, options.InputFormat) {
+			fmt.Fprintf(os.Stderr, "%s: lint only supports json schema inputs\n", path)
+			os.Exit(2)
+		}
+		file, _, err := readSchema(path, options)
+		if *fix {
+			file, err = readLintSource(path, options)
+		}
+		if err != nil {
+			panic(err)
+		}
+		_diagnostics, fixed, err := internal.LintSchema(file, *enumLimit, *fix)
+		if err != nil {
+			panic(fmt.Errorf("%s: %w", path, err))
+		}
+		if fixed != nil {
+			_path := *fixOutput
+			if len(_path) == 0 || len(flags.Args()) > 1 {
+				_path = strings.TrimSuffix(path, filepath.Ext(path)) + ".fixed.json"
+			}
+			err = os.WriteFile(_path, fixed, 0644)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Fprintf(os.Stderr, "%s: rewritten schema written to %s\n", path, _path)
+		}
+		for _, diagnostic := range _diagnostics {
+			failed = failed || diagnostic.Severity == internal.ERROR || (*strict && diagnostic.Severity == internal.WARNING)
+			if len(flags.Args()) > 1 {
+				diagnostic.Subject = path + diagnostic.Subject
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	if format == internal.JSON_LINT {
+		os.Stdout.Write(append(internal.EncodeJson(diagnostics), '\n'))
+	} else {
+		for _, diagnostic := range diagnostics {
+			fmt.Println(diagnostic.String())
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func writeDiff(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	packageName := flags.String("package", "test", "proto package name used for both schemas")
+	strict := flags.Bool("strict", false, "exit with an error when json or text format breaking changes are reported, not only wire breaking changes")
+	format := internal.TEXT_LINT
+	flags.Var(&choice[internal.LintFormat]{&format, []internal.LintFormat{internal.TEXT_LINT, internal.JSON_LINT}}, "format", "report format: text or json")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: j2p diff [flags] old.json|old.proto new.json")
+		os.Exit(2)
+	}
+	files := make([]*internal.ProtoFile, 0)
+	for _, path := range flags.Args() {
+		file, err := readDiffSide(path, *packageName, isFlagSet(flags, "package"), options)
+		if err != nil {
+			panic(err)
+		}
+		files = append(files, file)
+	}
+	failed := false
+	diagnostics := make([]internal.Diagnostic, 0)
+	internal.DiffProtos(files[0], files[1], func(diagnostic internal.Diagnostic) {
+		failed = failed || diagnostic.Severity == internal.ERROR || (*strict && diagnostic.Severity == internal.WARNING)
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	if format == internal.JSON_LINT {
+		os.Stdout.Write(append(internal.EncodeJson(diagnostics), '\n'))
+	} else {
+		for _, diagnostic := range diagnostics {
+			fmt.Println(diagnostic.String())
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readDiffSide(path string, packageName string, pinned bool, options internal.Options) (*internal.ProtoFile, error) {
+	if filepath.Ext(path) == ".proto" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return internal.ParseProto(data), nil
+	}
+	file, documentPackage, err := readSchema(path, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(documentPackage) != 0 && !pinned {
+		packageName = documentPackage
+	}
+	parser, err := newParser(path, file, options)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Build(packageName), nil
+}
+
+func writeRegistry(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("registry", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	registryUrl := flags.String("url", envOr("SCHEMA_REGISTRY_URL", "http://localhost:8081"), "schema registry url (defaults to $SCHEMA_REGISTRY_URL)")
+	username := flags.String("user", os.Getenv("SCHEMA_REGISTRY_USER"), "basic auth user of the schema registry (defaults to $SCHEMA_REGISTRY_USER)")
+	password := flags.String("password", os.Getenv("SCHEMA_REGISTRY_PASSWORD"), "basic auth password of the schema registry (defaults to $SCHEMA_REGISTRY_PASSWORD)")
+	version := flags.String("version", "latest", "version of the subject to convert")
+	output := flags.String("out", "test.proto", "path to the generated proto file")
+	packageName := flags.String("package", "test", "proto package name")
+	register := flags.String("register", "", "subject the generated protobuf schema is registered under, after checking it against the compatibility level of the subject")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: j2p registry [flags] subject")
+		os.Exit(2)
+	}
+	diagnosticHandler := func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	client := internal.NewRegistryClient(*registryUrl, *username, *password)
+	schema, err := client.Schema(flags.Arg(0), *version)
+	if err != nil {
+		panic(err)
+	}
+	file, err := client.Resolve(schema, diagnosticHandler)
+	if err != nil {
+		panic(err)
+	}
+	file, documentPackage, err := internal.PrepareSchema("", file, options, diagnosticHandler)
+	if err != nil {
+		panic(err)
+	}
+	if len(documentPackage) != 0 && !isFlagSet(flags, "package") {
+		*packageName = documentPackage
+	}
+	options.Source = fmt.Sprintf("%s/subjects/%s/versions/%d", client.Url, schema.Subject, schema.Version)
+	parser := internal.NewWithOptions(file, options)
+	built := parser.Build(*packageName)
+	for _, diagnostic := range parser.Diagnostics() {
+		diagnosticHandler(diagnostic)
+	}
+	content := internal.Render(internal.NewTemplate(options), built, options)
+	err = os.WriteFile(*output, []byte(content), 0644)
+	if err != nil {
+		panic(err)
+	}
+	if len(*register) == 0 {
+		return
+	}
+	if internal.UsesJ2pOptions(built) {
+		fmt.Fprintf(os.Stderr, "%s imports %s, which is not registered; use -defaults comment to register it\n", *output, internal.J2P_OPTIONS_PROTO)
+		os.Exit(1)
+	}
+	protobuf := internal.RegistrySchema{SchemaType: "PROTOBUF", Schema: content}
+	level, err := client.Compatibility(*register)
+	if err != nil {
+		panic(err)
+	}
+	compatible, messages, err := client.CheckCompatibility(*register, protobuf)
+	if err != nil {
+		panic(err)
+	}
+	if !compatible {
+		for _, message := range messages {
+			fmt.Fprintln(os.Stderr, message)
+		}
+		fmt.Fprintf(os.Stderr, "%s is not %s compatible with the latest version of %s\n", *output, level, *register)
+		os.Exit(1)
+	}
+	id, err := client.Register(*register, protobuf)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "registered %s under %s (%s compatibility) with id %d\n", *output, *register, level, id)
+}
+
+func writePush(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("push", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	module := flags.String("module", "", "buf schema registry module the generated protos are pushed to, e.g. buf.build/org/module")
+	packageName := flags.String("package", "test", "proto package name of the schemas without a $id")
+	outputDirectory := flags.String("out-dir", "", "directory where the module is assembled (defaults to a temporary directory removed after the push)")
+	buf := flags.String("buf", "buf", "buf executable used to push the module")
+	labels := []string{}
+	flags.Var(&stringList{&labels}, "label", "label attached to the pushed commit (repeatable)")
+	dryRun := flags.Bool("dry-run", false, "assemble the module without pushing it")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() == 0 || len(*module) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: j2p push -module buf.build/org/module [flags] schema.json...")
+		os.Exit(2)
+	}
+	directory := *outputDirectory
+	if len(directory) == 0 {
+		temporary, err := os.MkdirTemp("", "j2p-push-")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(temporary)
+		directory = temporary
+	}
+	schemas := make([][]byte, 0)
+	for _, path := range flags.Args() {
+		file, _, err := readSchema(path, options)
+		if err != nil {
+			panic(err)
+		}
+		schemas = append(schemas, file)
+	}
+	bundle := internal.NewBundle(schemas, *packageName, options)
+	files := bundle.Parse()
+	for _, diagnostic := range bundle.Diagnostics() {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	dependencies := internal.BufDependencies(files)
+	files["buf.yaml"] = internal.BufYaml(*module, dependencies)
+	for path, content := range files {
+		path = filepath.Join(directory, path)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "module %s assembled in %s\n", *module, directory)
+		return
+	}
+	if len(dependencies) != 0 {
+		err := internal.RunBuf(*buf, directory, "dep", "update")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	pushArgs := []string{"push"}
+	for _, label := range labels {
+		pushArgs = append(pushArgs, "--label", label)
+	}
+	err := internal.RunBuf(*buf, directory, pushArgs...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serveNats(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("nats", flag.ExitOnError)
+	flags.String("config", "", "json file with the default generator options of every request; command line flags take precedence")
+	server := flags.String("server", envOr("NATS_URL", nats.DefaultURL), "nats server url (defaults to $NATS_URL)")
+	subject := flags.String("subject", "j2p.convert", "subject on which conversion requests are received")
+	queue := flags.String("queue", "j2p", "queue group shared by the j2p instances serving the subject")
+	credentials := flags.String("creds", os.Getenv("NATS_CREDS"), "nats credentials file (defaults to $NATS_CREDS)")
+	reflectionAddress := flags.String("reflection", "", "address on which the descriptors of converted schemas are served over grpc server reflection (e.g. :9090)")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	var store *internal.DescriptorStore
+	if len(*reflectionAddress) != 0 {
+		store = internal.NewDescriptorStore()
+		reflectionServer := serveReflection(*reflectionAddress, store)
+		defer reflectionServer.GracefulStop()
+	}
+	natsOptions := []nats.Option{nats.Name("j2p " + internal.VERSION), nats.MaxReconnects(-1)}
+	if len(*credentials) != 0 {
+		natsOptions = append(natsOptions, nats.UserCredentials(*credentials))
+	}
+	connection, err := nats.Connect(*server, natsOptions...)
+	if err != nil {
+		panic(err)
+	}
+	_, err = connection.QueueSubscribe(*subject, *queue, func(message *nats.Msg) {
+		err := message.Respond(internal.ConvertMessage(message.Data, options, store))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
 	if err != nil {
 		panic(err)
 	}
-	parser := internal.New(file)
-	parsed := parser.Parse("test")
-	var buffer bytes.Buffer
-	for _, value := range parsed {
-		str := strings.Split(value, "\n")
-		for _, line := range str {
-			if len(line) != 0 {
-				buffer.WriteString(line)
-				buffer.WriteString("\r\n")
+	fmt.Fprintf(os.Stderr, "serving conversions on %s (queue %s) at %s\n", *subject, *queue, connection.ConnectedUrl())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	err = connection.Drain()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func envOr(name string, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok && len(value) != 0 {
+		return value
+	}
+	return fallback
+}
+
+func readLintSource(path string, options internal.Options) ([]byte, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err = internal.ToJsonSchema(path, file, options.InputFormat)
+	if err != nil {
+		return nil, err
+	}
+	if internal.IsOpenApi(file) || internal.IsSwagger(file) || internal.IsAsyncApi(file) {
+		return nil, fmt.Errorf("%s: -fix only rewrites json schema documents", path)
+	}
+	return file, nil
+}
+
+func writeReversed(args []string) {
+	flags := flag.NewFlagSet("reverse", flag.ExitOnError)
+	output := flags.String("out", "-", "path of the generated json schema (- for standard output)")
+	id := flags.String("id", "", "$id of the generated json schema")
+	root := flags.String("root", "", "message referenced by the root of the generated json schema")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: j2p reverse [flags] file.proto|descriptor-set.pb...")
+		os.Exit(2)
+	}
+	files := make([]*internal.ProtoFile, 0)
+	for _, path := range flags.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		if internal.IsDescriptorSet(path) {
+			set, err := internal.ReadDescriptorSet(data)
+			if err != nil {
+				panic(fmt.Errorf("%s: %w", path, err))
 			}
+			files = append(files, set...)
+			continue
+		}
+		files = append(files, internal.ParseProto(data))
+	}
+	reverser := internal.NewProtoReverser(files, func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	})
+	content := append(reverser.Schema(*id, *root), '\n')
+	if *output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+	err := os.WriteFile(*output, content, 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func observeStream(inferrer *internal.Inferrer, path string, limit int) error {
+	reader := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
 		}
+		defer file.Close()
+		reader = file
+	}
+	_, err := inferrer.ObserveStream(bufio.NewReader(reader), limit)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
 	}
+	return nil
+}
 
-	os.WriteFile("test.proto", buffer.Bytes(), 0)
+func newParser(path string, file []byte, options internal.Options) (internal.ProtoBuilder, error) {
+	switch {
+	case internal.IsAvro(path, options.InputFormat):
+		{
+			return internal.NewAvroParser(file, options)
+		}
+	case internal.IsJtd(path, options.InputFormat):
+		{
+			stem := filepath.Base(path)
+			for _, extension := range []string{".json", ".jtd", ".yaml", ".yml"} {
+				stem = strings.TrimSuffix(stem, extension)
+			}
+			return internal.NewJtdParser(file, stem, options)
+		}
+	case internal.IsGraphql(path, options.InputFormat):
+		{
+			return internal.NewGraphqlParser(file, options)
+		}
+	}
+	return internal.NewWithOptions(file, options), nil
+}
+
+func readSchema(path string, options internal.Options) ([]byte, string, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return internal.PrepareSchema(path, file, options, func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	})
+}
+
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	output := false
+	flags.Visit(func(value *flag.Flag) {
+		output = output || value.Name == name
+	})
+	return output
+}
+
+func writeCombined(paths []string, output string, packageName string, options internal.Options) {
+	schemas := make([][]byte, 0)
+	for _, path := range paths {
+		file, _, err := readSchema(path, options)
+		if err != nil {
+			panic(err)
+		}
+		schemas = append(schemas, file)
+	}
+	bundle := internal.NewBundle(schemas, packageName, options)
+	file := bundle.Combine(packageName)
+	for _, diagnostic := range bundle.Diagnostics() {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	err := os.WriteFile(output, []byte(internal.Render(internal.NewTemplate(options), file, options)), 0644)
+	if err != nil {
+		panic(err)
+	}
+	if internal.UsesJ2pOptions(file) {
+		writeJ2pOptions(filepath.Dir(output))
+	}
+}
+
+func writeJ2pOptions(outputDirectory string) {
+	path := filepath.Join(outputDirectory, filepath.FromSlash(internal.J2P_OPTIONS_PROTO))
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(path, []byte(internal.J2P_OPTIONS), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeBundle(paths []string, outputDirectory string, packageName string, sourceMap bool, options internal.Options) {
+	schemas := make([][]byte, 0)
+	for _, path := range paths {
+		file, _, err := readSchema(path, options)
+		if err != nil {
+			panic(err)
+		}
+		schemas = append(schemas, file)
+	}
+	bundle := internal.NewBundle(schemas, packageName, options)
+	for index, document := range bundle.Documents {
+		document.Source = paths[index]
+	}
+	files := bundle.Parse()
+	for _, diagnostic := range bundle.Diagnostics() {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	for path, content := range files {
+		path = filepath.Join(outputDirectory, path)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if !sourceMap {
+		return
+	}
+	for _, document := range bundle.Documents {
+		content := internal.NewSourceMap(bundle.Files[document.Path], files[document.Path], document.Path, document.Source).String()
+		err := os.WriteFile(internal.SourceMapPath(filepath.Join(outputDirectory, document.Path)), []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func writeCrd(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("crd", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	outputDirectory := flags.String("out-dir", ".", "output directory where the proto of every version is written under the directory of its package")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: j2p crd [flags] crd.yaml")
+		os.Exit(1)
+	}
+	diagnosticHandler := func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	file, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		panic(err)
+	}
+	file, err = internal.ToJsonSchema(flags.Arg(0), file, options.InputFormat)
+	if err != nil {
+		panic(err)
+	}
+	if !internal.IsCrd(file) {
+		fmt.Fprintf(os.Stderr, "%s is not a custom resource definition\n", flags.Arg(0))
+		os.Exit(1)
+	}
+	versions, err := internal.FromCrd(file, options.WellKnownTypes, diagnosticHandler)
+	if err != nil {
+		panic(err)
+	}
+	if len(options.Source) == 0 {
+		options.Source = flags.Arg(0)
+	}
+	for _, version := range versions {
+		built, err := internal.ConvertSchema(version.Schema, version.Package, options, diagnosticHandler)
+		if err != nil {
+			panic(err)
+		}
+		_, directory, stem := internal.PackageFromID(version.ID)
+		output := filepath.Join(*outputDirectory, filepath.FromSlash(directory), stem+".proto")
+		err = os.MkdirAll(filepath.Dir(output), 0755)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(output, []byte(internal.RenderTarget(built, options)), 0644)
+		if err != nil {
+			panic(err)
+		}
+		if internal.UsesJ2pOptions(built) {
+			writeJ2pOptions(*outputDirectory)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", version.Name, output)
+	}
+}
+
+func writeHelm(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("helm", flag.ExitOnError)
+	flags.String("config", "", "json file with generator options; command line flags take precedence")
+	outputDirectory := flags.String("out-dir", ".", "output directory where the values proto of every chart is written under the directory of its package")
+	prefix := flags.String("package-prefix", "helm", "package prefix of the generated protos, followed by the chart name and the major chart version")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: j2p helm [flags] chart-or-charts-directory...")
+		os.Exit(1)
+	}
+	diagnosticHandler := func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	seen := make(map[string]string)
+	for _, root := range flags.Args() {
+		charts, err := internal.FindHelmCharts(root, *prefix, diagnosticHandler)
+		if err != nil {
+			panic(err)
+		}
+		for _, chart := range charts {
+			if directory, ok := seen[chart.Path]; ok {
+				if directory == chart.Directory {
+					continue
+				}
+				diagnosticHandler(internal.Diagnostic{Severity: internal.ERROR, Subject: chart.Directory, Message: fmt.Sprintf("chart %s %s maps onto %s like %s and was skipped", chart.Name, chart.Version, chart.Package, directory)})
+				continue
+			}
+			seen[chart.Path] = chart.Directory
+			_options := options
+			if len(_options.Source) == 0 {
+				_options.Source = filepath.Join(chart.Directory, internal.HELM_SCHEMA_FILE)
+			}
+			built, err := internal.ConvertSchema(chart.Schema, chart.Package, _options, diagnosticHandler)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", chart.Directory, err)
+				os.Exit(1)
+			}
+			output := filepath.Join(*outputDirectory, filepath.FromSlash(chart.Path))
+			err = os.MkdirAll(filepath.Dir(output), 0755)
+			if err != nil {
+				panic(err)
+			}
+			err = os.WriteFile(output, []byte(internal.RenderTarget(built, _options)), 0644)
+			if err != nil {
+				panic(err)
+			}
+			if internal.UsesJ2pOptions(built) {
+				writeJ2pOptions(*outputDirectory)
+			}
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", chart.Name, chart.Version, output)
+		}
+	}
+}
+
+func serveHttp(args []string) {
+	options := internal.DefaultOptions()
+	if path := configPath(args); len(path) != 0 {
+		internal.LoadOptions(path, &options)
+	}
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.String("config", "", "json file with the default generator options of every request; command line flags take precedence")
+	address := flags.String("listen", ":8080", "address on which GET /convert?url=...&format=proto|descriptor|zip is served")
+	reflectionAddress := flags.String("reflection", "", "address on which the descriptors of converted schemas are served over grpc server reflection (e.g. :9090)")
+	allowPrivate := flags.Bool("allow-private", false, "allow urls resolving to loopback, private or link-local addresses; only enable this on trusted networks")
+	bindOptions(flags, &options)
+	flags.Parse(args)
+	var store *internal.DescriptorStore
+	if len(*reflectionAddress) != 0 {
+		store = internal.NewDescriptorStore()
+		reflectionServer := serveReflection(*reflectionAddress, store)
+		defer reflectionServer.GracefulStop()
+	}
+	mux := http.NewServeMux()
+	handler := internal.NewConvertHandler(options, store)
+	if *allowPrivate {
+		handler.Fetch = internal.HttpFetcher
+	}
+	mux.Handle("/convert", handler)
+	server := http.Server{Handler: mux}
+	listener, err := net.Listen("tcp", *address)
+	if err != nil {
+		panic(err)
+	}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "serving conversions at http://%s/convert\n", listener.Addr())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	err = server.Shutdown(context.Background())
+	if err != nil {
+		panic(err)
+	}
+}
+
+type reflectionServices struct {
+	server *grpc.Server
+	store  *internal.DescriptorStore
+}
+
+func (rcvr reflectionServices) GetServiceInfo() map[string]grpc.ServiceInfo {
+	output := rcvr.server.GetServiceInfo()
+	for _, service := range rcvr.store.Services() {
+		output[service] = grpc.ServiceInfo{}
+	}
+	return output
+}
+
+func serveReflection(address string, store *internal.DescriptorStore) *grpc.Server {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		panic(err)
+	}
+	server := grpc.NewServer()
+	reflectionOptions := reflection.ServerOptions{Services: reflectionServices{server, store}, DescriptorResolver: store, ExtensionResolver: store}
+	reflectionv1.RegisterServerReflectionServer(server, reflection.NewServerV1(reflectionOptions))
+	reflectionv1alpha.RegisterServerReflectionServer(server, reflection.NewServer(reflectionOptions))
+	go func() {
+		err := server.Serve(listener)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "serving grpc server reflection at %s\n", listener.Addr())
+	return server
 }
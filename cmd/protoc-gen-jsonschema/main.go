@@ -0,0 +1,51 @@
+package main
+
+import (
+	"J2PGo/internal"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "--version" {
+		fmt.Fprintln(stdout, internal.VERSION)
+		return nil
+	}
+	if len(args) > 0 && args[0] == "--dockerfile" {
+		fmt.Fprint(stdout, internal.BUF_PLUGIN_DOCKERFILE)
+		return nil
+	}
+	if len(args) > 1 && args[0] == "--buf-plugin-yaml" {
+		fmt.Fprint(stdout, internal.BufPluginYaml(args[1], internal.VERSION))
+		return nil
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return err
+	}
+	request := pluginpb.CodeGeneratorRequest{}
+	err = proto.Unmarshal(data, &request)
+	if err != nil {
+		return err
+	}
+	response := internal.GenerateJsonSchemas(&request, func(diagnostic internal.Diagnostic) {
+		fmt.Fprintln(stderr, diagnostic.String())
+	})
+	data, err = proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(data)
+	return err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"J2PGo/internal"
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const TEST_PROTO = `syntax = "proto3";
+
+package acme.orders.v1;
+
+message Order {
+  string id = 1;
+  repeated string tags = 2;
+}
+`
+
+func TestRun(t *testing.T) {
+	files, err := internal.CompileSource("acme/orders.proto", TEST_PROTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		parameter string
+		files     string
+		error     string
+	}{
+		{"id_prefix=https://acme.com", "acme/orders.schema.json", ""},
+		{"pretty", "", "unknown parameter pretty, expected root, id_prefix, lint or strict"},
+	}
+	for _, test := range tests {
+		data, err := proto.Marshal(internal.NewCodeGeneratorRequest(files, test.parameter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		if err := run(nil, bytes.NewReader(data), &stdout, &stderr); err != nil {
+			t.Fatal(err)
+		}
+		response := pluginpb.CodeGeneratorResponse{}
+		if err := proto.Unmarshal(stdout.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0)
+		for _, file := range response.File {
+			names = append(names, file.GetName())
+		}
+		if strings.Join(names, " ") != test.files || response.GetError() != test.error {
+			t.Fatalf("%s: expected %q and the error %q, got %q and %q", test.parameter, test.files, test.error, names, response.GetError())
+		}
+		if len(response.File) != 0 && !strings.Contains(response.File[0].GetContent(), `"$id": "https://acme.com/acme/orders.schema.json"`) {
+			t.Fatalf("unexpected schema:\n%s", response.File[0].GetContent())
+		}
+	}
+	if err := run(nil, strings.NewReader("not a request"), &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error for a malformed request")
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--version"}, internal.VERSION + "\n"},
+		{[]string{"--dockerfile"}, internal.BUF_PLUGIN_DOCKERFILE},
+		{[]string{"--buf-plugin-yaml", "buf.build/acme/jsonschema"}, internal.BufPluginYaml("buf.build/acme/jsonschema", internal.VERSION)},
+	}
+	for _, test := range tests {
+		var stdout bytes.Buffer
+		if err := run(test.args, strings.NewReader(""), &stdout, &bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != test.expected {
+			t.Fatalf("%v: expected %q, got %q", test.args, test.expected, stdout.String())
+		}
+	}
+}
+
+func TestRunStrict(t *testing.T) {
+	files, err := internal.CompileSource("acme/orders.proto", strings.Replace(TEST_PROTO, "repeated string tags = 2;", "uint64 total = 2;", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(internal.NewCodeGeneratorRequest(files, "strict"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := run(nil, bytes.NewReader(data), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	response := pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(stdout.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.GetError() != "acme/orders.proto: 2 lint failures" || !strings.Contains(stderr.String(), "error: acme/orders.proto: #/$defs/Order/properties/total/type:") {
+		t.Fatalf("unexpected response %q with the diagnostics:\n%s", response.GetError(), stderr.String())
+	}
+}
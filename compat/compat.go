@@ -0,0 +1,51 @@
+package compat
+
+import (
+	"J2PGo/internal"
+	"fmt"
+)
+
+type Severity string
+
+const (
+	WIRE_BREAKING Severity = "error"
+	JSON_BREAKING Severity = "warning"
+	COMPATIBLE    Severity = "info"
+)
+
+const PACKAGE = "compat"
+
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Subject  string   `json:"subject"`
+	Message  string   `json:"message"`
+}
+
+func (issue Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", issue.Severity, issue.Subject, issue.Message)
+}
+
+func Check(old []byte, new []byte) ([]Issue, error) {
+	oldFile, err := internal.ConvertSchema(old, PACKAGE, internal.DefaultOptions(), func(diagnostic internal.Diagnostic) {})
+	if err != nil {
+		return nil, fmt.Errorf("old schema: %w", err)
+	}
+	newFile, err := internal.ConvertSchema(new, PACKAGE, internal.DefaultOptions(), func(diagnostic internal.Diagnostic) {})
+	if err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+	output := make([]Issue, 0)
+	internal.DiffProtos(oldFile, newFile, func(diagnostic internal.Diagnostic) {
+		output = append(output, Issue{Severity: Severity(diagnostic.Severity), Subject: diagnostic.Subject, Message: diagnostic.Message})
+	})
+	return output, nil
+}
+
+func Breaking(issues []Issue, json bool) bool {
+	for _, issue := range issues {
+		if issue.Severity == WIRE_BREAKING || (json && issue.Severity == JSON_BREAKING) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package compat
+
+import (
+	"testing"
+)
+
+const OLD_SCHEMA = `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}, "note": {"type": "string"}}}`
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		new      string
+		wire     bool
+		json     bool
+		expected Issue
+	}{
+		{"added", `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}, "note": {"type": "string"}, "total": {"type": "number"}}}`, false, false, Issue{COMPATIBLE, "Order.total", "field 3 was added"}},
+		{"removed", `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}}}`, true, true, Issue{WIRE_BREAKING, "Order.note", "field 2 was removed without reserving its number"}},
+		{"inserted", `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}, "memo": {"type": "string"}, "note": {"type": "string"}}}`, true, true, Issue{WIRE_BREAKING, "Order.note", "field was renumbered from 2 to 3"}},
+		{"retyped", `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}, "note": {"type": "boolean"}}}`, true, true, Issue{WIRE_BREAKING, "Order.note", "type changed from string to bool"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues, err := Check([]byte(OLD_SCHEMA), []byte(test.new))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) == 0 || issues[0] != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, issues)
+			}
+			if Breaking(issues, false) != test.wire || Breaking(issues, true) != test.json {
+				t.Fatalf("unexpected breaking %v", issues)
+			}
+		})
+	}
+	if _, err := Check([]byte(OLD_SCHEMA), []byte("{")); err == nil {
+		t.Fatal("expected an error for an invalid new schema")
+	}
+}
+
+func TestBreaking(t *testing.T) {
+	issues := []Issue{{JSON_BREAKING, "Order.note", "json name changed from note to memo"}}
+	if Breaking(issues, false) || !Breaking(issues, true) {
+		t.Fatalf("json breaking issues only break with json, got %v", issues)
+	}
+}
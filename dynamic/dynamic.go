@@ -0,0 +1,101 @@
+package dynamic
+
+import (
+	"J2PGo/internal"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const FILE = "dynamic.proto"
+
+type Schema struct {
+	File     protoreflect.FileDescriptor
+	Root     protoreflect.MessageDescriptor
+	Types    *dynamicpb.Types
+	messages map[string]*internal.ProtoMessage
+	enums    map[string]*internal.ProtoEnum
+}
+
+func New(schema []byte, packageName string) (*Schema, error) {
+	options := internal.DefaultOptions()
+	file, err := internal.ConvertSchema(schema, packageName, options, func(diagnostic internal.Diagnostic) {})
+	if err != nil {
+		return nil, err
+	}
+	files, err := internal.CompileSource(FILE, internal.Render(internal.NewTemplate(options), file, options))
+	if err != nil {
+		return nil, err
+	}
+	registry := new(protoregistry.Files)
+	var register func(file protoreflect.FileDescriptor) error
+	register = func(file protoreflect.FileDescriptor) error {
+		if _, err := registry.FindFileByPath(file.Path()); err == nil {
+			return nil
+		}
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			if err := register(imports.Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		return registry.RegisterFile(file)
+	}
+	err = register(files[0])
+	if err != nil {
+		return nil, err
+	}
+	output := Schema{File: files[0], Types: dynamicpb.NewTypes(registry), messages: make(map[string]*internal.ProtoMessage), enums: make(map[string]*internal.ProtoEnum)}
+	for _, definition := range file.Definitions {
+		if definition.Message != nil {
+			output.messages[definition.Message.Name] = definition.Message
+			continue
+		}
+		output.enums[definition.Enum.Name] = definition.Enum
+	}
+	if root := internal.RootMessage(file); len(root) != 0 {
+		output.Root = files[0].Messages().ByName(protoreflect.Name(root))
+	}
+	return &output, nil
+}
+
+func (schema *Schema) Descriptor(name string) (protoreflect.MessageDescriptor, error) {
+	if len(name) == 0 {
+		if schema.Root == nil {
+			return nil, fmt.Errorf("schema has no root message")
+		}
+		return schema.Root, nil
+	}
+	descriptor := schema.File.Messages().ByName(protoreflect.Name(name))
+	if descriptor == nil {
+		return nil, fmt.Errorf("message %s is not defined by the schema", name)
+	}
+	return descriptor, nil
+}
+
+func (schema *Schema) NewMessage(name string) (*dynamicpb.Message, error) {
+	descriptor, err := schema.Descriptor(name)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicpb.NewMessage(descriptor), nil
+}
+
+func (schema *Schema) UnmarshalJson(name string, data []byte) (*dynamicpb.Message, error) {
+	message, err := schema.NewMessage(name)
+	if err != nil {
+		return nil, err
+	}
+	err = protojson.UnmarshalOptions{Resolver: schema.Types}.Unmarshal(data, message)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (schema *Schema) MarshalJson(message *dynamicpb.Message) ([]byte, error) {
+	return protojson.MarshalOptions{Resolver: schema.Types}.Marshal(message)
+}
@@ -0,0 +1,55 @@
+package dynamic
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const ORDER_SCHEMA = `{"title": "Order", "type": "object", "properties": {
+	"orderId": {"type": "string"},
+	"status": {"type": "string", "enum": ["new", "in-progress"]},
+	"quantity": {"type": "integer"},
+	"price": {"type": "number"},
+	"tags": {"type": "array", "items": {"type": "string"}},
+	"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+	"payment": {"oneOf": [{"type": "string"}, {"type": "object", "title": "Card", "properties": {"last4": {"type": "string"}}}]},
+	"shipping": {"type": "object", "properties": {"city": {"type": "string"}}}
+}}`
+
+func TestNew(t *testing.T) {
+	schema, err := New([]byte(ORDER_SCHEMA), "acme.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Root == nil || schema.Root.FullName() != "acme.v1.Order" {
+		t.Fatalf("unexpected root %v", schema.Root)
+	}
+	descriptor, err := schema.Descriptor("Shipping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field := descriptor.Fields().ByName("city"); field == nil || field.Kind() != protoreflect.StringKind {
+		t.Fatalf("unexpected shipping fields %v", descriptor.Fields())
+	}
+	if _, err := schema.Descriptor("Missing"); err == nil {
+		t.Fatal("a missing message was resolved")
+	}
+	message, err := schema.UnmarshalJson("", []byte(`{"orderId": "o-1", "quantity": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Get(schema.Root.Fields().ByName("quantity")).Int() != 3 {
+		t.Fatalf("unexpected message %v", message)
+	}
+	data, err := schema.MarshalJson(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schema.UnmarshalJson("", data); err != nil {
+		t.Fatalf("the marshalled message %s does not unmarshal: %v", data, err)
+	}
+	if _, err := New([]byte(`{"type": "object"`), "acme.v1"); err == nil {
+		t.Fatal("a truncated schema was accepted")
+	}
+}
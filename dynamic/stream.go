@@ -0,0 +1,157 @@
+package dynamic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const MAX_RECORD_SIZE = 4 << 20
+
+type Direction string
+
+const (
+	JSON_TO_PROTO Direction = "json-to-proto"
+	PROTO_TO_JSON Direction = "proto-to-json"
+)
+
+type Stream struct {
+	transcoder    *Transcoder
+	direction     Direction
+	MaxRecordSize int
+	Source        io.Reader
+	Sink          io.Writer
+	Records       int64
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (transcoder *Transcoder) Stream(direction Direction) *Stream {
+	return &Stream{transcoder: transcoder, direction: direction, MaxRecordSize: MAX_RECORD_SIZE}
+}
+
+func (stream *Stream) ReadFrom(reader io.Reader) (int64, error) {
+	if stream.Sink == nil {
+		return 0, fmt.Errorf("stream has no sink to write the transcoded records to")
+	}
+	source := &countingReader{reader: reader}
+	err := stream.copy(source, stream.Sink)
+	return source.count, err
+}
+
+func (stream *Stream) WriteTo(writer io.Writer) (int64, error) {
+	if stream.Source == nil {
+		return 0, fmt.Errorf("stream has no source to read the records from")
+	}
+	sink := &countingWriter{writer: writer}
+	err := stream.copy(stream.Source, sink)
+	return sink.count, err
+}
+
+func (stream *Stream) copy(reader io.Reader, writer io.Writer) error {
+	input := bufio.NewReader(reader)
+	output := bufio.NewWriter(writer)
+	buffer := make([]byte, 0, 4096)
+	for {
+		record, err := stream.next(input, buffer[:0])
+		if err == io.EOF {
+			return output.Flush()
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", stream.Records+1, err)
+		}
+		buffer = record[:0]
+		if stream.direction == JSON_TO_PROTO && len(bytes.TrimSpace(record)) == 0 {
+			continue
+		}
+		err = stream.write(output, record)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", stream.Records+1, err)
+		}
+		stream.Records++
+	}
+}
+
+func (stream *Stream) next(input *bufio.Reader, buffer []byte) ([]byte, error) {
+	if stream.direction == PROTO_TO_JSON {
+		size, err := binary.ReadUvarint(input)
+		if err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("truncated length prefix")
+			}
+			return nil, err
+		}
+		if size > uint64(stream.MaxRecordSize) {
+			return nil, fmt.Errorf("record of %d bytes exceeds the %d bytes limit", size, stream.MaxRecordSize)
+		}
+		if uint64(cap(buffer)) < size {
+			buffer = make([]byte, size)
+		}
+		buffer = buffer[:size]
+		_, err = io.ReadFull(input, buffer)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return buffer, err
+	}
+	for {
+		line, err := input.ReadSlice('\n')
+		buffer = append(buffer, line...)
+		if len(buffer) > stream.MaxRecordSize {
+			return nil, fmt.Errorf("record exceeds the %d bytes limit", stream.MaxRecordSize)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err == io.EOF && len(buffer) != 0 {
+			return buffer, nil
+		}
+		return buffer, err
+	}
+}
+
+func (stream *Stream) write(output io.Writer, record []byte) error {
+	if stream.direction == PROTO_TO_JSON {
+		data, err := stream.transcoder.ToJson(record)
+		if err != nil {
+			return err
+		}
+		_, err = output.Write(append(data, '\n'))
+		return err
+	}
+	data, err := stream.transcoder.ToProto(record)
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(protowire.AppendVarint(nil, uint64(len(data))))
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(data)
+	return err
+}
+
+func (writer *countingWriter) Write(data []byte) (int, error) {
+	count, err := writer.writer.Write(data)
+	writer.count += int64(count)
+	return count, err
+}
+
+func (reader *countingReader) Read(data []byte) (int, error) {
+	count, err := reader.reader.Read(data)
+	reader.count += int64(count)
+	return count, err
+}
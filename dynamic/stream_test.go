@@ -0,0 +1,58 @@
+package dynamic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	_, transcoder := newTestTranscoder(t)
+	records := "{\"orderId\": \"o-1\"}\n\n{\"orderId\": \"o-2\", \"tags\": [\"a\"]}\n{\"status\": \"new\"}"
+	var wire bytes.Buffer
+	encoder := transcoder.Stream(JSON_TO_PROTO)
+	encoder.Sink = &wire
+	read, err := encoder.ReadFrom(strings.NewReader(records))
+	if err != nil || read != int64(len(records)) || encoder.Records != 3 {
+		t.Fatalf("expected 3 records from %d bytes, got %d records from %d bytes: %v", len(records), encoder.Records, read, err)
+	}
+	var output bytes.Buffer
+	decoder := transcoder.Stream(PROTO_TO_JSON)
+	decoder.Source = &wire
+	if _, err := decoder.WriteTo(&output); err != nil || decoder.Records != 3 {
+		t.Fatalf("expected 3 records, got %d: %v", decoder.Records, err)
+	}
+	expected := "{\"orderId\":\"o-1\"}\n{\"orderId\":\"o-2\",\"tags\":[\"a\"]}\n{\"status\":\"new\"}\n"
+	if output.String() != expected {
+		t.Fatalf("unexpected records:\n%s", output.String())
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	_, transcoder := newTestTranscoder(t)
+	tests := []struct {
+		direction Direction
+		input     []byte
+	}{
+		{JSON_TO_PROTO, []byte("{\"orderId\": \"o-1\"}\n{\"quantity\": \"x\"}\n")},
+		{PROTO_TO_JSON, []byte{0x80}},
+		{PROTO_TO_JSON, []byte{0x05, 0x0a}},
+		{PROTO_TO_JSON, []byte{0xff, 0xff, 0xff, 0x0f}},
+	}
+	for _, test := range tests {
+		stream := transcoder.Stream(test.direction)
+		stream.Sink = &bytes.Buffer{}
+		if _, err := stream.ReadFrom(bytes.NewReader(test.input)); err == nil {
+			t.Fatalf("%s %v was transcoded", test.direction, test.input)
+		}
+	}
+	stream := transcoder.Stream(JSON_TO_PROTO)
+	stream.MaxRecordSize = 8
+	stream.Sink = &bytes.Buffer{}
+	if _, err := stream.ReadFrom(strings.NewReader("{\"orderId\": \"o-1\"}\n")); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("a record over the limit was accepted: %v", err)
+	}
+	if _, err := transcoder.Stream(JSON_TO_PROTO).ReadFrom(strings.NewReader("{}")); err == nil {
+		t.Fatal("a stream without a sink was read")
+	}
+}
@@ -0,0 +1,358 @@
+package dynamic
+
+import (
+	"J2PGo/internal"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var JSON_KINDS = map[string]string{
+	"double":   "number",
+	"float":    "number",
+	"int32":    "number",
+	"sint32":   "number",
+	"sfixed32": "number",
+	"uint32":   "number",
+	"fixed32":  "number",
+	"int64":    "number",
+	"sint64":   "number",
+	"sfixed64": "number",
+	"uint64":   "number",
+	"fixed64":  "number",
+	"bool":     "boolean",
+	"string":   "string",
+	"bytes":    "string",
+}
+
+type Transcoder struct {
+	schema     *Schema
+	descriptor protoreflect.MessageDescriptor
+}
+
+func (schema *Schema) Transcoder(name string) (*Transcoder, error) {
+	descriptor, err := schema.Descriptor(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Transcoder{schema: schema, descriptor: descriptor}, nil
+}
+
+func Transcode(schema []byte, document []byte) ([]byte, error) {
+	_schema, err := New(schema, "dynamic")
+	if err != nil {
+		return nil, err
+	}
+	transcoder, err := _schema.Transcoder("")
+	if err != nil {
+		return nil, err
+	}
+	return transcoder.ToProto(document)
+}
+
+func (transcoder *Transcoder) ToProto(document []byte) ([]byte, error) {
+	message, err := transcoder.schema.NewMessage(string(transcoder.descriptor.Name()))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value any
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	value, err = transcoder.schema.toProtoJson(string(transcoder.descriptor.Name()), value, "")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	err = protojson.UnmarshalOptions{Resolver: transcoder.schema.Types}.Unmarshal(data, message)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
+
+func (transcoder *Transcoder) ToJson(wire []byte) ([]byte, error) {
+	message, err := transcoder.schema.NewMessage(string(transcoder.descriptor.Name()))
+	if err != nil {
+		return nil, err
+	}
+	err = proto.UnmarshalOptions{Resolver: transcoder.schema.Types}.Unmarshal(wire, message)
+	if err != nil {
+		return nil, err
+	}
+	data, err := protojson.MarshalOptions{Resolver: transcoder.schema.Types, UseProtoNames: true, EmitUnpopulated: true}.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(transcoder.schema.fromProtoJson(string(transcoder.descriptor.Name()), value))
+}
+
+func (schema *Schema) toProtoJson(messageName string, value any, pointer string) (any, error) {
+	message, ok := schema.messages[messageName]
+	if !ok {
+		return value, nil
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("#%s: expected an object for %s", pointer, messageName)
+	}
+	output := make(map[string]any)
+	for key, value := range object {
+		_pointer := pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+		if value == nil {
+			continue
+		}
+		field := schema.fieldFor(message, key, value)
+		if field == nil {
+			return nil, fmt.Errorf("#%s: property is not defined by %s", _pointer, messageName)
+		}
+		_value, err := schema.toProtoValue(field, value, _pointer)
+		if err != nil {
+			return nil, err
+		}
+		output[field.Name] = _value
+	}
+	return output, nil
+}
+
+func (schema *Schema) fieldFor(message *internal.ProtoMessage, key string, value any) *internal.ProtoField {
+	candidates := make([]*internal.ProtoField, 0)
+	for _, field := range message.Fields {
+		if field.Original == key || (len(field.Original) == 0 && (field.Name == key || field.JsonName == key)) {
+			candidates = append(candidates, field)
+		}
+	}
+	if len(candidates) < 2 {
+		for _, field := range candidates {
+			return field
+		}
+		return nil
+	}
+	kind := jsonKind(value)
+	for _, field := range candidates {
+		if schema.accepts(field.Type, kind) {
+			return field
+		}
+	}
+	return candidates[0]
+}
+
+func (schema *Schema) accepts(typeName string, kind string) bool {
+	if _kind, ok := JSON_KINDS[typeName]; ok {
+		return _kind == kind || (_kind == "number" && kind == "string" && strings.HasSuffix(typeName, "64"))
+	}
+	if _, ok := schema.enums[typeName]; ok {
+		return kind == "string" || kind == "number"
+	}
+	if _, ok := schema.messages[typeName]; ok {
+		return kind == "object"
+	}
+	switch strings.TrimPrefix(typeName, ".") {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask", "google.type.Date", "google.type.TimeOfDay":
+		{
+			return kind == "string"
+		}
+	case "google.protobuf.Struct", "google.protobuf.Any", "google.protobuf.Empty":
+		{
+			return kind == "object"
+		}
+	case "google.protobuf.ListValue":
+		{
+			return kind == "array"
+		}
+	}
+	return true
+}
+
+func (schema *Schema) toProtoValue(field *internal.ProtoField, value any, pointer string) (any, error) {
+	if strings.HasPrefix(field.Type, "map<") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("#%s: expected an object for %s", pointer, field.Name)
+		}
+		valueType := strings.TrimSpace(strings.TrimSuffix(field.Type[strings.Index(field.Type, ",")+1:], ">"))
+		output := make(map[string]any)
+		for key, value := range object {
+			_value, err := schema.toProtoType(valueType, value, pointer+"/"+key)
+			if err != nil {
+				return nil, err
+			}
+			output[key] = _value
+		}
+		return output, nil
+	}
+	if field.Label == internal.REPEATED_LABEL {
+		items, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("#%s: expected an array for %s", pointer, field.Name)
+		}
+		output := make([]any, 0, len(items))
+		for index, item := range items {
+			_item, err := schema.toProtoType(field.Type, item, fmt.Sprintf("%s/%d", pointer, index))
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, _item)
+		}
+		return output, nil
+	}
+	return schema.toProtoType(field.Type, value, pointer)
+}
+
+func (schema *Schema) toProtoType(typeName string, value any, pointer string) (any, error) {
+	if enum, ok := schema.enums[typeName]; ok {
+		if _value, ok := value.(string); ok {
+			for _, enumValue := range enum.Values {
+				if enumValue.Original == _value {
+					return enumValue.Name, nil
+				}
+			}
+		}
+		return value, nil
+	}
+	return schema.toProtoJson(typeName, value, pointer)
+}
+
+func jsonKind(value any) string {
+	switch value.(type) {
+	case string:
+		{
+			return "string"
+		}
+	case bool:
+		{
+			return "boolean"
+		}
+	case json.Number, float64:
+		{
+			return "number"
+		}
+	case []any:
+		{
+			return "array"
+		}
+	case map[string]any:
+		{
+			return "object"
+		}
+	}
+	return "null"
+}
+
+func (schema *Schema) fromProtoJson(messageName string, value any) any {
+	message, ok := schema.messages[messageName]
+	if !ok {
+		return value
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	output := make(map[string]any)
+	for _, field := range message.Fields {
+		value, ok := object[field.Name]
+		if !ok || value == nil {
+			continue
+		}
+		_value := schema.fromProtoValue(field, value)
+		if _value == nil || (!field.Required && field.Label != internal.OPTIONAL_LABEL && len(field.Oneof) == 0 && isZeroJson(field, _value)) {
+			continue
+		}
+		name := field.Original
+		if len(name) == 0 {
+			name = field.Name
+		}
+		output[name] = _value
+	}
+	return output
+}
+
+func (schema *Schema) fromProtoValue(field *internal.ProtoField, value any) any {
+	if strings.HasPrefix(field.Type, "map<") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		valueType := strings.TrimSpace(strings.TrimSuffix(field.Type[strings.Index(field.Type, ",")+1:], ">"))
+		output := make(map[string]any)
+		for key, value := range object {
+			output[key] = schema.fromProtoType(valueType, value)
+		}
+		return output
+	}
+	if items, ok := value.([]any); ok && field.Label == internal.REPEATED_LABEL {
+		output := make([]any, 0, len(items))
+		for _, item := range items {
+			output = append(output, schema.fromProtoType(field.Type, item))
+		}
+		return output
+	}
+	return schema.fromProtoType(field.Type, value)
+}
+
+func (schema *Schema) fromProtoType(typeName string, value any) any {
+	if enum, ok := schema.enums[typeName]; ok {
+		for _, enumValue := range enum.Values {
+			if enumValue.Name == value {
+				if len(enumValue.Original) == 0 {
+					return nil
+				}
+				return enumValue.Original
+			}
+		}
+		return value
+	}
+	if _value, ok := value.(string); ok && JSON_KINDS[typeName] == "number" {
+		if number := json.Number(_value); number.String() != "" {
+			if _, err := number.Float64(); err == nil {
+				return number
+			}
+		}
+	}
+	return schema.fromProtoJson(typeName, value)
+}
+
+func isZeroJson(field *internal.ProtoField, value any) bool {
+	switch value := value.(type) {
+	case string:
+		{
+			return len(value) == 0
+		}
+	case bool:
+		{
+			return !value
+		}
+	case json.Number:
+		{
+			number, err := value.Float64()
+			return err == nil && number == 0
+		}
+	case []any:
+		{
+			return len(value) == 0
+		}
+	case map[string]any:
+		{
+			return strings.HasPrefix(field.Type, "map<") && len(value) == 0
+		}
+	}
+	return value == nil
+}
@@ -0,0 +1,86 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func newTestTranscoder(t *testing.T) (*Schema, *Transcoder) {
+	t.Helper()
+	schema, err := New([]byte(ORDER_SCHEMA), "acme.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcoder, err := schema.Transcoder("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, transcoder
+}
+
+func TestToProto(t *testing.T) {
+	schema, transcoder := newTestTranscoder(t)
+	wire, err := transcoder.ToProto([]byte(`{"orderId": "o-1", "status": "in-progress", "payment": {"last4": "4242"}, "labels": {"env": "prod"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := dynamicpb.NewMessage(schema.Root)
+	if err := proto.Unmarshal(wire, message); err != nil {
+		t.Fatal(err)
+	}
+	fields := schema.Root.Fields()
+	if message.Get(fields.ByName("orderId")).String() != "o-1" {
+		t.Fatalf("the property was not mapped to its field: %v", message)
+	}
+	status := fields.ByName("status")
+	if name := status.Enum().Values().ByNumber(message.Get(status).Enum()).Name(); name != "STATUS_IN_PROGRESS" {
+		t.Fatalf("the enum string was mapped to %s", name)
+	}
+	if oneof := message.WhichOneof(fields.ByName("payment_object").ContainingOneof()); oneof == nil || oneof.Name() != "payment_object" {
+		t.Fatalf("the object member of the union was not selected: %v", oneof)
+	}
+	if labels := message.Get(fields.ByName("labels")).Map(); labels.Len() != 1 {
+		t.Fatalf("the map was not transcoded: %v", labels)
+	}
+	tests := []string{
+		`{"quantity": "three"}`,
+		`{"tags": "a"}`,
+		`{"labels": ["env"]}`,
+		`[]`,
+	}
+	for _, test := range tests {
+		if _, err := transcoder.ToProto([]byte(test)); err == nil {
+			t.Fatalf("%s was transcoded", test)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	_, transcoder := newTestTranscoder(t)
+	tests := []string{
+		`{"orderId": "o-1", "status": "in-progress", "quantity": 3, "price": 9.5, "tags": ["a", "b"], "labels": {"env": "prod"}, "payment": {"last4": "4242"}, "shipping": {"city": "Oslo"}}`,
+		`{"payment": "cash", "status": "new"}`,
+		`{"shipping": {}}`,
+		`{}`,
+	}
+	for _, test := range tests {
+		wire, err := transcoder.ToProto([]byte(test))
+		if err != nil {
+			t.Fatalf("%s: %v", test, err)
+		}
+		data, err := transcoder.ToJson(wire)
+		if err != nil {
+			t.Fatalf("%s: %v", test, err)
+		}
+		var expected, output any
+		json.Unmarshal([]byte(test), &expected)
+		json.Unmarshal(data, &output)
+		if !reflect.DeepEqual(expected, output) {
+			t.Fatalf("%s came back as %s", test, data)
+		}
+	}
+}
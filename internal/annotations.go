@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var VERSION_COMPONENT = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]*)?$`)
+
+const (
+	FIELD_BEHAVIOR_IMPORT   = "google/api/field_behavior.proto"
+	HTTP_ANNOTATIONS_IMPORT = "google/api/annotations.proto"
+	EMPTY_IMPORT            = "google/protobuf/empty.proto"
+	ANY_IMPORT              = "google/protobuf/any.proto"
+)
+
+func FieldBehaviors(message Properties, propertyName string) []string {
+	output := make([]string, 0)
+	property := message.Properties[propertyName]
+	required := isRequired(message, propertyName)
+	if property.ReadOnly != nil && *property.ReadOnly {
+		return append(output, "OUTPUT_ONLY")
+	}
+	if required {
+		output = append(output, "REQUIRED")
+	} else {
+		output = append(output, "OPTIONAL")
+	}
+	if property.WriteOnly != nil && *property.WriteOnly {
+		output = append(output, "INPUT_ONLY")
+	}
+	return output
+}
+
+func isRequired(message Properties, propertyName string) bool {
+	for _, value := range message.Required {
+		if value == propertyName {
+			return true
+		}
+	}
+	return false
+}
+
+func usesFieldBehavior(file *ProtoFile) bool {
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			if len(field.Behaviors) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func usesAny(file *ProtoFile) bool {
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			typeName := strings.TrimPrefix(field.Type, ".")
+			if strings.HasPrefix(typeName, "map<") {
+				typeName = strings.TrimPrefix(strings.TrimSpace(strings.TrimSuffix(typeName[strings.Index(typeName, ",")+1:], ">")), ".")
+			}
+			if typeName == "google.protobuf.Any" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type HttpExtension struct {
+	Method          string `json:"method"`
+	Path            string `json:"path"`
+	Body            string `json:"body"`
+	ResponseBody    string `json:"response_body"`
+	Rpc             string `json:"rpc"`
+	Service         string `json:"service"`
+	Response        string `json:"response"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+}
+
+type GrpcExtension struct {
+	Service string       `json:"service"`
+	Methods []GrpcMethod `json:"methods"`
+}
+
+type GrpcMethod struct {
+	Name            string `json:"name"`
+	Request         string `json:"request"`
+	Response        string `json:"response"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+}
+
+func (rcvr DefaultJsonSchemaParser) buildServices(packageName string, keys []string) ([]*ProtoService, []string) {
+	services := make(map[string]*ProtoService)
+	names := make([]string, 0)
+	imports := make(map[string]bool)
+	for _, key := range keys {
+		definition := rcvr.schema.Definitions[key]
+		if definition.XHttp == nil {
+			continue
+		}
+		if definition.GetType() != NESTED_OBJECT_TYPE && len(definition.Properties) == 0 {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: key, Message: "x-http is only supported on object definitions"})
+			continue
+		}
+		extension := definition.XHttp
+		method := ProtoMethod{Input: rcvr.nestedObjectHander("", key, rcvr.definitionPointers[key], definition), Name: extension.Rpc, ClientStreaming: extension.ClientStreaming, ServerStreaming: extension.ServerStreaming}
+		if len(method.Name) == 0 {
+			method.Name = strings.TrimSuffix(method.Input, "Request")
+		}
+		method.Output = rcvr.methodType(extension.Response, imports)
+		if len(extension.Path) != 0 {
+			method.Http = &ProtoHttpRule{Method: extension.Method, Path: extension.Path, Body: extension.Body, ResponseBody: extension.ResponseBody}
+			imports[HTTP_ANNOTATIONS_IMPORT] = true
+		}
+		serviceName := extension.Service
+		if len(serviceName) == 0 {
+			serviceName = toServiceName(packageName)
+		}
+		service, ok := services[serviceName]
+		if !ok {
+			service = &ProtoService{Name: serviceName}
+			services[serviceName] = service
+			names = append(names, serviceName)
+		}
+		service.Methods = append(service.Methods, &method)
+	}
+	if extension := rcvr.schema.XGrpc; extension != nil {
+		serviceName := extension.Service
+		if len(serviceName) == 0 {
+			serviceName = toServiceName(packageName)
+		}
+		service, ok := services[serviceName]
+		if !ok {
+			service = &ProtoService{Name: serviceName}
+			services[serviceName] = service
+			names = append(names, serviceName)
+		}
+		for index, _method := range extension.Methods {
+			if len(_method.Name) == 0 {
+				rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: fmt.Sprintf("#/x-grpc/methods/%d", index), Message: "x-grpc methods require a name"})
+				continue
+			}
+			method := ProtoMethod{Name: _method.Name, Input: rcvr.methodType(_method.Request, imports), Output: rcvr.methodType(_method.Response, imports), ClientStreaming: _method.ClientStreaming, ServerStreaming: _method.ServerStreaming}
+			service.Methods = append(service.Methods, &method)
+		}
+	}
+	sort.Strings(names)
+	output := make([]*ProtoService, 0)
+	for _, name := range names {
+		output = append(output, services[name])
+	}
+	_imports := make([]string, 0)
+	for value := range imports {
+		_imports = append(_imports, value)
+	}
+	sort.Strings(_imports)
+	return output, _imports
+}
+
+func (rcvr DefaultJsonSchemaParser) methodType(ref string, imports map[string]bool) string {
+	switch {
+	case ref == "#" || ref == "#/":
+		{
+			return rcvr.nestedObjectHander("", RootMessageName(rcvr.schema), "#", rcvr.root)
+		}
+	case strings.HasPrefix(ref, "#"):
+		{
+			refType, properties := Properties{Ref: &ref}.GetRef(rcvr.schema.Definitions)
+			return rcvr.nestedObjectHander("", refType, ref, properties)
+		}
+	case len(ref) != 0:
+		{
+			return ref
+		}
+	}
+	imports[EMPTY_IMPORT] = true
+	return "google.protobuf.Empty"
+}
+
+func toServiceName(packageName string) string {
+	segments := strings.Split(packageName, ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if !VERSION_COMPONENT.MatchString(segments[i]) && len(segments[i]) != 0 {
+			return *toPascalCase(segments[i]) + "Service"
+		}
+	}
+	return "Service"
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestServiceMethodTypes(t *testing.T) {
+	schema := `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}},
+"x-grpc": {"methods": [{"name": "Echo", "request": "#", "response": "#"}, {"name": "Get", "request": "#/definitions/GetOrderRequest", "response": "#"}, {"name": "Ping"}]},
+"definitions": {"GetOrderRequest": {"type": "object", "properties": {"id": {"type": "string"}}, "x-http": {"method": "get", "path": "/orders/{id}", "response": "#"}}}}`
+	file := NewWithOptions([]byte(schema), DefaultOptions()).Build("test")
+	messages := make([]string, 0)
+	for _, definition := range file.Definitions {
+		if definition.Message != nil {
+			messages = append(messages, definition.Message.Name)
+		}
+	}
+	if fmt.Sprint(messages) != "[Order GetOrderRequest]" {
+		t.Fatalf("expected only Order and GetOrderRequest, got %v", messages)
+	}
+	methods := make([]string, 0)
+	for _, service := range file.Services {
+		for _, method := range service.Methods {
+			methods = append(methods, fmt.Sprintf("%s(%s) %s", method.Name, method.Input, method.Output))
+		}
+	}
+	expected := "[GetOrder(GetOrderRequest) Order Echo(Order) Order Get(GetOrderRequest) Order Ping(google.protobuf.Empty) google.protobuf.Empty]"
+	if fmt.Sprint(methods) != expected {
+		t.Fatalf("expected the methods %s, got %v", expected, methods)
+	}
+}
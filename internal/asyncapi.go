@@ -0,0 +1,309 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+func IsAsyncApi(data []byte) bool {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return false
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return false
+	}
+	version, ok := document.Get("asyncapi")
+	if !ok {
+		return false
+	}
+	_version, ok := version.(string)
+	return ok && (strings.HasPrefix(_version, "2.") || strings.HasPrefix(_version, "3."))
+}
+
+func FromAsyncApi(data []byte, services bool, diagnosticHandler DiagnosticHandler) ([]byte, error) {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return nil, fmt.Errorf("asyncapi documents must be objects")
+	}
+	output := NewJsonObject()
+	output.Set("$schema", "http://json-schema.org/draft-07/schema#")
+	output.Set("$id", openApiID(document))
+	definitions := NewJsonObject()
+	refs := make(map[string]string)
+	components := jsonObject(document, "components")
+	schemas := jsonObject(components, "schemas")
+	for _, key := range schemas.Keys {
+		definitions.Set(key, fromOpenApiSchema(schemas.Values[key], OPENAPI_SCHEMAS+escapePointer(key), diagnosticHandler))
+	}
+	messages := jsonObject(components, "messages")
+	for _, key := range messages.Keys {
+		if message, ok := messages.Values[key].(*JsonObject); ok {
+			pointer := "#/components/messages/" + escapePointer(key)
+			if ref, ok := asyncApiPayload(definitions, key, message, pointer, diagnosticHandler); ok {
+				refs[pointer] = ref
+			}
+		}
+	}
+	channels := jsonObject(document, "channels")
+	for _, key := range channels.Keys {
+		channel, ok := channels.Values[key].(*JsonObject)
+		if !ok {
+			continue
+		}
+		pointer := "#/channels/" + escapePointer(key)
+		channelName := asyncApiChannelName(key, channel)
+		for _, operation := range []string{"publish", "subscribe"} {
+			message := jsonObject(jsonObject(channel, operation), "message")
+			if _, ok := message.Get("$ref"); ok {
+				continue
+			}
+			if oneOf, ok := message.Get("oneOf"); ok {
+				if members, ok := oneOf.([]any); ok {
+					for index, member := range members {
+						if _member, ok := member.(*JsonObject); ok {
+							if _, ok := _member.Get("$ref"); ok {
+								continue
+							}
+							memberPointer := fmt.Sprintf("%s/%s/message/oneOf/%d", pointer, operation, index)
+							if ref, ok := asyncApiPayload(definitions, asyncApiMessageName(_member, fmt.Sprintf("%s%s%d", channelName, upperFirst(operation), index+1)), _member, memberPointer, diagnosticHandler); ok {
+								refs[memberPointer] = ref
+							}
+						}
+					}
+				}
+				continue
+			}
+			if len(message.Keys) != 0 {
+				messagePointer := pointer + "/" + operation + "/message"
+				if ref, ok := asyncApiPayload(definitions, asyncApiMessageName(message, channelName+upperFirst(operation)), message, messagePointer, diagnosticHandler); ok {
+					refs[messagePointer] = ref
+				}
+			}
+		}
+		channelMessages := jsonObject(channel, "messages")
+		for _, name := range channelMessages.Keys {
+			if message, ok := channelMessages.Values[name].(*JsonObject); ok {
+				messagePointer := pointer + "/messages/" + escapePointer(name)
+				if ref, ok := message.Get("$ref"); ok {
+					if _ref, ok := refs[fmt.Sprint(ref)]; ok {
+						refs[messagePointer] = _ref
+					}
+					continue
+				}
+				if ref, ok := asyncApiPayload(definitions, asyncApiMessageName(message, channelName+upperFirst(name)), message, messagePointer, diagnosticHandler); ok {
+					refs[messagePointer] = ref
+				}
+			}
+		}
+	}
+	if services {
+		asyncApiOperations(document, definitions, refs, diagnosticHandler)
+	}
+	if len(definitions.Keys) == 0 {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: "#", Message: "the asyncapi document has no message payloads or component schemas"})
+	}
+	output.Set("definitions", definitions)
+	return EncodeJson(output), nil
+}
+
+func asyncApiOperations(document *JsonObject, definitions *JsonObject, refs map[string]string, diagnosticHandler DiagnosticHandler) {
+	channels := jsonObject(document, "channels")
+	for _, key := range channels.Keys {
+		channel, ok := channels.Values[key].(*JsonObject)
+		if !ok {
+			continue
+		}
+		pointer := "#/channels/" + escapePointer(key)
+		for _, operation := range []string{"subscribe", "publish"} {
+			_operation := jsonObject(channel, operation)
+			if len(_operation.Keys) == 0 {
+				continue
+			}
+			messagePointers := make([]string, 0)
+			message := jsonObject(_operation, "message")
+			if ref, ok := message.Get("$ref"); ok {
+				messagePointers = append(messagePointers, fmt.Sprint(ref))
+			} else if oneOf, ok := message.Get("oneOf"); ok {
+				members, _ := oneOf.([]any)
+				for index, member := range members {
+					if _member, ok := member.(*JsonObject); ok {
+						if ref, ok := _member.Get("$ref"); ok {
+							messagePointers = append(messagePointers, fmt.Sprint(ref))
+							continue
+						}
+					}
+					messagePointers = append(messagePointers, fmt.Sprintf("%s/%s/message/oneOf/%d", pointer, operation, index))
+				}
+			} else {
+				messagePointers = append(messagePointers, pointer+"/"+operation+"/message")
+			}
+			rpc := upperFirst(operation) + asyncApiChannelName(key, channel)
+			if operationId, ok := _operation.Get("operationId"); ok {
+				rpc = *toPascalCase(fmt.Sprint(operationId))
+			}
+			asyncApiMethod(definitions, rpc, operation == "subscribe", channel, messagePointers, refs, pointer+"/"+operation, diagnosticHandler)
+		}
+	}
+	operations := jsonObject(document, "operations")
+	for _, key := range operations.Keys {
+		operation, ok := operations.Values[key].(*JsonObject)
+		if !ok {
+			continue
+		}
+		pointer := "#/operations/" + escapePointer(key)
+		channelRef, _ := jsonObject(operation, "channel").Get("$ref")
+		_channelRef := fmt.Sprint(channelRef)
+		channel := jsonObject(channels, strings.ReplaceAll(strings.ReplaceAll(strings.TrimPrefix(_channelRef, "#/channels/"), "~1", "/"), "~0", "~"))
+		messagePointers := make([]string, 0)
+		if messages, ok := operation.Get("messages"); ok {
+			_messages, _ := messages.([]any)
+			for _, message := range _messages {
+				if _message, ok := message.(*JsonObject); ok {
+					if ref, ok := _message.Get("$ref"); ok {
+						messagePointers = append(messagePointers, fmt.Sprint(ref))
+					}
+				}
+			}
+		}
+		if len(messagePointers) == 0 {
+			for _, name := range jsonObject(channel, "messages").Keys {
+				messagePointers = append(messagePointers, _channelRef+"/messages/"+escapePointer(name))
+			}
+		}
+		action, _ := operation.Get("action")
+		if action != "send" && action != "receive" {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("unknown action %v, the operation was skipped", action)})
+			continue
+		}
+		asyncApiMethod(definitions, *toPascalCase(key), action == "send", channel, messagePointers, refs, pointer, diagnosticHandler)
+	}
+}
+
+func asyncApiMethod(definitions *JsonObject, rpc string, serverStreaming bool, channel *JsonObject, messagePointers []string, refs map[string]string, pointer string, diagnosticHandler DiagnosticHandler) {
+	members := make([]any, 0)
+	for _, messagePointer := range messagePointers {
+		ref, ok := refs[messagePointer]
+		if !ok {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("message %s has no payload schema and was skipped", messagePointer)})
+			continue
+		}
+		member := NewJsonObject()
+		member.Set("$ref", ref)
+		members = append(members, member)
+	}
+	if len(members) == 0 {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "the operation has no messages and was skipped"})
+		return
+	}
+	message := members[0].(*JsonObject)
+	if len(members) > 1 {
+		union := NewJsonObject()
+		union.Set("oneOf", members)
+		name := openApiDefinitionName(definitions, rpc+"Message", pointer, diagnosticHandler)
+		definitions.Set(name, union)
+		message = NewJsonObject()
+		message.Set("$ref", "#/definitions/"+escapePointer(name))
+	}
+	request := NewJsonObject()
+	request.Set("type", string(OBJECT))
+	properties := NewJsonObject()
+	parameters := jsonObject(channel, "parameters")
+	for _, name := range parameters.Keys {
+		schema, ok := jsonObject(parameters, name).Get("schema")
+		if !ok {
+			schema = NewJsonObject()
+			schema.(*JsonObject).Set("type", string(STRING))
+		}
+		properties.Set(name, fromOpenApiSchema(schema, pointer+"/parameters/"+escapePointer(name), diagnosticHandler))
+	}
+	extension := NewJsonObject()
+	extension.Set("rpc", rpc)
+	if serverStreaming {
+		extension.Set("response", message.Values["$ref"])
+		extension.Set("server_streaming", true)
+	} else {
+		properties.Set("payload", message)
+		extension.Set("client_streaming", true)
+	}
+	request.Set("properties", properties)
+	request.Set("x-http", extension)
+	definitions.Set(openApiDefinitionName(definitions, rpc+"Request", pointer, diagnosticHandler), request)
+}
+
+func jsonObject(object *JsonObject, key string) *JsonObject {
+	if object != nil {
+		if value, ok := object.Get(key); ok {
+			if _value, ok := value.(*JsonObject); ok {
+				return _value
+			}
+		}
+	}
+	return NewJsonObject()
+}
+
+func asyncApiChannelName(key string, channel *JsonObject) string {
+	if address, ok := channel.Get("address"); ok {
+		if _address, ok := address.(string); ok && len(_address) != 0 {
+			key = _address
+		}
+	}
+	var output strings.Builder
+	for _, segment := range strings.FieldsFunc(key, func(r rune) bool {
+		return r == '/' || r == '.' || r == '-' || r == '_' || r == ':'
+	}) {
+		if strings.HasPrefix(segment, "{") {
+			continue
+		}
+		output.WriteString(upperFirst(segment))
+	}
+	return output.String()
+}
+
+func asyncApiMessageName(message *JsonObject, fallback string) string {
+	for _, key := range []string{"name", "messageId"} {
+		if value, ok := message.Get(key); ok {
+			if name, ok := value.(string); ok && len(name) != 0 {
+				return name
+			}
+		}
+	}
+	return fallback
+}
+
+func asyncApiPayload(definitions *JsonObject, name string, message *JsonObject, pointer string, diagnosticHandler DiagnosticHandler) (string, bool) {
+	if format, ok := message.Get("schemaFormat"); ok {
+		if _format, ok := format.(string); ok && !strings.Contains(_format, "schema+json") && !strings.Contains(_format, "aai+json") && !strings.Contains(_format, "aai+yaml") && !strings.Contains(_format, "schema+yaml") {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("payloads in %s format are not supported and were skipped", _format)})
+			return "", false
+		}
+	}
+	payload, ok := message.Get("payload")
+	if !ok {
+		return "", false
+	}
+	_payload, ok := payload.(*JsonObject)
+	if !ok {
+		return "", false
+	}
+	if schema, ok := _payload.Get("schema"); ok {
+		if _schema, ok := schema.(*JsonObject); ok {
+			_payload = _schema
+		}
+	}
+	if ref, ok := _payload.Get("$ref"); ok && len(_payload.Keys) == 1 {
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: fmt.Sprintf("message %s uses %s", name, ref)})
+		return fromOpenApiRef(fmt.Sprint(ref)), true
+	}
+	if _, ok := definitions.Get(name); ok {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("message %s collides with an existing schema", name)})
+		name = name + "Payload"
+	}
+	definitions.Set(name, fromOpenApiSchema(_payload, pointer+"/payload", diagnosticHandler))
+	return "#/definitions/" + escapePointer(name), true
+}
@@ -0,0 +1,72 @@
+package internal
+
+import "testing"
+
+const ASYNCAPI_TEST_DOCUMENT = `{"asyncapi": "2.6.0", "info": {"title": "Accounts", "version": "1.0.0"}, "channels": {
+	"user/signedup": {"subscribe": {"message": {"$ref": "#/components/messages/UserSignedUp"}}},
+	"user/deleted": {"publish": {"message": {"payload": {"type": "object", "properties": {"userId": {"type": "string"}}}}}}
+}, "components": {"messages": {"UserSignedUp": {"payload": {"type": "object", "properties": {"user": {"$ref": "#/components/schemas/User"}}}}}, "schemas": {"User": {"type": "object", "properties": {"id": {"type": "string"}}}}}}`
+
+func TestFromAsyncApi(t *testing.T) {
+	if !IsAsyncApi([]byte(ASYNCAPI_TEST_DOCUMENT)) {
+		t.Fatal("the document was not detected as asyncapi")
+	}
+	output, _ := convertTestDocument(t, ASYNCAPI_TEST_DOCUMENT, DefaultOptions())
+	expected := `syntax = "proto3";
+
+package accounts.v1;
+
+message User {
+  string id = 1;
+}
+
+message UserDeletedPublish {
+  string userId = 1 [json_name = "user_id"];
+}
+
+message UserSignedUp {
+  User user = 1;
+}
+`
+	if output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
+
+func TestAsyncApiServices(t *testing.T) {
+	options := DefaultOptions()
+	options.AsyncApiServices = true
+	output, _ := convertTestDocument(t, ASYNCAPI_TEST_DOCUMENT, options)
+	expected := `syntax = "proto3";
+
+package accounts.v1;
+
+import "google/protobuf/empty.proto";
+
+message UserDeletedPublish {
+  string userId = 1 [json_name = "user_id"];
+}
+
+message PublishUserDeletedRequest {
+  UserDeletedPublish payload = 1;
+}
+
+message SubscribeUserSignedupRequest {}
+
+message User {
+  string id = 1;
+}
+
+message UserSignedUp {
+  User user = 1;
+}
+
+service AccountsService {
+  rpc PublishUserDeleted(stream PublishUserDeletedRequest) returns (google.protobuf.Empty);
+  rpc SubscribeUserSignedup(SubscribeUserSignedupRequest) returns (stream UserSignedUp);
+}
+`
+	if output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
@@ -0,0 +1,327 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const TIMESTAMP_IMPORT = "google/protobuf/timestamp.proto"
+
+var AVRO_PRIMITIVES = map[string]string{
+	"boolean": "bool",
+	"int":     "int32",
+	"long":    "int64",
+	"float":   "float",
+	"double":  "double",
+	"bytes":   "bytes",
+	"string":  "string",
+}
+
+type ProtoBuilder interface {
+	Build(packageName string) *ProtoFile
+	Diagnostics() []Diagnostic
+}
+
+type avroType struct {
+	Label Label
+	Type  string
+}
+
+type AvroParser struct {
+	schema            any
+	hash              string
+	options           Options
+	names             map[string]string
+	typeNames         map[string]bool
+	roots             []string
+	definitions       map[string]ProtoDefinition
+	dependencies      map[string][]string
+	imports           map[string]bool
+	diagnostics       *[]Diagnostic
+	diagnosticHandler DiagnosticHandler
+}
+
+func IsAvro(path string, format InputFormat) bool {
+	return format == AVRO_INPUT || (format == AUTO_INPUT && strings.EqualFold(filepath.Ext(path), ".avsc"))
+}
+
+func NewAvroParser(data []byte, options Options) (*AvroParser, error) {
+	schema, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics := make([]Diagnostic, 0)
+	hash := sha256.Sum256(data)
+	output := AvroParser{
+		schema:       schema,
+		hash:         hex.EncodeToString(hash[:]),
+		options:      options,
+		names:        make(map[string]string),
+		typeNames:    make(map[string]bool),
+		definitions:  make(map[string]ProtoDefinition),
+		dependencies: make(map[string][]string),
+		imports:      make(map[string]bool),
+		diagnostics:  &diagnostics,
+	}
+	output.diagnosticHandler = func(diagnostic Diagnostic) {
+		*output.diagnostics = append(*output.diagnostics, diagnostic)
+	}
+	return &output, nil
+}
+
+func (rcvr *AvroParser) Diagnostics() []Diagnostic {
+	return *rcvr.diagnostics
+}
+
+func (rcvr *AvroParser) Build(packageName string) *ProtoFile {
+	output := ProtoFile{Package: packageName}
+	if rcvr.options.Metadata {
+		output.Metadata = &ProtoMetadata{Version: VERSION, Source: rcvr.options.Source, Hash: rcvr.hash}
+		if rcvr.options.MetadataTimestamp {
+			output.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	schemas, isList := rcvr.schema.([]any)
+	if !isList {
+		schemas = []any{rcvr.schema}
+	}
+	for index, schema := range schemas {
+		pointer := "#"
+		if isList {
+			pointer = fmt.Sprintf("#/%d", index)
+		}
+		resolved := rcvr.resolve(schema, "", pointer)
+		if _, ok := rcvr.definitions[resolved.Type]; ok {
+			rcvr.roots = append(rcvr.roots, resolved.Type)
+			continue
+		}
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "top level avro schemas that are not records or enums were skipped"})
+	}
+	for _, key := range TopologicalOrder(rcvr.definitions, rcvr.dependencies, rcvr.roots) {
+		output.Definitions = append(output.Definitions, rcvr.definitions[key])
+	}
+	for _, definition := range output.Definitions {
+		if definition.Message != nil {
+			definition.Message.ReservedRanges = append(definition.Message.ReservedRanges, rcvr.options.ReservedRanges...)
+			NumberFields(definition.Message, rcvr.options.Numbering, nil)
+		}
+	}
+	if usesAny(&output) {
+		output.Imports = append([]string{ANY_IMPORT}, output.Imports...)
+	}
+	imports := make([]string, 0)
+	for value := range rcvr.imports {
+		imports = append(imports, value)
+	}
+	sort.Strings(imports)
+	output.Imports = append(output.Imports, imports...)
+	if len(rcvr.options.MergeFile) != 0 {
+		Merge(&output, ReadProto(rcvr.options.MergeFile), rcvr.options.Numbering, rcvr.diagnosticHandler)
+	}
+	return &output
+}
+
+func (rcvr *AvroParser) fullName(name string, namespace string) string {
+	if strings.Contains(name, ".") || len(namespace) == 0 {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func (rcvr *AvroParser) register(name string, namespace string, pointer string) (string, string) {
+	fullName := rcvr.fullName(name, namespace)
+	if index := strings.LastIndex(fullName, "."); index >= 0 {
+		namespace, name = fullName[:index], fullName[index+1:]
+	}
+	typeName := *toTypeName(name, rcvr.diagnosticHandler)
+	for suffix := 2; rcvr.typeNames[typeName]; suffix++ {
+		typeName = fmt.Sprintf("%s%d", *toTypeName(name, rcvr.diagnosticHandler), suffix)
+		if !rcvr.typeNames[typeName] {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("%s collides with another named type and was renamed to %s", fullName, typeName)})
+		}
+	}
+	rcvr.typeNames[typeName] = true
+	rcvr.names[fullName] = typeName
+	return typeName, namespace
+}
+
+func (rcvr *AvroParser) resolve(schema any, namespace string, pointer string) avroType {
+	switch value := schema.(type) {
+	case string:
+		{
+			if value == "null" {
+				rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "null outside of a union was typed as google.protobuf.Any"})
+				return avroType{Label: OPTIONAL_LABEL, Type: "google.protobuf.Any"}
+			}
+			if primitive, ok := AVRO_PRIMITIVES[value]; ok {
+				return avroType{Type: primitive}
+			}
+			if typeName, ok := rcvr.names[rcvr.fullName(value, namespace)]; ok {
+				return avroType{Type: typeName}
+			}
+			if typeName, ok := rcvr.names[value]; ok {
+				return avroType{Type: typeName}
+			}
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: pointer, Message: fmt.Sprintf("unknown avro type %s was typed as google.protobuf.Any", value)})
+			return avroType{Type: "google.protobuf.Any"}
+		}
+	case []any:
+		{
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "nested unions were typed as google.protobuf.Any"})
+			return avroType{Type: "google.protobuf.Any"}
+		}
+	case *JsonObject:
+		{
+			_type, _ := value.Get("type")
+			if logicalType, ok := value.Get("logicalType"); ok {
+				if _logicalType, ok := logicalType.(string); ok && strings.HasPrefix(_logicalType, "timestamp-") {
+					rcvr.imports[TIMESTAMP_IMPORT] = true
+					return avroType{Type: "google.protobuf.Timestamp"}
+				}
+			}
+			switch _type {
+			case "record", "error":
+				{
+					return avroType{Type: rcvr.record(value, namespace, pointer)}
+				}
+			case "enum":
+				{
+					return avroType{Type: rcvr.enum(value, namespace, pointer)}
+				}
+			case "fixed":
+				{
+					if name, ok := value.Get("name"); ok {
+						rcvr.names[rcvr.fullName(fmt.Sprint(name), namespace)] = "bytes"
+					}
+					return avroType{Type: "bytes"}
+				}
+			case "array":
+				{
+					items, _ := value.Get("items")
+					element := rcvr.resolve(items, namespace, pointer+"/items")
+					if element.Label != NO_LABEL || strings.HasPrefix(element.Type, "map<") {
+						rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "arrays of arrays, maps or nullable values have no proto equivalent, the elements were typed as google.protobuf.Any"})
+						element.Type = "google.protobuf.Any"
+					}
+					return avroType{Label: REPEATED_LABEL, Type: element.Type}
+				}
+			case "map":
+				{
+					values, _ := value.Get("values")
+					element := rcvr.resolve(values, namespace, pointer+"/values")
+					if element.Label != NO_LABEL || strings.HasPrefix(element.Type, "map<") {
+						rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "maps of arrays, maps or nullable values have no proto equivalent, the values were typed as google.protobuf.Any"})
+						element.Type = "google.protobuf.Any"
+					}
+					return avroType{Type: fmt.Sprintf("map<string, %s>", element.Type)}
+				}
+			}
+			return rcvr.resolve(_type, namespace, pointer+"/type")
+		}
+	}
+	rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: pointer, Message: "unsupported avro schema was typed as google.protobuf.Any"})
+	return avroType{Type: "google.protobuf.Any"}
+}
+
+func (rcvr *AvroParser) record(schema *JsonObject, namespace string, pointer string) string {
+	name, _ := schema.Get("name")
+	if _namespace, ok := schema.Get("namespace"); ok {
+		namespace = fmt.Sprint(_namespace)
+	}
+	typeName, namespace := rcvr.register(fmt.Sprint(name), namespace, pointer)
+	message := ProtoMessage{Name: typeName, Pointer: pointer}
+	index := 1
+	fields, _ := schema.Get("fields")
+	_fields, _ := fields.([]any)
+	for position, field := range _fields {
+		_field, ok := field.(*JsonObject)
+		if !ok {
+			continue
+		}
+		fieldPointer := fmt.Sprintf("%s/fields/%d", pointer, position)
+		fieldName := fmt.Sprint(_field.Values["name"])
+		var protoFields []*ProtoField
+		if members, ok := _field.Values["type"].([]any); ok {
+			protoFields = rcvr.union(fieldName, members, namespace, &index, fieldPointer+"/type")
+		} else {
+			resolved := rcvr.resolve(_field.Values["type"], namespace, fieldPointer+"/type")
+			protoFields = []*ProtoField{toField(resolved.Label, resolved.Type, fieldName, &index, rcvr.diagnosticHandler)}
+		}
+		for _, protoField := range protoFields {
+			protoField.Pointer = fieldPointer
+			protoField.Original = fieldName
+			rcvr.depend(typeName, protoField.Type)
+		}
+		message.Fields = append(message.Fields, protoFields...)
+	}
+	rcvr.definitions[typeName] = ProtoDefinition{Message: &message}
+	return typeName
+}
+
+func (rcvr *AvroParser) union(fieldName string, members []any, namespace string, index *int, pointer string) []*ProtoField {
+	types := make([]any, 0)
+	for _, member := range members {
+		if member != "null" {
+			types = append(types, member)
+		}
+	}
+	if len(types) == 1 {
+		resolved := rcvr.resolve(types[0], namespace, pointer)
+		if len(types) != len(members) && resolved.Label == NO_LABEL && !strings.HasPrefix(resolved.Type, "map<") {
+			resolved.Label = OPTIONAL_LABEL
+		}
+		return []*ProtoField{toField(resolved.Label, resolved.Type, fieldName, index, rcvr.diagnosticHandler)}
+	}
+	if len(types) != len(members) {
+		rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: "null members of unions are represented by an unset oneof"})
+	}
+	output := make([]*ProtoField, 0)
+	oneofName := toOneofName(rcvr.options, fieldName)
+	for position, member := range types {
+		resolved := rcvr.resolve(member, namespace, fmt.Sprintf("%s/%d", pointer, position))
+		if resolved.Label != NO_LABEL || strings.HasPrefix(resolved.Type, "map<") {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "arrays and maps cannot be oneof members and were typed as google.protobuf.Any"})
+			resolved.Type = "google.protobuf.Any"
+		}
+		typeName := resolved.Type
+		if index := strings.LastIndex(typeName, "."); index >= 0 {
+			typeName = typeName[index+1:]
+		}
+		field := toField(NO_LABEL, resolved.Type, toOneofMemberName(rcvr.options, fieldName, typeName), index, rcvr.diagnosticHandler)
+		field.Oneof = oneofName
+		output = append(output, field)
+	}
+	return output
+}
+
+func (rcvr *AvroParser) enum(schema *JsonObject, namespace string, pointer string) string {
+	name, _ := schema.Get("name")
+	if _namespace, ok := schema.Get("namespace"); ok {
+		namespace = fmt.Sprint(_namespace)
+	}
+	typeName, _ := rcvr.register(fmt.Sprint(name), namespace, pointer)
+	symbols := make([]string, 0)
+	if values, ok := schema.Get("symbols"); ok {
+		if _values, ok := values.([]any); ok {
+			for _, value := range _values {
+				symbols = append(symbols, fmt.Sprint(value))
+			}
+		}
+	}
+	enum := ToEnum(typeName, pointer, symbols, rcvr.options, func(string) bool { return false }, rcvr.diagnosticHandler)
+	for _, value := range enum.Values {
+		value.Pointer = strings.Replace(value.Pointer, pointer+"/enum/", pointer+"/symbols/", 1)
+	}
+	rcvr.definitions[typeName] = ProtoDefinition{Enum: enum}
+	return typeName
+}
+
+func (rcvr *AvroParser) depend(typeName string, dependency string) {
+	dependency = strings.TrimSuffix(strings.TrimPrefix(dependency, "map<string, "), ">")
+	rcvr.dependencies[typeName] = append(rcvr.dependencies[typeName], dependency)
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+const AVRO_TEST_SCHEMA = `{"type":"record","name":"User","namespace":"com.acme.events","fields":[
+ {"name":"id","type":"long"},
+ {"name":"email","type":["null","string"],"default":null},
+ {"name":"created_at","type":{"type":"long","logicalType":"timestamp-millis"}},
+ {"name":"status","type":{"type":"enum","name":"Status","symbols":["ACTIVE","BANNED"]}},
+ {"name":"address","type":["null",{"type":"record","name":"Address","fields":[{"name":"city","type":"string"},{"name":"geo","type":{"type":"fixed","name":"Geo","size":16}}]}]},
+ {"name":"previous","type":{"type":"array","items":"Address"}},
+ {"name":"attrs","type":{"type":"map","values":"string"}},
+ {"name":"payload","type":["null","string","Address","int"]},
+ {"name":"hash","type":"Geo"},
+ {"name":"matrix","type":{"type":"array","items":{"type":"array","items":"int"}}}
+]}`
+
+func TestAvroParser(t *testing.T) {
+	options := DefaultOptions()
+	parser, err := NewAvroParser([]byte(AVRO_TEST_SCHEMA), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := parser.Build("test")
+	expected := `syntax = "proto3";
+
+package test;
+
+import "google/protobuf/any.proto";
+import "google/protobuf/timestamp.proto";
+
+message Address {
+  string city = 1;
+  bytes geo = 2;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_BANNED = 2;
+}
+
+message User {
+  int64 id = 1;
+  optional string email = 2;
+  google.protobuf.Timestamp created_at = 3 [json_name = "created_at"];
+  Status status = 4;
+  optional Address address = 5;
+  repeated Address previous = 6;
+  map<string, string> attrs = 7;
+  oneof payload_union {
+    string payload_string = 8 [json_name = "payload_string"];
+    Address payload_address = 9 [json_name = "payload_address"];
+    int32 payload_int32 = 10 [json_name = "payload_int_32"];
+  }
+  bytes hash = 11;
+  repeated google.protobuf.Any matrix = 12;
+}
+`
+	if output := Render(NewTemplate(options), file, options); output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
+
+func TestRenderAvroCollections(t *testing.T) {
+	schema := `{"title": "Order", "type": "object", "required": ["tags", "counts"], "properties": {
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"notes": {"type": "array", "items": {"type": "string"}},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"counts": {"type": "object", "additionalProperties": {"type": "integer"}}
+	}}`
+	options := DefaultOptions()
+	options.Target = AVRO_TARGET
+	output := bytes.Buffer{}
+	if err := json.Compact(&output, []byte(RenderTarget(NewWithOptions([]byte(schema), options).Build("test"), options))); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":"record","name":"Order","namespace":"test","fields":[` +
+		`{"name":"tags","type":{"type":"array","items":"string"}},` +
+		`{"name":"notes","type":["null",{"type":"array","items":"string"}],"default":null},` +
+		`{"name":"counts","type":{"type":"map","values":"int"}},` +
+		`{"name":"labels","type":["null",{"type":"map","values":"string"}],"default":null}]}`
+	if output.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, output.String())
+	}
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+var AVRO_SCALARS = map[string]string{
+	"double":   "double",
+	"float":    "float",
+	"int32":    "int",
+	"sint32":   "int",
+	"sfixed32": "int",
+	"uint32":   "long",
+	"fixed32":  "long",
+	"int64":    "long",
+	"sint64":   "long",
+	"sfixed64": "long",
+	"uint64":   "long",
+	"fixed64":  "long",
+	"bool":     "boolean",
+	"string":   "string",
+	"bytes":    "bytes",
+}
+
+var AVRO_WELL_KNOWN_TYPES = map[string]any{
+	"google.protobuf.Timestamp":   avroLogical("long", "timestamp-millis"),
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.Struct":      "string",
+	"google.protobuf.Value":       "string",
+	"google.protobuf.ListValue":   "string",
+	"google.protobuf.Any":         "string",
+	"google.protobuf.DoubleValue": []any{"null", "double"},
+	"google.protobuf.FloatValue":  []any{"null", "float"},
+	"google.protobuf.Int64Value":  []any{"null", "long"},
+	"google.protobuf.UInt64Value": []any{"null", "long"},
+	"google.protobuf.Int32Value":  []any{"null", "int"},
+	"google.protobuf.UInt32Value": []any{"null", "long"},
+	"google.protobuf.BoolValue":   []any{"null", "boolean"},
+	"google.protobuf.StringValue": []any{"null", "string"},
+	"google.protobuf.BytesValue":  []any{"null", "bytes"},
+	"google.type.Date":            avroLogical("int", "date"),
+	"google.type.TimeOfDay":       avroLogical("long", "time-micros"),
+	"google.rpc.Status":           "string",
+}
+
+var AVRO_NAME_PATTERN = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type avroWriter struct {
+	file        *ProtoFile
+	options     Options
+	definitions map[string]ProtoDefinition
+	defined     map[string]bool
+}
+
+func RenderAvro(file *ProtoFile, options Options) string {
+	writer := avroWriter{file: file, options: options, definitions: make(map[string]ProtoDefinition), defined: make(map[string]bool)}
+	referenced := referencedTypes(file)
+	for _, definition := range file.Definitions {
+		writer.definitions[definitionName(definition)] = definition
+	}
+	roots := make([]any, 0)
+	for _, definition := range file.Definitions {
+		if name := definitionName(definition); !referenced[name] && definition.Message != nil {
+			roots = append(roots, writer.named(name))
+		}
+	}
+	var output any = roots
+	if len(roots) == 1 {
+		output = roots[0]
+	}
+	content := string(EncodeJson(output)) + "\n"
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	return content
+}
+
+func (rcvr *avroWriter) named(name string) any {
+	if rcvr.defined[name] {
+		return rcvr.fullName(name)
+	}
+	rcvr.defined[name] = true
+	definition := rcvr.definitions[name]
+	output := NewJsonObject()
+	if definition.Enum != nil {
+		output.Set("type", "enum")
+		rcvr.setName(output, name)
+		output.Set("symbols", avroSymbols(definition.Enum, rcvr.options))
+		return output
+	}
+	output.Set("type", "record")
+	rcvr.setName(output, name)
+	fields := make([]any, 0)
+	for _, member := range definition.Message.Members() {
+		if member.Oneof != nil {
+			fields = append(fields, rcvr.oneof(member.Oneof))
+			continue
+		}
+		field := member.Field
+		_type := rcvr.fieldType(field)
+		nullable := field.Label == OPTIONAL_LABEL || !field.Required
+		fields = append(fields, rcvr.field(field.Original, field.Name, field.Comment, _type, nullable && !avroNullable(_type)))
+	}
+	output.Set("fields", fields)
+	return output
+}
+
+func (rcvr *avroWriter) oneof(oneof *ProtoOneof) *JsonObject {
+	name := oneof.Fields[0].Original
+	types := make([]any, 0)
+	for _, field := range oneof.Fields {
+		if field.Original != name {
+			name = oneof.Name
+		}
+		_type := rcvr.typeOf(field.Type)
+		if union, ok := _type.([]any); ok {
+			_type = union[1]
+		}
+		types = append(types, _type)
+	}
+	return rcvr.field(name, oneof.Name, "", append([]any{"null"}, types...), false)
+}
+
+func (rcvr *avroWriter) field(original string, name string, comment string, _type any, nullable bool) *JsonObject {
+	output := NewJsonObject()
+	if len(original) == 0 || !AVRO_NAME_PATTERN.MatchString(original) {
+		original = name
+	}
+	output.Set("name", original)
+	if len(comment) != 0 {
+		output.Set("doc", comment)
+	}
+	if nullable {
+		_type = []any{"null", _type}
+	}
+	output.Set("type", _type)
+	if avroNullable(_type) {
+		output.Set("default", nil)
+	}
+	return output
+}
+
+func (rcvr *avroWriter) fieldType(field *ProtoField) any {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		output := NewJsonObject()
+		output.Set("type", "map")
+		output.Set("values", rcvr.typeOf(strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">"))))
+		return output
+	}
+	_type := rcvr.typeOf(field.Type)
+	if field.Label == REPEATED_LABEL {
+		output := NewJsonObject()
+		output.Set("type", "array")
+		output.Set("items", _type)
+		return output
+	}
+	return _type
+}
+
+func (rcvr *avroWriter) typeOf(typeName string) any {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if scalar, ok := AVRO_SCALARS[typeName]; ok {
+		return scalar
+	}
+	if wellKnown, ok := AVRO_WELL_KNOWN_TYPES[typeName]; ok {
+		return wellKnown
+	}
+	if typeName == "google.protobuf.Empty" {
+		if rcvr.defined[typeName] {
+			return typeName
+		}
+		rcvr.defined[typeName] = true
+		output := NewJsonObject()
+		output.Set("type", "record")
+		output.Set("name", "Empty")
+		output.Set("namespace", "google.protobuf")
+		output.Set("fields", []any{})
+		return output
+	}
+	if len(rcvr.file.Package) != 0 {
+		typeName = strings.TrimPrefix(typeName, rcvr.file.Package+".")
+	}
+	if _, ok := rcvr.definitions[typeName]; ok {
+		return rcvr.named(typeName)
+	}
+	return "string"
+}
+
+func (rcvr *avroWriter) setName(object *JsonObject, name string) {
+	object.Set("name", strings.ReplaceAll(name, ".", "_"))
+	if len(rcvr.file.Package) != 0 {
+		object.Set("namespace", rcvr.file.Package)
+	}
+}
+
+func (rcvr *avroWriter) fullName(name string) string {
+	name = strings.ReplaceAll(name, ".", "_")
+	if len(rcvr.file.Package) != 0 {
+		return rcvr.file.Package + "." + name
+	}
+	return name
+}
+
+func avroSymbols(enum *ProtoEnum, options Options) []any {
+	output := make([]any, 0)
+	seen := make(map[string]bool)
+	prefix := strings.ToUpper(enum.Name) + "_"
+	for _, value := range enum.Values {
+		symbol := value.Original
+		if len(symbol) == 0 && strings.TrimPrefix(value.Name, prefix) == strings.ToUpper(*fixString(options.EnumZeroValue)) {
+			continue
+		}
+		if !AVRO_NAME_PATTERN.MatchString(symbol) || seen[symbol] {
+			symbol = strings.TrimPrefix(value.Name, prefix)
+		}
+		if !AVRO_NAME_PATTERN.MatchString(symbol) {
+			symbol = "_" + symbol
+		}
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		output = append(output, symbol)
+	}
+	return output
+}
+
+func avroLogical(typeName string, logicalType string) *JsonObject {
+	output := NewJsonObject()
+	output.Set("type", typeName)
+	output.Set("logicalType", logicalType)
+	return output
+}
+
+func avroNullable(_type any) bool {
+	union, ok := _type.([]any)
+	return ok && len(union) != 0 && union[0] == "null"
+}
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"strings"
+)
+
+var BIGQUERY_SCALARS = map[string]string{
+	"double":   "FLOAT",
+	"float":    "FLOAT",
+	"int32":    "INTEGER",
+	"sint32":   "INTEGER",
+	"sfixed32": "INTEGER",
+	"uint32":   "INTEGER",
+	"fixed32":  "INTEGER",
+	"int64":    "INTEGER",
+	"sint64":   "INTEGER",
+	"sfixed64": "INTEGER",
+	"uint64":   "NUMERIC",
+	"fixed64":  "NUMERIC",
+	"bool":     "BOOLEAN",
+	"string":   "STRING",
+	"bytes":    "BYTES",
+}
+
+var BIGQUERY_WELL_KNOWN_TYPES = map[string]string{
+	"google.protobuf.Timestamp":   "TIMESTAMP",
+	"google.protobuf.Duration":    "STRING",
+	"google.protobuf.FieldMask":   "STRING",
+	"google.protobuf.Struct":      "JSON",
+	"google.protobuf.Value":       "JSON",
+	"google.protobuf.ListValue":   "JSON",
+	"google.protobuf.Any":         "JSON",
+	"google.protobuf.Empty":       "JSON",
+	"google.protobuf.DoubleValue": "FLOAT",
+	"google.protobuf.FloatValue":  "FLOAT",
+	"google.protobuf.Int64Value":  "INTEGER",
+	"google.protobuf.UInt64Value": "NUMERIC",
+	"google.protobuf.Int32Value":  "INTEGER",
+	"google.protobuf.UInt32Value": "INTEGER",
+	"google.protobuf.BoolValue":   "BOOLEAN",
+	"google.protobuf.StringValue": "STRING",
+	"google.protobuf.BytesValue":  "BYTES",
+	"google.type.Date":            "DATE",
+	"google.type.TimeOfDay":       "TIME",
+	"google.rpc.Status":           "JSON",
+}
+
+type bigQueryWriter struct {
+	file        *ProtoFile
+	definitions map[string]ProtoDefinition
+	path        map[string]bool
+}
+
+func RenderBigQuery(file *ProtoFile, options Options) string {
+	writer := bigQueryWriter{file: file, definitions: make(map[string]ProtoDefinition), path: make(map[string]bool)}
+	referenced := referencedTypes(file)
+	for _, definition := range file.Definitions {
+		writer.definitions[definitionName(definition)] = definition
+	}
+	fields := make([]any, 0)
+	for _, definition := range file.Definitions {
+		if definition.Message != nil && !referenced[definition.Message.Name] {
+			fields = writer.fields(definition.Message)
+			break
+		}
+	}
+	content := string(EncodeJson(fields)) + "\n"
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	return content
+}
+
+func (rcvr *bigQueryWriter) fields(message *ProtoMessage) []any {
+	rcvr.path[message.Name] = true
+	defer delete(rcvr.path, message.Name)
+	output := make([]any, 0)
+	for _, field := range message.Fields {
+		mode := "NULLABLE"
+		if field.Label == REPEATED_LABEL {
+			mode = "REPEATED"
+		}
+		if field.Required && len(field.Oneof) == 0 && mode != "REPEATED" {
+			mode = "REQUIRED"
+		}
+		if strings.HasPrefix(field.Type, "map<") {
+			index := strings.Index(field.Type, ",")
+			entry := []any{
+				rcvr.field("key", strings.TrimPrefix(field.Type[:index], "map<"), "REQUIRED", ""),
+				rcvr.field("value", strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")), "NULLABLE", ""),
+			}
+			column := bigQueryColumn(bigQueryName(field), "RECORD", "REPEATED", field.Comment)
+			column.Set("fields", entry)
+			output = append(output, column)
+			continue
+		}
+		output = append(output, rcvr.field(bigQueryName(field), field.Type, mode, field.Comment))
+	}
+	return output
+}
+
+func (rcvr *bigQueryWriter) field(name string, typeName string, mode string, description string) *JsonObject {
+	if scalar, ok := BIGQUERY_SCALARS[typeName]; ok {
+		return bigQueryColumn(name, scalar, mode, description)
+	}
+	if wellKnown, ok := BIGQUERY_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		return bigQueryColumn(name, wellKnown, mode, description)
+	}
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(rcvr.file.Package) != 0 {
+		typeName = strings.TrimPrefix(typeName, rcvr.file.Package+".")
+	}
+	definition, ok := rcvr.definitions[typeName]
+	if !ok || definition.Enum != nil {
+		return bigQueryColumn(name, "STRING", mode, description)
+	}
+	if rcvr.path[typeName] || len(definition.Message.Fields) == 0 {
+		return bigQueryColumn(name, "JSON", mode, description)
+	}
+	output := bigQueryColumn(name, "RECORD", mode, description)
+	output.Set("fields", rcvr.fields(definition.Message))
+	return output
+}
+
+func bigQueryColumn(name string, typeName string, mode string, description string) *JsonObject {
+	output := NewJsonObject()
+	output.Set("name", name)
+	output.Set("type", typeName)
+	output.Set("mode", mode)
+	if len(description) != 0 {
+		output.Set("description", description)
+	}
+	return output
+}
+
+func bigQueryName(field *ProtoField) string {
+	if len(field.Oneof) == 0 && len(field.Original) != 0 && AVRO_NAME_PATTERN.MatchString(field.Original) {
+		return field.Original
+	}
+	return field.Name
+}
@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const BUF_PLUGIN_DOCKERFILE = `# syntax=docker/dockerfile:1.4
+FROM --platform=linux/amd64 golang:1.21-bookworm AS build
+WORKDIR /src
+COPY . .
+RUN CGO_ENABLED=0 go build -trimpath -ldflags "-s -w" -o /go/bin/protoc-gen-jsonschema ./cmd/protoc-gen-jsonschema
+
+FROM scratch
+COPY --from=build --link /go/bin/protoc-gen-jsonschema /
+USER nobody
+ENTRYPOINT ["/protoc-gen-jsonschema"]
+`
+
+func BufPluginYaml(name string, version string) string {
+	var builder strings.Builder
+	builder.WriteString("version: v1\n")
+	builder.WriteString(fmt.Sprintf("name: %s\n", name))
+	builder.WriteString(fmt.Sprintf("plugin_version: %s\n", version))
+	builder.WriteString("description: Generates json schemas for protobuf messages and lints their round trip through json schema.\n")
+	return builder.String()
+}
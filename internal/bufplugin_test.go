@@ -0,0 +1,16 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBufPluginYaml(t *testing.T) {
+	expected := "version: v1\nname: buf.build/acme/jsonschema\nplugin_version: v1.2.3\ndescription: Generates json schemas for protobuf messages and lints their round trip through json schema.\n"
+	if output := BufPluginYaml("buf.build/acme/jsonschema", "v1.2.3"); output != expected {
+		t.Fatalf("unexpected buf.plugin.yaml:\n%s", output)
+	}
+	if !strings.Contains(BUF_PLUGIN_DOCKERFILE, "./cmd/protoc-gen-jsonschema") || !strings.Contains(BUF_PLUGIN_DOCKERFILE, `ENTRYPOINT ["/protoc-gen-jsonschema"]`) {
+		t.Fatalf("the dockerfile does not build the plugin:\n%s", BUF_PLUGIN_DOCKERFILE)
+	}
+}
@@ -0,0 +1,454 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const COMMON_PROTO = "common.proto"
+
+type BundleDocument struct {
+	ID      string
+	Package string
+	Path    string
+	Source  string
+	Schema  []byte
+	Root    string
+	imports map[string]bool
+}
+
+type Bundle struct {
+	Documents   []*BundleDocument
+	Files       map[string]*ProtoFile
+	options     Options
+	combined    bool
+	diagnostics []Diagnostic
+}
+
+func NewBundle(schemas [][]byte, defaultPackage string, options Options) *Bundle {
+	output := Bundle{options: options}
+	for index, value := range schemas {
+		schema := Schema{}
+		err := json.Unmarshal(value, &schema)
+		if err != nil {
+			panic(err)
+		}
+		document := BundleDocument{Schema: value, Root: RootMessageName(schema), imports: make(map[string]bool)}
+		if schema.ID != nil {
+			document.ID = trimFragment(*schema.ID)
+		}
+		if len(document.ID) == 0 {
+			document.ID = fmt.Sprintf("schema%d.json", index+1)
+		}
+		packageName, directory, stem := PackageFromID(document.ID)
+		if len(packageName) == 0 {
+			packageName = defaultPackage
+		}
+		document.Package = packageName
+		document.Path = path.Join(directory, stem+".proto")
+		output.Documents = append(output.Documents, &document)
+	}
+	return &output
+}
+
+func (bundle *Bundle) Parse() map[string]string {
+	files := bundle.build()
+	output := make(map[string]string)
+	bundle.Files = make(map[string]*ProtoFile)
+	if bundle.options.ExtractCommon {
+		if common := bundle.extractCommon(files); common != nil {
+			output[COMMON_PROTO] = Render(NewTemplate(bundle.options), common, bundle.options)
+			bundle.Files[COMMON_PROTO] = common
+		}
+	}
+	if bundle.options.SplitEnums {
+		enumsPaths := make([]string, 0)
+		for index, document := range bundle.Documents {
+			enumsPath := path.Join(path.Dir(document.Path), ENUMS_PROTO)
+			enums, ok := bundle.Files[enumsPath]
+			if !ok {
+				enums = &ProtoFile{Metadata: files[index].Metadata, Package: document.Package}
+				bundle.Files[enumsPath] = enums
+				enumsPaths = append(enumsPaths, enumsPath)
+			}
+			SplitEnums(files[index], enums, bundle.options.ImportPath(enumsPath), func(diagnostic Diagnostic) {
+				bundle.diagnostics = append(bundle.diagnostics, diagnostic)
+			})
+		}
+		for _, enumsPath := range enumsPaths {
+			enums := bundle.Files[enumsPath]
+			if len(enums.Definitions) == 0 {
+				delete(bundle.Files, enumsPath)
+				continue
+			}
+			output[enumsPath] = Render(NewTemplate(bundle.options), enums, bundle.options)
+		}
+	}
+	for index, document := range bundle.Documents {
+		output[document.Path] = Render(NewTemplate(bundle.options), files[index], bundle.options)
+		bundle.Files[document.Path] = files[index]
+		if UsesJ2pOptions(files[index]) {
+			output[J2P_OPTIONS_PROTO] = J2P_OPTIONS
+		}
+	}
+	return output
+}
+
+func (bundle *Bundle) build() []*ProtoFile {
+	files := make([]*ProtoFile, 0)
+	for _, document := range bundle.Documents {
+		options := bundle.options
+		_document := document
+		options.ExternalRefResolver = func(ref string) (string, bool) {
+			return bundle.resolve(_document, ref)
+		}
+		parser := NewWithOptions(document.Schema, options)
+		file := parser.Build(document.Package)
+		imports := make([]string, 0)
+		for value := range document.imports {
+			imports = append(imports, value)
+		}
+		sort.Strings(imports)
+		file.Imports = append(file.Imports, imports...)
+		files = append(files, file)
+		bundle.diagnostics = append(bundle.diagnostics, parser.Diagnostics()...)
+	}
+	return files
+}
+
+func (bundle *Bundle) Combine(packageName string) *ProtoFile {
+	bundle.combined = true
+	for _, document := range bundle.Documents {
+		document.Package = packageName
+	}
+	output := ProtoFile{Package: packageName}
+	definitions := make(map[string]ProtoDefinition)
+	structures := make(map[string]string)
+	services := make(map[string]*ProtoService)
+	imports := make(map[string]bool)
+	for index, file := range bundle.build() {
+		if output.Metadata == nil {
+			output.Metadata = file.Metadata
+		}
+		for _, value := range file.Imports {
+			imports[value] = true
+		}
+		renames := make(map[string]string)
+		kept := make([]ProtoDefinition, 0)
+		for _, definition := range file.Definitions {
+			renameTypes(definition, renames)
+			name := definitionName(definition)
+			structure := structureKey(definition)
+			if existing, ok := definitions[name]; ok && structureKey(existing) == structure {
+				continue
+			}
+			if existing, ok := structures[structure]; ok && (definition.Message == nil || len(definition.Message.Fields) != 0) {
+				bundle.diagnostics = append(bundle.diagnostics, Diagnostic{Severity: INFO, Subject: bundle.Documents[index].ID, Message: fmt.Sprintf("%s is identical to %s and was merged into it", name, existing)})
+				renames[name] = existing
+				continue
+			}
+			if _, ok := definitions[name]; ok {
+				renamed := name
+				for suffix := 2; ; suffix++ {
+					renamed = fmt.Sprintf("%s%d", name, suffix)
+					if _, ok := definitions[renamed]; !ok {
+						break
+					}
+				}
+				bundle.diagnostics = append(bundle.diagnostics, Diagnostic{Severity: WARNING, Subject: bundle.Documents[index].ID, Message: fmt.Sprintf("%s differs from a definition with the same name in another schema and was renamed to %s", name, renamed)})
+				renames[name] = renamed
+				if definition.Message != nil {
+					definition.Message.Name = renamed
+				} else {
+					definition.Enum.Name = renamed
+				}
+			}
+			definitions[definitionName(definition)] = definition
+			structures[structure] = definitionName(definition)
+			kept = append(kept, definition)
+		}
+		for _, definition := range kept {
+			renameTypes(definition, renames)
+		}
+		output.Definitions = append(output.Definitions, kept...)
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				method.Input = renameType(method.Input, renames)
+				method.Output = renameType(method.Output, renames)
+			}
+			if existing, ok := services[service.Name]; ok {
+				existing.Methods = append(existing.Methods, service.Methods...)
+				continue
+			}
+			services[service.Name] = service
+			output.Services = append(output.Services, service)
+		}
+	}
+	for _, document := range bundle.Documents {
+		delete(imports, bundle.options.ImportPath(document.Path))
+	}
+	for value := range imports {
+		output.Imports = append(output.Imports, value)
+	}
+	sort.Strings(output.Imports)
+	return &output
+}
+
+func renameTypes(definition ProtoDefinition, renames map[string]string) {
+	if definition.Message == nil {
+		return
+	}
+	for _, field := range definition.Message.Fields {
+		field.Type = renameType(field.Type, renames)
+	}
+}
+
+func renameType(typeName string, renames map[string]string) string {
+	if strings.HasPrefix(typeName, "map<") {
+		index := strings.Index(typeName, ", ")
+		return typeName[:index+2] + renameType(strings.TrimSuffix(typeName[index+2:], ">"), renames) + ">"
+	}
+	if renamed, ok := renames[typeName]; ok {
+		return renamed
+	}
+	return typeName
+}
+
+func structureKey(definition ProtoDefinition) string {
+	if definition.Enum != nil {
+		values := make([]ProtoEnumValue, 0)
+		for _, value := range definition.Enum.Values {
+			values = append(values, ProtoEnumValue{Name: value.Name, Number: value.Number})
+		}
+		return "enum " + string(EncodeJson(values)) + fmt.Sprint(definition.Enum.ProtoReserved, definition.Enum.AllowAlias)
+	}
+	fields := make([]ProtoField, 0)
+	for _, field := range definition.Message.Fields {
+		_field := *field
+		_field.Pointer, _field.Original = "", ""
+		fields = append(fields, _field)
+	}
+	return "message " + string(EncodeJson(fields)) + fmt.Sprint(definition.Message.ProtoReserved)
+}
+
+func (bundle *Bundle) extractCommon(files []*ProtoFile) *ProtoFile {
+	occurrences := make(map[string][]ProtoDefinition)
+	for _, file := range files {
+		for _, definition := range file.Definitions {
+			name := definitionName(definition)
+			occurrences[name] = append(occurrences[name], definition)
+		}
+	}
+	common := make(map[string]bool)
+	for name, definitions := range occurrences {
+		if len(definitions) < 2 {
+			continue
+		}
+		shared := true
+		for _, definition := range definitions[1:] {
+			if !reflect.DeepEqual(definition, definitions[0]) {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			common[name] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name := range common {
+			message := occurrences[name][0].Message
+			if message == nil {
+				continue
+			}
+			for _, field := range message.Fields {
+				if _, ok := occurrences[field.Type]; ok && !common[field.Type] {
+					delete(common, name)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	if len(common) == 0 {
+		return nil
+	}
+	output := ProtoFile{Package: bundle.options.CommonPackage}
+	names := make([]string, 0)
+	for name := range common {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		definitions := make([]ProtoDefinition, 0)
+		uses := false
+		for _, definition := range file.Definitions {
+			name := definitionName(definition)
+			if !common[name] {
+				definitions = append(definitions, definition)
+				continue
+			}
+			if !seen[name] {
+				seen[name] = true
+				output.Definitions = append(output.Definitions, definition)
+			}
+		}
+		file.Definitions = definitions
+		for _, definition := range file.Definitions {
+			if definition.Message == nil {
+				continue
+			}
+			for _, field := range definition.Message.Fields {
+				if common[field.Type] {
+					field.Type = fmt.Sprintf("%s.%s", output.Package, field.Type)
+					uses = true
+				}
+			}
+		}
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				if common[method.Input] {
+					method.Input = fmt.Sprintf("%s.%s", output.Package, method.Input)
+					uses = true
+				}
+				if common[method.Output] {
+					method.Output = fmt.Sprintf("%s.%s", output.Package, method.Output)
+					uses = true
+				}
+			}
+		}
+		if uses {
+			file.Imports = append(file.Imports, bundle.options.ImportPath(COMMON_PROTO))
+		}
+		if !usesAny(file) {
+			file.Imports = removeString(file.Imports, ANY_IMPORT)
+		}
+	}
+	if usesAny(&output) {
+		output.Imports = append([]string{ANY_IMPORT}, output.Imports...)
+	}
+	output.Imports = append(output.Imports, bundle.commonImports(&output)...)
+	bundle.diagnostics = append(bundle.diagnostics, Diagnostic{Severity: INFO, Subject: COMMON_PROTO, Message: fmt.Sprintf("extracted %s shared by several schemas", strings.Join(names, ", "))})
+	return &output
+}
+
+func (bundle *Bundle) commonImports(file *ProtoFile) []string {
+	mappings := importMap(bundle.options)
+	imports := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			for _, mapping := range mappings {
+				if mapping.Type == field.Type && len(mapping.Import) != 0 {
+					imports[mapping.Import] = true
+				}
+			}
+			for _, document := range bundle.Documents {
+				if strings.HasPrefix(field.Type, document.Package+".") {
+					imports[bundle.options.ImportPath(document.Path)] = true
+				}
+			}
+			if len(field.Behaviors) != 0 {
+				imports[FIELD_BEHAVIOR_IMPORT] = true
+			}
+		}
+	}
+	output := make([]string, 0)
+	for value := range imports {
+		output = append(output, value)
+	}
+	sort.Strings(output)
+	return output
+}
+
+func definitionName(definition ProtoDefinition) string {
+	if definition.Message != nil {
+		return definition.Message.Name
+	}
+	return definition.Enum.Name
+}
+
+func (bundle *Bundle) Diagnostics() []Diagnostic {
+	return bundle.diagnostics
+}
+
+func (bundle *Bundle) resolve(document *BundleDocument, ref string) (string, bool) {
+	base, err := url.Parse(document.ID)
+	if err != nil {
+		return "", false
+	}
+	target, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	resolved := base.ResolveReference(target)
+	fragment := resolved.Fragment
+	resolved.Fragment = ""
+	for _, _document := range bundle.Documents {
+		if _document.ID != resolved.String() {
+			continue
+		}
+		typeName := _document.Root
+		segments := strings.Split(strings.Trim(fragment, "/"), "/")
+		if len(fragment) != 0 && len(segments[len(segments)-1]) != 0 {
+			typeName = *toPascalCase(segments[len(segments)-1])
+		}
+		if _document != document && !bundle.combined {
+			document.imports[bundle.options.ImportPath(_document.Path)] = true
+		}
+		if bundle.combined {
+			return typeName, true
+		}
+		return fmt.Sprintf("%s.%s", _document.Package, typeName), true
+	}
+	return "", false
+}
+
+func PackageFromID(id string) (packageName string, directory string, stem string) {
+	parsed, err := url.Parse(trimFragment(id))
+	if err != nil {
+		return "", "", ""
+	}
+	components := make([]string, 0)
+	labels := strings.Split(parsed.Hostname(), ".")
+	if len(labels) > 1 {
+		labels = labels[:len(labels)-1]
+	}
+	for i := len(labels) - 1; i >= 0; i-- {
+		if labels[i] != "www" && len(labels[i]) != 0 {
+			components = append(components, toPackageComponent(labels[i]))
+		}
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if len(segment) != 0 {
+			components = append(components, toPackageComponent(segment))
+		}
+	}
+	stem = segments[len(segments)-1]
+	for _, extension := range []string{".json", ".schema", ".yaml", ".yml"} {
+		stem = strings.TrimSuffix(stem, extension)
+	}
+	return strings.Join(components, "."), strings.Join(components, "/"), toPackageComponent(stem)
+}
+
+func toPackageComponent(str string) string {
+	output, _ := sanitizeIdentifier(strings.ToLower(*fixString(str)))
+	return output
+}
+
+func trimFragment(id string) string {
+	if index := strings.Index(id, "#"); index >= 0 {
+		return id[:index]
+	}
+	return id
+}
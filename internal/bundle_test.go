@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+const BUNDLE_ORDER_SCHEMA = `{"$id": "https://acme.com/orders/order.json", "title": "Order", "type": "object", "properties": {"ship": {"$ref": "#/definitions/Address"}, "id": {"type": "string"}}, "definitions": {"Address": {"type": "object", "properties": {"city": {"type": "string"}}}}}`
+
+func TestBundleExtractCommon(t *testing.T) {
+	user := `{"$id": "https://acme.com/users/user.json", "title": "User", "type": "object", "properties": {"home": {"$ref": "#/definitions/Address"}}, "definitions": {"Address": {"type": "object", "properties": {"city": {"type": "string"}}}}}`
+	options := DefaultOptions()
+	options.ExtractCommon = true
+	files := NewBundle([][]byte{[]byte(BUNDLE_ORDER_SCHEMA), []byte(user)}, "test", options).Parse()
+	expected := map[string]string{
+		COMMON_PROTO:              "syntax = \"proto3\";\n\npackage common;\n\nmessage Address {\n  string city = 1;\n}\n",
+		"acme/orders/order.proto": "syntax = \"proto3\";\n\npackage acme.orders;\n\nimport \"common.proto\";\n\nmessage Order {\n  string id = 1;\n  common.Address ship = 2;\n}\n",
+		"acme/users/user.proto":   "syntax = \"proto3\";\n\npackage acme.users;\n\nimport \"common.proto\";\n\nmessage User {\n  common.Address home = 1;\n}\n",
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files, got %v", len(expected), files)
+	}
+	for name, content := range expected {
+		if files[name] != content {
+			t.Fatalf("%s: expected\n%s\ngot\n%s", name, content, files[name])
+		}
+	}
+}
+
+func TestBundleKeepsDivergentDefinitions(t *testing.T) {
+	user := `{"$id": "https://acme.com/users/user.json", "title": "User", "type": "object", "properties": {"home": {"$ref": "#/definitions/Address"}}, "definitions": {"Address": {"type": "object", "properties": {"street": {"type": "string"}}}}}`
+	options := DefaultOptions()
+	options.ExtractCommon = true
+	files := NewBundle([][]byte{[]byte(BUNDLE_ORDER_SCHEMA), []byte(user)}, "test", options).Parse()
+	if _, ok := files[COMMON_PROTO]; ok {
+		t.Fatalf("divergent definitions were extracted:\n%s", files[COMMON_PROTO])
+	}
+	if !strings.Contains(files["acme/users/user.proto"], "message Address {\n  string street = 1;\n}") {
+		t.Fatalf("the local Address was not kept:\n%s", files["acme/users/user.proto"])
+	}
+}
@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var CAPNP_SCALARS = map[string]string{
+	"double":   "Float64",
+	"float":    "Float32",
+	"int32":    "Int32",
+	"sint32":   "Int32",
+	"sfixed32": "Int32",
+	"uint32":   "UInt32",
+	"fixed32":  "UInt32",
+	"int64":    "Int64",
+	"sint64":   "Int64",
+	"sfixed64": "Int64",
+	"uint64":   "UInt64",
+	"fixed64":  "UInt64",
+	"bool":     "Bool",
+	"string":   "Text",
+	"bytes":    "Data",
+}
+
+var CAPNP_WELL_KNOWN_TYPES = map[string]string{
+	"google.protobuf.Timestamp":   "Text",
+	"google.protobuf.Duration":    "Text",
+	"google.protobuf.FieldMask":   "Text",
+	"google.protobuf.Struct":      "Text",
+	"google.protobuf.Value":       "Text",
+	"google.protobuf.ListValue":   "Text",
+	"google.protobuf.Any":         "Text",
+	"google.protobuf.Empty":       "Void",
+	"google.protobuf.DoubleValue": "Float64",
+	"google.protobuf.FloatValue":  "Float32",
+	"google.protobuf.Int64Value":  "Int64",
+	"google.protobuf.UInt64Value": "UInt64",
+	"google.protobuf.Int32Value":  "Int32",
+	"google.protobuf.UInt32Value": "UInt32",
+	"google.protobuf.BoolValue":   "Bool",
+	"google.protobuf.StringValue": "Text",
+	"google.protobuf.BytesValue":  "Data",
+	"google.type.Date":            "Text",
+	"google.type.TimeOfDay":       "Text",
+	"google.rpc.Status":           "Text",
+}
+
+var CAPNP_KEYWORDS = map[string]bool{
+	"annotation": true, "const": true, "enum": true, "extends": true, "false": true, "import": true, "inf": true,
+	"interface": true, "struct": true, "true": true, "union": true, "using": true, "void": true,
+}
+
+const CAPNP_TEMPLATE = `# Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}# versions:
+#   j2p {{.Version}}
+{{if .Source}}# source: {{.Source}}
+{{end}}# sha256: {{.Hash}}
+{{if .Timestamp}}# generated: {{.Timestamp}}
+{{end}}{{end}}
+@{{.Id}};
+{{range .Types}}{{if .Values}}
+enum {{.Name}} @{{.Id}} {
+{{range .Values}}  {{.Name}} @{{.Value}};
+{{end}}}
+{{else}}
+struct {{.Name}} @{{.Id}} {
+{{range .Fields}}{{if .Members}}  {{.Name}} :union {
+{{range .Members}}    {{.Name}} @{{.Ordinal}} :{{.Type}};{{with .Comment}} # {{.}}{{end}}
+{{end}}  }
+{{else}}  {{.Name}} @{{.Ordinal}} :{{.Type}};{{with .Comment}} # {{.}}{{end}}
+{{end}}{{end}}}
+{{end}}{{end}}`
+
+type CapnpFile struct {
+	Metadata *ProtoMetadata
+	Id       string
+	Types    []*CapnpType
+}
+
+type CapnpType struct {
+	Id     string
+	Name   string
+	Fields []CapnpField
+	Values []GoValue
+}
+
+type CapnpField struct {
+	Name    string
+	Ordinal int
+	Type    string
+	Comment string
+	Members []CapnpField
+}
+
+type capnpBuilder struct {
+	file    *ProtoFile
+	id      string
+	types   []*CapnpType
+	defined map[string]bool
+}
+
+func RenderCapnp(file *ProtoFile, options Options) string {
+	capnpFile := NewCapnpFile(file, options)
+	var buffer bytes.Buffer
+	err := template.Must(template.New("capnp").Parse(CAPNP_TEMPLATE)).Execute(&buffer, capnpFile)
+	if err != nil {
+		panic(err)
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(buffer.String(), "\n", "\r\n")
+	}
+	return buffer.String()
+}
+
+func NewCapnpFile(file *ProtoFile, options Options) *CapnpFile {
+	output := CapnpFile{Metadata: file.Metadata, Id: CapnpId(file.Package)}
+	builder := capnpBuilder{file: file, id: output.Id, defined: make(map[string]bool)}
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			builder.types = append(builder.types, builder.enum(definition.Enum))
+			continue
+		}
+		builder.types = append(builder.types, builder.message(definition.Message))
+	}
+	output.Types = builder.types
+	return &output
+}
+
+func CapnpId(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return fmt.Sprintf("0x%016x", binary.BigEndian.Uint64(hash[:8])|1<<63)
+}
+
+func (rcvr *capnpBuilder) message(message *ProtoMessage) *CapnpType {
+	name := toCapnpTypeName(rcvr.file.Package, message.Name)
+	output := CapnpType{Id: CapnpId(rcvr.id + "." + name), Name: name}
+	ordinals := capnpOrdinals(message)
+	for _, member := range message.Members() {
+		if member.Oneof == nil || len(member.Oneof.Fields) == 1 {
+			field := member.Field
+			if field == nil {
+				field = member.Oneof.Fields[0]
+			}
+			output.Fields = append(output.Fields, CapnpField{Name: toCapnpName(sourceName(field)), Ordinal: ordinals[field], Type: rcvr.fieldType(name, field), Comment: field.Comment})
+			continue
+		}
+		unionName := member.Oneof.Fields[0].Original
+		union := CapnpField{}
+		for _, field := range member.Oneof.Fields {
+			if field.Original != unionName {
+				unionName = member.Oneof.Name
+			}
+			union.Members = append(union.Members, CapnpField{Name: toCapnpName(sourceMemberName(field)), Ordinal: ordinals[field], Type: rcvr.fieldType(name, field), Comment: field.Comment})
+		}
+		if len(unionName) == 0 {
+			unionName = member.Oneof.Name
+		}
+		union.Name = toCapnpName(unionName)
+		output.Fields = append(output.Fields, union)
+	}
+	return &output
+}
+
+func capnpOrdinals(message *ProtoMessage) map[*ProtoField]int {
+	fields := make([]*ProtoField, len(message.Fields))
+	copy(fields, message.Fields)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Number < fields[j].Number
+	})
+	output := make(map[*ProtoField]int)
+	for index, field := range fields {
+		output[field] = index
+	}
+	return output
+}
+
+func (rcvr *capnpBuilder) enum(enum *ProtoEnum) *CapnpType {
+	name := toCapnpTypeName("", enum.Name)
+	output := CapnpType{Id: CapnpId(rcvr.id + "." + name), Name: name}
+	prefix := strings.ToUpper(enum.Name) + "_"
+	numbers := make(map[int]bool)
+	for _, value := range enum.Values {
+		if numbers[value.Number] {
+			continue
+		}
+		numbers[value.Number] = true
+		output.Values = append(output.Values, GoValue{Name: toCapnpName(strings.TrimPrefix(value.Name, prefix)), Value: fmt.Sprint(len(output.Values))})
+	}
+	return &output
+}
+
+func (rcvr *capnpBuilder) fieldType(structName string, field *ProtoField) string {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		key := rcvr.typeOf(strings.TrimPrefix(field.Type[:index], "map<"))
+		value := rcvr.typeOf(strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")))
+		name := structName + toGoName(field.Name) + "Entry"
+		if !rcvr.defined[name] {
+			rcvr.defined[name] = true
+			rcvr.types = append(rcvr.types, &CapnpType{Id: CapnpId(rcvr.id + "." + name), Name: name, Fields: []CapnpField{{Name: "key", Ordinal: 0, Type: key}, {Name: "value", Ordinal: 1, Type: value}}})
+		}
+		return fmt.Sprintf("List(%s)", name)
+	}
+	typeName := rcvr.typeOf(field.Type)
+	if field.Label == REPEATED_LABEL {
+		return fmt.Sprintf("List(%s)", typeName)
+	}
+	return typeName
+}
+
+func (rcvr *capnpBuilder) typeOf(typeName string) string {
+	if scalar, ok := CAPNP_SCALARS[typeName]; ok {
+		return scalar
+	}
+	if wellKnown, ok := CAPNP_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		return wellKnown
+	}
+	return toCapnpTypeName(rcvr.file.Package, typeName)
+}
+
+func toCapnpTypeName(packageName string, typeName string) string {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(packageName) != 0 {
+		typeName = strings.TrimPrefix(typeName, packageName+".")
+	}
+	segments := strings.Split(typeName, ".")
+	for index, segment := range segments {
+		segments[index] = toGoName(segment)
+	}
+	return strings.Join(segments, "")
+}
+
+func toCapnpName(name string) string {
+	output := lowerInitialism(toGoName(name))
+	if CAPNP_KEYWORDS[output] {
+		return output + "Field"
+	}
+	return output
+}
+
+func lowerInitialism(name string) string {
+	runes := []rune(name)
+	index := 0
+	for index < len(runes) && unicode.IsUpper(runes[index]) {
+		index++
+	}
+	if index > 1 && index < len(runes) && unicode.IsLower(runes[index]) {
+		index--
+	}
+	for i := 0; i < index; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
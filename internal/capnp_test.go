@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+const CAPNP_TEST_SCHEMA = `{"title": "Link", "type": "object", "properties": {"url": {"type": "string"}, "id": {"type": "string"}, "httpServer": {"type": "string"}, "name": {"type": "string"}, "kind": {"oneOf": [{"type": "string"}, {"type": "integer"}]}}}`
+
+func TestRenderCapnp(t *testing.T) {
+	options := DefaultOptions()
+	file := NewWithOptions([]byte(CAPNP_TEST_SCHEMA), options).Build("test")
+	expected := `struct Link @0xc92ee819da5fbf22 {
+  id @0 :Text;
+  url @1 :Text;
+  kind :union {
+    kindString @2 :Text;
+    kindInt32 @3 :Int32;
+  }
+  name @4 :Text;
+  httpServer @5 :Text;
+}`
+	if output := RenderCapnp(file, options); !strings.Contains(output, expected) {
+		t.Fatalf("unexpected capnp schema:\n%s", output)
+	}
+}
+
+func TestCapnpOrdinalsFollowFieldNumbers(t *testing.T) {
+	options := DefaultOptions()
+	options.Numbering = HASH_NUMBERING
+	file := NewWithOptions([]byte(CAPNP_TEST_SCHEMA), options).Build("test")
+	message := file.Definitions[0].Message
+	ordinals := capnpOrdinals(message)
+	for _, field := range message.Fields {
+		for _, other := range message.Fields {
+			if field.Number < other.Number && ordinals[field] > ordinals[other] {
+				t.Fatalf("%s (%d) is ordered after %s (%d)", field.Name, field.Number, other.Name, other.Number)
+			}
+		}
+	}
+}
+
+func TestToCapnpName(t *testing.T) {
+	tests := map[string]string{
+		"id":          "id",
+		"url":         "url",
+		"http_server": "httpServer",
+		"urlPath":     "urlPath",
+		"name":        "name",
+		"struct":      "structField",
+	}
+	for name, expected := range tests {
+		if output := toCapnpName(name); output != expected {
+			t.Fatalf("%s: expected %s, got %s", name, expected, output)
+		}
+	}
+}
+
+const INITIALISM_TEST_SCHEMA = `{"title": "Resp", "type": "object", "properties": {"HTTPCode": {"type": "integer"}, "userID": {"type": "string"}, "first_name": {"type": "string"}, "note": {"oneOf": [{"type": "string"}, {"type": "integer"}]}}}`
+
+func initialismTestFile(t *testing.T) (*ProtoFile, Options) {
+	t.Helper()
+	options := DefaultOptions()
+	options.OneofMemberNameTemplate = "_$NAME$__as__$TYPE$_"
+	return NewWithOptions([]byte(INITIALISM_TEST_SCHEMA), options).Build("test"), options
+}
+
+func TestCapnpFieldNames(t *testing.T) {
+	file, options := initialismTestFile(t)
+	output := RenderCapnp(file, options)
+	for _, expected := range []string{"  httpCode @", "  userID @", "  firstName @", "  note :union {", "    noteString @", "    noteInt32 @"} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("expected %q in\n%s", expected, output)
+		}
+	}
+}
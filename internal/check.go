@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+func CheckProto(file string, importPaths []string, sourceMap SourceMap, diagnosticHandler DiagnosticHandler) error {
+	failures := 0
+	diagnostic := func(severity Severity, err reporter.ErrorWithPos) {
+		position := err.GetPosition()
+		message := err.Error()
+		for strings.HasPrefix(message, position.String()+": ") {
+			message = strings.TrimPrefix(message, position.String()+": ")
+		}
+		if entry, ok := sourceMap.Lookup(position.Line); ok && position.Filename == file {
+			diagnosticHandler(Diagnostic{Severity: severity, Subject: entry.Pointer, Message: fmt.Sprintf("%s: %s", position, message)})
+			return
+		}
+		diagnosticHandler(Diagnostic{Severity: severity, Subject: position.String(), Message: message})
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+		Reporter: reporter.NewReporter(func(err reporter.ErrorWithPos) error {
+			failures++
+			diagnostic(ERROR, err)
+			return nil
+		}, func(err reporter.ErrorWithPos) {
+			diagnostic(WARNING, err)
+		}),
+	}
+	_, err := compiler.Compile(context.Background(), file)
+	var errorWithPos reporter.ErrorWithPos
+	if failures == 0 && errors.As(err, &errorWithPos) {
+		failures++
+		diagnostic(ERROR, errorWithPos)
+	}
+	if failures != 0 {
+		return fmt.Errorf("%s does not compile", file)
+	}
+	return err
+}
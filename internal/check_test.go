@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckProto(t *testing.T) {
+	sourceMap := SourceMap{Entries: []SourceMapEntry{{Line: 3, Kind: "message", Name: "Order", Pointer: "#"}, {Line: 5, Kind: "field", Name: "Order.item", Pointer: "#/properties/item"}}}
+	tests := []struct {
+		name        string
+		content     string
+		diagnostics string
+		error       string
+	}{
+		{"valid", "syntax = \"proto3\";\n\nmessage Order {\n  string id = 1;\n  string item = 2;\n}\n", "[]", ""},
+		{"mapped field", "syntax = \"proto3\";\n\nmessage Order {\n  string id = 1;\n  Item item = 2;\n}\n", "[error: #/properties/item: order.proto:5:3: field Order.item: unknown type Item]", "order.proto does not compile"},
+		{"mapped message", "syntax = \"proto3\";\n\nmessage Order {\n  Item id = 1;\n  string item = 2;\n}\n", "[error: #: order.proto:4:3: field Order.id: unknown type Item]", "order.proto does not compile"},
+		{"warning", "syntax = \"proto3\";\nimport \"google/protobuf/empty.proto\";\nmessage Order {\n  string id = 1;\n}\n", "[warning: order.proto:2:1: import \"google/protobuf/empty.proto\" not used]", ""},
+		{"missing import", "syntax = \"proto3\";\n\nimport \"missing.proto\";\n\nmessage Order {\n  string id = 1;\n}\n", "[error: #: order.proto:3:8: open DIR/missing.proto: no such file or directory]", "order.proto does not compile"},
+		{"syntax", "syntax = \"proto3\"\n\nmessage Order {\n  string id = 1;\n}\n", "[error: #: order.proto:3:1: syntax error: unexpected \"message\", expecting string literal or ';']", "order.proto does not compile"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(directory, "order.proto"), []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			diagnostics := make([]Diagnostic, 0)
+			err := CheckProto("order.proto", []string{directory}, sourceMap, func(diagnostic Diagnostic) {
+				diagnostics = append(diagnostics, diagnostic)
+			})
+			if output := strings.ReplaceAll(fmt.Sprint(diagnostics), directory, "DIR"); output != test.diagnostics {
+				t.Fatalf("expected the diagnostics %s, got %s", test.diagnostics, output)
+			}
+			if (err == nil && len(test.error) != 0) || (err != nil && err.Error() != test.error) {
+				t.Fatalf("expected the error %q, got %v", test.error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var COMPOUND_VALUE_KEYWORDS = map[string]bool{"enum": true, "const": true, "default": true, "examples": true, "example": true}
+
+type compound struct {
+	resources         map[string]string
+	anchors           map[string]string
+	diagnosticHandler DiagnosticHandler
+}
+
+func ResolveCompound(data []byte, diagnosticHandler DiagnosticHandler) ([]byte, error) {
+	document, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := document.(*JsonObject)
+	if !ok {
+		return data, nil
+	}
+	context := compound{resources: make(map[string]string), anchors: make(map[string]string), diagnosticHandler: diagnosticHandler}
+	base := &url.URL{}
+	if id, ok := root.Get("$id"); ok {
+		if parsed, err := url.Parse(fmt.Sprint(id)); err == nil {
+			base = parsed
+		}
+	}
+	base.Fragment = ""
+	context.resources[base.String()] = ""
+	embedded := context.index(root, base, "", true)
+	if embedded == 0 {
+		return data, nil
+	}
+	context.rewrite(root, base)
+	diagnosticHandler(Diagnostic{Severity: INFO, Subject: "#", Message: fmt.Sprintf("resolved references to %d embedded schema resources", embedded)})
+	return EncodeJson(root), nil
+}
+
+func (context *compound) index(node any, base *url.URL, pointer string, root bool) int {
+	output := 0
+	switch value := node.(type) {
+	case *JsonObject:
+		{
+			if id, ok := value.Get("$id"); ok && !root {
+				if _id, ok := id.(string); ok {
+					if parsed, err := url.Parse(_id); err == nil {
+						base = base.ResolveReference(parsed)
+						base.Fragment = ""
+						if existing, ok := context.resources[base.String()]; ok {
+							context.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: "#" + pointer, Message: fmt.Sprintf("$id %s is already used by #%s", base, existing)})
+						} else {
+							context.resources[base.String()] = pointer
+							output++
+						}
+					}
+				}
+			}
+			if anchor, ok := value.Get("$anchor"); ok {
+				if _anchor, ok := anchor.(string); ok {
+					context.anchors[base.String()+"#"+_anchor] = pointer
+				}
+			}
+			for _, key := range value.Keys {
+				if COMPOUND_VALUE_KEYWORDS[key] {
+					continue
+				}
+				output += context.index(value.Values[key], base, pointer+"/"+escapePointer(key), false)
+			}
+		}
+	case []any:
+		{
+			for index, item := range value {
+				output += context.index(item, base, fmt.Sprintf("%s/%d", pointer, index), false)
+			}
+		}
+	}
+	return output
+}
+
+func (context *compound) rewrite(node any, base *url.URL) {
+	switch value := node.(type) {
+	case *JsonObject:
+		{
+			if id, ok := value.Get("$id"); ok {
+				if _id, ok := id.(string); ok {
+					if parsed, err := url.Parse(_id); err == nil {
+						base = base.ResolveReference(parsed)
+						base.Fragment = ""
+					}
+				}
+			}
+			for _, key := range value.Keys {
+				if COMPOUND_VALUE_KEYWORDS[key] {
+					continue
+				}
+				if ref, ok := value.Values[key].(string); ok && key == "$ref" {
+					value.Values[key] = context.rewriteRef(ref, base)
+					continue
+				}
+				context.rewrite(value.Values[key], base)
+			}
+		}
+	case []any:
+		{
+			for _, item := range value {
+				context.rewrite(item, base)
+			}
+		}
+	}
+}
+
+func (context *compound) rewriteRef(ref string, base *url.URL) string {
+	target, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	resolved := base.ResolveReference(target)
+	fragment := resolved.Fragment
+	resolved.Fragment = ""
+	pointer, ok := context.resources[resolved.String()]
+	if !ok {
+		return ref
+	}
+	if len(fragment) != 0 && !strings.HasPrefix(fragment, "/") {
+		anchor, ok := context.anchors[resolved.String()+"#"+fragment]
+		if !ok {
+			return ref
+		}
+		return "#" + anchor
+	}
+	return "#" + pointer + fragment
+}
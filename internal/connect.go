@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"strings"
+)
+
+var CONNECT_SCALARS = map[string]string{
+	"double":   "double",
+	"float":    "float",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"uint32":   "int64",
+	"fixed32":  "int64",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint64":   "int64",
+	"fixed64":  "int64",
+	"bool":     "boolean",
+	"string":   "string",
+	"bytes":    "bytes",
+}
+
+var CONNECT_WELL_KNOWN_TYPES = map[string][2]string{
+	"google.protobuf.Timestamp":   {"int64", "org.apache.kafka.connect.data.Timestamp"},
+	"google.protobuf.Duration":    {"string", ""},
+	"google.protobuf.FieldMask":   {"string", ""},
+	"google.protobuf.Struct":      {"string", ""},
+	"google.protobuf.Value":       {"string", ""},
+	"google.protobuf.ListValue":   {"string", ""},
+	"google.protobuf.Any":         {"string", ""},
+	"google.protobuf.Empty":       {"string", ""},
+	"google.protobuf.DoubleValue": {"double", ""},
+	"google.protobuf.FloatValue":  {"float", ""},
+	"google.protobuf.Int64Value":  {"int64", ""},
+	"google.protobuf.UInt64Value": {"int64", ""},
+	"google.protobuf.Int32Value":  {"int32", ""},
+	"google.protobuf.UInt32Value": {"int64", ""},
+	"google.protobuf.BoolValue":   {"boolean", ""},
+	"google.protobuf.StringValue": {"string", ""},
+	"google.protobuf.BytesValue":  {"bytes", ""},
+	"google.type.Date":            {"int32", "org.apache.kafka.connect.data.Date"},
+	"google.type.TimeOfDay":       {"int32", "org.apache.kafka.connect.data.Time"},
+	"google.rpc.Status":           {"string", ""},
+}
+
+type connectWriter struct {
+	file        *ProtoFile
+	definitions map[string]ProtoDefinition
+	path        map[string]bool
+}
+
+func RenderConnect(file *ProtoFile, options Options) string {
+	writer := connectWriter{file: file, definitions: make(map[string]ProtoDefinition), path: make(map[string]bool)}
+	referenced := referencedTypes(file)
+	for _, definition := range file.Definitions {
+		writer.definitions[definitionName(definition)] = definition
+	}
+	var output any = NewJsonObject()
+	for _, definition := range file.Definitions {
+		if definition.Message != nil && !referenced[definition.Message.Name] {
+			output = writer.schema(definition.Message.Name, false)
+			break
+		}
+	}
+	content := string(EncodeJson(output)) + "\n"
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	return content
+}
+
+func (rcvr *connectWriter) schema(typeName string, optional bool) *JsonObject {
+	output := NewJsonObject()
+	if scalar, ok := CONNECT_SCALARS[typeName]; ok {
+		output.Set("type", scalar)
+		output.Set("optional", optional)
+		return output
+	}
+	if wellKnown, ok := CONNECT_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		output.Set("type", wellKnown[0])
+		output.Set("optional", optional)
+		if len(wellKnown[1]) != 0 {
+			output.Set("name", wellKnown[1])
+			output.Set("version", 1)
+		}
+		return output
+	}
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(rcvr.file.Package) != 0 {
+		typeName = strings.TrimPrefix(typeName, rcvr.file.Package+".")
+	}
+	definition, ok := rcvr.definitions[typeName]
+	if !ok || definition.Enum != nil || rcvr.path[typeName] {
+		output.Set("type", "string")
+		output.Set("optional", optional)
+		return output
+	}
+	rcvr.path[typeName] = true
+	defer delete(rcvr.path, typeName)
+	fields := make([]any, 0)
+	for _, field := range definition.Message.Fields {
+		_optional := !field.Required || len(field.Oneof) != 0
+		_field := NewJsonObject()
+		_field.Set("field", bigQueryName(field))
+		if strings.HasPrefix(field.Type, "map<") {
+			index := strings.Index(field.Type, ",")
+			_field.Set("type", "map")
+			_field.Set("keys", rcvr.schema(strings.TrimPrefix(field.Type[:index], "map<"), false))
+			_field.Set("values", rcvr.schema(strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")), true))
+			_field.Set("optional", _optional)
+		} else if field.Label == REPEATED_LABEL {
+			_field.Set("type", "array")
+			_field.Set("items", rcvr.schema(field.Type, false))
+			_field.Set("optional", _optional)
+		} else {
+			schema := rcvr.schema(field.Type, _optional)
+			for _, key := range schema.Keys {
+				_field.Set(key, schema.Values[key])
+			}
+		}
+		if len(field.Comment) != 0 {
+			_field.Set("doc", field.Comment)
+		}
+		fields = append(fields, _field)
+	}
+	output.Set("type", "struct")
+	output.Set("fields", fields)
+	output.Set("optional", optional)
+	output.Set("name", connectName(rcvr.file.Package, typeName))
+	return output
+}
+
+func connectName(packageName string, typeName string) string {
+	typeName = strings.ReplaceAll(typeName, ".", "_")
+	if len(packageName) != 0 {
+		return packageName + "." + typeName
+	}
+	return typeName
+}
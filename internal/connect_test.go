@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConnectCollections(t *testing.T) {
+	schema := `{"title": "Order", "type": "object", "required": ["tags", "labels"], "properties": {
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"notes": {"type": "array", "items": {"type": "string"}},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"counts": {"type": "object", "additionalProperties": {"type": "integer"}}
+	}}`
+	options := DefaultOptions()
+	options.Target = CONNECT_TARGET
+	output := struct {
+		Fields []struct {
+			Field    string `json:"field"`
+			Type     string `json:"type"`
+			Optional bool   `json:"optional"`
+		} `json:"fields"`
+	}{}
+	if err := json.Unmarshal([]byte(RenderTarget(NewWithOptions([]byte(schema), options).Build("test"), options)), &output); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"tags": false, "labels": false, "notes": true, "counts": true}
+	if len(output.Fields) != len(expected) {
+		t.Fatalf("expected %d fields, got %+v", len(expected), output.Fields)
+	}
+	for _, field := range output.Fields {
+		if optional, ok := expected[field.Field]; !ok || field.Optional != optional || (field.Type != "array" && field.Type != "map") {
+			t.Errorf("expected %s to be a collection with optional %v, got %+v", field.Field, optional, field)
+		}
+	}
+}
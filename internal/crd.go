@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const CRD_API_GROUP = "apiextensions.k8s.io/"
+
+var (
+	OBJECT_META_TYPE   = ImportMapping{Type: "k8s.io.apimachinery.pkg.apis.meta.v1.ObjectMeta", Import: "k8s.io/apimachinery/pkg/apis/meta/v1/generated.proto"}
+	INT_OR_STRING_TYPE = ImportMapping{Type: "k8s.io.apimachinery.pkg.util.intstr.IntOrString", Import: "k8s.io/apimachinery/pkg/util/intstr/generated.proto"}
+)
+
+const (
+	K8S_OBJECT_META   = "https://kubernetes.io/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+	K8S_INT_OR_STRING = "https://kubernetes.io/schemas/io.k8s.apimachinery.pkg.util.intstr.IntOrString"
+	K8S_FREE_FORM     = "http://json-schema.org/draft-07/schema"
+)
+
+type CrdVersion struct {
+	Name    string
+	ID      string
+	Package string
+	Storage bool
+	Schema  []byte
+}
+
+func IsCrd(data []byte) bool {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return false
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return false
+	}
+	kind, _ := document.Get("kind")
+	apiVersion, _ := document.Get("apiVersion")
+	_apiVersion, ok := apiVersion.(string)
+	return kind == "CustomResourceDefinition" && ok && strings.HasPrefix(_apiVersion, CRD_API_GROUP)
+}
+
+func FromCrd(data []byte, wellKnownTypes bool, diagnosticHandler DiagnosticHandler) ([]CrdVersion, error) {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return nil, fmt.Errorf("custom resource definitions must be objects")
+	}
+	spec := jsonObject(document, "spec")
+	group, _ := spec.Get("group")
+	kind, _ := jsonObject(spec, "names").Get("kind")
+	_group, _ := group.(string)
+	_kind, _ := kind.(string)
+	if len(_group) == 0 || len(_kind) == 0 {
+		return nil, fmt.Errorf("custom resource definitions require spec.group and spec.names.kind")
+	}
+	labels := strings.Split(_group, ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	versions := make([]any, 0)
+	if value, ok := spec.Get("versions"); ok {
+		versions, _ = value.([]any)
+	}
+	if version, ok := spec.Get("version"); ok && len(versions) == 0 {
+		_version := NewJsonObject()
+		_version.Set("name", version)
+		_version.Set("storage", true)
+		versions = append(versions, _version)
+	}
+	output := make([]CrdVersion, 0)
+	for index, version := range versions {
+		_version, ok := version.(*JsonObject)
+		if !ok {
+			continue
+		}
+		pointer := fmt.Sprintf("#/spec/versions/%d", index)
+		name, _ := _version.Get("name")
+		_name, _ := name.(string)
+		if len(_name) == 0 {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "the version has no name and was skipped"})
+			continue
+		}
+		schema := jsonObject(jsonObject(_version, "schema"), "openAPIV3Schema")
+		if len(schema.Keys) == 0 {
+			schema = jsonObject(jsonObject(spec, "validation"), "openAPIV3Schema")
+			pointer = "#/spec/validation"
+		}
+		if len(schema.Keys) == 0 {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("version %s has no openAPIV3Schema and was skipped", _name)})
+			continue
+		}
+		copied, err := DecodeJson(EncodeJson(schema))
+		if err != nil {
+			return nil, err
+		}
+		schema = copied.(*JsonObject)
+		pointer += "/schema/openAPIV3Schema"
+		fromOpenApiSchema(schema, pointer, diagnosticHandler)
+		definitions := NewJsonObject()
+		(&crdSchema{definitions: definitions, wellKnownTypes: wellKnownTypes, diagnosticHandler: diagnosticHandler}).visit(schema, _kind, pointer)
+		if len(definitions.Keys) != 0 {
+			schema.Set("definitions", definitions)
+		}
+		properties := jsonObject(schema, "properties")
+		for _, property := range []string{"apiVersion", "kind"} {
+			if _, ok := properties.Get(property); !ok {
+				_property := NewJsonObject()
+				_property.Set("type", string(STRING))
+				properties.Set(property, _property)
+			}
+		}
+		if metadata := jsonObject(properties, "metadata"); len(jsonObject(metadata, "properties").Keys) == 0 && wellKnownTypes {
+			_metadata := NewJsonObject()
+			_metadata.Set("$ref", K8S_OBJECT_META)
+			properties.Set("metadata", _metadata)
+		}
+		schema.Set("properties", properties)
+		schema.Delete("$schema")
+		schema.Delete("$id")
+		root := NewJsonObject()
+		root.Set("$schema", "http://json-schema.org/draft-07/schema#")
+		root.Set("title", _kind)
+		for _, key := range schema.Keys {
+			root.Set(key, schema.Values[key])
+		}
+		id := path.Join(append(labels, _name, strings.ToLower(_kind)+".json")...)
+		root.Set("$id", id)
+		storage, _ := _version.Get("storage")
+		packageName, _, _ := PackageFromID(id)
+		output = append(output, CrdVersion{Name: _name, ID: id, Package: packageName, Storage: storage == true, Schema: EncodeJson(root)})
+	}
+	if len(output) == 0 {
+		return nil, fmt.Errorf("the custom resource definition has no version with an openAPIV3Schema")
+	}
+	return output, nil
+}
+
+func StorageVersion(versions []CrdVersion) CrdVersion {
+	for _, version := range versions {
+		if version.Storage {
+			return version
+		}
+	}
+	return versions[0]
+}
+
+type crdSchema struct {
+	definitions       *JsonObject
+	wellKnownTypes    bool
+	diagnosticHandler DiagnosticHandler
+}
+
+func (rcvr *crdSchema) visit(schema *JsonObject, name string, pointer string) {
+	if value, _ := schema.Get("x-kubernetes-int-or-string"); value == true {
+		for _, key := range []string{"type", "anyOf", "oneOf", "pattern", "format"} {
+			schema.Delete(key)
+		}
+		if rcvr.wellKnownTypes {
+			schema.Set("$ref", K8S_INT_OR_STRING)
+		} else {
+			integer, _string := NewJsonObject(), NewJsonObject()
+			integer.Set("type", string(INTEGER))
+			_string.Set("type", string(STRING))
+			schema.Set("anyOf", []any{integer, _string})
+		}
+	}
+	if value, _ := schema.Get("x-kubernetes-validations"); value != nil {
+		rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer + "/x-kubernetes-validations", Message: "cel validation rules have no proto equivalent and are ignored"})
+	}
+	_, properties := schema.Get("properties")
+	_, additionalProperties := schema.Get("additionalProperties")
+	if value, _ := schema.Get("x-kubernetes-preserve-unknown-fields"); value == true && !properties && !additionalProperties && rcvr.wellKnownTypes {
+		schema.Delete("type")
+		schema.Set("$ref", K8S_FREE_FORM)
+	}
+	for _, key := range schema.Keys {
+		if strings.HasPrefix(key, "x-kubernetes-") {
+			schema.Delete(key)
+		}
+	}
+	for _, key := range schema.Keys {
+		switch value := schema.Values[key].(type) {
+		case *JsonObject:
+			{
+				if key == "properties" {
+					for _, property := range value.Keys {
+						if _value, ok := value.Values[property].(*JsonObject); ok {
+							rcvr.visit(_value, upperFirst(property), pointer+"/properties/"+escapePointer(property))
+						}
+					}
+					continue
+				}
+				if key != "items" && key != "additionalProperties" && key != "not" {
+					continue
+				}
+				rcvr.visit(value, name, pointer+"/"+key)
+				if _, ok := value.Get("properties"); ok && key == "items" {
+					schema.Set(key, rcvr.hoist(value, name, pointer+"/items"))
+				}
+			}
+		case []any:
+			{
+				if key != "allOf" && key != "anyOf" && key != "oneOf" {
+					continue
+				}
+				for index, item := range value {
+					if _item, ok := item.(*JsonObject); ok {
+						rcvr.visit(_item, name, fmt.Sprintf("%s/%s/%d", pointer, key, index))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (rcvr *crdSchema) hoist(schema *JsonObject, name string, pointer string) *JsonObject {
+	_name := name
+	for index := 2; ; index++ {
+		if _, ok := rcvr.definitions.Get(_name); !ok {
+			break
+		}
+		_name = fmt.Sprintf("%s%d", name, index)
+	}
+	rcvr.definitions.Set(_name, schema)
+	rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: fmt.Sprintf("inline item schema was moved to #/definitions/%s", _name)})
+	ref := NewJsonObject()
+	ref.Set("$ref", "#/definitions/"+escapePointer(_name))
+	return ref
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+const WIDGET_CRD = `{
+	"apiVersion": "apiextensions.k8s.io/v1",
+	"kind": "CustomResourceDefinition",
+	"spec": {
+		"group": "example.com",
+		"names": {"kind": "Widget"},
+		"versions": [{
+			"name": "v1",
+			"storage": true,
+			"schema": {"openAPIV3Schema": {"type": "object", "properties": {
+				"spec": {"type": "object", "properties": {
+					"size": {"type": "integer"},
+					"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+				}}
+			}}}
+		}]
+	}
+}`
+
+func TestCrdMapProperties(t *testing.T) {
+	if !IsCrd([]byte(WIDGET_CRD)) {
+		t.Fatal("the widget definition was not detected as a custom resource definition")
+	}
+	versions, err := FromCrd([]byte(WIDGET_CRD), true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].Package != "com.example.v1" {
+		t.Fatalf("unexpected versions %+v", versions)
+	}
+	options := DefaultOptions()
+	file, err := ConvertSchema(versions[0].Schema, versions[0].Package, options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := RenderTarget(file, options)
+	if !strings.Contains(output, "map<string, string> labels") {
+		t.Fatalf("the labels property was not typed as a map:\n%s", output)
+	}
+	if strings.Contains(output, "message Labels") {
+		t.Fatalf("the labels property generated an empty message:\n%s", output)
+	}
+}
@@ -0,0 +1,213 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+var CUE_SCALARS = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"uint32":   "uint32",
+	"fixed32":  "uint32",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint64":   "uint64",
+	"fixed64":  "uint64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "bytes",
+}
+
+var CUE_WELL_KNOWN_TYPES = map[string][2]string{
+	"google.protobuf.Timestamp":   {"time.Time", "time"},
+	"google.protobuf.Duration":    {"string", ""},
+	"google.protobuf.FieldMask":   {"string", ""},
+	"google.protobuf.Struct":      {"{...}", ""},
+	"google.protobuf.Value":       {"_", ""},
+	"google.protobuf.ListValue":   {"[...]", ""},
+	"google.protobuf.Any":         {"{...}", ""},
+	"google.protobuf.Empty":       {"{}", ""},
+	"google.protobuf.DoubleValue": {"float64 | null", ""},
+	"google.protobuf.FloatValue":  {"float32 | null", ""},
+	"google.protobuf.Int64Value":  {"int64 | null", ""},
+	"google.protobuf.UInt64Value": {"uint64 | null", ""},
+	"google.protobuf.Int32Value":  {"int32 | null", ""},
+	"google.protobuf.UInt32Value": {"uint32 | null", ""},
+	"google.protobuf.BoolValue":   {"bool | null", ""},
+	"google.protobuf.StringValue": {"string | null", ""},
+	"google.protobuf.BytesValue":  {"bytes | null", ""},
+	"google.type.Date":            {"string", ""},
+	"google.type.TimeOfDay":       {"string", ""},
+	"google.rpc.Status":           {"{...}", ""},
+}
+
+var CUE_KEYWORDS = map[string]bool{"package": true, "import": true, "for": true, "in": true, "if": true, "let": true, "true": true, "false": true, "null": true}
+
+var CUE_IDENTIFIER_PATTERN = regexp.MustCompile(`^[A-Za-z$][A-Za-z0-9_$]*$`)
+
+const CUE_TEMPLATE = `// Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}{{end}}
+package {{.Package}}
+{{if .Imports}}
+import (
+{{range .Imports}}	"{{.}}"
+{{end}})
+{{end}}{{range .Definitions}}
+{{if .Values}}{{.Name}}: {{.Values}}
+{{else}}{{.Name}}: {
+{{range .Fields}}{{with .Comment}}	// {{.}}
+{{end}}	{{.Label}}: {{.Type}}
+{{end}}}
+{{end}}{{end}}`
+
+type CueFile struct {
+	Metadata    *ProtoMetadata
+	Package     string
+	Imports     []string
+	Definitions []CueDefinition
+}
+
+type CueDefinition struct {
+	Name   string
+	Fields []CueField
+	Values string
+}
+
+type CueField struct {
+	Label   string
+	Type    string
+	Comment string
+}
+
+func RenderCue(file *ProtoFile, options Options) string {
+	cueFile := NewCueFile(file, options)
+	var buffer bytes.Buffer
+	err := template.Must(template.New("cue").Parse(CUE_TEMPLATE)).Execute(&buffer, cueFile)
+	if err != nil {
+		panic(err)
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(buffer.String(), "\n", "\r\n")
+	}
+	return buffer.String()
+}
+
+func NewCueFile(file *ProtoFile, options Options) *CueFile {
+	output := CueFile{Metadata: file.Metadata, Package: toGoPackageName(file.Package)}
+	imports := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			output.Definitions = append(output.Definitions, CueDefinition{Name: toCueDefinitionName(file.Package, definition.Enum.Name), Values: cueEnum(definition.Enum, options)})
+			continue
+		}
+		_definition := CueDefinition{Name: toCueDefinitionName(file.Package, definition.Message.Name)}
+		for _, member := range definition.Message.Members() {
+			if member.Oneof != nil {
+				name := member.Oneof.Fields[0].Original
+				types := make([]string, 0)
+				for _, field := range member.Oneof.Fields {
+					if field.Original != name {
+						name = member.Oneof.Name
+					}
+					types = append(types, cueType(file.Package, field.Type, imports))
+				}
+				if len(name) == 0 {
+					name = member.Oneof.Name
+				}
+				_definition.Fields = append(_definition.Fields, CueField{Label: toCueLabel(name) + "?", Type: strings.Join(types, " | ")})
+				continue
+			}
+			field := member.Field
+			name := field.Original
+			if len(name) == 0 {
+				name = field.Name
+			}
+			label := toCueLabel(name)
+			if !field.Required {
+				label += "?"
+			}
+			_definition.Fields = append(_definition.Fields, CueField{Label: label, Type: cueFieldType(file.Package, field, imports), Comment: field.Comment})
+		}
+		output.Definitions = append(output.Definitions, _definition)
+	}
+	for value := range imports {
+		output.Imports = append(output.Imports, value)
+	}
+	sort.Strings(output.Imports)
+	return &output
+}
+
+func cueEnum(enum *ProtoEnum, options Options) string {
+	values := make([]string, 0)
+	prefix := strings.ToUpper(enum.Name) + "_"
+	for _, value := range enum.Values {
+		_value := value.Original
+		if len(_value) == 0 {
+			if strings.TrimPrefix(value.Name, prefix) == strings.ToUpper(*fixString(options.EnumZeroValue)) {
+				continue
+			}
+			_value = value.Name
+		}
+		data, _ := json.Marshal(_value)
+		values = append(values, string(data))
+	}
+	if len(values) == 0 {
+		return "string"
+	}
+	return strings.Join(values, " | ")
+}
+
+func cueFieldType(packageName string, field *ProtoField, imports map[string]bool) string {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		return fmt.Sprintf("{[string]: %s}", cueType(packageName, strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")), imports))
+	}
+	typeName := cueType(packageName, field.Type, imports)
+	if field.Label == REPEATED_LABEL {
+		if strings.Contains(typeName, "|") {
+			typeName = "(" + typeName + ")"
+		}
+		return fmt.Sprintf("[...%s]", typeName)
+	}
+	return typeName
+}
+
+func cueType(packageName string, typeName string, imports map[string]bool) string {
+	if scalar, ok := CUE_SCALARS[typeName]; ok {
+		return scalar
+	}
+	if wellKnown, ok := CUE_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		if len(wellKnown[1]) != 0 {
+			imports[wellKnown[1]] = true
+		}
+		return wellKnown[0]
+	}
+	return toCueDefinitionName(packageName, typeName)
+}
+
+func toCueDefinitionName(packageName string, typeName string) string {
+	return "#" + strings.ReplaceAll(toGoTypeName(packageName, typeName), "_", "")
+}
+
+func toCueLabel(name string) string {
+	if CUE_IDENTIFIER_PATTERN.MatchString(name) && !CUE_KEYWORDS[name] {
+		return name
+	}
+	data, _ := json.Marshal(name)
+	return string(data)
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+type DefaultMode string
+
+const (
+	NO_DEFAULTS      DefaultMode = "none"
+	COMMENT_DEFAULTS DefaultMode = "comment"
+	OPTION_DEFAULTS  DefaultMode = "option"
+)
+
+const J2P_OPTIONS_PROTO = "j2p/options.proto"
+
+const J2P_OPTIONS = `syntax = "proto3";
+
+package j2p;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.FieldOptions {
+  string json_default = 50000;
+}
+`
+
+func ApplyDefault(property Properties, field *ProtoField, options Options) {
+	if comment := ExampleComment(property, options); len(comment) != 0 {
+		field.Comment = strings.TrimPrefix(field.Comment+"; "+comment, "; ")
+	}
+	if len(property.Default) == 0 {
+		return
+	}
+	var buffer bytes.Buffer
+	err := json.Compact(&buffer, property.Default)
+	if err != nil {
+		return
+	}
+	switch options.Defaults {
+	case COMMENT_DEFAULTS:
+		{
+			field.Comment = strings.TrimPrefix(field.Comment+"; default: "+buffer.String(), "; ")
+		}
+	case OPTION_DEFAULTS:
+		{
+			field.Extensions = append(field.Extensions, "(j2p.json_default) = "+strconv.Quote(buffer.String()))
+		}
+	}
+}
+
+func ExampleComment(property Properties, options Options) string {
+	if options.ExampleComments <= 0 {
+		return ""
+	}
+	examples := append([]json.RawMessage{}, property.Examples...)
+	if len(property.Example) != 0 {
+		examples = append(examples, property.Example)
+	}
+	values := make([]string, 0)
+	for _, example := range examples {
+		var buffer bytes.Buffer
+		if json.Compact(&buffer, example) == nil {
+			values = append(values, buffer.String())
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	output := []rune("examples: " + strings.Join(values, ", "))
+	if len(output) > options.ExampleComments {
+		return string(output[:options.ExampleComments]) + "..."
+	}
+	return string(output)
+}
+
+func UsesJ2pOptions(file *ProtoFile) bool {
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			if len(field.Extensions) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const DEFAULTS_TEST_SCHEMA = `{
+	"title": "Order",
+	"type": "object",
+	"properties": {
+		"status": {"type": "string", "default": "open", "examples": ["open", "closed"]},
+		"limits": {"type": "object", "properties": {"max": {"type": "integer"}}, "default": {"max": 10}},
+		"note": {"type": "string", "description": "free text", "example": "leave at the door"}
+	}
+}`
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     DefaultMode
+		examples int
+		expected string
+		imported bool
+	}{
+		{name: "none", mode: NO_DEFAULTS, expected: "message Order {\n  string note = 1;\n  Limits limits = 2;\n  string status = 3;\n}\n"},
+		{name: "comment", mode: COMMENT_DEFAULTS, expected: "message Order {\n  string note = 1;\n  Limits limits = 2; // default: {\"max\":10}\n  string status = 3; // default: \"open\"\n}\n"},
+		{name: "option", mode: OPTION_DEFAULTS, expected: "message Order {\n  string note = 1;\n  Limits limits = 2 [(j2p.json_default) = \"{\\\"max\\\":10}\"];\n  string status = 3 [(j2p.json_default) = \"\\\"open\\\"\"];\n}\n", imported: true},
+		{name: "examples", mode: COMMENT_DEFAULTS, examples: 80, expected: "message Order {\n  string note = 1; // examples: \"leave at the door\"\n  Limits limits = 2; // default: {\"max\":10}\n  string status = 3; // examples: \"open\", \"closed\"; default: \"open\"\n}\n"},
+		{name: "truncated examples", mode: NO_DEFAULTS, examples: 20, expected: "message Order {\n  string note = 1; // examples: \"leave at ...\n  Limits limits = 2;\n  string status = 3; // examples: \"open\", \"c...\n}\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.Defaults = test.mode
+			options.ExampleComments = test.examples
+			file := NewWithOptions([]byte(DEFAULTS_TEST_SCHEMA), options).Build("test")
+			output := RenderTarget(file, options)
+			if !strings.HasSuffix(output, test.expected) {
+				t.Fatalf("expected the message:\n%s\ngot:\n%s", test.expected, output)
+			}
+			if UsesJ2pOptions(file) != test.imported || strings.Contains(output, "import \""+J2P_OPTIONS_PROTO+"\";") != test.imported {
+				t.Fatalf("expected the j2p options import to be %v:\n%s", test.imported, output)
+			}
+			if _, err := CompileSource("test.proto", output); err != nil {
+				t.Fatalf("%v:\n%s", err, output)
+			}
+		})
+	}
+}
+
+func TestApplyDefault(t *testing.T) {
+	tests := []struct {
+		property string
+		mode     DefaultMode
+		expected string
+	}{
+		{`{"default": [1, 2]}`, COMMENT_DEFAULTS, "existing; default: [1,2]"},
+		{`{"default": null}`, OPTION_DEFAULTS, `existing [(j2p.json_default) = "null"]`},
+		{`{"default": false}`, NO_DEFAULTS, "existing"},
+		{`{}`, OPTION_DEFAULTS, "existing"},
+	}
+	for _, test := range tests {
+		property := Properties{}
+		if err := json.Unmarshal([]byte(test.property), &property); err != nil {
+			t.Fatal(err)
+		}
+		options := DefaultOptions()
+		options.Defaults = test.mode
+		field := ProtoField{Comment: "existing"}
+		ApplyDefault(property, &field, options)
+		output := field.Comment
+		if len(field.Extensions) != 0 {
+			output += " [" + strings.Join(field.Extensions, ", ") + "]"
+		}
+		if output != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.property, test.expected, output)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package internal
+
+import "fmt"
+
+type Severity string
+
+const (
+	INFO    Severity = "info"
+	WARNING Severity = "warning"
+	ERROR   Severity = "error"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Subject  string
+	Message  string
+}
+
+type DiagnosticHandler func(diagnostic Diagnostic)
+
+func (diagnostic Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", diagnostic.Severity, diagnostic.Subject, diagnostic.Message)
+}
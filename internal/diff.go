@@ -0,0 +1,212 @@
+package internal
+
+import "fmt"
+
+var WIRE_COMPATIBLE_TYPES = map[string]string{
+	"int32":    "varint",
+	"uint32":   "varint",
+	"int64":    "varint",
+	"uint64":   "varint",
+	"bool":     "varint",
+	"sint32":   "zigzag",
+	"sint64":   "zigzag",
+	"fixed32":  "fixed32",
+	"sfixed32": "fixed32",
+	"fixed64":  "fixed64",
+	"sfixed64": "fixed64",
+	"string":   "bytes",
+	"bytes":    "bytes",
+}
+
+type protoDiff struct {
+	oldEnums          map[string]bool
+	newEnums          map[string]bool
+	diagnosticHandler DiagnosticHandler
+}
+
+func DiffProtos(old *ProtoFile, new *ProtoFile, diagnosticHandler DiagnosticHandler) {
+	context := protoDiff{oldEnums: protoEnumNames(old), newEnums: protoEnumNames(new), diagnosticHandler: diagnosticHandler}
+	if old.Package != new.Package {
+		diagnosticHandler(Diagnostic{Severity: ERROR, Subject: new.Package, Message: fmt.Sprintf("package changed from %s, every type name changed", old.Package)})
+	}
+	messages, enums := protoDefinitions(new)
+	for _, definition := range old.Definitions {
+		if definition.Message != nil {
+			message, ok := messages[definition.Message.Name]
+			if !ok {
+				diagnosticHandler(Diagnostic{Severity: ERROR, Subject: definition.Message.Name, Message: "message was removed"})
+				continue
+			}
+			context.message(definition.Message, message)
+			continue
+		}
+		enum, ok := enums[definition.Enum.Name]
+		if !ok {
+			diagnosticHandler(Diagnostic{Severity: ERROR, Subject: definition.Enum.Name, Message: "enum was removed"})
+			continue
+		}
+		context.enum(definition.Enum, enum)
+	}
+	messages, enums = protoDefinitions(old)
+	for _, definition := range new.Definitions {
+		if definition.Message != nil && messages[definition.Message.Name] == nil {
+			diagnosticHandler(Diagnostic{Severity: INFO, Subject: definition.Message.Name, Message: "message was added"})
+		}
+		if definition.Enum != nil && enums[definition.Enum.Name] == nil {
+			diagnosticHandler(Diagnostic{Severity: INFO, Subject: definition.Enum.Name, Message: "enum was added"})
+		}
+	}
+}
+
+func (rcvr *protoDiff) message(old *ProtoMessage, new *ProtoMessage) {
+	numbers := make(map[int]*ProtoField)
+	names := make(map[string]*ProtoField)
+	for _, field := range new.Fields {
+		numbers[field.Number] = field
+		names[field.Name] = field
+	}
+	oldNames := make(map[string]bool)
+	for _, field := range old.Fields {
+		oldNames[field.Name] = true
+	}
+	matched := make(map[*ProtoField]bool)
+	for _, field := range old.Fields {
+		subject := old.Name + "." + field.Name
+		if _field, ok := names[field.Name]; ok {
+			matched[_field] = true
+			if _field.Number != field.Number {
+				rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("field was renumbered from %d to %d", field.Number, _field.Number)})
+			}
+			rcvr.field(subject, field, _field)
+			continue
+		}
+		if _field, ok := numbers[field.Number]; ok && !oldNames[_field.Name] {
+			matched[_field] = true
+			if protoJsonName(field) == protoJsonName(_field) {
+				rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("field %d was renamed to %s, which breaks the text format", field.Number, _field.Name)})
+			} else {
+				rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("field %d was renamed to %s, which breaks the json and text formats", field.Number, _field.Name)})
+			}
+			rcvr.field(subject, field, _field)
+			continue
+		}
+		if new.IsReserved(field.Number) {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("field %d was removed and its number reserved", field.Number)})
+			continue
+		}
+		rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("field %d was removed without reserving its number", field.Number)})
+	}
+	oldNumbers := make(map[int]*ProtoField)
+	for _, field := range old.Fields {
+		oldNumbers[field.Number] = field
+	}
+	for _, field := range new.Fields {
+		if matched[field] {
+			continue
+		}
+		subject := new.Name + "." + field.Name
+		if old.IsReserved(field.Number) {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("field reuses the reserved number %d", field.Number)})
+			continue
+		}
+		if previous, ok := oldNumbers[field.Number]; ok {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("field reuses the number %d of %s", field.Number, previous.Name)})
+			continue
+		}
+		rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: subject, Message: fmt.Sprintf("field %d was added", field.Number)})
+	}
+}
+
+func (rcvr *protoDiff) field(subject string, field *ProtoField, _field *ProtoField) {
+	if field.Name == _field.Name && protoJsonName(field) != protoJsonName(_field) {
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("json name changed from %s to %s", protoJsonName(field), protoJsonName(_field))})
+	}
+	if field.Type != _field.Type {
+		severity := ERROR
+		if rcvr.wireType(field.Type, rcvr.oldEnums) == rcvr.wireType(_field.Type, rcvr.newEnums) && len(rcvr.wireType(field.Type, rcvr.oldEnums)) != 0 {
+			severity = WARNING
+		}
+		rcvr.diagnosticHandler(Diagnostic{Severity: severity, Subject: subject, Message: fmt.Sprintf("type changed from %s to %s", field.Type, _field.Type)})
+	}
+	if (field.Label == REPEATED_LABEL) != (_field.Label == REPEATED_LABEL) {
+		rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("cardinality changed from %s to %s", protoCardinality(field), protoCardinality(_field))})
+	} else if field.Label != _field.Label {
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("presence changed from %s to %s", protoCardinality(field), protoCardinality(_field))})
+	}
+	if field.Oneof != _field.Oneof && (len(field.Oneof) != 0 || len(_field.Oneof) != 0) {
+		rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("oneof changed from %q to %q", field.Oneof, _field.Oneof)})
+	}
+}
+
+func (rcvr *protoDiff) enum(old *ProtoEnum, new *ProtoEnum) {
+	numbers := make(map[int]*ProtoEnumValue)
+	for _, value := range new.Values {
+		numbers[value.Number] = value
+	}
+	known := make(map[int]bool)
+	for _, value := range old.Values {
+		known[value.Number] = true
+		subject := old.Name + "." + value.Name
+		_value, ok := numbers[value.Number]
+		if !ok {
+			if new.IsReserved(value.Number) {
+				rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("value %d was removed and its number reserved", value.Number)})
+				continue
+			}
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: subject, Message: fmt.Sprintf("value %d was removed without reserving its number", value.Number)})
+			continue
+		}
+		if value.Name != _value.Name {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("value %d was renamed to %s, which breaks the json and text formats", value.Number, _value.Name)})
+		}
+	}
+	for _, value := range new.Values {
+		if !known[value.Number] {
+			rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: new.Name + "." + value.Name, Message: fmt.Sprintf("value %d was added", value.Number)})
+		}
+	}
+}
+
+func (rcvr *protoDiff) wireType(typeName string, enums map[string]bool) string {
+	if enums[typeName] {
+		return "varint"
+	}
+	return WIRE_COMPATIBLE_TYPES[typeName]
+}
+
+func protoDefinitions(file *ProtoFile) (map[string]*ProtoMessage, map[string]*ProtoEnum) {
+	messages := make(map[string]*ProtoMessage)
+	enums := make(map[string]*ProtoEnum)
+	for _, definition := range file.Definitions {
+		if definition.Message != nil {
+			messages[definition.Message.Name] = definition.Message
+			continue
+		}
+		enums[definition.Enum.Name] = definition.Enum
+	}
+	return messages, enums
+}
+
+func protoEnumNames(file *ProtoFile) map[string]bool {
+	output := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			output[definition.Enum.Name] = true
+		}
+	}
+	return output
+}
+
+func protoCardinality(field *ProtoField) string {
+	if len(field.Label) == 0 {
+		return "implicit"
+	}
+	return string(field.Label)
+}
+
+func protoJsonName(field *ProtoField) string {
+	if len(field.JsonName) != 0 {
+		return field.JsonName
+	}
+	return toProtoJsonName(field.Name)
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffProtos(t *testing.T) {
+	old := &ProtoFile{Package: "acme", Definitions: []ProtoDefinition{
+		{Message: &ProtoMessage{Name: "Order", Fields: []*ProtoField{
+			{Type: "string", Name: "id", Number: 1},
+			{Type: "int32", Name: "count", Number: 2},
+			{Type: "string", Name: "tags", Number: 3, Label: REPEATED_LABEL},
+			{Type: "string", Name: "note", Number: 4},
+			{Type: "string", Name: "legacy", Number: 5},
+			{Type: "string", Name: "owner", Number: 6},
+			{Type: "string", Name: "total", Number: 7},
+		}}},
+		{Message: &ProtoMessage{Name: "Item"}},
+		{Enum: &ProtoEnum{Name: "Status", Values: []*ProtoEnumValue{{Name: "STATUS_UNSPECIFIED", Number: 0}, {Name: "STATUS_OPEN", Number: 1}}}},
+	}}
+	new := &ProtoFile{Package: "acme", Definitions: []ProtoDefinition{
+		{Message: &ProtoMessage{Name: "Order", ProtoReserved: ProtoReserved{ReservedRanges: []ProtoRange{{Start: 5, End: 5}}}, Fields: []*ProtoField{
+			{Type: "string", Name: "id", Number: 1},
+			{Type: "int64", Name: "count", Number: 2},
+			{Type: "string", Name: "tags", Number: 3},
+			{Type: "string", Name: "memo", Number: 4},
+			{Type: "string", Name: "owner", Number: 8},
+			{Type: "double", Name: "total", Number: 7},
+			{Type: "string", Name: "created", Number: 9},
+		}}},
+		{Enum: &ProtoEnum{Name: "Status", Values: []*ProtoEnumValue{{Name: "STATUS_UNSPECIFIED", Number: 0}}}},
+	}}
+	expected := []string{
+		"error: Item: message was removed",
+		"warning: Order.count: type changed from int32 to int64",
+		"error: Order.tags: cardinality changed from repeated to implicit",
+		"warning: Order.note: field 4 was renamed to memo, which breaks the json and text formats",
+		"warning: Order.legacy: field 5 was removed and its number reserved",
+		"error: Order.owner: field was renumbered from 6 to 8",
+		"error: Order.total: type changed from string to double",
+		"info: Order.created: field 9 was added",
+		"error: Status.STATUS_OPEN: value 1 was removed without reserving its number",
+	}
+	diagnostics := make(map[string]bool)
+	DiffProtos(old, new, func(diagnostic Diagnostic) {
+		diagnostics[fmt.Sprintf("%s: %s: %s", diagnostic.Severity, diagnostic.Subject, diagnostic.Message)] = true
+	})
+	for _, diagnostic := range expected {
+		if !diagnostics[diagnostic] {
+			t.Fatalf("expected %q in %v", diagnostic, diagnostics)
+		}
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), diagnostics)
+	}
+}
+
+func TestDiffProtosInsertion(t *testing.T) {
+	old := &ProtoFile{Package: "acme", Definitions: []ProtoDefinition{{Message: &ProtoMessage{Name: "T", Fields: []*ProtoField{
+		{Type: "string", Name: "a", Number: 1},
+		{Type: "string", Name: "c", Number: 2},
+	}}}}}
+	new := &ProtoFile{Package: "acme", Definitions: []ProtoDefinition{{Message: &ProtoMessage{Name: "T", Fields: []*ProtoField{
+		{Type: "string", Name: "a", Number: 1},
+		{Type: "string", Name: "b", Number: 2},
+		{Type: "string", Name: "c", Number: 3},
+	}}}}}
+	diagnostics := make([]string, 0)
+	DiffProtos(old, new, func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, fmt.Sprintf("%s: %s: %s", diagnostic.Severity, diagnostic.Subject, diagnostic.Message))
+	})
+	expected := "[error: T.c: field was renumbered from 2 to 3 error: T.b: field reuses the number 2 of c]"
+	if fmt.Sprint(diagnostics) != expected {
+		t.Fatalf("expected %s, got %v", expected, diagnostics)
+	}
+}
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Draft string
+
+const (
+	AUTO_DRAFT    Draft = "auto"
+	DRAFT_04      Draft = "draft-04"
+	DRAFT_06      Draft = "draft-06"
+	DRAFT_07      Draft = "draft-07"
+	DRAFT_2019_09 Draft = "2019-09"
+	DRAFT_2020_12 Draft = "2020-12"
+)
+
+var (
+	DRAFT_SCHEMA_MAPS  = map[string]bool{"properties": true, "patternProperties": true, "definitions": true, "$defs": true, "dependentSchemas": true, "dependencies": true}
+	DRAFT_SCHEMA_LISTS = map[string]bool{"allOf": true, "anyOf": true, "oneOf": true, "prefixItems": true}
+	DRAFT_SCHEMAS      = map[string]bool{"items": true, "additionalItems": true, "additionalProperties": true, "not": true, "if": true, "then": true, "else": true, "contains": true, "propertyNames": true, "unevaluatedItems": true, "unevaluatedProperties": true}
+)
+
+type draftNormalizer struct {
+	source            string
+	changes           int
+	diagnosticHandler DiagnosticHandler
+}
+
+func DraftFromURI(uri string) (Draft, bool) {
+	for _, value := range []struct {
+		marker string
+		draft  Draft
+	}{{"draft-03", DRAFT_04}, {"draft-04", DRAFT_04}, {"draft-06", DRAFT_06}, {"draft-07", DRAFT_07}, {"2019-09", DRAFT_2019_09}, {"2020-12", DRAFT_2020_12}} {
+		if strings.Contains(uri, value.marker) {
+			return value.draft, true
+		}
+	}
+	return "", false
+}
+
+func DetectDraft(root *JsonObject) (Draft, bool) {
+	if value, ok := root.Get("$schema"); ok {
+		if draft, ok := DraftFromURI(fmt.Sprint(value)); ok {
+			return draft, true
+		}
+	}
+	data, _ := json.Marshal(root)
+	content := string(data)
+	switch {
+	case strings.Contains(content, `"prefixItems"`) || strings.Contains(content, `"$dynamicRef"`):
+		{
+			return DRAFT_2020_12, false
+		}
+	case strings.Contains(content, `"$defs"`) || strings.Contains(content, `"$recursiveRef"`) || strings.Contains(content, `"dependentRequired"`):
+		{
+			return DRAFT_2019_09, false
+		}
+	case strings.Contains(content, `"exclusiveMinimum":true`) || strings.Contains(content, `"exclusiveMaximum":true`) || strings.Contains(content, `"exclusiveMinimum":false`) || strings.Contains(content, `"exclusiveMaximum":false`):
+		{
+			return DRAFT_04, false
+		}
+	}
+	if _, ok := root.Get("$id"); !ok {
+		if id, ok := root.Get("id"); ok {
+			if _, ok := id.(string); ok {
+				return DRAFT_04, false
+			}
+		}
+	}
+	return DRAFT_07, false
+}
+
+func NormalizeDraft(data []byte, draft Draft, diagnosticHandler DiagnosticHandler) ([]byte, error) {
+	document, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := document.(*JsonObject)
+	if !ok {
+		return data, nil
+	}
+	if len(draft) == 0 || draft == AUTO_DRAFT {
+		detected, declared := DetectDraft(root)
+		if !declared && detected != DRAFT_07 {
+			diagnosticHandler(Diagnostic{Severity: INFO, Subject: "#", Message: fmt.Sprintf("no known $schema was declared, structural hints read the schema as %s", detected)})
+		}
+		draft = detected
+	}
+	if normalizeDraft(root, draft, "", diagnosticHandler) == 0 {
+		return data, nil
+	}
+	return EncodeJson(root), nil
+}
+
+func normalizeDraft(root *JsonObject, draft Draft, source string, diagnosticHandler DiagnosticHandler) int {
+	normalizer := draftNormalizer{source: source, diagnosticHandler: diagnosticHandler}
+	normalizer.schema(root, draft, "")
+	return normalizer.changes
+}
+
+func (rcvr *draftNormalizer) schema(node any, draft Draft, pointer string) {
+	schema, ok := node.(*JsonObject)
+	if !ok {
+		return
+	}
+	if len(pointer) != 0 {
+		if value, ok := schema.Get("$schema"); ok {
+			if declared, ok := DraftFromURI(fmt.Sprint(value)); ok && declared != draft {
+				rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: rcvr.source + "#" + pointer, Message: fmt.Sprintf("$schema switches the dialect from %s to %s", draft, declared)})
+				draft = declared
+			}
+		}
+	}
+	if draft == DRAFT_04 {
+		if id, ok := schema.Get("id"); ok {
+			if _, ok := id.(string); ok {
+				if _, ok := schema.Get("$id"); !ok {
+					schema.Set("$id", id)
+				}
+				schema.Delete("id")
+				rcvr.changes++
+			}
+		}
+		rcvr.bound(schema, "minimum", "exclusiveMinimum")
+		rcvr.bound(schema, "maximum", "exclusiveMaximum")
+	}
+	rcvr.tuple(schema, draft, pointer)
+	for _, key := range append([]string{}, schema.Keys...) {
+		value := schema.Values[key]
+		_pointer := pointer + "/" + escapePointer(key)
+		switch {
+		case DRAFT_SCHEMA_MAPS[key]:
+			{
+				if members, ok := value.(*JsonObject); ok {
+					for _, name := range members.Keys {
+						rcvr.schema(members.Values[name], draft, _pointer+"/"+escapePointer(name))
+					}
+				}
+			}
+		case DRAFT_SCHEMA_LISTS[key]:
+			{
+				if members, ok := value.([]any); ok {
+					for i, member := range members {
+						rcvr.schema(member, draft, fmt.Sprintf("%s/%d", _pointer, i))
+					}
+				}
+			}
+		case DRAFT_SCHEMAS[key]:
+			{
+				rcvr.schema(value, draft, _pointer)
+			}
+		}
+	}
+}
+
+func (rcvr *draftNormalizer) bound(schema *JsonObject, bound string, exclusive string) {
+	value, ok := schema.Get(exclusive)
+	if !ok {
+		return
+	}
+	_value, ok := value.(bool)
+	if !ok {
+		return
+	}
+	schema.Delete(exclusive)
+	if limit, ok := schema.Get(bound); ok && _value {
+		schema.Delete(bound)
+		schema.Set(exclusive, limit)
+	}
+	rcvr.changes++
+}
+
+func (rcvr *draftNormalizer) tuple(schema *JsonObject, draft Draft, pointer string) {
+	members := make([]any, 0)
+	if draft == DRAFT_2020_12 {
+		prefixItems, ok := schema.Get("prefixItems")
+		if !ok {
+			return
+		}
+		if _prefixItems, ok := prefixItems.([]any); ok {
+			members = append(members, _prefixItems...)
+		}
+		if items, ok := schema.Get("items"); ok {
+			if _, ok := items.(bool); !ok {
+				members = append(members, items)
+			}
+		}
+		schema.Delete("prefixItems")
+		pointer += "/prefixItems"
+	} else {
+		items, ok := schema.Get("items")
+		if !ok {
+			return
+		}
+		_items, ok := items.([]any)
+		if !ok {
+			return
+		}
+		members = append(members, _items...)
+		if additionalItems, ok := schema.Get("additionalItems"); ok {
+			if _, ok := additionalItems.(bool); !ok {
+				members = append(members, additionalItems)
+			}
+		}
+		schema.Delete("additionalItems")
+		pointer += "/items"
+	}
+	rcvr.changes++
+	schema.Delete("items")
+	same := len(members) != 0
+	for _, member := range members {
+		same = same && string(EncodeJson(member)) == string(EncodeJson(members[0]))
+	}
+	if same {
+		schema.Set("items", members[0])
+		rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: rcvr.source + "#" + pointer, Message: "tuple items share one schema and were read as a list"})
+		return
+	}
+	schema.Set("items", NewJsonObject())
+	rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: rcvr.source + "#" + pointer, Message: "tuple items with different schemas have no proto equivalent and were widened to google.protobuf.Any"})
+}
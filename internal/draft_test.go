@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func normalizeTestDraft(t *testing.T, input string, draft Draft) (string, string) {
+	t.Helper()
+	diagnostics := make([]Diagnostic, 0)
+	data, err := NormalizeDraft([]byte(input), draft, func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := bytes.Buffer{}
+	if err := json.Compact(&output, data); err != nil {
+		t.Fatal(err)
+	}
+	return output.String(), fmt.Sprint(diagnostics)
+}
+
+func TestDetectDraft(t *testing.T) {
+	tests := []struct {
+		input    string
+		draft    Draft
+		declared bool
+	}{
+		{`{"$schema": "http://json-schema.org/draft-04/schema#"}`, DRAFT_04, true},
+		{`{"$schema": "http://json-schema.org/draft-03/schema#"}`, DRAFT_04, true},
+		{`{"$schema": "https://json-schema.org/draft/2020-12/schema"}`, DRAFT_2020_12, true},
+		{`{"$schema": "https://example.com/custom", "$defs": {}}`, DRAFT_2019_09, false},
+		{`{"prefixItems": [{"type": "string"}]}`, DRAFT_2020_12, false},
+		{`{"properties": {"a": {"minimum": 1, "exclusiveMinimum": true}}}`, DRAFT_04, false},
+		{`{"id": "https://acme.com/order.json"}`, DRAFT_04, false},
+		{`{"id": "https://acme.com/order.json", "$id": "https://acme.com/order.json"}`, DRAFT_07, false},
+		{`{"properties": {"id": {"type": "string"}}}`, DRAFT_07, false},
+	}
+	for _, test := range tests {
+		document, err := DecodeJson([]byte(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if draft, declared := DetectDraft(document.(*JsonObject)); draft != test.draft || declared != test.declared {
+			t.Errorf("%s: expected %s (declared %v), got %s (declared %v)", test.input, test.draft, test.declared, draft, declared)
+		}
+	}
+}
+
+func TestNormalizeDraft(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		draft       Draft
+		expected    string
+		diagnostics string
+	}{
+		{
+			name:        "exclusive bounds",
+			input:       `{"type": "object", "properties": {"a": {"type": "integer", "minimum": 1, "exclusiveMinimum": true, "maximum": 9, "exclusiveMaximum": false}}}`,
+			draft:       DRAFT_04,
+			expected:    `{"type":"object","properties":{"a":{"type":"integer","maximum":9,"exclusiveMinimum":1}}}`,
+			diagnostics: "[]",
+		},
+		{
+			name:        "detected exclusive bounds",
+			input:       `{"type": "object", "properties": {"a": {"type": "number", "maximum": 5, "exclusiveMaximum": true}}}`,
+			draft:       AUTO_DRAFT,
+			expected:    `{"type":"object","properties":{"a":{"type":"number","exclusiveMaximum":5}}}`,
+			diagnostics: "[info: #: no known $schema was declared, structural hints read the schema as draft-04]",
+		},
+		{
+			name:        "numeric bounds are left alone after draft-04",
+			input:       `{"type": "object", "properties": {"a": {"type": "integer", "exclusiveMinimum": 1}}}`,
+			draft:       DRAFT_07,
+			expected:    `{"type":"object","properties":{"a":{"type":"integer","exclusiveMinimum":1}}}`,
+			diagnostics: "[]",
+		},
+		{
+			name:        "id",
+			input:       `{"id": "https://acme.com/order.json", "type": "object", "definitions": {"Item": {"id": "#item", "type": "object", "properties": {"id": {"type": "string"}}}}}`,
+			draft:       DRAFT_04,
+			expected:    `{"type":"object","definitions":{"Item":{"type":"object","properties":{"id":{"type":"string"}},"$id":"#item"}},"$id":"https://acme.com/order.json"}`,
+			diagnostics: "[]",
+		},
+		{
+			name:        "tuple items",
+			input:       `{"type": "array", "items": [{"type": "string"}, {"type": "string"}], "additionalItems": false}`,
+			draft:       DRAFT_07,
+			expected:    `{"type":"array","items":{"type":"string"}}`,
+			diagnostics: "[info: #/items: tuple items share one schema and were read as a list]",
+		},
+		{
+			name:        "mixed tuple items",
+			input:       `{"type": "array", "items": [{"type": "string"}], "additionalItems": {"type": "integer"}}`,
+			draft:       DRAFT_07,
+			expected:    `{"type":"array","items":{}}`,
+			diagnostics: "[warning: #/items: tuple items with different schemas have no proto equivalent and were widened to google.protobuf.Any]",
+		},
+		{
+			name:        "prefix items",
+			input:       `{"type": "object", "properties": {"pair": {"type": "array", "prefixItems": [{"type": "integer"}], "items": {"type": "integer"}}}}`,
+			draft:       DRAFT_2020_12,
+			expected:    `{"type":"object","properties":{"pair":{"type":"array","items":{"type":"integer"}}}}`,
+			diagnostics: "[info: #/properties/pair/prefixItems: tuple items share one schema and were read as a list]",
+		},
+		{
+			name:        "unchanged",
+			input:       `{"type": "array", "items": {"type": "string"}}`,
+			draft:       DRAFT_2020_12,
+			expected:    `{"type":"array","items":{"type":"string"}}`,
+			diagnostics: "[]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, diagnostics := normalizeTestDraft(t, test.input, test.draft)
+			if output != test.expected || diagnostics != test.diagnostics {
+				t.Fatalf("expected %s %s, got %s %s", test.expected, test.diagnostics, output, diagnostics)
+			}
+		})
+	}
+}
+
+func TestNormalizeDraftSwitch(t *testing.T) {
+	input := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {"flag": {"type": "integer", "exclusiveMinimum": 0}},
+		"$defs": {
+			"Legacy": {
+				"$schema": "http://json-schema.org/draft-04/schema#",
+				"type": "object",
+				"properties": {
+					"count": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+					"pair": {"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "array", "prefixItems": [{"type": "string"}]},
+					"tuple": {"type": "array", "items": [{"type": "string"}]}
+				}
+			}
+		}
+	}`
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"flag":{"type":"integer","exclusiveMinimum":0}},"$defs":{"Legacy":{"$schema":"http://json-schema.org/draft-04/schema#","type":"object","properties":{"count":{"type":"integer","exclusiveMinimum":0},"pair":{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"string"}},"tuple":{"type":"array","items":{"type":"string"}}}}}}`
+	diagnostics := "[info: #/$defs/Legacy: $schema switches the dialect from 2020-12 to draft-04 " +
+		"info: #/$defs/Legacy/properties/pair: $schema switches the dialect from draft-04 to 2020-12 " +
+		"info: #/$defs/Legacy/properties/pair/prefixItems: tuple items share one schema and were read as a list " +
+		"info: #/$defs/Legacy/properties/tuple/items: tuple items share one schema and were read as a list]"
+	output, _diagnostics := normalizeTestDraft(t, input, AUTO_DRAFT)
+	if output != expected || _diagnostics != diagnostics {
+		t.Fatalf("expected %s %s, got %s %s", expected, diagnostics, output, _diagnostics)
+	}
+}
+
+func TestNormalizeDraftVendored(t *testing.T) {
+	sources := map[string]string{
+		"https://acme.com/legacy.json": `{"$schema": "http://json-schema.org/draft-04/schema#", "id": "https://acme.com/legacy.json", "type": "object", "properties": {"count": {"type": "integer", "maximum": 9, "exclusiveMaximum": true}}}`,
+	}
+	input := `{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object", "properties": {"legacy": {"$ref": "https://acme.com/legacy.json"}, "count": {"type": "integer", "exclusiveMaximum": 9}}}`
+	diagnostics := make([]string, 0)
+	data := Vendor([]byte(input), "", false, &VendorLock{Sources: make(map[string]string)}, func(source string) ([]byte, error) {
+		return []byte(sources[source]), nil
+	}, func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic.String())
+	})
+	output := bytes.Buffer{}
+	if err := json.Compact(&output, data); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"legacy":{"$ref":"#/$defs/legacy"},"count":{"type":"integer","exclusiveMaximum":9}},"$defs":{"legacy":{"type":"object","properties":{"count":{"type":"integer","exclusiveMaximum":9}}}}}`
+	if output.String() != expected {
+		t.Fatalf("expected %s, got %s (%v)", expected, output.String(), diagnostics)
+	}
+}
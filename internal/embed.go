@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var GO_WELL_KNOWN_PACKAGES = map[string]string{
+	"google/protobuf/any.proto":            "google.golang.org/protobuf/types/known/anypb",
+	"google/protobuf/api.proto":            "google.golang.org/protobuf/types/known/apipb",
+	"google/protobuf/descriptor.proto":     "google.golang.org/protobuf/types/descriptorpb",
+	"google/protobuf/duration.proto":       "google.golang.org/protobuf/types/known/durationpb",
+	"google/protobuf/empty.proto":          "google.golang.org/protobuf/types/known/emptypb",
+	"google/protobuf/field_mask.proto":     "google.golang.org/protobuf/types/known/fieldmaskpb",
+	"google/protobuf/source_context.proto": "google.golang.org/protobuf/types/known/sourcecontextpb",
+	"google/protobuf/struct.proto":         "google.golang.org/protobuf/types/known/structpb",
+	"google/protobuf/timestamp.proto":      "google.golang.org/protobuf/types/known/timestamppb",
+	"google/protobuf/type.proto":           "google.golang.org/protobuf/types/known/typepb",
+	"google/protobuf/wrappers.proto":       "google.golang.org/protobuf/types/known/wrapperspb",
+}
+
+const EMBED_TEMPLATE = `// Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}{{end}}
+package {{.Package}}
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+{{range .Imports}}	_ "{{.}}"
+{{end}})
+
+var FileDescriptorSet = []byte({{.Data}})
+
+var File protoreflect.FileDescriptor
+
+func init() {
+	set := descriptorpb.FileDescriptorSet{}
+	err := proto.Unmarshal(FileDescriptorSet, &set)
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range set.File {
+		descriptor, err := protoregistry.GlobalFiles.FindFileByPath(file.GetName())
+		if err != nil {
+			descriptor, err = protodesc.NewFile(file, protoregistry.GlobalFiles)
+			if err != nil {
+				panic(err)
+			}
+			err = protoregistry.GlobalFiles.RegisterFile(descriptor)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if file.GetName() == {{printf "%q" .Name}} {
+			File = descriptor
+		}
+	}
+}
+`
+
+type EmbedFile struct {
+	Metadata *ProtoMetadata
+	Package  string
+	Name     string
+	Imports  []string
+	Data     string
+}
+
+func RenderEmbed(files linker.Files, file *ProtoFile, options Options) (string, error) {
+	output := EmbedFile{Metadata: file.Metadata, Package: options.GoPackage, Name: files[0].Path()}
+	if len(output.Package) == 0 {
+		output.Package = toGoPackageName(file.Package)
+	}
+	set := descriptorpb.FileDescriptorSet{}
+	imports := make(map[string]bool)
+	for _, descriptor := range NewFileDescriptorSet(files).File {
+		if goPackage, ok := GO_WELL_KNOWN_PACKAGES[descriptor.GetName()]; ok {
+			imports[goPackage] = true
+			continue
+		}
+		set.File = append(set.File, descriptor)
+	}
+	for value := range imports {
+		if value != "google.golang.org/protobuf/types/descriptorpb" {
+			output.Imports = append(output.Imports, value)
+		}
+	}
+	sort.Strings(output.Imports)
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&set)
+	if err != nil {
+		return "", err
+	}
+	output.Data = fmt.Sprintf("%q", data)
+	var buffer bytes.Buffer
+	err = template.Must(template.New("embed").Parse(EMBED_TEMPLATE)).Execute(&buffer, output)
+	if err != nil {
+		panic(err)
+	}
+	content, err := format.Source(buffer.Bytes())
+	if err != nil {
+		panic(fmt.Errorf("generated go code does not compile: %w", err))
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(string(content), "\n", "\r\n"), nil
+	}
+	return string(content), nil
+}
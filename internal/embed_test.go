@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const EMBED_TEST_SCHEMA = `{
+	"title": "Order",
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"created": {"$ref": "#/definitions/timestamp"},
+		"metadata": {"$ref": "http://json-schema.org/draft-07/schema#"}
+	},
+	"definitions": {
+		"timestamp": {"$id": "https://schema.org/DateTime", "type": "string"}
+	}
+}`
+
+func renderTestEmbed(t *testing.T, options Options) string {
+	t.Helper()
+	file := NewWithOptions([]byte(EMBED_TEST_SCHEMA), options).Build("acme.orders.v1")
+	file.Metadata = &ProtoMetadata{Version: "1.2.3", Source: "order.json", Hash: "abc"}
+	files, err := CompileSource("order.proto", RenderTarget(file, options))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := RenderEmbed(files, file, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestRenderEmbed(t *testing.T) {
+	output := renderTestEmbed(t, DefaultOptions())
+	source, err := parser.ParseFile(token.NewFileSet(), "order.pb.embed.go", output, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, output)
+	}
+	if source.Name.Name != "ordersv1" {
+		t.Fatalf("expected package ordersv1, got %s", source.Name.Name)
+	}
+	imports := make([]string, 0)
+	for _, spec := range source.Imports {
+		if spec.Name != nil && spec.Name.Name == "_" {
+			imports = append(imports, spec.Path.Value)
+		}
+	}
+	if expected := `"google.golang.org/protobuf/types/known/structpb" "google.golang.org/protobuf/types/known/timestamppb"`; strings.Join(imports, " ") != expected {
+		t.Fatalf("expected the blank imports %s, got %v", expected, imports)
+	}
+	for _, line := range []string{"// versions:\n//   j2p 1.2.3\n// source: order.json\n// sha256: abc\n", `if file.GetName() == "order.proto" {`} {
+		if !strings.Contains(output, line) {
+			t.Fatalf("expected %q in:\n%s", line, output)
+		}
+	}
+	start := strings.Index(output, "var FileDescriptorSet = []byte(") + len("var FileDescriptorSet = []byte(")
+	data, err := strconv.Unquote(output[start : start+strings.Index(output[start:], ")\n")])
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal([]byte(data), &set); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0)
+	for _, file := range set.File {
+		names = append(names, file.GetName())
+	}
+	if strings.Join(names, " ") != "order.proto" {
+		t.Fatalf("the well known types should not be embedded, got %v", names)
+	}
+}
+
+func TestRenderEmbedOptions(t *testing.T) {
+	options := DefaultOptions()
+	options.GoPackage = "orders"
+	options.Newline = CRLF_NEWLINE
+	output := renderTestEmbed(t, options)
+	if !strings.Contains(output, "\r\npackage orders\r\n") || strings.Contains(strings.ReplaceAll(output, "\r\n", ""), "\n") {
+		t.Fatalf("expected package orders with crlf newlines:\n%q", output)
+	}
+}
@@ -0,0 +1,32 @@
+package internal
+
+import "fmt"
+
+const ENVELOPE_ONEOF = "payload"
+
+func (rcvr DefaultJsonSchemaParser) buildEnvelope(topLevel []string, definitions map[string]ProtoDefinition) {
+	name := *toPascalCase(rcvr.options.Envelope)
+	if _, ok := definitions[name]; ok || rcvr.duplicateCheck(name) {
+		rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: rcvr.options.Envelope, Message: fmt.Sprintf("%s already exists and the envelope was not generated", name)})
+		return
+	}
+	message := ProtoMessage{Name: name}
+	index := 1
+	seen := make(map[string]bool)
+	for _, value := range topLevel {
+		definition, ok := definitions[value]
+		if !ok || definition.Message == nil || seen[value] {
+			continue
+		}
+		seen[value] = true
+		field := toField(NO_LABEL, value, lowerFirst(value), &index, rcvr.diagnosticHandler)
+		field.Oneof = ENVELOPE_ONEOF
+		message.Fields = append(message.Fields, field)
+		rcvr.dependencies[name] = append(rcvr.dependencies[name], value)
+	}
+	if len(message.Fields) == 0 {
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: rcvr.options.Envelope, Message: "the schema has no top-level messages, the envelope was not generated"})
+		return
+	}
+	definitions[name] = ProtoDefinition{Message: &message}
+}
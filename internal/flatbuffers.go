@@ -0,0 +1,309 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var FBS_SCALARS = map[string]string{
+	"double":   "double",
+	"float":    "float",
+	"int32":    "int",
+	"sint32":   "int",
+	"sfixed32": "int",
+	"uint32":   "uint",
+	"fixed32":  "uint",
+	"int64":    "long",
+	"sint64":   "long",
+	"sfixed64": "long",
+	"uint64":   "ulong",
+	"fixed64":  "ulong",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "[ubyte]",
+}
+
+var FBS_WELL_KNOWN_TYPES = map[string]string{
+	"google.protobuf.Timestamp":   "string",
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.Struct":      "string",
+	"google.protobuf.Value":       "string",
+	"google.protobuf.ListValue":   "string",
+	"google.protobuf.Any":         "string",
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "long",
+	"google.protobuf.UInt64Value": "ulong",
+	"google.protobuf.Int32Value":  "int",
+	"google.protobuf.UInt32Value": "uint",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue":  "[ubyte]",
+	"google.type.Date":            "string",
+	"google.type.TimeOfDay":       "string",
+	"google.rpc.Status":           "string",
+}
+
+var FBS_KEYWORDS = map[string]bool{
+	"attribute": true, "bool": true, "byte": true, "double": true, "enum": true, "false": true, "file_extension": true,
+	"file_identifier": true, "float": true, "include": true, "int": true, "long": true, "namespace": true, "null": true,
+	"root_type": true, "rpc_service": true, "short": true, "string": true, "struct": true, "table": true, "true": true,
+	"ubyte": true, "uint": true, "ulong": true, "union": true, "ushort": true,
+}
+
+const FBS_TEMPLATE = `// Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}{{end}}{{if .Namespace}}
+namespace {{.Namespace}};
+{{end}}{{range .Types}}{{if eq .Kind "enum"}}
+enum {{.Name}} : int {
+{{range $index, $value := .Values}}{{if $index}},
+{{end}}  {{$value.Name}} = {{$value.Value}}{{end}}
+}
+{{else if eq .Kind "union"}}
+union {{.Name}} {
+{{range $index, $member := .Members}}{{if $index}},
+{{end}}  {{$member}}{{end}}
+}
+{{else}}
+table {{.Name}} {
+{{range .Fields}}{{with .Comment}}  // {{.}}
+{{end}}  {{.Name}}:{{.Type}}{{with .Default}} = {{.}}{{end}} ({{.Attributes}});
+{{end}}}
+{{end}}{{end}}{{with .Root}}
+root_type {{.}};
+{{end}}`
+
+type FbsFile struct {
+	Metadata  *ProtoMetadata
+	Namespace string
+	Types     []*FbsType
+	Root      string
+}
+
+type FbsType struct {
+	Kind    string
+	Name    string
+	Fields  []FbsField
+	Values  []GoValue
+	Members []string
+}
+
+type FbsField struct {
+	Name       string
+	Type       string
+	Default    string
+	Attributes string
+	Comment    string
+}
+
+type fbsBuilder struct {
+	file    *ProtoFile
+	enums   map[string]bool
+	types   []*FbsType
+	defined map[string]bool
+}
+
+func RenderFlatBuffers(file *ProtoFile, options Options) string {
+	fbsFile := NewFbsFile(file, options)
+	var buffer bytes.Buffer
+	err := template.Must(template.New("fbs").Parse(FBS_TEMPLATE)).Execute(&buffer, fbsFile)
+	if err != nil {
+		panic(err)
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(buffer.String(), "\n", "\r\n")
+	}
+	return buffer.String()
+}
+
+func NewFbsFile(file *ProtoFile, options Options) *FbsFile {
+	output := FbsFile{Metadata: file.Metadata, Namespace: file.Package}
+	builder := fbsBuilder{file: file, enums: make(map[string]bool), defined: make(map[string]bool)}
+	referenced := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			builder.enums[definition.Enum.Name] = true
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			referenced[field.Type] = true
+		}
+	}
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			builder.types = append(builder.types, fbsEnum(definition.Enum))
+			continue
+		}
+		builder.table(definition.Message)
+		if len(output.Root) == 0 && !referenced[definition.Message.Name] {
+			output.Root = toFbsTypeName(file.Package, definition.Message.Name)
+		}
+	}
+	output.Types = builder.types
+	return &output
+}
+
+func (rcvr *fbsBuilder) table(message *ProtoMessage) {
+	_type := FbsType{Kind: "table", Name: toFbsTypeName(rcvr.file.Package, message.Name)}
+	members := message.Members()
+	ids := fbsIds(members)
+	for index, member := range members {
+		id := ids[index]
+		if member.Oneof != nil {
+			name := member.Oneof.Fields[0].Original
+			union := FbsType{Kind: "union"}
+			for _, field := range member.Oneof.Fields {
+				if field.Original != name {
+					name = member.Oneof.Name
+				}
+				union.Members = append(union.Members, rcvr.unionMember(field))
+			}
+			if len(name) == 0 {
+				name = member.Oneof.Name
+			}
+			union.Name = _type.Name + toGoName(name)
+			rcvr.types = append(rcvr.types, &union)
+			_type.Fields = append(_type.Fields, FbsField{Name: toFbsName(toSnakeName(name)), Type: union.Name, Attributes: fmt.Sprintf("id: %d", id)})
+			continue
+		}
+		field := member.Field
+		typeName, scalar := rcvr.fieldType(_type.Name, field)
+		output := FbsField{Name: toFbsName(toSnakeName(sourceName(field))), Type: typeName, Attributes: fmt.Sprintf("id: %d", id), Comment: field.Comment}
+		_, wrapper := PROTO_WRAPPERS[strings.TrimPrefix(field.Type, ".")]
+		if scalar && field.Label != REPEATED_LABEL && (field.Label == OPTIONAL_LABEL || wrapper) {
+			output.Default = "null"
+		}
+		if field.Required && !scalar {
+			output.Attributes += ", required"
+		}
+		_type.Fields = append(_type.Fields, output)
+	}
+	rcvr.types = append(rcvr.types, &_type)
+}
+
+func fbsIds(members []ProtoMember) []int {
+	numbers := make([]int, len(members))
+	order := make([]int, len(members))
+	for index, member := range members {
+		order[index] = index
+		if member.Field != nil {
+			numbers[index] = member.Field.Number
+			continue
+		}
+		numbers[index] = member.Oneof.Fields[0].Number
+		for _, field := range member.Oneof.Fields {
+			numbers[index] = min(numbers[index], field.Number)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return numbers[order[i]] < numbers[order[j]]
+	})
+	output := make([]int, len(members))
+	id := 0
+	for _, index := range order {
+		if members[index].Oneof != nil {
+			id++
+		}
+		output[index] = id
+		id++
+	}
+	return output
+}
+
+func (rcvr *fbsBuilder) unionMember(field *ProtoField) string {
+	typeName, scalar := rcvr.typeOf(field.Type)
+	if !scalar && typeName != "string" && typeName != "[ubyte]" {
+		return typeName
+	}
+	name := toGoName(strings.TrimPrefix(typeName, "[")) + "Value"
+	if typeName == "[ubyte]" {
+		name = "BytesValue"
+	}
+	if !rcvr.defined[name] {
+		rcvr.defined[name] = true
+		rcvr.types = append(rcvr.types, &FbsType{Kind: "table", Name: name, Fields: []FbsField{{Name: "value", Type: typeName, Attributes: "id: 0"}}})
+	}
+	return name
+}
+
+func (rcvr *fbsBuilder) fieldType(tableName string, field *ProtoField) (string, bool) {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		key, _ := rcvr.typeOf(strings.TrimPrefix(field.Type[:index], "map<"))
+		value, _ := rcvr.typeOf(strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")))
+		name := tableName + toGoName(field.Name) + "Entry"
+		if !rcvr.defined[name] {
+			rcvr.defined[name] = true
+			rcvr.types = append(rcvr.types, &FbsType{Kind: "table", Name: name, Fields: []FbsField{{Name: "key", Type: key, Attributes: "id: 0, key"}, {Name: "value", Type: value, Attributes: "id: 1"}}})
+		}
+		return "[" + name + "]", false
+	}
+	typeName, scalar := rcvr.typeOf(field.Type)
+	if field.Label == REPEATED_LABEL {
+		if strings.HasPrefix(typeName, "[") {
+			return "[string]", false
+		}
+		return "[" + typeName + "]", false
+	}
+	return typeName, scalar
+}
+
+func (rcvr *fbsBuilder) typeOf(typeName string) (string, bool) {
+	if scalar, ok := FBS_SCALARS[typeName]; ok {
+		return scalar, scalar != "string" && scalar != "[ubyte]"
+	}
+	if wellKnown, ok := FBS_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		return wellKnown, wellKnown != "string" && wellKnown != "[ubyte]"
+	}
+	if strings.TrimPrefix(typeName, ".") == "google.protobuf.Empty" {
+		if !rcvr.defined["Empty"] {
+			rcvr.defined["Empty"] = true
+			rcvr.types = append(rcvr.types, &FbsType{Kind: "table", Name: "Empty"})
+		}
+		return "Empty", false
+	}
+	return toFbsTypeName(rcvr.file.Package, typeName), rcvr.enums[typeName]
+}
+
+func fbsEnum(enum *ProtoEnum) *FbsType {
+	output := FbsType{Kind: "enum", Name: toFbsTypeName("", enum.Name)}
+	prefix := strings.ToUpper(enum.Name) + "_"
+	numbers := make(map[int]bool)
+	for _, value := range enum.Values {
+		if numbers[value.Number] {
+			continue
+		}
+		numbers[value.Number] = true
+		name := strings.TrimPrefix(value.Name, prefix)
+		if len(name) == 0 || unicode.IsDigit(rune(name[0])) {
+			name = value.Name
+		}
+		output.Values = append(output.Values, GoValue{Name: toFbsName(name), Value: fmt.Sprint(value.Number)})
+	}
+	return &output
+}
+
+func toFbsTypeName(packageName string, typeName string) string {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(packageName) != 0 {
+		typeName = strings.TrimPrefix(typeName, packageName+".")
+	}
+	return toFbsName(strings.ReplaceAll(typeName, ".", "_"))
+}
+
+func toFbsName(name string) string {
+	if FBS_KEYWORDS[name] {
+		return name + "_"
+	}
+	return name
+}
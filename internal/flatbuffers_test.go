@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderFlatBuffers(t *testing.T) {
+	options := DefaultOptions()
+	file := NewWithOptions([]byte(CAPNP_TEST_SCHEMA), options).Build("test")
+	expected := `table Link {
+  id:string (id: 0);
+  url:string (id: 1);
+  kind:LinkKind (id: 3);
+  name:string (id: 4);
+  http_server:string (id: 5);
+}
+
+root_type Link;`
+	if output := RenderFlatBuffers(file, options); !strings.Contains(output, expected) {
+		t.Fatalf("unexpected flatbuffers schema:\n%s", output)
+	}
+}
+
+func TestFbsIdsFollowFieldNumbers(t *testing.T) {
+	options := DefaultOptions()
+	options.Numbering = HASH_NUMBERING
+	file := NewWithOptions([]byte(CAPNP_TEST_SCHEMA), options).Build("test")
+	members := file.Definitions[0].Message.Members()
+	ids := fbsIds(members)
+	used := make(map[int]bool)
+	for index, member := range members {
+		slots := []int{ids[index]}
+		if member.Oneof != nil {
+			slots = append(slots, ids[index]-1)
+		}
+		for _, slot := range slots {
+			if slot < 0 || used[slot] {
+				t.Fatalf("id %d is invalid or used twice: %v", slot, ids)
+			}
+			used[slot] = true
+		}
+	}
+	for id := range used {
+		if id >= len(used) {
+			t.Fatalf("ids are not contiguous: %v", ids)
+		}
+	}
+	for i, member := range members {
+		for j, other := range members {
+			if member.Field != nil && other.Field != nil && member.Field.Number < other.Field.Number && ids[i] > ids[j] {
+				t.Fatalf("%s is ordered after %s", member.Field.Name, other.Field.Name)
+			}
+		}
+	}
+}
+
+func TestFbsFieldNames(t *testing.T) {
+	file, options := initialismTestFile(t)
+	output := RenderFlatBuffers(file, options)
+	for _, expected := range []string{"  http_code:int", "  user_id:string", "  first_name:string", "  note:RespNote"} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("expected %q in\n%s", expected, output)
+		}
+	}
+}
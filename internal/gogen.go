@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const (
+	GO_PLUGIN   = "protoc-gen-go"
+	GRPC_PLUGIN = "protoc-gen-go-grpc"
+)
+
+func CompileProto(file string, importPaths []string) (linker.Files, error) {
+	compiler := protocompile.Compiler{Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths})}
+	return compiler.Compile(context.Background(), file)
+}
+
+func CompileSource(file string, content string) (linker.Files, error) {
+	sources := map[string]string{file: content, J2P_OPTIONS_PROTO: J2P_OPTIONS}
+	compiler := protocompile.Compiler{Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{Accessor: protocompile.SourceAccessorFromMap(sources)})}
+	return compiler.Compile(context.Background(), file)
+}
+
+func NewCodeGeneratorRequest(files linker.Files, parameter string) *pluginpb.CodeGeneratorRequest {
+	output := pluginpb.CodeGeneratorRequest{Parameter: proto.String(parameter)}
+	seen := make(map[string]bool)
+	var visit func(file protoreflect.FileDescriptor)
+	visit = func(file protoreflect.FileDescriptor) {
+		if seen[file.Path()] {
+			return
+		}
+		seen[file.Path()] = true
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			visit(imports.Get(i).FileDescriptor)
+		}
+		output.ProtoFile = append(output.ProtoFile, protodesc.ToFileDescriptorProto(file))
+	}
+	for _, file := range files {
+		output.FileToGenerate = append(output.FileToGenerate, file.Path())
+		visit(file)
+	}
+	return &output
+}
+
+func GoPluginParameter(request *pluginpb.CodeGeneratorRequest, goImportPath string, goPackage string) string {
+	parameters := []string{"paths=source_relative"}
+	for _, file := range request.ProtoFile {
+		if len(file.GetOptions().GetGoPackage()) != 0 {
+			continue
+		}
+		importPath := goImportPath
+		if directory := path.Dir(file.GetName()); directory != "." {
+			importPath = path.Join(goImportPath, directory)
+		}
+		if len(goPackage) != 0 {
+			importPath += ";" + goPackage
+		}
+		parameters = append(parameters, fmt.Sprintf("M%s=%s", file.GetName(), importPath))
+	}
+	return strings.Join(parameters, ",")
+}
+
+func GeneratePbGo(file string, importPaths []string, outputDirectory string, options Options, diagnosticHandler DiagnosticHandler) error {
+	files, err := CompileProto(file, importPaths)
+	if err != nil {
+		return err
+	}
+	goImportPath := options.GoImportPath
+	if len(goImportPath) == 0 {
+		goImportPath = strings.ReplaceAll(string(files[0].Package()), ".", "/")
+	}
+	request := NewCodeGeneratorRequest(files, "")
+	request.Parameter = proto.String(GoPluginParameter(request, goImportPath, options.GoPackage))
+	if _, err := exec.LookPath(GO_PLUGIN); err != nil {
+		return fmt.Errorf("%s was not found on PATH, install it with go install google.golang.org/protobuf/cmd/protoc-gen-go", GO_PLUGIN)
+	}
+	response, err := RunPlugin(GO_PLUGIN, request)
+	if err != nil {
+		return err
+	}
+	err = WriteCodeGeneratorResponse(response, outputDirectory)
+	if err != nil {
+		return err
+	}
+	services := false
+	for _, _file := range files {
+		services = services || _file.Services().Len() != 0
+	}
+	if !services {
+		return nil
+	}
+	if _, err := exec.LookPath(GRPC_PLUGIN); err != nil {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: file, Message: fmt.Sprintf("%s was not found on PATH, the grpc stubs were not generated", GRPC_PLUGIN)})
+		return nil
+	}
+	response, err = RunPlugin(GRPC_PLUGIN, request)
+	if err != nil {
+		return err
+	}
+	return WriteCodeGeneratorResponse(response, outputDirectory)
+}
+
+func RunPlugin(name string, request *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	data, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(name)
+	command.Stdin = bytes.NewReader(data)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	err = command.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	response := pluginpb.CodeGeneratorResponse{}
+	err = proto.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &response, nil
+}
+
+func WriteCodeGeneratorResponse(response *pluginpb.CodeGeneratorResponse, outputDirectory string) error {
+	if len(response.GetError()) != 0 {
+		return fmt.Errorf("%s", response.GetError())
+	}
+	for _, file := range response.File {
+		_path := filepath.Join(outputDirectory, filepath.FromSlash(file.GetName()))
+		err := os.MkdirAll(filepath.Dir(_path), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(_path, []byte(file.GetContent()), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewFileDescriptorSet(files linker.Files) *descriptorpb.FileDescriptorSet {
+	return &descriptorpb.FileDescriptorSet{File: NewCodeGeneratorRequest(files, "").ProtoFile}
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const GOGEN_TEST_PROTO = `syntax = "proto3";
+
+package acme.orders.v1;
+
+message Order {
+  string id = 1;
+}
+%s`
+
+func TestGeneratePbGo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake plugins are shell scripts")
+	}
+	tests := []struct {
+		name     string
+		service  string
+		plugins  []string
+		requests string
+		warning  string
+		error    string
+	}{
+		{name: "missing protoc-gen-go", error: "protoc-gen-go was not found on PATH"},
+		{name: "messages", plugins: []string{GO_PLUGIN, GRPC_PLUGIN}, requests: GO_PLUGIN},
+		{name: "services", service: "service Orders {\n  rpc Get(Order) returns (Order);\n}\n", plugins: []string{GO_PLUGIN, GRPC_PLUGIN}, requests: GO_PLUGIN + " " + GRPC_PLUGIN},
+		{name: "missing protoc-gen-go-grpc", service: "service Orders {\n  rpc Get(Order) returns (Order);\n}\n", plugins: []string{GO_PLUGIN}, requests: GO_PLUGIN, warning: "protoc-gen-go-grpc was not found on PATH, the grpc stubs were not generated"},
+		{name: "failing plugin", plugins: []string{"fail:" + GO_PLUGIN}, error: "protoc-gen-go: exit status 1: cannot generate"},
+	}
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bin, output := t.TempDir(), t.TempDir()
+			for _, plugin := range test.plugins {
+				script := "#!/bin/sh\n" + cat + " > \"" + filepath.Join(output, plugin) + ".request\"\n"
+				if name, ok := strings.CutPrefix(plugin, "fail:"); ok {
+					plugin, script = name, "#!/bin/sh\necho cannot generate >&2\nexit 1\n"
+				}
+				if err := os.WriteFile(filepath.Join(bin, plugin), []byte(script), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("PATH", bin)
+			source := t.TempDir()
+			if err := os.WriteFile(filepath.Join(source, "orders.proto"), []byte(strings.Replace(GOGEN_TEST_PROTO, "%s", test.service, 1)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			warnings := make([]string, 0)
+			err := GeneratePbGo("orders.proto", []string{source}, output, DefaultOptions(), func(diagnostic Diagnostic) {
+				warnings = append(warnings, diagnostic.Message)
+			})
+			if (err == nil) != (len(test.error) == 0) || (err != nil && !strings.HasPrefix(err.Error(), test.error)) {
+				t.Fatalf("expected the error %q, got %v", test.error, err)
+			}
+			if strings.Join(warnings, " ") != test.warning {
+				t.Fatalf("expected the warning %q, got %v", test.warning, warnings)
+			}
+			requests := make([]string, 0)
+			for _, plugin := range []string{GO_PLUGIN, GRPC_PLUGIN} {
+				data, err := os.ReadFile(filepath.Join(output, plugin+".request"))
+				if err != nil {
+					continue
+				}
+				requests = append(requests, plugin)
+				request := pluginpb.CodeGeneratorRequest{}
+				if err := proto.Unmarshal(data, &request); err != nil {
+					t.Fatal(err)
+				}
+				if request.GetParameter() != "paths=source_relative,Morders.proto=acme/orders/v1" || strings.Join(request.FileToGenerate, " ") != "orders.proto" {
+					t.Fatalf("%s received an unexpected request %q %v", plugin, request.GetParameter(), request.FileToGenerate)
+				}
+			}
+			if strings.Join(requests, " ") != test.requests {
+				t.Fatalf("expected the plugins %q to run, got %v", test.requests, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var GO_SCALARS = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint32":   "uint32",
+	"fixed32":  "uint32",
+	"uint64":   "uint64",
+	"fixed64":  "uint64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "[]byte",
+}
+
+var GO_WELL_KNOWN_TYPES = map[string][2]string{
+	"google.protobuf.Timestamp":   {"time.Time", "time"},
+	"google.protobuf.Duration":    {"string", ""},
+	"google.protobuf.FieldMask":   {"string", ""},
+	"google.protobuf.Struct":      {"map[string]any", ""},
+	"google.protobuf.Value":       {"any", ""},
+	"google.protobuf.ListValue":   {"[]any", ""},
+	"google.protobuf.Any":         {"json.RawMessage", "encoding/json"},
+	"google.protobuf.Empty":       {"struct{}", ""},
+	"google.protobuf.DoubleValue": {"*float64", ""},
+	"google.protobuf.FloatValue":  {"*float32", ""},
+	"google.protobuf.Int64Value":  {"*int64", ""},
+	"google.protobuf.UInt64Value": {"*uint64", ""},
+	"google.protobuf.Int32Value":  {"*int32", ""},
+	"google.protobuf.UInt32Value": {"*uint32", ""},
+	"google.protobuf.BoolValue":   {"*bool", ""},
+	"google.protobuf.StringValue": {"*string", ""},
+	"google.protobuf.BytesValue":  {"[]byte", ""},
+	"google.type.Date":            {"string", ""},
+	"google.type.TimeOfDay":       {"string", ""},
+	"google.rpc.Status":           {"json.RawMessage", "encoding/json"},
+}
+
+var GO_INITIALISMS = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+var GO_VERSION_PATTERN = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]*)?$`)
+
+const GO_TEMPLATE = `// Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}{{end}}
+package {{.Package}}
+{{if .Imports}}
+import (
+{{range .Imports}}"{{.}}"
+{{end}}){{end}}
+{{range .Types}}{{if .Values}}
+type {{.Name}} string
+
+const (
+{{range .Values}}{{.Name}} {{.Type}} = {{printf "%q" .Value}}
+{{end}})
+{{else}}
+type {{.Name}} struct {
+{{range .Fields}}{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{with .Comment}} // {{.}}{{end}}
+{{end}}}
+{{end}}{{end}}`
+
+type GoFile struct {
+	Metadata *ProtoMetadata
+	Package  string
+	Imports  []string
+	Types    []GoType
+}
+
+type GoType struct {
+	Name   string
+	Fields []GoField
+	Values []GoValue
+}
+
+type GoField struct {
+	Name    string
+	Type    string
+	Tag     string
+	Comment string
+}
+
+type GoValue struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+func RenderGo(file *ProtoFile, options Options) string {
+	goFile := NewGoFile(file, options)
+	var buffer bytes.Buffer
+	err := template.Must(template.New("go").Parse(GO_TEMPLATE)).Execute(&buffer, goFile)
+	if err != nil {
+		panic(err)
+	}
+	output, err := format.Source(buffer.Bytes())
+	if err != nil {
+		panic(fmt.Errorf("generated go code does not compile: %w", err))
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(string(output), "\n", "\r\n")
+	}
+	return string(output)
+}
+
+func NewGoFile(file *ProtoFile, options Options) *GoFile {
+	output := GoFile{Metadata: file.Metadata, Package: options.GoPackage}
+	if len(output.Package) == 0 {
+		output.Package = toGoPackageName(file.Package)
+	}
+	enums := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			enums[definition.Enum.Name] = true
+		}
+	}
+	imports := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			output.Types = append(output.Types, goEnum(definition.Enum, options))
+			continue
+		}
+		_type := GoType{Name: toGoTypeName(file.Package, definition.Message.Name)}
+		for _, member := range definition.Message.Members() {
+			if member.Oneof != nil {
+				imports["encoding/json"] = true
+				_type.Fields = append(_type.Fields, goOneof(file.Package, member.Oneof, enums))
+				continue
+			}
+			field := member.Field
+			typeName, _import := goFieldType(file.Package, field, enums)
+			if len(_import) != 0 {
+				imports[_import] = true
+			}
+			name := field.Original
+			if len(name) == 0 {
+				name = field.Name
+			}
+			_type.Fields = append(_type.Fields, GoField{Name: toGoName(name), Type: typeName, Tag: goTag(name, field, options), Comment: field.Comment})
+		}
+		output.Types = append(output.Types, _type)
+	}
+	for value := range imports {
+		output.Imports = append(output.Imports, value)
+	}
+	sort.Strings(output.Imports)
+	return &output
+}
+
+func goOneof(packageName string, oneof *ProtoOneof, enums map[string]bool) GoField {
+	name := oneof.Fields[0].Original
+	types := make([]string, 0)
+	for _, field := range oneof.Fields {
+		if field.Original != name {
+			name = oneof.Name
+		}
+		typeName, _ := goType(packageName, field.Type, enums, false)
+		types = append(types, typeName)
+	}
+	if len(name) == 0 {
+		name = oneof.Name
+	}
+	return GoField{Name: toGoName(name), Type: "json.RawMessage", Tag: fmt.Sprintf("json:\"%s,omitempty\"", name), Comment: "one of " + strings.Join(types, ", ")}
+}
+
+func goEnum(enum *ProtoEnum, options Options) GoType {
+	output := GoType{Name: toGoTypeName("", enum.Name)}
+	prefix := strings.ToUpper(enum.Name) + "_"
+	for _, value := range enum.Values {
+		name := strings.TrimPrefix(value.Name, prefix)
+		_value := value.Original
+		if len(_value) == 0 {
+			if name == strings.ToUpper(*fixString(options.EnumZeroValue)) {
+				continue
+			}
+			_value = value.Name
+		}
+		output.Values = append(output.Values, GoValue{Name: output.Name + toGoName(name), Type: output.Name, Value: _value})
+	}
+	return output
+}
+
+func goFieldType(packageName string, field *ProtoField, enums map[string]bool) (string, string) {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		key, _ := goType(packageName, strings.TrimPrefix(field.Type[:index], "map<"), enums, false)
+		value, _import := goType(packageName, strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")), enums, false)
+		return fmt.Sprintf("map[%s]%s", key, value), _import
+	}
+	typeName, _import := goType(packageName, field.Type, enums, field.Label != REPEATED_LABEL)
+	if field.Label == REPEATED_LABEL {
+		return "[]" + typeName, _import
+	}
+	if field.Label == OPTIONAL_LABEL && !strings.HasPrefix(typeName, "*") && !strings.HasPrefix(typeName, "[]") && !strings.HasPrefix(typeName, "map[") && typeName != "any" && typeName != "json.RawMessage" {
+		return "*" + typeName, _import
+	}
+	return typeName, _import
+}
+
+func goType(packageName string, typeName string, enums map[string]bool, pointer bool) (string, string) {
+	if scalar, ok := GO_SCALARS[typeName]; ok {
+		return scalar, ""
+	}
+	if wellKnown, ok := GO_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		return wellKnown[0], wellKnown[1]
+	}
+	if enums[typeName] || !pointer {
+		return toGoTypeName(packageName, typeName), ""
+	}
+	return "*" + toGoTypeName(packageName, typeName), ""
+}
+
+func goTag(name string, field *ProtoField, options Options) string {
+	tag := fmt.Sprintf("json:\"%s,omitempty\"", name)
+	if field.Required {
+		tag = fmt.Sprintf("json:\"%s\"", name)
+		if options.GoValidateTags {
+			tag += " validate:\"required\""
+		}
+	}
+	return tag
+}
+
+func toGoTypeName(packageName string, typeName string) string {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(packageName) != 0 {
+		typeName = strings.TrimPrefix(typeName, packageName+".")
+	}
+	segments := strings.Split(typeName, ".")
+	for index, segment := range segments {
+		segments[index] = toGoName(segment)
+	}
+	return strings.Join(segments, "_")
+}
+
+func toGoName(str string) string {
+	var output strings.Builder
+	for _, word := range nameWords(str) {
+		if GO_INITIALISMS[strings.ToUpper(word)] {
+			output.WriteString(strings.ToUpper(word))
+			continue
+		}
+		if strings.ToUpper(word) == word {
+			word = strings.ToLower(word)
+		}
+		output.WriteString(upperFirst(word))
+	}
+	if output.Len() == 0 || unicode.IsDigit(rune(output.String()[0])) {
+		return "X" + output.String()
+	}
+	return output.String()
+}
+
+func nameWords(str string) []string {
+	words := make([]string, 0)
+	for _, part := range strings.FieldsFunc(*fixString(str), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		start := 0
+		for index := 1; index < len(runes); index++ {
+			if unicode.IsUpper(runes[index]) && (unicode.IsLower(runes[index-1]) || (index+1 < len(runes) && unicode.IsLower(runes[index+1]) && unicode.IsUpper(runes[index-1]))) {
+				words = append(words, string(runes[start:index]))
+				start = index
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+func toGoPackageName(packageName string) string {
+	segments := strings.Split(packageName, ".")
+	name := segments[len(segments)-1]
+	if len(segments) > 1 && GO_VERSION_PATTERN.MatchString(name) {
+		name = segments[len(segments)-2] + name
+	}
+	name = strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	if len(name) == 0 {
+		return "main"
+	}
+	return name
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type GraphFormat string
+
+const (
+	DOT_GRAPH  GraphFormat = "dot"
+	JSON_GRAPH GraphFormat = "json"
+)
+
+type GraphNode struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Field string `json:"field"`
+}
+
+type Graph struct {
+	Package string      `json:"package"`
+	Nodes   []GraphNode `json:"nodes"`
+	Edges   []GraphEdge `json:"edges"`
+}
+
+func NewGraph(file *ProtoFile) Graph {
+	output := Graph{Package: file.Package, Nodes: make([]GraphNode, 0), Edges: make([]GraphEdge, 0)}
+	external := make(map[string]bool)
+	local := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		local[definitionName(definition)] = true
+	}
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			output.Nodes = append(output.Nodes, GraphNode{Name: definition.Enum.Name, Kind: "enum"})
+			continue
+		}
+		output.Nodes = append(output.Nodes, GraphNode{Name: definition.Message.Name, Kind: "message"})
+		for _, field := range definition.Message.Fields {
+			if !local[field.Type] && !strings.Contains(field.Type, ".") {
+				continue
+			}
+			if !local[field.Type] {
+				external[field.Type] = true
+			}
+			output.Edges = append(output.Edges, GraphEdge{From: definition.Message.Name, To: field.Type, Field: field.Name})
+		}
+	}
+	for _, service := range file.Services {
+		output.Nodes = append(output.Nodes, GraphNode{Name: service.Name, Kind: "service"})
+		for _, method := range service.Methods {
+			for _, value := range []string{method.Input, method.Output} {
+				if !local[value] {
+					external[value] = true
+				}
+				output.Edges = append(output.Edges, GraphEdge{From: service.Name, To: value, Field: method.Name})
+			}
+		}
+	}
+	names := make([]string, 0)
+	for name := range external {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		output.Nodes = append(output.Nodes, GraphNode{Name: name, Kind: "external"})
+	}
+	return output
+}
+
+func (graph Graph) DOT() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("digraph %q {\n", graph.Package))
+	builder.WriteString("  node [shape=box];\n")
+	for _, node := range graph.Nodes {
+		switch node.Kind {
+		case "enum":
+			{
+				builder.WriteString(fmt.Sprintf("  %q [shape=ellipse];\n", node.Name))
+			}
+		case "service":
+			{
+				builder.WriteString(fmt.Sprintf("  %q [shape=component];\n", node.Name))
+			}
+		case "external":
+			{
+				builder.WriteString(fmt.Sprintf("  %q [style=dashed];\n", node.Name))
+			}
+		default:
+			{
+				builder.WriteString(fmt.Sprintf("  %q;\n", node.Name))
+			}
+		}
+	}
+	for _, edge := range graph.Edges {
+		builder.WriteString(fmt.Sprintf("  %q -> %q [label=%q];\n", edge.From, edge.To, edge.Field))
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
@@ -0,0 +1,595 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var GRAPHQL_SCALARS = map[string]string{
+	"Int":     "int32",
+	"Float":   "double",
+	"String":  "string",
+	"Boolean": "bool",
+	"ID":      "string",
+}
+
+type graphqlToken struct {
+	Kind  rune
+	Value string
+	Line  int
+}
+
+type graphqlType struct {
+	Name    string
+	List    int
+	NonNull bool
+}
+
+type graphqlField struct {
+	Name string
+	Type graphqlType
+	Line int
+}
+
+type graphqlDefinition struct {
+	Kind    string
+	Name    string
+	Fields  []graphqlField
+	Values  []string
+	Members []string
+	Line    int
+}
+
+type GraphqlParser struct {
+	definitions       []*graphqlDefinition
+	hash              string
+	options           Options
+	diagnostics       *[]Diagnostic
+	diagnosticHandler DiagnosticHandler
+}
+
+func IsGraphql(path string, format InputFormat) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".graphql", ".graphqls", ".gql":
+		{
+			return format == AUTO_INPUT || format == GRAPHQL_INPUT
+		}
+	}
+	return format == GRAPHQL_INPUT
+}
+
+func NewGraphqlParser(data []byte, options Options) (*GraphqlParser, error) {
+	tokens, err := graphqlTokens(string(data))
+	if err != nil {
+		return nil, err
+	}
+	diagnostics := make([]Diagnostic, 0)
+	hash := sha256.Sum256(data)
+	output := GraphqlParser{hash: hex.EncodeToString(hash[:]), options: options, diagnostics: &diagnostics}
+	output.diagnosticHandler = func(diagnostic Diagnostic) {
+		*output.diagnostics = append(*output.diagnostics, diagnostic)
+	}
+	reader := graphqlReader{tokens: tokens}
+	for !reader.done() {
+		definition, err := reader.definition()
+		if err != nil {
+			return nil, err
+		}
+		if definition != nil {
+			output.definitions = append(output.definitions, definition)
+		}
+	}
+	return &output, nil
+}
+
+func (rcvr *GraphqlParser) Diagnostics() []Diagnostic {
+	return *rcvr.diagnostics
+}
+
+func (rcvr *GraphqlParser) Build(packageName string) *ProtoFile {
+	output := ProtoFile{Package: packageName}
+	if rcvr.options.Metadata {
+		output.Metadata = &ProtoMetadata{Version: VERSION, Source: rcvr.options.Source, Hash: rcvr.hash}
+		if rcvr.options.MetadataTimestamp {
+			output.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	kinds := make(map[string]string)
+	merged := make(map[string]*graphqlDefinition)
+	operations := map[string]bool{"Query": true, "Mutation": true, "Subscription": true}
+	ordered := make([]*graphqlDefinition, 0)
+	for _, definition := range rcvr.definitions {
+		if definition.Kind == "schema" {
+			operations = make(map[string]bool)
+			for _, field := range definition.Fields {
+				operations[field.Type.Name] = true
+			}
+			continue
+		}
+		if existing, ok := merged[definition.Name]; ok {
+			existing.Fields = append(existing.Fields, definition.Fields...)
+			existing.Values = append(existing.Values, definition.Values...)
+			existing.Members = append(existing.Members, definition.Members...)
+			continue
+		}
+		merged[definition.Name] = definition
+		kinds[definition.Name] = definition.Kind
+		ordered = append(ordered, definition)
+	}
+	definitions := make(map[string]ProtoDefinition)
+	dependencies := make(map[string][]string)
+	for _, definition := range ordered {
+		pointer := fmt.Sprintf("line %d", definition.Line)
+		if operations[definition.Name] && definition.Kind == "type" {
+			rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: definition.Name, Message: "operation types describe the api rather than the model and were skipped"})
+			continue
+		}
+		switch definition.Kind {
+		case "enum":
+			{
+				enum := ToEnum(definition.Name, pointer, definition.Values, rcvr.options, func(string) bool { return false }, rcvr.diagnosticHandler)
+				definitions[enum.Name] = ProtoDefinition{Enum: enum}
+			}
+		case "union":
+			{
+				typeName := *toTypeName(definition.Name, rcvr.diagnosticHandler)
+				message := ProtoMessage{Name: typeName, Pointer: pointer}
+				index := 1
+				oneofName := toOneofName(rcvr.options, lowerFirst(definition.Name))
+				for _, member := range definition.Members {
+					field := toField(NO_LABEL, *toPascalCase(member), lowerFirst(member), &index, rcvr.diagnosticHandler)
+					field.Oneof = oneofName
+					message.Fields = append(message.Fields, field)
+					dependencies[typeName] = append(dependencies[typeName], field.Type)
+				}
+				definitions[typeName] = ProtoDefinition{Message: &message}
+			}
+		case "type", "input", "interface":
+			{
+				typeName := *toTypeName(definition.Name, rcvr.diagnosticHandler)
+				message := ProtoMessage{Name: typeName, Pointer: pointer}
+				index := 1
+				for _, field := range definition.Fields {
+					label, _type := rcvr.fieldType(field, kinds)
+					protoField := toField(label, _type, field.Name, &index, rcvr.diagnosticHandler)
+					protoField.Pointer = fmt.Sprintf("line %d", field.Line)
+					message.Fields = append(message.Fields, protoField)
+					dependencies[typeName] = append(dependencies[typeName], _type)
+				}
+				definitions[typeName] = ProtoDefinition{Message: &message}
+			}
+		case "scalar":
+			{
+				rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: definition.Name, Message: "custom scalars are typed as string"})
+			}
+		}
+	}
+	for _, key := range TopologicalOrder(definitions, dependencies, nil) {
+		output.Definitions = append(output.Definitions, definitions[key])
+	}
+	for _, definition := range output.Definitions {
+		if definition.Message != nil {
+			definition.Message.ReservedRanges = append(definition.Message.ReservedRanges, rcvr.options.ReservedRanges...)
+			NumberFields(definition.Message, rcvr.options.Numbering, nil)
+		}
+	}
+	if usesAny(&output) {
+		output.Imports = append([]string{ANY_IMPORT}, output.Imports...)
+	}
+	output.Imports = append(output.Imports, mappedImports(rcvr.options, &output)...)
+	if len(rcvr.options.MergeFile) != 0 {
+		Merge(&output, ReadProto(rcvr.options.MergeFile), rcvr.options.Numbering, rcvr.diagnosticHandler)
+	}
+	return &output
+}
+
+func (rcvr *GraphqlParser) fieldType(field graphqlField, kinds map[string]string) (Label, string) {
+	typeName, ok := GRAPHQL_SCALARS[field.Type.Name]
+	if !ok {
+		typeName = *toPascalCase(field.Type.Name)
+		switch kinds[field.Type.Name] {
+		case "scalar":
+			{
+				typeName = "string"
+			}
+		case "":
+			{
+				rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: field.Name, Message: fmt.Sprintf("unknown type %s was typed as google.protobuf.Any", field.Type.Name)})
+				typeName = "google.protobuf.Any"
+			}
+		}
+	}
+	switch {
+	case field.Type.List > 1:
+		{
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: field.Name, Message: "nested lists have no proto equivalent, the elements were typed as google.protobuf.Any"})
+			return REPEATED_LABEL, "google.protobuf.Any"
+		}
+	case field.Type.List == 1:
+		{
+			return REPEATED_LABEL, typeName
+		}
+	case !field.Type.NonNull:
+		{
+			return OPTIONAL_LABEL, typeName
+		}
+	}
+	return NO_LABEL, typeName
+}
+
+func graphqlTokens(source string) ([]graphqlToken, error) {
+	tokens := make([]graphqlToken, 0)
+	runes := []rune(source)
+	line := 1
+	for i := 0; i < len(runes); {
+		value := runes[i]
+		switch {
+		case value == '\n':
+			{
+				line++
+				i++
+			}
+		case unicode.IsSpace(value) || value == ',' || value == '\uFEFF':
+			{
+				i++
+			}
+		case value == '#':
+			{
+				for i < len(runes) && runes[i] != '\n' {
+					i++
+				}
+			}
+		case value == '"':
+			{
+				start := line
+				if strings.HasPrefix(string(runes[i:]), `"""`) {
+					end := strings.Index(string(runes[i+3:]), `"""`)
+					if end < 0 {
+						return nil, fmt.Errorf("line %d: unterminated block string", start)
+					}
+					block := []rune(string(runes[i+3:])[:end])
+					line += strings.Count(string(block), "\n")
+					i += 3 + len(block) + 3
+					tokens = append(tokens, graphqlToken{Kind: '"', Value: string(block), Line: start})
+					continue
+				}
+				j := i + 1
+				for j < len(runes) && runes[j] != '"' && runes[j] != '\n' {
+					if runes[j] == '\\' {
+						j++
+					}
+					j++
+				}
+				if j >= len(runes) || runes[j] != '"' {
+					return nil, fmt.Errorf("line %d: unterminated string", start)
+				}
+				tokens = append(tokens, graphqlToken{Kind: '"', Value: string(runes[i+1 : j]), Line: start})
+				i = j + 1
+			}
+		case value == '_' || unicode.IsLetter(value):
+			{
+				j := i
+				for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+					j++
+				}
+				tokens = append(tokens, graphqlToken{Kind: 'a', Value: string(runes[i:j]), Line: line})
+				i = j
+			}
+		case value == '-' || unicode.IsDigit(value):
+			{
+				j := i + 1
+				for j < len(runes) && (unicode.IsDigit(runes[j]) || strings.ContainsRune(".eE+-", runes[j])) {
+					j++
+				}
+				tokens = append(tokens, graphqlToken{Kind: '0', Value: string(runes[i:j]), Line: line})
+				i = j
+			}
+		case strings.HasPrefix(string(runes[i:]), "..."):
+			{
+				tokens = append(tokens, graphqlToken{Kind: '.', Value: "...", Line: line})
+				i += 3
+			}
+		case strings.ContainsRune("!$&()/:=@[]{|}", value):
+			{
+				tokens = append(tokens, graphqlToken{Kind: value, Value: string(value), Line: line})
+				i++
+			}
+		default:
+			{
+				return nil, fmt.Errorf("line %d: unexpected character %q", line, value)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+type graphqlReader struct {
+	tokens   []graphqlToken
+	position int
+}
+
+func (reader *graphqlReader) done() bool {
+	return reader.position >= len(reader.tokens)
+}
+
+func (reader *graphqlReader) peek() graphqlToken {
+	if reader.done() {
+		return graphqlToken{}
+	}
+	return reader.tokens[reader.position]
+}
+
+func (reader *graphqlReader) next() graphqlToken {
+	token := reader.peek()
+	reader.position++
+	return token
+}
+
+func (reader *graphqlReader) expect(kind rune) (graphqlToken, error) {
+	token := reader.next()
+	if token.Kind != kind {
+		if token.Kind == 0 {
+			return token, fmt.Errorf("unexpected end of schema, expected %q", kind)
+		}
+		return token, fmt.Errorf("line %d: unexpected %q, expected %q", token.Line, token.Value, kind)
+	}
+	return token, nil
+}
+
+func (reader *graphqlReader) skipDescription() {
+	for reader.peek().Kind == '"' {
+		reader.next()
+	}
+}
+
+func (reader *graphqlReader) skipDirectives() error {
+	for reader.peek().Kind == '@' {
+		reader.next()
+		if _, err := reader.expect('a'); err != nil {
+			return err
+		}
+		if reader.peek().Kind == '(' {
+			if err := reader.skipGroup('(', ')'); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (reader *graphqlReader) skipGroup(open rune, close rune) error {
+	depth := 0
+	for !reader.done() {
+		token := reader.next()
+		if token.Kind == open {
+			depth++
+		}
+		if token.Kind == close {
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unexpected end of schema, expected %q", close)
+}
+
+func (reader *graphqlReader) definition() (*graphqlDefinition, error) {
+	reader.skipDescription()
+	keyword, err := reader.expect('a')
+	if err != nil {
+		return nil, err
+	}
+	if keyword.Value == "extend" {
+		keyword, err = reader.expect('a')
+		if err != nil {
+			return nil, err
+		}
+	}
+	definition := graphqlDefinition{Kind: keyword.Value, Line: keyword.Line}
+	switch keyword.Value {
+	case "schema":
+		{
+			if err := reader.skipDirectives(); err != nil {
+				return nil, err
+			}
+			if _, err := reader.expect('{'); err != nil {
+				return nil, err
+			}
+			for reader.peek().Kind != '}' {
+				operation, err := reader.expect('a')
+				if err != nil {
+					return nil, err
+				}
+				if _, err := reader.expect(':'); err != nil {
+					return nil, err
+				}
+				name, err := reader.expect('a')
+				if err != nil {
+					return nil, err
+				}
+				definition.Fields = append(definition.Fields, graphqlField{Name: operation.Value, Type: graphqlType{Name: name.Value}, Line: operation.Line})
+			}
+			reader.next()
+			return &definition, nil
+		}
+	case "directive":
+		{
+			for !reader.done() {
+				token := reader.next()
+				if token.Kind == 'a' && token.Value == "on" {
+					break
+				}
+				if token.Kind == '(' {
+					reader.position--
+					if err := reader.skipGroup('(', ')'); err != nil {
+						return nil, err
+					}
+				}
+			}
+			for reader.peek().Kind == '|' || (reader.peek().Kind == 'a' && reader.isLocation(reader.peek().Value)) {
+				reader.next()
+			}
+			return nil, nil
+		}
+	}
+	name, err := reader.expect('a')
+	if err != nil {
+		return nil, err
+	}
+	definition.Name = name.Value
+	switch keyword.Value {
+	case "scalar":
+		{
+			return &definition, reader.skipDirectives()
+		}
+	case "type", "input", "interface":
+		{
+			if reader.peek().Kind == 'a' && reader.peek().Value == "implements" {
+				reader.next()
+				for reader.peek().Kind == '&' || reader.peek().Kind == 'a' {
+					reader.next()
+				}
+			}
+			if err := reader.skipDirectives(); err != nil {
+				return nil, err
+			}
+			if reader.peek().Kind != '{' {
+				return &definition, nil
+			}
+			reader.next()
+			for reader.peek().Kind != '}' {
+				field, err := reader.field()
+				if err != nil {
+					return nil, err
+				}
+				definition.Fields = append(definition.Fields, field)
+			}
+			reader.next()
+			return &definition, nil
+		}
+	case "enum":
+		{
+			if err := reader.skipDirectives(); err != nil {
+				return nil, err
+			}
+			if reader.peek().Kind != '{' {
+				return &definition, nil
+			}
+			reader.next()
+			for reader.peek().Kind != '}' {
+				reader.skipDescription()
+				value, err := reader.expect('a')
+				if err != nil {
+					return nil, err
+				}
+				definition.Values = append(definition.Values, value.Value)
+				if err := reader.skipDirectives(); err != nil {
+					return nil, err
+				}
+			}
+			reader.next()
+			return &definition, nil
+		}
+	case "union":
+		{
+			if err := reader.skipDirectives(); err != nil {
+				return nil, err
+			}
+			if reader.peek().Kind != '=' {
+				return &definition, nil
+			}
+			reader.next()
+			if reader.peek().Kind == '|' {
+				reader.next()
+			}
+			for {
+				member, err := reader.expect('a')
+				if err != nil {
+					return nil, err
+				}
+				definition.Members = append(definition.Members, member.Value)
+				if reader.peek().Kind != '|' {
+					break
+				}
+				reader.next()
+			}
+			return &definition, nil
+		}
+	}
+	return nil, fmt.Errorf("line %d: unsupported definition %s", keyword.Line, keyword.Value)
+}
+
+func (reader *graphqlReader) isLocation(value string) bool {
+	return strings.ToUpper(value) == value && value != strings.ToLower(value)
+}
+
+func (reader *graphqlReader) field() (graphqlField, error) {
+	reader.skipDescription()
+	name, err := reader.expect('a')
+	if err != nil {
+		return graphqlField{}, err
+	}
+	if reader.peek().Kind == '(' {
+		if err := reader.skipGroup('(', ')'); err != nil {
+			return graphqlField{}, err
+		}
+	}
+	if _, err := reader.expect(':'); err != nil {
+		return graphqlField{}, err
+	}
+	_type, err := reader.typeReference()
+	if err != nil {
+		return graphqlField{}, err
+	}
+	if reader.peek().Kind == '=' {
+		reader.next()
+		if reader.peek().Kind == '[' || reader.peek().Kind == '{' {
+			open := reader.peek().Kind
+			close := ']'
+			if open == '{' {
+				close = '}'
+			}
+			if err := reader.skipGroup(open, close); err != nil {
+				return graphqlField{}, err
+			}
+		} else {
+			reader.next()
+		}
+	}
+	return graphqlField{Name: name.Value, Type: _type, Line: name.Line}, reader.skipDirectives()
+}
+
+func (reader *graphqlReader) typeReference() (graphqlType, error) {
+	if reader.peek().Kind == '[' {
+		reader.next()
+		inner, err := reader.typeReference()
+		if err != nil {
+			return inner, err
+		}
+		if _, err := reader.expect(']'); err != nil {
+			return inner, err
+		}
+		output := graphqlType{Name: inner.Name, List: inner.List + 1}
+		if reader.peek().Kind == '!' {
+			reader.next()
+			output.NonNull = true
+		}
+		return output, nil
+	}
+	name, err := reader.expect('a')
+	if err != nil {
+		return graphqlType{}, err
+	}
+	output := graphqlType{Name: name.Value}
+	if reader.peek().Kind == '!' {
+		reader.next()
+		output.NonNull = true
+	}
+	return output, nil
+}
@@ -0,0 +1,86 @@
+package internal
+
+import "testing"
+
+const GRAPHQL_TEST_SCHEMA = `"""
+Library schema
+"""
+schema { query: Query }
+scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+directive @auth(role: String = "user") on FIELD_DEFINITION | OBJECT
+# comment
+interface Node { id: ID! }
+type Book implements Node & Entity @auth {
+  id: ID!
+  "The title"
+  title: String!
+  pages: Int
+  rating: Float
+  authors: [Author!]!
+  published: DateTime
+  genre: Genre
+  related(first: Int = 10, filter: BookFilter): [[Book]]
+}
+type Author { id: ID!, name: String! }
+enum Genre { FICTION @deprecated(reason: "x") SCIENCE }
+union SearchResult = | Book | Author
+input BookFilter { title: String, genres: [Genre!] = [FICTION] }
+type Query { book(id: ID!): Book, search(q: String!): [SearchResult!]! }
+extend type Author { bio: String }`
+
+func TestGraphqlParser(t *testing.T) {
+	options := DefaultOptions()
+	parser, err := NewGraphqlParser([]byte(GRAPHQL_TEST_SCHEMA), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := parser.Build("test")
+	expected := `syntax = "proto3";
+
+package test;
+
+import "google/protobuf/any.proto";
+
+message Author {
+  string id = 1;
+  string name = 2;
+  optional string bio = 3;
+}
+
+enum Genre {
+  GENRE_UNSPECIFIED = 0;
+  GENRE_FICTION = 1;
+  GENRE_SCIENCE = 2;
+}
+
+message Book {
+  string id = 1;
+  string title = 2;
+  optional int32 pages = 3;
+  optional double rating = 4;
+  repeated Author authors = 5;
+  optional string published = 6;
+  optional Genre genre = 7;
+  repeated google.protobuf.Any related = 8;
+}
+
+message BookFilter {
+  optional string title = 1;
+  repeated Genre genres = 2;
+}
+
+message Node {
+  string id = 1;
+}
+
+message SearchResult {
+  oneof searchResult_union {
+    Book book = 1;
+    Author author = 2;
+  }
+}
+`
+	if output := Render(NewTemplate(options), file, options); output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	HELM_CHART_FILE  = "Chart.yaml"
+	HELM_SCHEMA_FILE = "values.schema.json"
+)
+
+type HelmChart struct {
+	Name      string
+	Version   string
+	Directory string
+	Package   string
+	Path      string
+	Schema    []byte
+}
+
+func FindHelmCharts(root string, prefix string, diagnosticHandler DiagnosticHandler) ([]HelmChart, error) {
+	output := make([]HelmChart, 0)
+	err := filepath.WalkDir(root, func(_path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			if strings.HasSuffix(entry.Name(), ".tgz") {
+				diagnosticHandler(Diagnostic{Severity: INFO, Subject: _path, Message: "packaged charts are skipped, unpack them with helm pull --untar"})
+			}
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(_path, HELM_CHART_FILE))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		chart := struct {
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+		}{}
+		err = yaml.Unmarshal(data, &chart)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Join(_path, HELM_CHART_FILE), err)
+		}
+		if len(chart.Name) == 0 {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: filepath.Join(_path, HELM_CHART_FILE), Message: "the chart has no name and was skipped"})
+			return nil
+		}
+		schema, err := os.ReadFile(filepath.Join(_path, HELM_SCHEMA_FILE))
+		if os.IsNotExist(err) {
+			diagnosticHandler(Diagnostic{Severity: INFO, Subject: _path, Message: fmt.Sprintf("chart %s has no %s", chart.Name, HELM_SCHEMA_FILE)})
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := DecodeJson(schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Join(_path, HELM_SCHEMA_FILE), err)
+		}
+		if document, ok := value.(*JsonObject); ok {
+			if _, ok := document.Get("title"); !ok {
+				words := strings.FieldsFunc(chart.Name, func(r rune) bool {
+					return r == '-' || r == '_' || r == '.'
+				})
+				for index, word := range words {
+					words[index] = upperFirst(word)
+				}
+				document.Set("title", *toPascalCase(strings.Join(words, "") + "Values"))
+				schema = EncodeJson(document)
+			}
+		}
+		components := make([]string, 0)
+		for _, component := range append(strings.Split(prefix, "."), chart.Name, helmMajorVersion(chart.Version)) {
+			if len(component) != 0 {
+				components = append(components, toPackageComponent(component))
+			}
+		}
+		output = append(output, HelmChart{
+			Name:      chart.Name,
+			Version:   chart.Version,
+			Directory: _path,
+			Package:   strings.Join(components, "."),
+			Path:      path.Join(append(components, "values.proto")...),
+			Schema:    schema,
+		})
+		return nil
+	})
+	return output, err
+}
+
+func helmMajorVersion(version string) string {
+	major := strings.TrimPrefix(strings.SplitN(strings.TrimSpace(version), ".", 2)[0], "v")
+	if len(major) == 0 || strings.Trim(major, "0123456789") != "" {
+		return ""
+	}
+	return "v" + major
+}
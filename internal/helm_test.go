@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestChart(t *testing.T, directory string, chart string, schema string) {
+	t.Helper()
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(directory, HELM_CHART_FILE), []byte(chart), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) == 0 {
+		return
+	}
+	if err := os.WriteFile(filepath.Join(directory, HELM_SCHEMA_FILE), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindHelmCharts(t *testing.T) {
+	root := t.TempDir()
+	writeTestChart(t, filepath.Join(root, "my-app"), "name: my-app\nversion: 2.3.1\n", `{"type": "object", "properties": {"replicaCount": {"type": "integer"}}}`)
+	writeTestChart(t, filepath.Join(root, "my-app", "charts", "redis"), "name: redis\nversion: v7.0.0\n", `{"title": "Cache", "type": "object", "properties": {"port": {"type": "integer"}}}`)
+	writeTestChart(t, filepath.Join(root, "plain"), "name: plain\nversion: 1.0.0\n", "")
+	diagnostics := make([]Diagnostic, 0)
+	charts, err := FindHelmCharts(root, "helm", func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(charts) != 2 || len(diagnostics) != 1 {
+		t.Fatalf("expected two charts and a diagnostic for the chart without a schema, got %+v %+v", charts, diagnostics)
+	}
+	tests := []struct {
+		packageName string
+		path        string
+		message     string
+	}{
+		{"helm.my_app.v2", "helm/my_app/v2/values.proto", "message MyAppValues {"},
+		{"helm.redis.v7", "helm/redis/v7/values.proto", "message Cache {"},
+	}
+	for index, test := range tests {
+		chart := charts[index]
+		if chart.Package != test.packageName || chart.Path != test.path {
+			t.Fatalf("%s: unexpected package %s and path %s", chart.Name, chart.Package, chart.Path)
+		}
+		file, err := ConvertSchema(chart.Schema, chart.Package, DefaultOptions(), func(Diagnostic) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output := RenderTarget(file, DefaultOptions()); !strings.Contains(output, test.message) {
+			t.Fatalf("%s: unexpected proto:\n%s", chart.Name, output)
+		}
+	}
+}
+
+func TestHelmMajorVersion(t *testing.T) {
+	tests := map[string]string{"1.2.3": "v1", "v10.0.0": "v10", "0.1.0": "v0", "latest": "", "": ""}
+	for version, expected := range tests {
+		if output := helmMajorVersion(version); output != expected {
+			t.Fatalf("%q: expected %q, got %q", version, expected, output)
+		}
+	}
+}
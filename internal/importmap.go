@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+type ImportMapping struct {
+	Type   string `json:"type"`
+	Import string `json:"import"`
+}
+
+var (
+	STRUCT_TYPE    = ImportMapping{Type: "google.protobuf.Struct", Import: "google/protobuf/struct.proto"}
+	TIMESTAMP_TYPE = ImportMapping{Type: "google.protobuf.Timestamp", Import: "google/protobuf/timestamp.proto"}
+	DURATION_TYPE  = ImportMapping{Type: "google.protobuf.Duration", Import: "google/protobuf/duration.proto"}
+	DATE_TYPE      = ImportMapping{Type: "google.type.Date", Import: "google/type/date.proto"}
+	TIME_TYPE      = ImportMapping{Type: "google.type.TimeOfDay", Import: "google/type/timeofday.proto"}
+	STATUS_TYPE    = ImportMapping{Type: "google.rpc.Status", Import: "google/rpc/status.proto"}
+)
+
+var WELL_KNOWN_IMPORT_MAP = map[string]ImportMapping{
+	"http://json-schema.org/draft-04/schema":                STRUCT_TYPE,
+	"http://json-schema.org/draft-06/schema":                STRUCT_TYPE,
+	"http://json-schema.org/draft-07/schema":                STRUCT_TYPE,
+	"https://json-schema.org/draft/2019-09/schema":          STRUCT_TYPE,
+	"https://json-schema.org/draft/2020-12/schema":          STRUCT_TYPE,
+	"https://schema.org/DateTime":                           TIMESTAMP_TYPE,
+	"https://schema.org/Date":                               DATE_TYPE,
+	"https://schema.org/Time":                               TIME_TYPE,
+	"https://schema.org/Duration":                           DURATION_TYPE,
+	"https://www.jsonrpc.org/specification#request_object":  STRUCT_TYPE,
+	"https://www.jsonrpc.org/specification#response_object": STRUCT_TYPE,
+	"https://www.jsonrpc.org/specification#error_object":    STATUS_TYPE,
+	"https://geojson.org/schema/GeoJSON.json":               STRUCT_TYPE,
+	"https://geojson.org/schema/Feature.json":               STRUCT_TYPE,
+	"https://geojson.org/schema/FeatureCollection.json":     STRUCT_TYPE,
+	"https://geojson.org/schema/Geometry.json":              STRUCT_TYPE,
+	"https://geojson.org/schema/GeometryCollection.json":    STRUCT_TYPE,
+	"https://geojson.org/schema/Point.json":                 STRUCT_TYPE,
+	"https://geojson.org/schema/MultiPoint.json":            STRUCT_TYPE,
+	"https://geojson.org/schema/LineString.json":            STRUCT_TYPE,
+	"https://geojson.org/schema/MultiLineString.json":       STRUCT_TYPE,
+	"https://geojson.org/schema/Polygon.json":               STRUCT_TYPE,
+	"https://geojson.org/schema/MultiPolygon.json":          STRUCT_TYPE,
+	K8S_OBJECT_META:   OBJECT_META_TYPE,
+	K8S_INT_OR_STRING: INT_OR_STRING_TYPE,
+}
+
+func importMap(options Options) map[string]ImportMapping {
+	output := make(map[string]ImportMapping)
+	if options.WellKnownTypes {
+		for key, value := range WELL_KNOWN_IMPORT_MAP {
+			output[key] = value
+		}
+	}
+	for key, value := range options.ImportMap {
+		output[key] = value
+	}
+	return output
+}
+
+func lookupImportMap(options Options, ref string) (ImportMapping, bool) {
+	mappings := importMap(options)
+	for _, value := range []string{ref, strings.TrimSuffix(ref, "#")} {
+		if mapping, ok := mappings[value]; ok {
+			return mapping, true
+		}
+	}
+	if strings.HasPrefix(ref, "#/") {
+		segments := strings.Split(ref, "/")
+		mapping, ok := mappings[segments[len(segments)-1]]
+		return mapping, ok
+	}
+	return ImportMapping{}, false
+}
+
+func lookupSchemaID(options Options, schema Properties) (ImportMapping, bool) {
+	if schema.ID == nil || strings.HasPrefix(*schema.ID, "#") {
+		return ImportMapping{}, false
+	}
+	return lookupImportMap(options, *schema.ID)
+}
+
+func isMappedDefinition(options Options, name string, definition Properties) bool {
+	mappings := importMap(options)
+	for _, value := range []string{name, "#/definitions/" + name, "#/$defs/" + name} {
+		if _, ok := mappings[value]; ok {
+			return true
+		}
+	}
+	_, ok := lookupSchemaID(options, definition)
+	return ok
+}
+
+func mappedImports(options Options, file *ProtoFile) []string {
+	mappings := importMap(options)
+	imports := make(map[string]bool)
+	for _, value := range file.Imports {
+		imports[value] = true
+	}
+	output := make([]string, 0)
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			for _, mapping := range mappings {
+				if mapping.Type == field.Type && len(mapping.Import) != 0 && !imports[mapping.Import] {
+					imports[mapping.Import] = true
+					output = append(output, mapping.Import)
+				}
+			}
+		}
+	}
+	sort.Strings(output)
+	return output
+}
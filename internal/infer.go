@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type inferredNode struct {
+	types      []Types
+	objects    int
+	present    int
+	nullable   bool
+	keys       []string
+	properties map[string]*inferredNode
+	items      *inferredNode
+}
+
+type Inferrer struct {
+	root              *inferredNode
+	diagnosticHandler DiagnosticHandler
+}
+
+func NewInferrer(diagnosticHandler DiagnosticHandler) *Inferrer {
+	return &Inferrer{root: &inferredNode{}, diagnosticHandler: diagnosticHandler}
+}
+
+func (inferrer *Inferrer) Observe(sample []byte) error {
+	value, err := DecodeJson(sample)
+	if err != nil {
+		return err
+	}
+	if array, ok := value.([]any); ok {
+		inferrer.diagnosticHandler(Diagnostic{Severity: INFO, Subject: "#", Message: "the sample is an array, the root message describes its elements"})
+		for _, item := range array {
+			inferrer.root.observe(item)
+		}
+		return nil
+	}
+	inferrer.root.observe(value)
+	return nil
+}
+
+func (inferrer *Inferrer) ObserveStream(reader io.Reader, limit int) (int, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	count := 0
+	for limit <= 0 || count < limit {
+		if !decoder.More() {
+			return count, nil
+		}
+		value, err := decodeJsonValue(decoder)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		inferrer.root.observe(value)
+		count++
+	}
+	inferrer.diagnosticHandler(Diagnostic{Severity: INFO, Subject: "#", Message: fmt.Sprintf("sampling stopped after %d records", count)})
+	return count, nil
+}
+
+func (node *inferredNode) addType(value Types) {
+	for _, existing := range node.types {
+		if existing == value {
+			return
+		}
+	}
+	node.types = append(node.types, value)
+}
+
+func (node *inferredNode) observe(value any) {
+	switch _value := value.(type) {
+	case *JsonObject:
+		{
+			node.addType(OBJECT)
+			node.objects++
+			if node.properties == nil {
+				node.properties = make(map[string]*inferredNode)
+			}
+			for _, key := range _value.Keys {
+				property, ok := node.properties[key]
+				if !ok {
+					property = &inferredNode{}
+					node.properties[key] = property
+					node.keys = append(node.keys, key)
+				}
+				property.present++
+				property.observe(_value.Values[key])
+			}
+		}
+	case []any:
+		{
+			node.addType(ARRAY)
+			if node.items == nil {
+				node.items = &inferredNode{}
+			}
+			for _, item := range _value {
+				node.items.observe(item)
+			}
+		}
+	case json.Number:
+		{
+			if strings.ContainsAny(_value.String(), ".eE") {
+				node.addType(NUMBER)
+			} else {
+				node.addType(INTEGER)
+			}
+		}
+	case string:
+		{
+			node.addType(STRING)
+		}
+	case bool:
+		{
+			node.addType(BOOLEAN)
+		}
+	case nil:
+		{
+			node.nullable = true
+		}
+	}
+}
+
+func (node *inferredNode) resolvedTypes(pointer string, diagnosticHandler DiagnosticHandler) []Types {
+	types := make([]Types, 0)
+	hasNumber := false
+	for _, value := range node.types {
+		if value == NUMBER {
+			hasNumber = true
+		}
+	}
+	for _, value := range node.types {
+		if value == INTEGER && hasNumber {
+			continue
+		}
+		types = append(types, value)
+	}
+	if len(types) == 0 {
+		return []Types{NULL}
+	}
+	if len(types) > 1 {
+		for _, value := range types {
+			if value == ARRAY {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("samples disagree on the type and arrays cannot be oneof members, %s was used", types[0])})
+				return types[:1]
+			}
+		}
+	}
+	return types
+}
+
+func (node *inferredNode) resolvedType(pointer string, diagnosticHandler DiagnosticHandler) Types {
+	types := node.resolvedTypes(pointer, diagnosticHandler)
+	if len(types) > 1 {
+		return NONE
+	}
+	return types[0]
+}
+
+func (inferrer *Inferrer) Schema(title string) []byte {
+	definitions := NewJsonObject()
+	root := inferrer.root.toSchema("#", title, definitions, inferrer.diagnosticHandler)
+	if _, ok := root.Get("properties"); !ok {
+		root.Set("properties", NewJsonObject())
+		root.Set("type", string(OBJECT))
+	}
+	output := NewJsonObject()
+	output.Set("$schema", "http://json-schema.org/draft-07/schema#")
+	output.Set("title", title)
+	for _, key := range root.Keys {
+		output.Set(key, root.Values[key])
+	}
+	if len(definitions.Keys) != 0 {
+		output.Set("definitions", definitions)
+	}
+	return EncodeJson(output)
+}
+
+func (node *inferredNode) toSchema(pointer string, name string, definitions *JsonObject, diagnosticHandler DiagnosticHandler) *JsonObject {
+	types := node.resolvedTypes(pointer, diagnosticHandler)
+	if len(types) == 1 {
+		return node.typedSchema(types[0], pointer, name, definitions, diagnosticHandler)
+	}
+	diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: fmt.Sprintf("samples disagree on the type, a union of %d types was inferred", len(types))})
+	members := make([]any, 0, len(types))
+	for position, value := range types {
+		members = append(members, node.typedSchema(value, fmt.Sprintf("%s/anyOf/%d", pointer, position), name, definitions, diagnosticHandler))
+	}
+	output := NewJsonObject()
+	output.Set("anyOf", members)
+	return output
+}
+
+func (node *inferredNode) typedSchema(_type Types, pointer string, name string, definitions *JsonObject, diagnosticHandler DiagnosticHandler) *JsonObject {
+	output := NewJsonObject()
+	output.Set("type", string(_type))
+	switch _type {
+	case OBJECT:
+		{
+			properties := NewJsonObject()
+			required := make([]any, 0)
+			for _, key := range node.keys {
+				property := node.properties[key]
+				properties.Set(key, property.toSchema(pointer+"/properties/"+escapePointer(key), key, definitions, diagnosticHandler))
+				if property.present == node.objects && !property.nullable {
+					required = append(required, key)
+				}
+			}
+			output.Set("properties", properties)
+			if len(required) != 0 && node.objects > 1 {
+				output.Set("required", required)
+			}
+		}
+	case ARRAY:
+		{
+			itemType := node.items.resolvedType(pointer+"/items", func(Diagnostic) {})
+			items := NewJsonObject()
+			if itemType != NONE {
+				items = node.items.toSchema(pointer+"/items", name, definitions, diagnosticHandler)
+			}
+			if itemType == OBJECT {
+				key := name
+				for suffix := 2; ; suffix++ {
+					if _, ok := definitions.Get(key); !ok {
+						break
+					}
+					key = fmt.Sprintf("%s%d", name, suffix)
+				}
+				definitions.Set(key, items)
+				items = NewJsonObject()
+				items.Set("$ref", "#/definitions/"+escapePointer(key))
+			}
+			if itemType == ARRAY {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "nested arrays have no proto equivalent, the elements were typed as google.protobuf.Any"})
+			}
+			if itemType == NONE {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "array elements disagree on the type, the elements were typed as google.protobuf.Any"})
+			}
+			if itemType == NULL || itemType == ARRAY || itemType == NONE {
+				items = NewJsonObject()
+			}
+			output.Set("items", items)
+		}
+	}
+	return output
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type inferredTestSchema struct {
+	Title      string                    `json:"title"`
+	Type       string                    `json:"type"`
+	Properties map[string]map[string]any `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+func inferTestSchema(t *testing.T, inferrer *Inferrer, title string) inferredTestSchema {
+	t.Helper()
+	schema := inferredTestSchema{}
+	if err := json.Unmarshal(inferrer.Schema(title), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestInferSample(t *testing.T) {
+	inferrer := NewInferrer(func(Diagnostic) {})
+	if err := inferrer.Observe([]byte(`{"id": 1, "name": "a", "tags": ["x"], "owner": {"email": "a@b"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	schema := inferTestSchema(t, inferrer, "Sample")
+	if schema.Title != "Sample" || schema.Type != "object" {
+		t.Fatalf("unexpected root %+v", schema)
+	}
+	expected := map[string]map[string]any{
+		"id":    {"type": "integer"},
+		"name":  {"type": "string"},
+		"tags":  {"type": "array", "items": map[string]any{"type": "string"}},
+		"owner": {"type": "object", "properties": map[string]any{"email": map[string]any{"type": "string"}}},
+	}
+	if !reflect.DeepEqual(schema.Properties, expected) {
+		t.Fatalf("unexpected properties %v", schema.Properties)
+	}
+	if len(schema.Required) != 0 {
+		t.Fatalf("a single sample inferred the required properties %v", schema.Required)
+	}
+	file := NewWithOptions(inferrer.Schema("Sample"), DefaultOptions()).Build("test")
+	if output := Render(NewTemplate(DefaultOptions()), file, DefaultOptions()); !strings.Contains(output, "message Sample {") || !strings.Contains(output, "repeated string tags") {
+		t.Fatalf("the inferred schema did not convert:\n%s", output)
+	}
+}
+
+func TestInferSamples(t *testing.T) {
+	inferrer := NewInferrer(func(Diagnostic) {})
+	for _, sample := range []string{
+		`{"id": 1, "name": "a", "tags": ["x"], "owner": {"email": "a@b"}}`,
+		`{"id": 2.5, "name": null, "tags": [], "extra": true, "owner": {"email": "c@d"}}`,
+	} {
+		if err := inferrer.Observe([]byte(sample)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema := inferTestSchema(t, inferrer, "Sample")
+	if !reflect.DeepEqual(schema.Properties["id"], map[string]any{"type": "number"}) {
+		t.Fatalf("integers and numbers were not widened to number: %v", schema.Properties["id"])
+	}
+	if _, ok := schema.Properties["extra"]; !ok {
+		t.Fatal("a property of a single sample was dropped")
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"id", "tags", "owner"}) {
+		t.Fatalf("expected the properties present and not null in every sample to be required, got %v", schema.Required)
+	}
+}
+
+func TestInferStream(t *testing.T) {
+	diagnostics := make([]Diagnostic, 0)
+	inferrer := NewInferrer(func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	count, err := inferrer.ObserveStream(strings.NewReader("{\"a\": 1}\n{\"a\": \"x\"}\n{\"a\": 2}\n"), 2)
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 records, got %d, %v", count, err)
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "stopped after 2 records") {
+		t.Fatalf("the record limit was not reported: %+v", diagnostics)
+	}
+	schema := inferTestSchema(t, inferrer, "Record")
+	expected := map[string]any{"anyOf": []any{map[string]any{"type": "integer"}, map[string]any{"type": "string"}}}
+	if !reflect.DeepEqual(schema.Properties["a"], expected) {
+		t.Fatalf("disagreeing samples were not unioned: %v", schema.Properties["a"])
+	}
+	count, err = NewInferrer(func(Diagnostic) {}).ObserveStream(strings.NewReader("{\"a\": 1}\n{\"a\": "), 0)
+	if err == nil || count != 1 {
+		t.Fatalf("a truncated record was accepted after %d records", count)
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func PrepareSchema(path string, file []byte, options Options, diagnosticHandler DiagnosticHandler) ([]byte, string, error) {
+	if IsGraphql(path, options.InputFormat) {
+		return file, "", nil
+	}
+	file, err := ToJsonSchema(path, file, options.InputFormat)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case IsOpenApi(file):
+		{
+			file, err = FromOpenApi(file, options.OpenApiServices, diagnosticHandler)
+		}
+	case IsSwagger(file):
+		{
+			file, err = FromSwagger(file, diagnosticHandler)
+		}
+	case IsAsyncApi(file):
+		{
+			file, err = FromAsyncApi(file, options.AsyncApiServices, diagnosticHandler)
+		}
+	case IsCrd(file):
+		{
+			versions, err := FromCrd(file, options.WellKnownTypes, diagnosticHandler)
+			if err != nil {
+				return nil, "", err
+			}
+			version := StorageVersion(versions)
+			if len(versions) > 1 {
+				diagnosticHandler(Diagnostic{Severity: INFO, Subject: "#/spec/versions", Message: fmt.Sprintf("converted the storage version %s of %d versions, use j2p crd to convert every version", version.Name, len(versions))})
+			}
+			return version.Schema, version.Package, nil
+		}
+	case IsAvro(path, options.InputFormat) || IsJtd(path, options.InputFormat):
+		{
+			return file, "", nil
+		}
+	default:
+		{
+			file, err = NormalizeDraft(file, options.Draft, diagnosticHandler)
+			if err != nil {
+				return nil, "", err
+			}
+			file, err = ResolveCompound(file, diagnosticHandler)
+			return file, "", err
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	schema := Schema{}
+	err = json.Unmarshal(file, &schema)
+	if err != nil {
+		return nil, "", err
+	}
+	if schema.ID == nil {
+		return file, "", nil
+	}
+	packageName, _, _ := PackageFromID(*schema.ID)
+	return file, packageName, nil
+}
+
+func ConvertSchema(data []byte, packageName string, options Options, diagnosticHandler DiagnosticHandler) (file *ProtoFile, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+	data, _, err = PrepareSchema("", data, options, diagnosticHandler)
+	if err != nil {
+		return nil, err
+	}
+	parser := NewWithOptions(data, options)
+	file = parser.Build(packageName)
+	for _, diagnostic := range parser.Diagnostics() {
+		diagnosticHandler(diagnostic)
+	}
+	return file, nil
+}
@@ -0,0 +1,24 @@
+package internal
+
+import "testing"
+
+func TestPrepareSchemaPackage(t *testing.T) {
+	tests := []struct {
+		schema      string
+		packageName string
+	}{
+		{`{"openapi": "3.0.0", "info": {"title": "Orders", "version": "1.0.0"}, "paths": {}, "components": {"schemas": {"Order": {"type": "object"}}}}`, "orders.v1"},
+		{`{"swagger": "2.0", "info": {"title": "Orders", "version": "2.1.0"}, "paths": {}, "definitions": {"Order": {"type": "object"}}}`, "orders.v2"},
+		{`{"$id": "https://acme.com/schemas/orders/v1/order.json", "type": "object"}`, ""},
+		{`{"type": "object", "properties": {"id": {"type": "string"}}}`, ""},
+	}
+	for _, test := range tests {
+		_, packageName, err := PrepareSchema("schema.json", []byte(test.schema), DefaultOptions(), func(Diagnostic) {})
+		if err != nil {
+			t.Fatalf("%s: %v", test.schema, err)
+		}
+		if packageName != test.packageName {
+			t.Fatalf("%s: expected the package %q, got %q", test.schema, test.packageName, packageName)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type JsonObject struct {
+	Keys   []string
+	Values map[string]any
+}
+
+func NewJsonObject() *JsonObject {
+	return &JsonObject{Values: make(map[string]any)}
+}
+
+func DecodeJson(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeJsonValue(decoder)
+}
+
+func decodeJsonValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch value := token.(type) {
+	case json.Delim:
+		{
+			if value == '{' {
+				object := NewJsonObject()
+				for decoder.More() {
+					key, err := decoder.Token()
+					if err != nil {
+						return nil, err
+					}
+					_value, err := decodeJsonValue(decoder)
+					if err != nil {
+						return nil, err
+					}
+					object.Set(key.(string), _value)
+				}
+				_, err := decoder.Token()
+				return object, err
+			}
+			if value == '[' {
+				array := make([]any, 0)
+				for decoder.More() {
+					_value, err := decodeJsonValue(decoder)
+					if err != nil {
+						return nil, err
+					}
+					array = append(array, _value)
+				}
+				_, err := decoder.Token()
+				return array, err
+			}
+			return nil, fmt.Errorf("unexpected delimiter %s", value)
+		}
+	}
+	return token, nil
+}
+
+func (object *JsonObject) Get(key string) (any, bool) {
+	value, ok := object.Values[key]
+	return value, ok
+}
+
+func (object *JsonObject) Set(key string, value any) {
+	if _, ok := object.Values[key]; !ok {
+		object.Keys = append(object.Keys, key)
+	}
+	object.Values[key] = value
+}
+
+func (object *JsonObject) Delete(key string) {
+	if _, ok := object.Values[key]; !ok {
+		return
+	}
+	delete(object.Values, key)
+	keys := make([]string, 0)
+	for _, value := range object.Keys {
+		if value != key {
+			keys = append(keys, value)
+		}
+	}
+	object.Keys = keys
+}
+
+func (object *JsonObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for index, key := range object.Keys {
+		if index != 0 {
+			buffer.WriteString(",")
+		}
+		_key, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(_key)
+		buffer.WriteString(":")
+		value, err := json.Marshal(object.Values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+func EncodeJson(value any) []byte {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
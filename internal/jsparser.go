@@ -2,11 +2,16 @@ package internal
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
-	"unicode"
+	"time"
 )
 
 type Schema struct {
@@ -18,14 +23,15 @@ type Schema struct {
 	Definitions       map[string]Properties `json:"definitions"`
 	PatternProperties PatternProperties     `json:"patternProperties"`
 	Required          []string              `json:"required"`
-	Defs              Defs                  `json:"$defs"`
-}
-
-type Defs struct {
-	DiskDevice Properties `json:"diskDevice"`
-	DiskUUID   Properties `json:"diskUUID"`
-	NFS        Properties `json:"nfs"`
-	Tmpfs      Properties `json:"tmpfs"`
+	Defs              map[string]Properties `json:"$defs"`
+	ReadOnly          *bool                 `json:"readOnly"`
+	WriteOnly         *bool                 `json:"writeOnly"`
+	XHttp             *HttpExtension        `json:"x-http"`
+	XGrpc             *GrpcExtension        `json:"x-grpc"`
+	Discriminator     *Discriminator        `json:"discriminator"`
+	XProtoName        *string               `json:"x-proto-name"`
+	XGoName           *string               `json:"x-go-name"`
+	XEnumVarnames     []string              `json:"x-enum-varnames"`
 }
 
 type PatternProperties struct {
@@ -37,32 +43,131 @@ type Empty struct {
 }
 
 type Properties struct {
-	Description       *string               `json:"description"`
-	Type              Types                 `json:"type"`
-	ExclusiveMinimum  *int64                `json:"exclusiveMinimum"`
-	Items             *Properties           `json:"items"`
-	MinItems          *int64                `json:"minItems"`
-	UniqueItems       *bool                 `json:"uniqueItems"`
-	Ref               *string               `json:"$ref"`
-	OneOf             []*Properties         `json:"oneOf"`
-	AllOf             []*Properties         `json:"allOf"`
-	AnyOf             []*Properties         `json:"anyOf"`
-	Enum              []string              `json:"enum"`
-	Pattern           *string               `json:"pattern"`
-	Minimum           *int64                `json:"minimum"`
-	Maximum           *int64                `json:"maximum"`
-	Format            string                `json:"format"`
-	Properties        map[string]Properties `json:"properties"`
-	Required          []string              `json:"required"`
-	PatternProperties PatternProperties     `json:"patternProperties"`
-	Defs              *Defs                 `json:"$defs"`
+	ID                   *string               `json:"$id"`
+	Description          *string               `json:"description"`
+	Type                 Types                 `json:"type"`
+	ExclusiveMinimum     *float64              `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64              `json:"exclusiveMaximum"`
+	Items                *Properties           `json:"items"`
+	MinItems             *int64                `json:"minItems"`
+	MaxItems             *int64                `json:"maxItems"`
+	UniqueItems          *bool                 `json:"uniqueItems"`
+	Ref                  *string               `json:"$ref"`
+	OneOf                []*Properties         `json:"oneOf"`
+	AllOf                []*Properties         `json:"allOf"`
+	AnyOf                []*Properties         `json:"anyOf"`
+	Enum                 []string              `json:"enum"`
+	Pattern              *string               `json:"pattern"`
+	MinLength            *int64                `json:"minLength"`
+	MaxLength            *int64                `json:"maxLength"`
+	MinProperties        *int64                `json:"minProperties"`
+	MaxProperties        *int64                `json:"maxProperties"`
+	AdditionalProperties AdditionalProperties  `json:"additionalProperties"`
+	Minimum              *float64              `json:"minimum"`
+	Maximum              *float64              `json:"maximum"`
+	MultipleOf           *float64              `json:"multipleOf"`
+	Default              json.RawMessage       `json:"default"`
+	Examples             []json.RawMessage     `json:"examples"`
+	Example              json.RawMessage       `json:"example"`
+	Format               string                `json:"format"`
+	Properties           map[string]Properties `json:"properties"`
+	Required             []string              `json:"required"`
+	PatternProperties    PatternProperties     `json:"patternProperties"`
+	Definitions          map[string]Properties `json:"definitions"`
+	Defs                 map[string]Properties `json:"$defs"`
+	ReadOnly             *bool                 `json:"readOnly"`
+	WriteOnly            *bool                 `json:"writeOnly"`
+	Nullable             *bool                 `json:"nullable"`
+	XHttp                *HttpExtension        `json:"x-http"`
+	Discriminator        *Discriminator        `json:"discriminator"`
+	XProtoName           *string               `json:"x-proto-name"`
+	XGoName              *string               `json:"x-go-name"`
+	XEnumVarnames        []string              `json:"x-enum-varnames"`
+	PropertyOrder        []string              `json:"-"`
+}
+
+func (properties *Properties) UnmarshalJSON(data []byte) error {
+	type alias Properties
+	value := alias{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	*properties = Properties(value)
+	raw := struct {
+		Properties json.RawMessage `json:"properties"`
+	}{}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	properties.PropertyOrder = objectKeys(raw.Properties)
+	return nil
+}
+
+func objectKeys(data json.RawMessage) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		return nil
+	}
+	keys := make([]string, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return keys
+		}
+		keys = append(keys, token.(string))
+	}
+	return keys
+}
+
+type AdditionalProperties struct {
+	Schema *Properties
+}
+
+func (additionalProperties *AdditionalProperties) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		additionalProperties.Schema = &Properties{}
+		return json.Unmarshal(data, additionalProperties.Schema)
+	}
+	return nil
 }
 
 type Items struct {
 	Type *string `json:"type"`
 }
 
-type NestedObjectHandler func(name string, value any)
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping"`
+}
+
+func (discriminator Discriminator) MappingKey(ref *string, typeName string) (string, bool) {
+	if ref == nil {
+		return "", false
+	}
+	keys := make([]string, 0)
+	for key := range discriminator.Mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if discriminator.Mapping[key] == *ref {
+			return key, true
+		}
+	}
+	return typeName, true
+}
+
+type NestedObjectHandler func(parentName string, name string, pointer string, value any) string
 type DuplicateCheck func(typeName string) bool
 
 type Types string
@@ -91,13 +196,14 @@ const (
 	NESTED_OBJECT_TYPE
 	REF_TYPE
 	PRIMITIVE_TYPE
+	MAP_TYPE
 )
 
 func (properties Properties) GetType() PropertyType {
 	if properties.Enum != nil {
 		return ENUM_TYPE
 	}
-	if properties.AnyOf != nil {
+	if properties.AnyOf != nil || properties.OneOf != nil {
 		return UNION_TYPE
 	}
 	if properties.Type == ARRAY {
@@ -112,6 +218,9 @@ func (properties Properties) GetType() PropertyType {
 		}
 		return PRIMITIVE_ARRAY_TYPE
 	}
+	if (properties.Type == OBJECT || properties.Type == NONE && properties.Ref == nil) && len(properties.Properties) == 0 && properties.AdditionalProperties.Schema != nil && !reflect.DeepEqual(*properties.AdditionalProperties.Schema, Properties{}) {
+		return MAP_TYPE
+	}
 	if properties.Type == OBJECT {
 		return NESTED_OBJECT_TYPE
 	}
@@ -121,27 +230,94 @@ func (properties Properties) GetType() PropertyType {
 	return PRIMITIVE_TYPE
 }
 
-func (properties Properties) GetRef(root map[string]Properties) (key string, value map[string]Properties) {
+func (properties Properties) ToMapProperty(root map[string]Properties, parentName string, propertyName string, pointer string, index *int, options Options, nestedObjectHander NestedObjectHandler, diagnosticHandler DiagnosticHandler) (*ProtoField, bool) {
+	value := *properties.AdditionalProperties.Schema
+	_pointer := pointer + "/additionalProperties"
+	var typeName string
+	switch value.GetType() {
+	case PRIMITIVE_TYPE:
+		{
+			scalar, ok := map[Types]string{STRING: "string", INTEGER: "int32", NUMBER: "double", BOOLEAN: "bool"}[value.Type]
+			if !ok {
+				return nil, false
+			}
+			typeName = scalar
+		}
+	case REF_TYPE:
+		{
+			if _typeName, ok := resolveExternalRef(options, *value.Ref); ok {
+				typeName = _typeName
+				break
+			}
+			refType, ref := value.GetRef(root)
+			if ref.GetType() == MAP_TYPE {
+				return nil, false
+			}
+			if mapping, ok := lookupSchemaID(options, ref); ok {
+				typeName = mapping.Type
+				break
+			}
+			typeName = *toPascalCase(nestedObjectHander(parentName, refType, *value.Ref, ref))
+		}
+	case NESTED_OBJECT_TYPE, ENUM_TYPE:
+		{
+			typeName = *toPascalCase(nestedObjectHander(parentName, toInlineTypeName(options, parentName, propertyName+"Value", _pointer), _pointer, value))
+		}
+	default:
+		{
+			return nil, false
+		}
+	}
+	return toField(NO_LABEL, fmt.Sprintf("map<string, %s>", typeName), propertyName, index, diagnosticHandler), true
+}
+
+func (properties Properties) GetRef(root map[string]Properties) (key string, value Properties) {
 	if strings.HasPrefix(strings.ToLower(*properties.Ref), "http") {
 		panic("External Json Schemas are not supported by J2P compiler")
 	}
 	path := strings.Split(*properties.Ref, "/")
 	len := len(path)
-	ref := root
+	for i := range path {
+		path[i] = strings.ReplaceAll(strings.ReplaceAll(path[i], "~1", "/"), "~0", "~")
+	}
+	ref := Properties{Properties: root}
 	for i := 1; i < len; i++ {
-		if i == 1 {
-			if path[i] == "$defs" {
-				panic("$defs is a Json Schema specification which is not supported by J2P compiler")
-			}
-			if path[i] == "definitions" {
-				continue
+		if i == 1 && (path[i] == "definitions" || path[i] == "$defs") {
+			continue
+		}
+		if i > 2 && i+1 < len {
+			switch path[i] {
+			case "properties":
+				{
+					continue
+				}
+			case "definitions":
+				{
+					i++
+					ref = ref.Definitions[path[i]]
+					continue
+				}
+			case "$defs":
+				{
+					i++
+					ref = ref.Defs[path[i]]
+					continue
+				}
 			}
 		}
-		ref = ref[path[i]].Properties
+		if i > 2 && path[i] == "items" && ref.Items != nil {
+			ref = *ref.Items
+			continue
+		}
+		ref = ref.Properties[path[i]]
 	}
 	return path[len-1], ref
 }
 
+func escapePointer(str string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(str, "~", "~0"), "/", "~1")
+}
+
 func (properties Properties) GetRefType(root map[string]Properties) string {
 	if properties.Ref == nil {
 		return ""
@@ -151,32 +327,55 @@ func (properties Properties) GetRefType(root map[string]Properties) string {
 	return path[len-1]
 }
 
-func (properties Properties) ToField(root map[string]Properties, propertyName string, index *int, nestedObjectHander NestedObjectHandler) string {
+func (properties Properties) ToField(root map[string]Properties, parentName string, propertyName string, pointer string, index *int, options Options, nestedObjectHander NestedObjectHandler, diagnosticHandler DiagnosticHandler) []*ProtoField {
 	_type := properties.GetType()
 	switch _type {
 	case PRIMITIVE_TYPE:
 		{
-			return ToPrimitiveProperty(propertyName, properties.Type, index)
+			return []*ProtoField{ToPrimitiveProperty(propertyName, properties.Type, index, diagnosticHandler)}
+		}
+	case MAP_TYPE:
+		{
+			if field, ok := properties.ToMapProperty(root, parentName, propertyName, pointer, index, options, nestedObjectHander, diagnosticHandler); ok {
+				return []*ProtoField{field}
+			}
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer + "/additionalProperties", Message: "additionalProperties values of this kind cannot be typed as a map, undeclared properties are dropped"})
+			return []*ProtoField{ToRefProperty(propertyName, nestedObjectHander(parentName, toInlineTypeName(options, parentName, propertyName, pointer), pointer, properties), index, diagnosticHandler)}
 		}
 	case REF_TYPE:
 		{
+			if typeName, ok := resolveExternalRef(options, *properties.Ref); ok {
+				return []*ProtoField{ToRefProperty(propertyName, typeName, index, diagnosticHandler)}
+			}
 			refType, ref := properties.GetRef(root)
-			nestedObjectHander(propertyName, ref)
-			return ToRefProperty(propertyName, refType, index)
+			if ref.GetType() == MAP_TYPE {
+				if field, ok := ref.ToMapProperty(root, parentName, propertyName, *properties.Ref, index, options, nestedObjectHander, diagnosticHandler); ok {
+					return []*ProtoField{field}
+				}
+			}
+			if mapping, ok := lookupSchemaID(options, ref); ok {
+				return []*ProtoField{ToRefProperty(propertyName, mapping.Type, index, diagnosticHandler)}
+			}
+			return []*ProtoField{ToRefProperty(propertyName, nestedObjectHander(parentName, refType, *properties.Ref, ref), index, diagnosticHandler)}
 		}
 	case PRIMITIVE_ARRAY_TYPE:
 		{
-			return ToPrimitiveArrayProperty(propertyName, properties.Items.Type, index)
+			return []*ProtoField{ToPrimitiveArrayProperty(propertyName, properties.Items.Type, index, diagnosticHandler)}
 		}
 	case UNKOWN_ARRAY_TYPE:
 		{
-			return ToRefArrayProperty(propertyName, "google.protobuf.Any", index)
+			return []*ProtoField{ToRefArrayProperty(propertyName, "google.protobuf.Any", index, diagnosticHandler)}
 		}
 	case REF_ARRAY_TYPE:
 		{
+			if typeName, ok := resolveExternalRef(options, *properties.Items.Ref); ok {
+				return []*ProtoField{ToRefArrayProperty(propertyName, typeName, index, diagnosticHandler)}
+			}
 			refType, ref := properties.Items.GetRef(root)
-			nestedObjectHander(propertyName, ref)
-			return ToRefArrayProperty(propertyName, refType, index)
+			if mapping, ok := lookupSchemaID(options, ref); ok {
+				return []*ProtoField{ToRefArrayProperty(propertyName, mapping.Type, index, diagnosticHandler)}
+			}
+			return []*ProtoField{ToRefArrayProperty(propertyName, nestedObjectHander(parentName, refType, *properties.Items.Ref, ref), index, diagnosticHandler)}
 		}
 	case COMPLEX_ARRAY_TYPE:
 		{
@@ -184,124 +383,164 @@ func (properties Properties) ToField(root map[string]Properties, propertyName st
 		}
 	case ENUM_TYPE:
 		{
-			nestedObjectHander(propertyName, properties.Enum)
-			return ToRefProperty(propertyName, propertyName, index)
+			return []*ProtoField{ToRefProperty(propertyName, nestedObjectHander(parentName, toInlineTypeName(options, parentName, propertyName, pointer), pointer, properties), index, diagnosticHandler)}
 		}
 	case NESTED_OBJECT_TYPE:
 		{
-			nestedObjectHander(propertyName, properties)
-			return ToRefProperty(propertyName, propertyName, index)
+			return []*ProtoField{ToRefProperty(propertyName, nestedObjectHander(parentName, toInlineTypeName(options, parentName, propertyName, pointer), pointer, properties), index, diagnosticHandler)}
 		}
 	case UNION_TYPE:
 		{
-			return ToUnionProperty(root, propertyName, properties.AnyOf, index, nestedObjectHander)
+			if properties.AnyOf == nil {
+				return ToUnionProperty(root, parentName, propertyName, pointer+"/oneOf", properties.OneOf, properties.Discriminator, index, options, nestedObjectHander, diagnosticHandler)
+			}
+			return ToUnionProperty(root, parentName, propertyName, pointer+"/anyOf", properties.AnyOf, properties.Discriminator, index, options, nestedObjectHander, diagnosticHandler)
 		}
 	}
-	return "--Invalid Type--"
+	diagnosticHandler(Diagnostic{Severity: ERROR, Subject: propertyName, Message: "unsupported property type was skipped"})
+	return nil
 }
 
-const MESSAGE_TEMPLATE = `
-message _$NAME$_ {
-_$VALUE$_	
-}
-`
-
-func ToMessage(root map[string]Properties, messageName string, properties map[string]Properties, nestedObjectHandler NestedObjectHandler, duplicateCheck DuplicateCheck) string {
-	typeName := toPascalCase(messageName)
+func ToMessage(root map[string]Properties, messageName string, pointer string, message Properties, options Options, nestedObjectHandler NestedObjectHandler, duplicateCheck DuplicateCheck, diagnosticHandler DiagnosticHandler) *ProtoMessage {
+	typeName := toTypeName(messageName, diagnosticHandler)
 	if duplicateCheck(*typeName) {
-		return ""
-	}
-	buffer := bytes.NewBufferString("")
-	keys := make([]string, 0)
-	for key := range properties {
-		keys = append(keys, key)
+		return nil
 	}
-	sort.Slice(keys, func(i, j int) bool {
-		return len(keys[i]) < len(keys[j])
-	})
+	output := ProtoMessage{Name: *typeName, Pointer: pointer, Comment: ExampleComment(message, options)}
+	MessageConstraints(message, pointer, diagnosticHandler)
 	index := 1
-	for _, key := range keys {
-		value := properties[key]
-		buffer.WriteString(value.ToField(root, key, &index, nestedObjectHandler))
-		buffer.WriteString("\n")
-	}
-	renderedStr := MESSAGE_TEMPLATE
-	renderedStr = strings.Replace(renderedStr, "_$NAME$_", *typeName, 1)
-	renderedStr = strings.Replace(renderedStr, "_$VALUE$_", buffer.String(), 1)
-	return renderedStr
-}
-
-func (schema Schema) ToProtobuf(root map[string]Properties, nestedObjectHandler NestedObjectHandler, duplicateCheck DuplicateCheck) string {
-	buffer := bytes.NewBufferString("")
-	keys := make([]string, 0)
-	for key := range schema.Definitions {
-		keys = append(keys, key)
-	}
-	sort.Slice(keys, func(i, j int) bool {
-		return len(keys[i]) < len(keys[j])
-	})
-	for _, key := range keys {
-		value := schema.Definitions[key]
-		_type := value.GetType()
-		switch _type {
-		case ENUM_TYPE:
-			{
-				buffer.WriteString(ToEnum(key, value.Enum, duplicateCheck))
+	for _, key := range OrderFields(message, options.FieldOrder) {
+		value := message.Properties[key]
+		fieldPointer := pointer + "/properties/" + escapePointer(key)
+		fields := value.ToField(root, *typeName, key, fieldPointer, &index, options, nestedObjectHandler, diagnosticHandler)
+		for _, field := range fields {
+			if len(field.Pointer) == 0 {
+				field.Pointer = fieldPointer
+			}
+			field.Original = key
+			if value.Nullable != nil && *value.Nullable && len(fields) == 1 && field.Label == NO_LABEL && !strings.HasPrefix(field.Type, "map<") {
+				field.Label = OPTIONAL_LABEL
+			}
+			if pinned, ok := pinnedFieldName(value); ok {
+				if len(fields) > 1 {
+					field.Oneof = pinned
+				} else {
+					field.Name = pinned
+					field.JsonName = ""
+					if pinned != key {
+						field.JsonName = key
+					}
+				}
+			}
+			if options.Provenance {
+				provenance := fmt.Sprintf("source: %s, property: %s", field.Pointer, strconv.Quote(key))
+				field.Comment = strings.TrimPrefix(field.Comment+"; "+provenance, "; ")
+			}
+			field.Required = isRequired(message, key)
+			field.ReadOnly = value.ReadOnly != nil && *value.ReadOnly
+			field.WriteOnly = value.WriteOnly != nil && *value.WriteOnly
+			if !options.FieldBehavior && field.ReadOnly {
+				field.Comment = strings.TrimPrefix(field.Comment+"; output only", "; ")
+			} else if !options.FieldBehavior && field.WriteOnly {
+				field.Comment = strings.TrimPrefix(field.Comment+"; input only", "; ")
+			}
+			if options.FieldBehavior {
+				field.Behaviors = FieldBehaviors(message, key)
 			}
-		default:
-			{
-				buffer.WriteString(ToMessage(root, key, value.Properties, nestedObjectHandler, duplicateCheck))
+			if len(fields) == 1 {
+				ApplyConstraints(value, field, options, diagnosticHandler)
+				ApplyDefault(value, field, options)
 			}
 		}
-		buffer.WriteString("\n")
+		if options.Omit == READ_ONLY_OMIT && value.ReadOnly != nil && *value.ReadOnly || options.Omit == WRITE_ONLY_OMIT && value.WriteOnly != nil && *value.WriteOnly {
+			output.Omitted = append(output.Omitted, fields...)
+			continue
+		}
+		output.Fields = append(output.Fields, fields...)
 	}
-	return buffer.String()
+	if len(message.Properties) == 0 && message.GetType() == UNION_TYPE {
+		output.Fields = message.ToField(root, *typeName, *toCamelCase(*typeName), pointer, &index, options, nestedObjectHandler, diagnosticHandler)
+	}
+	dedupeFieldNames(append(append([]*ProtoField{}, output.Fields...), output.Omitted...), diagnosticHandler)
+	return &output
 }
 
-const ENUM_TEMPLATE = `
-enum _$NAME$_ {
-_$VALUE$_
+func dedupeFieldNames(fields []*ProtoField, diagnosticHandler DiagnosticHandler) {
+	names := make(map[string]bool)
+	for _, field := range fields {
+		names[field.Name] = true
+	}
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		if !seen[field.Name] {
+			seen[field.Name] = true
+			continue
+		}
+		candidate := field.Name
+		for suffix := 2; names[candidate]; suffix++ {
+			candidate = fmt.Sprintf("%s_%d", field.Name, suffix)
+		}
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: field.Pointer, Message: fmt.Sprintf("field %s collides with another field and was renamed to %s", field.Name, candidate)})
+		if len(field.JsonName) == 0 {
+			field.JsonName = field.Original
+		}
+		field.Name = candidate
+		names[candidate] = true
+		seen[candidate] = true
+	}
 }
-`
 
-func ToEnum(enumName string, enumValue []string, duplicateCheck DuplicateCheck) string {
-	_enumName := toPascalCase(enumName)
+func ToEnum(enumName string, pointer string, enumValue []string, options Options, duplicateCheck DuplicateCheck, diagnosticHandler DiagnosticHandler) *ProtoEnum {
+	_enumName := toTypeName(enumName, diagnosticHandler)
 	if duplicateCheck(*_enumName) {
-		return ""
+		return nil
+	}
+	output := ProtoEnum{Name: *_enumName, Pointer: pointer}
+	numbers := make(map[string]int)
+	if len(options.EnumZeroValue) != 0 {
+		name := strings.ToUpper(fmt.Sprintf("%s_%s", *_enumName, *fixString(options.EnumZeroValue)))
+		numbers[name] = 0
+		output.Values = append(output.Values, &ProtoEnumValue{Name: name, Number: 0})
 	}
-	buffer := bytes.NewBufferString("")
+	offset := len(output.Values)
 	for index, value := range enumValue {
-		fixedValue := *fixString(value)
-		buffer.WriteString("\t")
-		buffer.WriteString(strings.ToUpper(fmt.Sprintf("%s_%s", *_enumName, fixedValue)))
-		buffer.WriteString(" ")
-		buffer.WriteString("=")
-		buffer.WriteString(" ")
-		buffer.WriteString(fmt.Sprintf("%d", index))
-		buffer.WriteString(";\n")
-	}
-	renderedStr := ENUM_TEMPLATE
-	renderedStr = strings.Replace(renderedStr, "_$NAME$_", *_enumName, 1)
-	renderedStr = strings.Replace(renderedStr, "_$VALUE$_", buffer.String(), 1)
-	return renderedStr
-}
-
-const UNION_TEMPLATE = `
-	oneof _$NAME$__union {
-_$VALUE$_
+		name := strings.ToUpper(fmt.Sprintf("%s_%s", *_enumName, *fixString(value)))
+		number := index + offset
+		if existing, ok := numbers[name]; ok {
+			suffix := 2
+			for {
+				if _, ok := numbers[fmt.Sprintf("%s_%d", name, suffix)]; !ok {
+					break
+				}
+				suffix++
+			}
+			renamed := fmt.Sprintf("%s_%d", name, suffix)
+			if options.EnumAllowAlias {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: *_enumName, Message: fmt.Sprintf("enum value \"%s\" collides with %s and was emitted as alias %s = %d", value, name, renamed, existing)})
+				output.AllowAlias = true
+				number = existing
+			} else {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: *_enumName, Message: fmt.Sprintf("enum value \"%s\" collides with %s and was renamed to %s", value, name, renamed)})
+			}
+			name = renamed
+		}
+		numbers[name] = number
+		output.Values = append(output.Values, &ProtoEnumValue{Name: name, Number: number, Original: value, Pointer: fmt.Sprintf("%s/enum/%d", pointer, index)})
 	}
-`
+	return &output
+}
 
-func ToUnionProperty(root map[string]Properties, unionName string, unionValue []*Properties, index *int, nestedObjectHandler NestedObjectHandler) string {
-	buffer := bytes.NewBufferString("")
+func ToUnionProperty(root map[string]Properties, parentName string, unionName string, pointer string, unionValue []*Properties, discriminator *Discriminator, index *int, options Options, nestedObjectHandler NestedObjectHandler, diagnosticHandler DiagnosticHandler) []*ProtoField {
 	if len(unionValue) == 2 {
 		isOptional := false
 		var _value *Properties
-		for _, value := range unionValue {
+		_pointer := pointer
+		for position, value := range unionValue {
 			if value.Type == NULL {
 				isOptional = true
 			} else {
 				_value = value
+				_pointer = fmt.Sprintf("%s/%d", pointer, position)
 			}
 
 		}
@@ -313,11 +552,20 @@ func ToUnionProperty(root map[string]Properties, unionName string, unionValue []
 			if len(_type) == 0 {
 				panic("Unions without types or formatted unions are not supported by J2P")
 			}
-			return fmt.Sprintf("\toptional %s", strings.TrimLeft(_value.ToField(root, fmt.Sprintf("%s_%s", *toCamelCase(unionName), *toCamelCase(_type)), index, nestedObjectHandler), "\t"))
+			fields := _value.ToField(root, parentName, toOneofMemberName(options, unionName, _type), _pointer, index, options, nestedObjectHandler, diagnosticHandler)
+			for _, field := range fields {
+				if !strings.HasPrefix(field.Type, "map<") {
+					field.Label = OPTIONAL_LABEL
+				}
+				field.Pointer = _pointer
+			}
+			return fields
 		}
 	}
-	for _, value := range unionValue {
-		buffer.WriteString("\t")
+	output := make([]*ProtoField, 0)
+	oneofName := toOneofName(options, unionName)
+	for position, value := range unionValue {
+		_pointer := fmt.Sprintf("%s/%d", pointer, position)
 		_type := string(value.Type)
 		if value.Type == NONE {
 			_type = value.GetRefType(root)
@@ -325,17 +573,72 @@ func ToUnionProperty(root map[string]Properties, unionName string, unionValue []
 		if len(_type) == 0 {
 			panic("Unions without types or formatted unions are not supported by J2P")
 		}
-		buffer.WriteString(value.ToField(root, fmt.Sprintf("%s_%s", *toCamelCase(unionName), *toCamelCase(_type)), index, nestedObjectHandler))
-		buffer.WriteString("\n")
+		memberName := toOneofMemberName(options, unionName, _type)
+		comment := ""
+		if discriminator != nil {
+			if mappingKey, ok := discriminator.MappingKey(value.Ref, _type); ok {
+				memberName = mappingKey
+				comment = fmt.Sprintf("discriminator: %s = %s", discriminator.PropertyName, strconv.Quote(mappingKey))
+			}
+		}
+		fields := value.ToField(root, parentName, memberName, _pointer, index, options, nestedObjectHandler, diagnosticHandler)
+		for _, field := range fields {
+			if strings.HasPrefix(field.Type, "map<") {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: _pointer, Message: "maps cannot be oneof members and were typed as google.protobuf.Struct"})
+				field.Type = STRUCT_TYPE.Type
+			}
+			field.Oneof = oneofName
+			field.Pointer = _pointer
+			field.Comment = comment
+		}
+		output = append(output, fields...)
+	}
+	return output
+}
+
+func resolveExternalRef(options Options, ref string) (string, bool) {
+	if mapping, ok := lookupImportMap(options, ref); ok {
+		return mapping.Type, true
+	}
+	if options.ExternalRefResolver == nil || strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+	return options.ExternalRefResolver(ref)
+}
+
+func RootMessageName(schema Schema) string {
+	if schema.Title != nil && len(*schema.Title) != 0 {
+		return *toPascalCase(*schema.Title)
+	}
+	if schema.ID != nil {
+		_, _, stem := PackageFromID(*schema.ID)
+		if len(stem) != 0 {
+			return *toPascalCase(stem)
+		}
+	}
+	return "Root"
+}
+
+func toOneofName(options Options, unionName string) string {
+	if options.OneofNamer != nil {
+		return options.OneofNamer(unionName)
 	}
-	renderedStr := UNION_TEMPLATE
-	renderedStr = strings.Replace(renderedStr, "_$NAME$_", *toCamelCase(unionName), 1)
-	renderedStr = strings.Replace(renderedStr, "_$VALUE$_", buffer.String(), 1)
+	return strings.Replace(options.OneofNameTemplate, "_$NAME$_", *toCamelCase(unionName), -1)
+}
+
+func toOneofMemberName(options Options, unionName string, typeName string) string {
+	if options.OneofMemberNamer != nil {
+		return options.OneofMemberNamer(unionName, typeName)
+	}
+	renderedStr := options.OneofMemberNameTemplate
+	renderedStr = strings.Replace(renderedStr, "_$NAME$_", *toCamelCase(unionName), -1)
+	renderedStr = strings.Replace(renderedStr, "_$TYPE$_", *toCamelCase(typeName), -1)
 	return renderedStr
 }
 
-func ToPrimitiveProperty(propertyName string, typeName Types, index *int) string {
+func ToPrimitiveProperty(propertyName string, typeName Types, index *int, diagnosticHandler DiagnosticHandler) *ProtoField {
 	var _typename string
+	label := NO_LABEL
 	switch typeName {
 	case INTEGER:
 		{
@@ -354,7 +657,8 @@ func ToPrimitiveProperty(propertyName string, typeName Types, index *int) string
 		}
 	case NULL:
 		{
-			_typename = "optional google.protobuf.Any"
+			_typename = "google.protobuf.Any"
+			label = OPTIONAL_LABEL
 		}
 	default:
 		{
@@ -366,67 +670,164 @@ func ToPrimitiveProperty(propertyName string, typeName Types, index *int) string
 			break
 		}
 	}
-	var output string
-	snakeCasePropertyName, ok := toSnakeCase(propertyName)
-	if ok {
-		output = fmt.Sprintf("\t%s %s = %d [json_name=\"%s\"];", _typename, *toCamelCase(propertyName), *index, *snakeCasePropertyName)
-	} else {
-		output = fmt.Sprintf("\t%s %s = %d;", _typename, *toCamelCase(propertyName), *index)
-	}
-	*index += 1
-	return output
+	return toField(label, _typename, propertyName, index, diagnosticHandler)
 }
 
-func ToPrimitiveArrayProperty(propertyName string, typeName Types, index *int) string {
-	var output string
-	output = fmt.Sprintf("\trepeated %s", strings.TrimPrefix(ToPrimitiveProperty(propertyName, typeName, index), "\t"))
+func ToPrimitiveArrayProperty(propertyName string, typeName Types, index *int, diagnosticHandler DiagnosticHandler) *ProtoField {
+	output := ToPrimitiveProperty(propertyName, typeName, index, diagnosticHandler)
+	output.Label = REPEATED_LABEL
 	return output
 }
 
-func ToRefArrayProperty(propertyName string, typeName string, index *int) string {
-	var output string
-	snakeCasePropertyName, ok := toSnakeCase(propertyName)
-	if ok {
-		output = fmt.Sprintf("\trepeated %s %s = %d [json_name=\"%s\"];", *toPascalCase(typeName), *toCamelCase(propertyName), *index, *snakeCasePropertyName)
-	} else {
-		output = fmt.Sprintf("\trepeated %s %s = %d;", *toPascalCase(typeName), *toCamelCase(propertyName), *index)
-	}
-	*index += 1
-	return output
+func ToRefArrayProperty(propertyName string, typeName string, index *int, diagnosticHandler DiagnosticHandler) *ProtoField {
+	return toField(REPEATED_LABEL, *toPascalCase(typeName), propertyName, index, diagnosticHandler)
+}
+
+func ToRefProperty(propertyName string, typeName string, index *int, diagnosticHandler DiagnosticHandler) *ProtoField {
+	return toField(NO_LABEL, *toPascalCase(typeName), propertyName, index, diagnosticHandler)
 }
 
-func ToRefProperty(propertyName string, typeName string, index *int) string {
-	var output string
-	snakeCasePropertyName, ok := toSnakeCase(propertyName)
-	if ok {
-		output = fmt.Sprintf("\t%s %s = %d [json_name=\"%s\"];", *toPascalCase(typeName), *toCamelCase(propertyName), *index, *snakeCasePropertyName)
-	} else {
-		output = fmt.Sprintf("\t%s %s = %d;", *toPascalCase(typeName), *toCamelCase(propertyName), *index)
+func toField(label Label, typeName string, propertyName string, index *int, diagnosticHandler DiagnosticHandler) *ProtoField {
+	fieldName, jsonName := toFieldName(propertyName, diagnosticHandler)
+	output := ProtoField{Label: label, Type: typeName, Name: fieldName, Number: *index, Original: propertyName}
+	if jsonName != nil {
+		output.JsonName = *jsonName
 	}
 	*index += 1
-	return output
+	return &output
 }
 
 type DefaultJsonSchemaParser struct {
 	schema             Schema
+	root               Properties
+	options            Options
+	hash               string
 	pushBacks          map[string]any
+	typeShapes         map[string]any
+	dependencies       map[string][]string
+	pointers           map[string]string
+	definitionPointers map[string]string
+	renames            *[]ManifestRename
+	pinned             map[string]bool
 	nestedObjectHander NestedObjectHandler
+	resolveTypeName    NestedObjectHandler
 	typeNames          []string
 	duplicateCheck     DuplicateCheck
+	diagnostics        *[]Diagnostic
+	diagnosticHandler  DiagnosticHandler
 }
 
 func New(jsonSchema []byte) DefaultJsonSchemaParser {
+	return NewWithOptions(jsonSchema, DefaultOptions())
+}
+
+func NewWithOptions(jsonSchema []byte, options Options) DefaultJsonSchemaParser {
 	schema := Schema{}
 	err := json.Unmarshal(jsonSchema, &schema)
 	if err != nil {
 		panic(err)
 	}
+	if len(schema.Defs) != 0 && schema.Definitions == nil {
+		schema.Definitions = make(map[string]Properties)
+	}
+	definitionPointers := make(map[string]string)
+	for key := range schema.Definitions {
+		definitionPointers[key] = "#/definitions/" + escapePointer(key)
+	}
+	for key, value := range schema.Defs {
+		if _, ok := schema.Definitions[key]; !ok {
+			schema.Definitions[key] = value
+			definitionPointers[key] = "#/$defs/" + escapePointer(key)
+		}
+	}
 	output := DefaultJsonSchemaParser{}
+	output.definitionPointers = definitionPointers
+	output.pointers = make(map[string]string)
+	output.renames = &[]ManifestRename{}
+	output.pinned = make(map[string]bool)
 	output.schema = schema
+	err = json.Unmarshal(jsonSchema, &output.root)
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(jsonSchema)
+	output.hash = hex.EncodeToString(hash[:])
+	output.options = options
 	output.pushBacks = make(map[string]any)
 	output.typeNames = make([]string, 0)
-	output.nestedObjectHander = func(name string, value any) {
-		output.pushBacks[name] = value
+	output.typeShapes = make(map[string]any)
+	output.dependencies = make(map[string][]string)
+	output.nestedObjectHander = func(parentName string, name string, pointer string, value any) string {
+		typeName := RootMessageName(output.schema)
+		if pointer != "#" || len(parentName) == 0 {
+			typeName = output.resolveTypeName(parentName, name, pointer, value)
+		}
+		if len(parentName) != 0 {
+			output.dependencies[parentName] = append(output.dependencies[parentName], typeName)
+		}
+		return typeName
+	}
+	output.resolveTypeName = func(parentName string, name string, pointer string, value any) string {
+		shape := toShape(value)
+		typeName := *toPascalCase(name)
+		if pinned, ok := pinnedTypeName(value); ok && isDefinitionPointer(pointer) {
+			typeName = pinned
+			output.pinned[pinned] = true
+		}
+		register := func(candidate string) string {
+			output.typeShapes[candidate] = shape
+			output.pushBacks[candidate] = shape
+			output.pointers[candidate] = pointer
+			if output.pinned[typeName] && candidate != typeName {
+				output.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: name, Message: fmt.Sprintf("pinned type name %s collides with a different definition", typeName)})
+			}
+			if candidate != typeName {
+				*output.renames = append(*output.renames, ManifestRename{Pointer: pointer, Requested: typeName, Assigned: candidate})
+				output.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: name, Message: fmt.Sprintf("type %s collides with a different definition and was renamed to %s", typeName, candidate)})
+			} else if typeName != upperFirst(name) && !output.pinned[typeName] {
+				output.diagnosticHandler(Diagnostic{Severity: INFO, Subject: name, Message: fmt.Sprintf("type renamed to %s", typeName)})
+			}
+			return candidate
+		}
+		for candidate, _pointer := range output.pointers {
+			if _pointer == pointer && reflect.DeepEqual(output.typeShapes[candidate], shape) {
+				return candidate
+			}
+		}
+		candidates := []string{typeName}
+		if len(parentName) != 0 {
+			candidates = append(candidates, *toPascalCase(parentName)+typeName)
+		}
+		for _, candidate := range candidates {
+			existing, ok := output.typeShapes[candidate]
+			if !ok {
+				return register(candidate)
+			}
+			if reflect.DeepEqual(existing, shape) {
+				return candidate
+			}
+		}
+		base := candidates[len(candidates)-1]
+		if output.options.Disambiguation == HASH_DISAMBIGUATION {
+			candidate := fmt.Sprintf("%s_%08x", base, disambiguationHash(pointer, shape))
+			existing, ok := output.typeShapes[candidate]
+			if !ok {
+				return register(candidate)
+			}
+			if reflect.DeepEqual(existing, shape) {
+				return candidate
+			}
+		}
+		for suffix := 2; ; suffix++ {
+			candidate := fmt.Sprintf("%s%d", base, suffix)
+			existing, ok := output.typeShapes[candidate]
+			if !ok {
+				return register(candidate)
+			}
+			if reflect.DeepEqual(existing, shape) {
+				return candidate
+			}
+		}
 	}
 	output.duplicateCheck = func(typeName string) bool {
 		for _, value := range output.typeNames {
@@ -437,148 +838,216 @@ func New(jsonSchema []byte) DefaultJsonSchemaParser {
 		output.typeNames = append(output.typeNames, typeName)
 		return false
 	}
+	output.diagnostics = &[]Diagnostic{}
+	output.diagnosticHandler = func(diagnostic Diagnostic) {
+		*output.diagnostics = append(*output.diagnostics, diagnostic)
+	}
 	return output
 }
 
-const HEADERS = `
-syntax = "proto3";
-
-package _$PACKAGE$_;
-
-import "google/protobuf/any.proto";
-
-`
-
 func (rcvr DefaultJsonSchemaParser) Parse(packageName string) []string {
-	values := make([]string, 0)
-	values = append(values, strings.Replace(HEADERS, "_$PACKAGE$_", packageName, 1))
-	values = append(values, rcvr.schema.ToProtobuf(rcvr.schema.Definitions, rcvr.nestedObjectHander, rcvr.duplicateCheck))
+	return []string{Render(NewTemplate(rcvr.options), rcvr.Build(packageName), rcvr.options)}
+}
+
+func (rcvr DefaultJsonSchemaParser) Build(packageName string) *ProtoFile {
+	output := ProtoFile{Package: packageName}
+	if rcvr.options.Metadata {
+		output.Metadata = &ProtoMetadata{Version: VERSION, Source: rcvr.options.Source, Hash: rcvr.hash}
+		if rcvr.options.MetadataTimestamp {
+			output.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	keys := make([]string, 0)
+	for key := range rcvr.schema.Definitions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) == len(keys[j]) {
+			return keys[i] < keys[j]
+		}
+		return len(keys[i]) < len(keys[j])
+	})
+	roots := make([]string, 0)
+	topLevel := make([]string, 0)
+	for _, value := range rcvr.options.Roots {
+		name, pointer, root, ok := rcvr.resolveRoot(value)
+		if !ok {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: value, Message: "root does not match any definition in the schema"})
+			continue
+		}
+		roots = append(roots, rcvr.nestedObjectHander("", name, pointer, root))
+	}
+	for _, key := range keys {
+		if isMappedDefinition(rcvr.options, key, rcvr.schema.Definitions[key]) {
+			continue
+		}
+		topLevel = append(topLevel, rcvr.nestedObjectHander("", key, rcvr.definitionPointers[key], rcvr.schema.Definitions[key]))
+	}
+	if len(rcvr.root.Properties) != 0 && len(rcvr.options.Roots) == 0 {
+		roots = append(roots, rcvr.nestedObjectHander("", RootMessageName(rcvr.schema), "#", rcvr.root))
+	}
+	topLevel = append(roots, topLevel...)
+	updateResources := rcvr.registerUpdateRequests()
+	services, imports := rcvr.buildServices(packageName, keys)
+	output.Services = services
+	output.Imports = append(output.Imports, imports...)
+	reachableRoots := append([]string{}, roots...)
+	for _, service := range services {
+		for _, method := range service.Methods {
+			reachableRoots = append(reachableRoots, method.Input, method.Output)
+		}
+	}
+	definitions := make(map[string]ProtoDefinition)
 	for len(rcvr.pushBacks) > 0 {
 		keys := make([]string, 0)
-		for key, value := range rcvr.pushBacks {
+		for key := range rcvr.pushBacks {
 			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := rcvr.pushBacks[key]
+			delete(rcvr.pushBacks, key)
 			if _value, ok := value.(map[string]Properties); ok {
-				values = append(values, ToMessage(rcvr.schema.Definitions, key, _value, rcvr.nestedObjectHander, rcvr.duplicateCheck))
+				if message := ToMessage(rcvr.schema.Definitions, key, rcvr.pointers[key], Properties{Properties: _value}, rcvr.options, rcvr.nestedObjectHander, rcvr.duplicateCheck, rcvr.diagnosticHandler); message != nil {
+					definitions[key] = ProtoDefinition{Message: message}
+				}
 				continue
 			}
 			if _value, ok := value.(Properties); ok {
-				values = append(values, ToMessage(rcvr.schema.Definitions, key, _value.Properties, rcvr.nestedObjectHander, rcvr.duplicateCheck))
+				if message := ToMessage(rcvr.schema.Definitions, key, rcvr.pointers[key], _value, rcvr.options, rcvr.nestedObjectHander, rcvr.duplicateCheck, rcvr.diagnosticHandler); message != nil {
+					if rcvr.pinned[key] {
+						message.Name = key
+					}
+					definitions[key] = ProtoDefinition{Message: message}
+				}
 				continue
 			}
 			if _value, ok := value.([]string); ok {
-				values = append(values, ToEnum(key, _value, rcvr.duplicateCheck))
+				if enum := ToEnum(key, rcvr.pointers[key], _value, rcvr.options, rcvr.duplicateCheck, rcvr.diagnosticHandler); enum != nil {
+					if rcvr.pinned[key] {
+						enum.Name = key
+					}
+					definitions[key] = ProtoDefinition{Enum: enum}
+				}
+				continue
+			}
+			if _value, ok := value.(EnumShape); ok {
+				if enum := ToEnum(key, rcvr.pointers[key], _value.Values, rcvr.options, rcvr.duplicateCheck, rcvr.diagnosticHandler); enum != nil {
+					if rcvr.pinned[key] {
+						enum.Name = key
+					}
+					pinEnumValueNames(enum, _value, rcvr.diagnosticHandler)
+					definitions[key] = ProtoDefinition{Enum: enum}
+				}
 				continue
 			}
 		}
-		for _, key := range keys {
-			delete(rcvr.pushBacks, key)
+	}
+	if len(updateResources) != 0 {
+		reachableRoots = append(reachableRoots, rcvr.buildUpdateRequests(updateResources, definitions)...)
+		output.Imports = append(output.Imports, FIELD_MASK_IMPORT)
+	}
+	if rcvr.options.Prune {
+		rcvr.prune(definitions, reachableRoots)
+	}
+	if len(rcvr.options.Envelope) != 0 {
+		rcvr.buildEnvelope(topLevel, definitions)
+	}
+	for _, key := range TopologicalOrder(definitions, rcvr.dependencies, roots) {
+		output.Definitions = append(output.Definitions, definitions[key])
+	}
+	for _, definition := range output.Definitions {
+		if definition.Message != nil {
+			definition.Message.ReservedRanges = append(definition.Message.ReservedRanges, rcvr.options.ReservedRanges...)
+			NumberFields(definition.Message, rcvr.options.Numbering, nil)
 		}
 	}
-	return values
-}
-
-func fixString(str string) *string {
-	output := str
-	output = strings.ReplaceAll(output, "#", "_")
-	output = strings.ReplaceAll(output, " ", "_")
-	output = strings.ReplaceAll(output, "-", "_")
-	output = strings.ReplaceAll(output, "/", "_")
-	output = strings.ReplaceAll(output, ".", "_")
+	if usesAny(&output) {
+		output.Imports = append([]string{ANY_IMPORT}, output.Imports...)
+	}
+	output.Imports = append(output.Imports, mappedImports(rcvr.options, &output)...)
+	if usesFieldBehavior(&output) {
+		output.Imports = append(output.Imports, FIELD_BEHAVIOR_IMPORT)
+	}
+	ApplyRequired(&output, rcvr.options, rcvr.diagnosticHandler)
+	if value, ok := validationImport(rcvr.options, &output); ok {
+		output.Imports = append(output.Imports, value)
+	}
+	if UsesJ2pOptions(&output) {
+		output.Imports = append(output.Imports, rcvr.options.ImportPath(J2P_OPTIONS_PROTO))
+	}
+	if len(rcvr.options.MergeFile) != 0 {
+		Merge(&output, ReadProto(rcvr.options.MergeFile), rcvr.options.Numbering, rcvr.diagnosticHandler)
+	}
+	ReserveOmitted(&output)
 	return &output
 }
 
-func toCamelCase(str string) *string {
-	fixedStr := fixString(str)
-	var output string
-	if fixedStr == nil {
-		output = ""
-		return &output
+func (rcvr DefaultJsonSchemaParser) resolveRoot(root string) (string, string, Properties, bool) {
+	if root == "#" || root == "#/" {
+		return RootMessageName(rcvr.schema), "#", rcvr.root, len(rcvr.root.Properties) != 0
 	}
-	_str := []byte(*fixedStr)
-	if len(_str) == 0 {
-		output = ""
-		return &output
+	if !strings.HasPrefix(root, "#/") {
+		value, ok := rcvr.schema.Definitions[root]
+		return root, rcvr.definitionPointers[root], value, ok
 	}
-	_str[0] = byte(strings.ToLower(string(_str[0]))[0])
-	output = string(_str)
-	return &output
+	pointer := root
+	definitions := rcvr.schema.Definitions
+	if strings.HasPrefix(root, "#/properties/") {
+		definitions = rcvr.root.Properties
+		root = "#/definitions/" + strings.TrimPrefix(root, "#/properties/")
+	}
+	name, value := Properties{Ref: &root}.GetRef(definitions)
+	seen := make(map[string]bool)
+	for value.Ref != nil && strings.HasPrefix(*value.Ref, "#/") && !seen[*value.Ref] {
+		seen[*value.Ref] = true
+		pointer = *value.Ref
+		name, value = value.GetRef(rcvr.schema.Definitions)
+	}
+	return name, pointer, value, !reflect.DeepEqual(value, Properties{})
 }
 
-func toPascalCase(str string) *string {
-	if strings.Contains(str, ".") {
-		return &str
+func (rcvr DefaultJsonSchemaParser) prune(definitions map[string]ProtoDefinition, roots []string) {
+	if len(roots) == 0 {
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: "prune", Message: "the schema has no root message, nothing was pruned"})
+		return
 	}
-	fixedStr := fixString(str)
-	var output string
-	if fixedStr == nil {
-		output = ""
-		return &output
+	reachable := Reachable(roots, rcvr.dependencies)
+	pruned := 0
+	for key := range definitions {
+		if !reachable[key] {
+			delete(definitions, key)
+			pruned++
+		}
 	}
-	_str := []byte(*fixedStr)
-	if len(_str) == 0 {
-		output = ""
-		return &output
+	if pruned != 0 {
+		rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: "prune", Message: fmt.Sprintf("omitted %d definitions unreachable from %s", pruned, strings.Join(roots, ", "))})
 	}
-	_str[0] = byte(strings.ToUpper(string(_str[0]))[0])
-	output = string(_str)
-	return &output
 }
 
-func toSnakeCase(str string) (*string, bool) {
-	fixedStr := fixString(str)
-	var output bytes.Buffer
-	if fixedStr == nil {
-		outputStr := output.String()
-		return &outputStr, false
-	}
-	_str := []byte(*fixedStr)
-	if len(_str) == 0 {
-		outputStr := output.String()
-		return &outputStr, false
-	}
-	isConverted := false
-	isPrevNum := false
-	isPrevUnderline := false
-	for index, value := range _str {
-		if value == '_' {
-			isConverted = true
-			isPrevUnderline = true
-			output.WriteString(strings.ToLower(string(value)))
-			continue
-		}
-		if unicode.IsUpper(rune(value)) {
-			if index != 0 {
-				output.WriteString("_")
-				isConverted = true
-			}
-			output.WriteString(strings.ToLower(string(value)))
-			continue
-		}
-		if unicode.IsNumber(rune(value)) {
-			if !isPrevNum {
-				if index != 0 {
-					output.WriteString("_")
-					isConverted = true
-				}
-				isPrevNum = true
-				output.WriteString(strings.ToLower(string(value)))
-				continue
-			}
-			output.WriteString(strings.ToLower(string(value)))
-			continue
-		}
-		if isPrevNum {
-			output.WriteString("_")
-			isConverted = true
-			isPrevNum = false
-			output.WriteString(strings.ToLower(string(value)))
-			continue
+func disambiguationHash(pointer string, shape any) uint32 {
+	hash := fnv.New32a()
+	if len(pointer) != 0 {
+		hash.Write([]byte(pointer))
+		return hash.Sum32()
+	}
+	data, _ := json.Marshal(shape)
+	hash.Write(data)
+	return hash.Sum32()
+}
+
+func toShape(value any) any {
+	if _value, ok := value.(Properties); ok {
+		if _value.Enum != nil && _value.XEnumVarnames != nil {
+			return EnumShape{Values: _value.Enum, Names: _value.XEnumVarnames}
 		}
-		if isPrevUnderline {
-			isPrevUnderline = false
+		if _value.Enum != nil {
+			return _value.Enum
 		}
-		output.WriteByte(value)
 	}
-	outputStr := output.String()
-	return &outputStr, isConverted
+	return value
+}
+
+func (rcvr DefaultJsonSchemaParser) Diagnostics() []Diagnostic {
+	return *rcvr.diagnostics
 }
@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var JTD_TYPES = map[string]string{
+	"boolean":   "bool",
+	"string":    "string",
+	"timestamp": "google.protobuf.Timestamp",
+	"float32":   "float",
+	"float64":   "double",
+	"int8":      "int32",
+	"uint8":     "uint32",
+	"int16":     "int32",
+	"uint16":    "uint32",
+	"int32":     "int32",
+	"uint32":    "uint32",
+}
+
+type JtdParser struct {
+	schema            *JsonObject
+	rootName          string
+	hash              string
+	options           Options
+	definitionNames   map[string]string
+	typeNames         map[string]bool
+	definitions       map[string]ProtoDefinition
+	dependencies      map[string][]string
+	imports           map[string]bool
+	diagnostics       *[]Diagnostic
+	diagnosticHandler DiagnosticHandler
+}
+
+func IsJtd(path string, format InputFormat) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return format == JTD_INPUT || (format == AUTO_INPUT && (strings.HasSuffix(name, ".jtd.json") || strings.HasSuffix(name, ".jtd")))
+}
+
+func NewJtdParser(data []byte, rootName string, options Options) (*JtdParser, error) {
+	schema, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	_schema, ok := schema.(*JsonObject)
+	if !ok {
+		return nil, fmt.Errorf("jtd schemas must be objects")
+	}
+	diagnostics := make([]Diagnostic, 0)
+	hash := sha256.Sum256(data)
+	output := JtdParser{
+		schema:          _schema,
+		rootName:        rootName,
+		hash:            hex.EncodeToString(hash[:]),
+		options:         options,
+		definitionNames: make(map[string]string),
+		typeNames:       make(map[string]bool),
+		definitions:     make(map[string]ProtoDefinition),
+		dependencies:    make(map[string][]string),
+		imports:         make(map[string]bool),
+		diagnostics:     &diagnostics,
+	}
+	output.diagnosticHandler = func(diagnostic Diagnostic) {
+		*output.diagnostics = append(*output.diagnostics, diagnostic)
+	}
+	return &output, nil
+}
+
+func (rcvr *JtdParser) Diagnostics() []Diagnostic {
+	return *rcvr.diagnostics
+}
+
+func (rcvr *JtdParser) Build(packageName string) *ProtoFile {
+	output := ProtoFile{Package: packageName}
+	if rcvr.options.Metadata {
+		output.Metadata = &ProtoMetadata{Version: VERSION, Source: rcvr.options.Source, Hash: rcvr.hash}
+		if rcvr.options.MetadataTimestamp {
+			output.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	roots := make([]string, 0)
+	if isJtdNamedForm(rcvr.schema) {
+		roots = append(roots, rcvr.register(rcvr.rootName, "#"))
+	}
+	definitions := jsonObject(rcvr.schema, "definitions")
+	for _, key := range definitions.Keys {
+		if definition, ok := definitions.Values[key].(*JsonObject); ok && isJtdNamedForm(definition) {
+			rcvr.definitionNames[key] = rcvr.register(key, "#/definitions/"+escapePointer(key))
+		}
+	}
+	if len(roots) != 0 {
+		rcvr.named(roots[0], rcvr.schema, "#")
+	} else if len(rcvr.schema.Keys) != 0 && !(len(rcvr.schema.Keys) == 1 && rcvr.schema.Keys[0] == "definitions") {
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: "#", Message: "the root schema is neither a properties, discriminator nor enum form and was skipped"})
+	}
+	for _, key := range definitions.Keys {
+		definition, _ := definitions.Values[key].(*JsonObject)
+		if typeName, ok := rcvr.definitionNames[key]; ok {
+			rcvr.named(typeName, definition, "#/definitions/"+escapePointer(key))
+		}
+	}
+	for _, key := range TopologicalOrder(rcvr.definitions, rcvr.dependencies, roots) {
+		output.Definitions = append(output.Definitions, rcvr.definitions[key])
+	}
+	for _, definition := range output.Definitions {
+		if definition.Message != nil {
+			definition.Message.ReservedRanges = append(definition.Message.ReservedRanges, rcvr.options.ReservedRanges...)
+			NumberFields(definition.Message, rcvr.options.Numbering, nil)
+		}
+	}
+	if usesAny(&output) {
+		output.Imports = append([]string{ANY_IMPORT}, output.Imports...)
+	}
+	imports := make([]string, 0)
+	for value := range rcvr.imports {
+		imports = append(imports, value)
+	}
+	sort.Strings(imports)
+	output.Imports = append(output.Imports, imports...)
+	if len(rcvr.options.MergeFile) != 0 {
+		Merge(&output, ReadProto(rcvr.options.MergeFile), rcvr.options.Numbering, rcvr.diagnosticHandler)
+	}
+	return &output
+}
+
+func isJtdNamedForm(schema *JsonObject) bool {
+	for _, key := range []string{"properties", "optionalProperties", "discriminator", "enum"} {
+		if _, ok := schema.Get(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (rcvr *JtdParser) register(name string, pointer string) string {
+	typeName := *toTypeName(name, rcvr.diagnosticHandler)
+	for suffix := 2; rcvr.typeNames[typeName]; suffix++ {
+		typeName = fmt.Sprintf("%s%d", *toTypeName(name, rcvr.diagnosticHandler), suffix)
+		if !rcvr.typeNames[typeName] {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("%s collides with another type and was renamed to %s", name, typeName)})
+		}
+	}
+	rcvr.typeNames[typeName] = true
+	return typeName
+}
+
+func (rcvr *JtdParser) named(typeName string, schema *JsonObject, pointer string) {
+	if values, ok := schema.Get("enum"); ok {
+		symbols := make([]string, 0)
+		if _values, ok := values.([]any); ok {
+			for _, value := range _values {
+				symbols = append(symbols, fmt.Sprint(value))
+			}
+		}
+		enum := ToEnum(typeName, pointer, symbols, rcvr.options, func(string) bool { return false }, rcvr.diagnosticHandler)
+		rcvr.definitions[typeName] = ProtoDefinition{Enum: enum}
+		return
+	}
+	if discriminator, ok := schema.Get("discriminator"); ok {
+		rcvr.discriminator(typeName, fmt.Sprint(discriminator), jsonObject(schema, "mapping"), pointer)
+		return
+	}
+	rcvr.message(typeName, schema, pointer, "")
+}
+
+func (rcvr *JtdParser) message(typeName string, schema *JsonObject, pointer string, discriminator string) {
+	message := ProtoMessage{Name: typeName, Pointer: pointer}
+	index := 1
+	for _, group := range []string{"properties", "optionalProperties"} {
+		properties := jsonObject(schema, group)
+		for _, key := range properties.Keys {
+			if key == discriminator {
+				continue
+			}
+			property, _ := properties.Values[key].(*JsonObject)
+			if property == nil {
+				property = NewJsonObject()
+			}
+			fieldPointer := pointer + "/" + group + "/" + escapePointer(key)
+			label, _type := rcvr.resolve(typeName, key, property, fieldPointer)
+			if group == "optionalProperties" && label == NO_LABEL && !strings.HasPrefix(_type, "map<") {
+				label = OPTIONAL_LABEL
+			}
+			field := toField(label, _type, key, &index, rcvr.diagnosticHandler)
+			field.Pointer = fieldPointer
+			rcvr.depend(typeName, _type)
+			message.Fields = append(message.Fields, field)
+		}
+	}
+	if additional, ok := schema.Get("additionalProperties"); ok && additional == true {
+		rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: "additional properties are dropped by the proto representation"})
+	}
+	rcvr.definitions[typeName] = ProtoDefinition{Message: &message}
+}
+
+func (rcvr *JtdParser) discriminator(typeName string, tag string, mapping *JsonObject, pointer string) {
+	message := ProtoMessage{Name: typeName, Pointer: pointer}
+	index := 1
+	oneofName := toOneofName(rcvr.options, tag)
+	for _, key := range mapping.Keys {
+		variant, _ := mapping.Values[key].(*JsonObject)
+		if variant == nil {
+			variant = NewJsonObject()
+		}
+		variantPointer := pointer + "/mapping/" + escapePointer(key)
+		variantName := rcvr.register(typeName+*toPascalCase(key), variantPointer)
+		rcvr.message(variantName, variant, variantPointer, tag)
+		field := toField(NO_LABEL, variantName, key, &index, rcvr.diagnosticHandler)
+		field.Oneof = oneofName
+		field.Pointer = variantPointer
+		field.Comment = fmt.Sprintf("discriminator: %s = %s", tag, strconv.Quote(key))
+		rcvr.depend(typeName, variantName)
+		message.Fields = append(message.Fields, field)
+	}
+	rcvr.definitions[typeName] = ProtoDefinition{Message: &message}
+}
+
+func (rcvr *JtdParser) resolve(parentName string, propertyName string, schema *JsonObject, pointer string) (Label, string) {
+	label, _type := rcvr.resolveType(parentName, propertyName, schema, pointer)
+	if nullable, ok := schema.Get("nullable"); ok && nullable == true && label == NO_LABEL && !strings.HasPrefix(_type, "map<") {
+		label = OPTIONAL_LABEL
+	}
+	return label, _type
+}
+
+func (rcvr *JtdParser) resolveType(parentName string, propertyName string, schema *JsonObject, pointer string) (Label, string) {
+	if ref, ok := schema.Get("ref"); ok {
+		if typeName, ok := rcvr.definitionNames[fmt.Sprint(ref)]; ok {
+			return NO_LABEL, typeName
+		}
+		definition := jsonObject(jsonObject(rcvr.schema, "definitions"), fmt.Sprint(ref))
+		return rcvr.resolveType(parentName, propertyName, definition, "#/definitions/"+escapePointer(fmt.Sprint(ref)))
+	}
+	if _type, ok := schema.Get("type"); ok {
+		typeName, ok := JTD_TYPES[fmt.Sprint(_type)]
+		if !ok {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: pointer, Message: fmt.Sprintf("unknown jtd type %v was typed as google.protobuf.Any", _type)})
+			return NO_LABEL, "google.protobuf.Any"
+		}
+		if typeName == "google.protobuf.Timestamp" {
+			rcvr.imports[TIMESTAMP_IMPORT] = true
+		}
+		return NO_LABEL, typeName
+	}
+	if elements, ok := schema.Get("elements"); ok {
+		_elements, _ := elements.(*JsonObject)
+		if _elements == nil {
+			_elements = NewJsonObject()
+		}
+		label, typeName := rcvr.resolveType(parentName, propertyName, _elements, pointer+"/elements")
+		if label != NO_LABEL || strings.HasPrefix(typeName, "map<") {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "elements of elements or values have no proto equivalent and were typed as google.protobuf.Any"})
+			typeName = "google.protobuf.Any"
+		}
+		return REPEATED_LABEL, typeName
+	}
+	if values, ok := schema.Get("values"); ok {
+		_values, _ := values.(*JsonObject)
+		if _values == nil {
+			_values = NewJsonObject()
+		}
+		label, typeName := rcvr.resolveType(parentName, propertyName, _values, pointer+"/values")
+		if label != NO_LABEL || strings.HasPrefix(typeName, "map<") {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: "values of elements or values have no proto equivalent and were typed as google.protobuf.Any"})
+			typeName = "google.protobuf.Any"
+		}
+		return NO_LABEL, fmt.Sprintf("map<string, %s>", typeName)
+	}
+	if isJtdNamedForm(schema) {
+		typeName := rcvr.register(toInlineTypeName(rcvr.options, parentName, propertyName, pointer), pointer)
+		rcvr.named(typeName, schema, pointer)
+		return NO_LABEL, typeName
+	}
+	return NO_LABEL, "google.protobuf.Any"
+}
+
+func (rcvr *JtdParser) depend(typeName string, dependency string) {
+	dependency = strings.TrimSuffix(strings.TrimPrefix(dependency, "map<string, "), ">")
+	rcvr.dependencies[typeName] = append(rcvr.dependencies[typeName], dependency)
+}
@@ -0,0 +1,89 @@
+package internal
+
+import "testing"
+
+const JTD_TEST_SCHEMA = `{
+ "definitions": {
+   "money": {"properties": {"amount": {"type": "float64"}, "currency": {"type": "string"}}},
+   "tags": {"elements": {"type": "string"}}
+ },
+ "properties": {
+   "id": {"type": "uint32"},
+   "placed": {"type": "timestamp"},
+   "total": {"ref": "money"},
+   "tags": {"ref": "tags"},
+   "status": {"enum": ["NEW", "SHIPPED"]},
+   "lines": {"elements": {"properties": {"sku": {"type": "string"}, "qty": {"type": "int16"}}}},
+   "attrs": {"values": {"type": "string"}},
+   "payment": {"discriminator": "method", "mapping": {
+      "card": {"properties": {"last4": {"type": "string"}}},
+      "cash": {"properties": {}}}},
+   "extra": {}
+ },
+ "optionalProperties": {
+   "note": {"type": "string", "nullable": true},
+   "coupon": {"type": "string"}
+ }
+}`
+
+func TestJtdParser(t *testing.T) {
+	options := DefaultOptions()
+	parser, err := NewJtdParser([]byte(JTD_TEST_SCHEMA), "Order", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := parser.Build("test")
+	expected := `syntax = "proto3";
+
+package test;
+
+import "google/protobuf/any.proto";
+import "google/protobuf/timestamp.proto";
+
+message Lines {
+  string sku = 1;
+  int32 qty = 2;
+}
+
+message Money {
+  double amount = 1;
+  string currency = 2;
+}
+
+message PaymentCard {
+  string last4 = 1 [json_name = "last_4"];
+}
+
+message PaymentCash {}
+
+message Payment {
+  oneof method_union {
+    PaymentCard card = 1; // discriminator: method = "card"
+    PaymentCash cash = 2; // discriminator: method = "cash"
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_NEW = 1;
+  STATUS_SHIPPED = 2;
+}
+
+message Order {
+  uint32 id = 1;
+  google.protobuf.Timestamp placed = 2;
+  Money total = 3;
+  repeated string tags = 4;
+  Status status = 5;
+  repeated Lines lines = 6;
+  map<string, string> attrs = 7;
+  Payment payment = 8;
+  google.protobuf.Any extra = 9;
+  optional string note = 10;
+  optional string coupon = 11;
+}
+`
+	if output := Render(NewTemplate(options), file, options); output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const ENUMS_PROTO = "enums.proto"
+
+func SplitEnums(file *ProtoFile, enums *ProtoFile, importPath string, diagnosticHandler DiagnosticHandler) {
+	existing := make(map[string]*ProtoEnum)
+	for _, definition := range enums.Definitions {
+		existing[definition.Enum.Name] = definition.Enum
+	}
+	moved := make(map[string]bool)
+	definitions := make([]ProtoDefinition, 0)
+	for _, definition := range file.Definitions {
+		if definition.Enum == nil {
+			definitions = append(definitions, definition)
+			continue
+		}
+		if enum, ok := existing[definition.Enum.Name]; ok {
+			if !reflect.DeepEqual(enum.Values, definition.Enum.Values) {
+				diagnosticHandler(Diagnostic{Severity: ERROR, Subject: definition.Enum.Name, Message: fmt.Sprintf("a different enum with the same name already exists in %s, it was kept in place", importPath)})
+				definitions = append(definitions, definition)
+				continue
+			}
+			moved[definition.Enum.Name] = true
+			continue
+		}
+		existing[definition.Enum.Name] = definition.Enum
+		enums.Definitions = append(enums.Definitions, definition)
+		moved[definition.Enum.Name] = true
+	}
+	file.Definitions = definitions
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			if moved[field.Type] {
+				file.Imports = append(file.Imports, importPath)
+				return
+			}
+		}
+	}
+}
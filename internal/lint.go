@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type LintFormat string
+
+const (
+	TEXT_LINT LintFormat = "text"
+	JSON_LINT LintFormat = "json"
+)
+
+var (
+	LINT_CONDITIONALS = []string{"if", "then", "else", "not", "dependentSchemas", "dependencies", "dependentRequired", "unevaluatedProperties", "unevaluatedItems"}
+	LINT_ANNOTATIONS  = map[string]bool{"$id": true, "$anchor": true, "$comment": true, "title": true, "description": true, "default": true, "examples": true, "example": true, "deprecated": true, "readOnly": true, "writeOnly": true}
+)
+
+type linter struct {
+	enumLimit   int
+	fix         bool
+	fixes       int
+	diagnostics []Diagnostic
+}
+
+func LintSchema(data []byte, enumLimit int, fix bool) ([]Diagnostic, []byte, error) {
+	document, err := DecodeJson(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	context := linter{enumLimit: enumLimit, fix: fix, diagnostics: make([]Diagnostic, 0)}
+	context.schema(document, "")
+	if context.fixes == 0 {
+		return context.diagnostics, nil, nil
+	}
+	return context.diagnostics, append(EncodeJson(document), '\n'), nil
+}
+
+func (rcvr *linter) fixed(pointer string, format string, args ...any) {
+	rcvr.fixes++
+	rcvr.report(INFO, pointer, "fixed: "+format, args...)
+}
+
+func (rcvr *linter) report(severity Severity, pointer string, format string, args ...any) {
+	rcvr.diagnostics = append(rcvr.diagnostics, Diagnostic{Severity: severity, Subject: "#" + pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+func (rcvr *linter) schema(node any, pointer string) {
+	schema, ok := node.(*JsonObject)
+	if !ok {
+		return
+	}
+	if value, ok := schema.Get("type"); ok {
+		if types, ok := value.([]any); ok {
+			rcvr.typeList(schema, types, pointer)
+		}
+	}
+	if value, ok := schema.Get("type"); ok && value == "array" {
+		if _, ok := schema.Get("items"); !ok {
+			if rcvr.fix {
+				schema.Set("items", NewJsonObject())
+				rcvr.fixed(pointer, "added empty items, the array converts to repeated google.protobuf.Any")
+			} else {
+				rcvr.report(ERROR, pointer, "arrays without items cannot be converted, declare the item schema")
+			}
+		}
+	}
+	if value, ok := schema.Get("items"); ok {
+		if _, ok := value.(bool); ok {
+			if rcvr.fix {
+				schema.Set("items", NewJsonObject())
+				rcvr.fixed(pointer+"/items", "replaced boolean items with an empty schema")
+			} else {
+				rcvr.report(ERROR, pointer+"/items", "boolean items cannot be converted, use an item schema")
+			}
+		}
+	}
+	if value, ok := schema.Get("enum"); ok {
+		if values, ok := value.([]any); ok {
+			for index, value := range values {
+				if _, ok := value.(string); !ok {
+					rcvr.report(ERROR, fmt.Sprintf("%s/enum/%d", pointer, index), "enum value %s is not a string and cannot become a proto enum value", EncodeJson(value))
+				}
+			}
+			if rcvr.enumLimit > 0 && len(values) > rcvr.enumLimit {
+				rcvr.report(WARNING, pointer+"/enum", "enum with %d values exceeds %d, consider a string field", len(values), rcvr.enumLimit)
+			}
+		}
+	}
+	if _, ok := schema.Get("const"); ok {
+		rcvr.report(INFO, pointer+"/const", "const is not enforced by the generated proto")
+	}
+	for _, key := range LINT_CONDITIONALS {
+		if _, ok := schema.Get(key); ok {
+			rcvr.report(WARNING, pointer+"/"+key, "%s has no proto equivalent and is ignored", key)
+		}
+	}
+	if value, ok := schema.Get("patternProperties"); ok {
+		if patterns, ok := value.(*JsonObject); ok && len(patterns.Keys) != 0 {
+			rcvr.report(WARNING, pointer+"/patternProperties", "patternProperties %s are ignored, properties matching them are dropped", strings.Join(patterns.Keys, ", "))
+		}
+	}
+	if value, ok := schema.Get("additionalProperties"); ok {
+		if additional, ok := value.(*JsonObject); ok && len(additional.Keys) != 0 {
+			if len(jsonObject(schema, "properties").Keys) != 0 {
+				rcvr.report(WARNING, pointer+"/additionalProperties", "additionalProperties schemas next to properties are ignored, undeclared properties are dropped")
+			} else if items, ok := additional.Get("type"); ok && items == "array" {
+				rcvr.report(WARNING, pointer+"/additionalProperties", "array values cannot be typed as a map, undeclared properties are dropped")
+			}
+		}
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if value, ok := schema.Get(key); ok {
+			if members, ok := value.([]any); ok {
+				rcvr.union(members, pointer+"/"+key)
+			}
+		}
+	}
+	for _, key := range schema.Keys {
+		value := schema.Values[key]
+		_pointer := pointer + "/" + escapePointer(key)
+		switch {
+		case DRAFT_SCHEMA_MAPS[key]:
+			{
+				if members, ok := value.(*JsonObject); ok {
+					for _, name := range members.Keys {
+						rcvr.schema(members.Values[name], _pointer+"/"+escapePointer(name))
+					}
+				}
+			}
+		case DRAFT_SCHEMA_LISTS[key]:
+			{
+				if members, ok := value.([]any); ok {
+					for index, member := range members {
+						rcvr.schema(member, fmt.Sprintf("%s/%d", _pointer, index))
+					}
+				}
+			}
+		case DRAFT_SCHEMAS[key]:
+			{
+				rcvr.schema(value, _pointer)
+			}
+		}
+	}
+}
+
+func (rcvr *linter) union(members []any, pointer string) {
+	types := make(map[string]int)
+	oneof := true
+	for _, member := range members {
+		if schema, ok := member.(*JsonObject); ok && len(members) == 2 {
+			if value, ok := schema.Get("type"); ok && value == "null" {
+				oneof = false
+			}
+		}
+	}
+	for index, member := range members {
+		_pointer := fmt.Sprintf("%s/%d", pointer, index)
+		schema, ok := member.(*JsonObject)
+		if !ok {
+			rcvr.report(ERROR, _pointer, "boolean union members cannot be converted")
+			continue
+		}
+		typeName := ""
+		if value, ok := schema.Get("$ref"); ok {
+			typeName = fmt.Sprint(value)
+		} else if value, ok := schema.Get("type"); ok {
+			typeName = fmt.Sprint(value)
+		}
+		if len(typeName) == 0 && rcvr.fix {
+			typeName = inferSchemaType(schema)
+			if len(typeName) != 0 {
+				schema.Set("type", typeName)
+				rcvr.fixed(_pointer, "declared the type %s implied by the member keywords", typeName)
+			}
+		}
+		if len(typeName) == 0 {
+			rcvr.report(ERROR, _pointer, "untyped union members cannot be converted, declare a type or a $ref")
+			continue
+		}
+		if typeName == "null" {
+			continue
+		}
+		if typeName == "array" && oneof {
+			rcvr.report(ERROR, _pointer, "arrays cannot be members of a oneof, wrap the array in an object")
+		}
+		if previous, ok := types[typeName]; ok {
+			rcvr.report(ERROR, _pointer, "union members %d and %d both convert to %s and collide in the oneof", previous, index, typeName)
+			continue
+		}
+		types[typeName] = index
+	}
+}
+
+func (rcvr *linter) typeList(schema *JsonObject, types []any, pointer string) {
+	if !rcvr.fix {
+		rcvr.report(ERROR, pointer+"/type", "type lists %v are not supported, declare a single type or an anyOf of typed schemas", types)
+		return
+	}
+	if len(types) == 1 {
+		schema.Set("type", types[0])
+		rcvr.fixed(pointer+"/type", "replaced the type list with %v", types[0])
+		return
+	}
+	_, anyOf := schema.Get("anyOf")
+	_, oneOf := schema.Get("oneOf")
+	if anyOf || oneOf {
+		rcvr.report(ERROR, pointer+"/type", "type lists %v are not supported and cannot be rewritten next to an existing union", types)
+		return
+	}
+	members := make([]any, 0)
+	for _, typeName := range types {
+		member := NewJsonObject()
+		member.Set("type", typeName)
+		if typeName != "null" {
+			for _, key := range schema.Keys {
+				if key != "type" && !LINT_ANNOTATIONS[key] {
+					member.Set(key, schema.Values[key])
+				}
+			}
+		}
+		members = append(members, member)
+	}
+	for _, key := range append([]string{}, schema.Keys...) {
+		if !LINT_ANNOTATIONS[key] {
+			schema.Delete(key)
+		}
+	}
+	schema.Set("anyOf", members)
+	rcvr.fixed(pointer+"/type", "rewrote the type list %v as an anyOf", types)
+}
+
+func inferSchemaType(schema *JsonObject) string {
+	for _, key := range []string{"const", "enum"} {
+		if value, ok := schema.Get(key); ok {
+			values := []any{value}
+			if key == "enum" {
+				values, _ = value.([]any)
+			}
+			output := ""
+			for _, value := range values {
+				typeName := jsonValueType(value)
+				if len(output) != 0 && output != typeName {
+					return ""
+				}
+				output = typeName
+			}
+			return output
+		}
+	}
+	for _, value := range []struct {
+		keys     []string
+		typeName string
+	}{{[]string{"properties", "required", "additionalProperties", "minProperties", "maxProperties"}, "object"}, {[]string{"items", "prefixItems", "minItems", "maxItems", "uniqueItems"}, "array"}, {[]string{"minLength", "maxLength", "pattern", "format"}, "string"}, {[]string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"}, "number"}} {
+		for _, key := range value.keys {
+			if _, ok := schema.Get(key); ok {
+				return value.typeName
+			}
+		}
+	}
+	return ""
+}
+
+func jsonValueType(value any) string {
+	switch value := value.(type) {
+	case string:
+		{
+			return "string"
+		}
+	case bool:
+		{
+			return "boolean"
+		}
+	case json.Number:
+		{
+			if _, err := value.Int64(); err == nil {
+				return "integer"
+			}
+			return "number"
+		}
+	case []any:
+		{
+			return "array"
+		}
+	case *JsonObject:
+		{
+			return "object"
+		}
+	}
+	return "null"
+}
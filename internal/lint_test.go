@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+const LINT_TEST_SCHEMA = `{"type": "object", "properties": {
+	"name": {"type": ["string", "null"]},
+	"tags": {"type": "array"},
+	"flags": {"type": "array", "items": true},
+	"status": {"enum": ["open", 1]},
+	"kind": {"const": "order"},
+	"payment": {"oneOf": [{"type": "string"}, {"minimum": 0}, {"type": "string"}]},
+	"extra": {"if": {"type": "string"}}
+}}`
+
+func lintFindings(diagnostics []Diagnostic) map[string]Severity {
+	output := make(map[string]Severity)
+	for _, diagnostic := range diagnostics {
+		output[diagnostic.Subject] = diagnostic.Severity
+	}
+	return output
+}
+
+func TestLintSchema(t *testing.T) {
+	diagnostics, fixed, err := LintSchema([]byte(LINT_TEST_SCHEMA), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != nil {
+		t.Fatalf("lint without -fix rewrote the schema: %s", fixed)
+	}
+	expected := map[string]Severity{
+		"#/properties/name/type":       ERROR,
+		"#/properties/tags":            ERROR,
+		"#/properties/flags/items":     ERROR,
+		"#/properties/status/enum/1":   ERROR,
+		"#/properties/kind/const":      INFO,
+		"#/properties/payment/oneOf/1": ERROR,
+		"#/properties/payment/oneOf/2": ERROR,
+		"#/properties/extra/if":        WARNING,
+	}
+	findings := lintFindings(diagnostics)
+	if fmt.Sprint(findings) != fmt.Sprint(expected) {
+		t.Fatalf("expected the findings %v, got %v", expected, diagnostics)
+	}
+}
+
+func TestLintEnumLimit(t *testing.T) {
+	schema := `{"type": "string", "enum": ["a", "b", "c"]}`
+	for limit, count := range map[int]int{0: 0, 3: 0, 2: 1} {
+		diagnostics, _, err := LintSchema([]byte(schema), limit, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diagnostics) != count {
+			t.Fatalf("limit %d: expected %d findings, got %v", limit, count, diagnostics)
+		}
+	}
+}
+
+func TestLintFix(t *testing.T) {
+	diagnostics, fixed, err := LintSchema([]byte(LINT_TEST_SCHEMA), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed == nil {
+		t.Fatal("lint -fix did not rewrite the schema")
+	}
+	findings := lintFindings(diagnostics)
+	for _, pointer := range []string{"#/properties/name/type", "#/properties/tags", "#/properties/flags/items", "#/properties/payment/oneOf/1"} {
+		if findings[pointer] != INFO {
+			t.Fatalf("%s was not fixed: %v", pointer, diagnostics)
+		}
+	}
+	diagnostics, refixed, err := LintSchema(fixed, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refixed != nil {
+		t.Fatalf("the fixed schema was rewritten again: %s", refixed)
+	}
+	expected := map[string]Severity{
+		"#/properties/status/enum/1":   ERROR,
+		"#/properties/kind/const":      INFO,
+		"#/properties/payment/oneOf/2": ERROR,
+		"#/properties/extra/if":        WARNING,
+	}
+	if fmt.Sprint(lintFindings(diagnostics)) != fmt.Sprint(expected) {
+		t.Fatalf("expected only the findings without a safe rewrite %v, got %v", expected, diagnostics)
+	}
+}
+
+func TestLintFixTypeList(t *testing.T) {
+	schema := `{"description": "a count", "type": ["integer", "string"], "minimum": 0}`
+	_, fixed, err := LintSchema([]byte(schema), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := DecodeJson(fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"description":"a count","anyOf":[{"type":"integer","minimum":0},{"type":"string","minimum":0}]}`
+	output, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != expected {
+		t.Fatalf("expected %s, got %s", expected, output)
+	}
+}
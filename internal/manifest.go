@@ -0,0 +1,42 @@
+package internal
+
+import "sort"
+
+type Disambiguation string
+
+const (
+	SUFFIX_DISAMBIGUATION Disambiguation = "suffix"
+	HASH_DISAMBIGUATION   Disambiguation = "hash"
+)
+
+type ManifestType struct {
+	Name    string `json:"name"`
+	Pointer string `json:"pointer"`
+}
+
+type ManifestRename struct {
+	Pointer   string `json:"pointer"`
+	Requested string `json:"requested"`
+	Assigned  string `json:"assigned"`
+}
+
+type Manifest struct {
+	Source  string           `json:"source"`
+	Types   []ManifestType   `json:"types"`
+	Renames []ManifestRename `json:"renames"`
+}
+
+func (rcvr DefaultJsonSchemaParser) Manifest() Manifest {
+	output := Manifest{Source: rcvr.options.Source, Types: make([]ManifestType, 0), Renames: append(make([]ManifestRename, 0), *rcvr.renames...)}
+	for name, pointer := range rcvr.pointers {
+		output.Types = append(output.Types, ManifestType{Name: name, Pointer: pointer})
+	}
+	sort.Slice(output.Types, func(i, j int) bool {
+		return output.Types[i].Name < output.Types[j].Name
+	})
+	return output
+}
+
+func (manifest Manifest) String() string {
+	return string(EncodeJson(manifest)) + "\n"
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+func ReadProto(path string) *ProtoFile {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	return ParseProto(data)
+}
+
+func Merge(file *ProtoFile, existing *ProtoFile, numbering Numbering, diagnosticHandler DiagnosticHandler) {
+	messages := make(map[string]*ProtoMessage)
+	enums := make(map[string]*ProtoEnum)
+	for _, definition := range existing.Definitions {
+		if definition.Message != nil {
+			messages[definition.Message.Name] = definition.Message
+		}
+		if definition.Enum != nil {
+			enums[definition.Enum.Name] = definition.Enum
+		}
+	}
+	for _, definition := range file.Definitions {
+		if definition.Message != nil {
+			if _existing, ok := messages[definition.Message.Name]; ok {
+				mergeMessage(definition.Message, _existing, numbering, diagnosticHandler)
+			}
+		}
+		if definition.Enum != nil {
+			if _existing, ok := enums[definition.Enum.Name]; ok {
+				mergeEnum(definition.Enum, _existing, diagnosticHandler)
+			}
+		}
+	}
+}
+
+func mergeMessage(message *ProtoMessage, existing *ProtoMessage, numbering Numbering, diagnosticHandler DiagnosticHandler) {
+	fields := make(map[string]*ProtoField)
+	for _, field := range existing.Fields {
+		fields[field.Name] = field
+	}
+	message.ProtoReserved = mergeReserved(message.ProtoReserved, existing.ProtoReserved)
+	matched := make(map[string]bool)
+	for _, field := range append(append(make([]*ProtoField, 0), message.Fields...), message.Omitted...) {
+		_existing, ok := fields[field.Name]
+		if !ok {
+			continue
+		}
+		matched[field.Name] = true
+		field.Number = _existing.Number
+		if message.IsReserved(field.Number) {
+			diagnosticHandler(Diagnostic{Severity: ERROR, Subject: fmt.Sprintf("%s.%s", message.Name, field.Name), Message: fmt.Sprintf("existing field number %d falls inside a reserved range", field.Number)})
+		}
+		if _existing.Type != field.Type || _existing.Label != field.Label {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: fmt.Sprintf("%s.%s", message.Name, field.Name), Message: fmt.Sprintf("field changed from %s to %s but keeps number %d", describeField(_existing), describeField(field), field.Number)})
+		}
+	}
+	for _, field := range existing.Fields {
+		if matched[field.Name] {
+			continue
+		}
+		message.ReservedRanges = append(message.ReservedRanges, ProtoRange{Start: field.Number, End: field.Number})
+		message.ReservedNames = append(message.ReservedNames, field.Name)
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: fmt.Sprintf("%s.%s", message.Name, field.Name), Message: fmt.Sprintf("field was removed and number %d is now reserved", field.Number)})
+	}
+	for _, field := range message.Fields {
+		if !matched[field.Name] && message.IsReservedName(field.Name) {
+			message.ReservedNames = removeString(message.ReservedNames, field.Name)
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: fmt.Sprintf("%s.%s", message.Name, field.Name), Message: "previously reserved field name was reintroduced with a new number"})
+		}
+	}
+	start := 1
+	for _, field := range existing.Fields {
+		start = max(start, field.Number+1)
+	}
+	numberFieldsFrom(message, numbering, matched, nextAfterReserved(start, existing.ProtoReserved, MAX_FIELD_NUMBER))
+}
+
+func nextAfterReserved(start int, reserved ProtoReserved, limit int) int {
+	for _, value := range reserved.ReservedRanges {
+		if value.End < limit {
+			start = max(start, value.End+1)
+		}
+	}
+	if start > limit {
+		return 1
+	}
+	return start
+}
+
+func mergeEnum(enum *ProtoEnum, existing *ProtoEnum, diagnosticHandler DiagnosticHandler) {
+	values := make(map[string]*ProtoEnumValue)
+	for _, value := range existing.Values {
+		values[value.Name] = value
+	}
+	enum.ProtoReserved = mergeReserved(enum.ProtoReserved, existing.ProtoReserved)
+	used := make(map[int]bool)
+	matched := make(map[string]bool)
+	for _, value := range enum.Values {
+		if _existing, ok := values[value.Name]; ok {
+			matched[value.Name] = true
+			value.Number = _existing.Number
+			used[value.Number] = true
+		}
+	}
+	for _, value := range existing.Values {
+		if matched[value.Name] {
+			continue
+		}
+		if !used[value.Number] {
+			enum.ReservedRanges = append(enum.ReservedRanges, ProtoRange{Start: value.Number, End: value.Number})
+		}
+		enum.ReservedNames = append(enum.ReservedNames, value.Name)
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: fmt.Sprintf("%s.%s", enum.Name, value.Name), Message: fmt.Sprintf("enum value was removed and number %d is now reserved", value.Number)})
+	}
+	next := 0
+	for _, value := range existing.Values {
+		next = max(next, value.Number+1)
+	}
+	next = nextAfterReserved(next, existing.ProtoReserved, math.MaxInt32)
+	for _, value := range enum.Values {
+		if matched[value.Name] {
+			continue
+		}
+		enum.ReservedNames = removeString(enum.ReservedNames, value.Name)
+		for used[next] || enum.IsReserved(next) {
+			next++
+		}
+		value.Number = next
+		used[next] = true
+	}
+}
+
+func mergeReserved(reserved ProtoReserved, existing ProtoReserved) ProtoReserved {
+	output := ProtoReserved{}
+	output.ReservedRanges = append(output.ReservedRanges, existing.ReservedRanges...)
+	output.ReservedNames = append(output.ReservedNames, existing.ReservedNames...)
+	for _, value := range reserved.ReservedRanges {
+		isDuplicate := false
+		for _, _value := range output.ReservedRanges {
+			if _value == value {
+				isDuplicate = true
+			}
+		}
+		if !isDuplicate {
+			output.ReservedRanges = append(output.ReservedRanges, value)
+		}
+	}
+	for _, value := range reserved.ReservedNames {
+		if !output.IsReservedName(value) {
+			output.ReservedNames = append(output.ReservedNames, value)
+		}
+	}
+	return output
+}
+
+func describeField(field *ProtoField) string {
+	if len(field.Label) != 0 {
+		return fmt.Sprintf("%s %s", field.Label, field.Type)
+	}
+	return field.Type
+}
+
+func removeString(values []string, value string) []string {
+	output := make([]string, 0)
+	for _, _value := range values {
+		if _value != value {
+			output = append(output, _value)
+		}
+	}
+	return output
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const MERGE_TEST_SCHEMA = `{"definitions": {"User": {"type": "object", "properties": {
+	"name": {"type": "string"},
+	"email": {"type": "string"},
+	"phone": {"type": "string"},
+	"role": {"type": "string", "enum": ["admin", "guest", "owner"]}
+}}}}`
+
+const MERGE_TEST_PROTO = `syntax = "proto3";
+
+package test;
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_GUEST = 4;
+  ROLE_BANNED = 5;
+}
+
+message User {
+  reserved 2;
+  reserved "legacy";
+  string email = 5;
+  string name = 1;
+  string nickname = 3;
+  Role role = 6;
+}
+`
+
+func TestMerge(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "user.proto")
+	if err := os.WriteFile(existing, []byte(MERGE_TEST_PROTO), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultOptions()
+	options.MergeFile = existing
+	file := NewWithOptions([]byte(MERGE_TEST_SCHEMA), options).Build("test")
+	var message *ProtoMessage
+	var enum *ProtoEnum
+	for _, definition := range file.Definitions {
+		if definition.Message != nil && definition.Message.Name == "User" {
+			message = definition.Message
+		}
+		if definition.Enum != nil && definition.Enum.Name == "Role" {
+			enum = definition.Enum
+		}
+	}
+	numbers := fieldNumbers(message)
+	if numbers["name"] != 1 || numbers["email"] != 5 || numbers["role"] != 6 {
+		t.Fatalf("existing field numbers were not preserved: %v", numbers)
+	}
+	if phone := numbers["phone"]; phone != 7 {
+		t.Fatalf("expected the new field to be numbered above every existing number, got %d", phone)
+	}
+	for _, number := range []int{2, 3} {
+		if !message.IsReserved(number) {
+			t.Fatalf("number %d is not reserved: %+v", number, message.ProtoReserved)
+		}
+	}
+	for _, name := range []string{"legacy", "nickname"} {
+		if !message.IsReservedName(name) {
+			t.Fatalf("name %s is not reserved: %+v", name, message.ProtoReserved)
+		}
+	}
+	values := make(map[string]int)
+	for _, value := range enum.Values {
+		values[value.Name] = value.Number
+	}
+	if values["ROLE_UNSPECIFIED"] != 0 || values["ROLE_GUEST"] != 4 {
+		t.Fatalf("existing enum numbers were not preserved: %v", values)
+	}
+	if values["ROLE_ADMIN"] != 6 || values["ROLE_OWNER"] != 7 {
+		t.Fatalf("expected the new enum values to be numbered above every existing number, got %v", values)
+	}
+	if !enum.IsReserved(5) || !enum.IsReservedName("ROLE_BANNED") {
+		t.Fatalf("the removed enum value was not reserved: %+v", enum.ProtoReserved)
+	}
+	if values["ROLE_ADMIN"] == values["ROLE_OWNER"] || enum.IsReserved(values["ROLE_ADMIN"]) || enum.IsReserved(values["ROLE_OWNER"]) || values["ROLE_ADMIN"] == 4 || values["ROLE_OWNER"] == 4 {
+		t.Fatalf("new enum values got colliding numbers: %v", values)
+	}
+}
+
+func TestMergeSkipsGaps(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "user.proto")
+	proto := "syntax = \"proto3\";\n\npackage test;\n\nmessage User {\n  reserved 4;\n  string email = 7;\n  string name = 2;\n}\n"
+	if err := os.WriteFile(existing, []byte(proto), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultOptions()
+	options.MergeFile = existing
+	schema := `{"title": "User", "type": "object", "properties": {"name": {"type": "string"}, "email": {"type": "string"}, "age": {"type": "integer"}, "phone": {"type": "string"}}}`
+	numbers := fieldNumbers(buildTestMessage(t, schema, options, "User"))
+	if numbers["name"] != 2 || numbers["email"] != 7 || numbers["age"] != 8 || numbers["phone"] != 9 {
+		t.Fatalf("expected the new fields after the highest existing number, got %v", numbers)
+	}
+}
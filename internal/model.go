@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MAX_FIELD_NUMBER = 536870911
+
+type Label string
+
+const (
+	NO_LABEL       Label = ""
+	OPTIONAL_LABEL Label = "optional"
+	REPEATED_LABEL Label = "repeated"
+)
+
+type ProtoFile struct {
+	Metadata    *ProtoMetadata    `json:"metadata,omitempty"`
+	Package     string            `json:"package,omitempty"`
+	Imports     []string          `json:"imports,omitempty"`
+	Definitions []ProtoDefinition `json:"definitions,omitempty"`
+	Services    []*ProtoService   `json:"services,omitempty"`
+}
+
+type ProtoMetadata struct {
+	Version   string `json:"version,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+type ProtoDefinition struct {
+	Message *ProtoMessage `json:"message,omitempty"`
+	Enum    *ProtoEnum    `json:"enum,omitempty"`
+}
+
+type ProtoMessage struct {
+	ProtoReserved
+	Name    string        `json:"name,omitempty"`
+	Fields  []*ProtoField `json:"fields,omitempty"`
+	Omitted []*ProtoField `json:"-"`
+	Pointer string        `json:"pointer,omitempty"`
+	Comment string        `json:"comment,omitempty"`
+}
+
+type ProtoRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type ProtoReserved struct {
+	ReservedRanges []ProtoRange `json:"reserved_ranges,omitempty"`
+	ReservedNames  []string     `json:"reserved_names,omitempty"`
+}
+
+type ProtoField struct {
+	Label       Label    `json:"label,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Number      int      `json:"number"`
+	JsonName    string   `json:"json_name,omitempty"`
+	Oneof       string   `json:"oneof,omitempty"`
+	Original    string   `json:"original,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	ReadOnly    bool     `json:"read_only,omitempty"`
+	WriteOnly   bool     `json:"write_only,omitempty"`
+	Behaviors   []string `json:"behaviors,omitempty"`
+	Constraints []string `json:"constraints,omitempty"`
+	Extensions  []string `json:"extensions,omitempty"`
+	Pointer     string   `json:"pointer,omitempty"`
+	Comment     string   `json:"comment,omitempty"`
+}
+
+type ProtoOneof struct {
+	Name   string
+	Fields []*ProtoField
+}
+
+type ProtoMember struct {
+	Field *ProtoField
+	Oneof *ProtoOneof
+}
+
+type ProtoEnum struct {
+	ProtoReserved
+	Name       string            `json:"name,omitempty"`
+	AllowAlias bool              `json:"allow_alias,omitempty"`
+	Values     []*ProtoEnumValue `json:"values,omitempty"`
+	Pointer    string            `json:"pointer,omitempty"`
+}
+
+type ProtoEnumValue struct {
+	Name     string `json:"name,omitempty"`
+	Number   int    `json:"number"`
+	Original string `json:"original,omitempty"`
+	Pointer  string `json:"pointer,omitempty"`
+}
+
+func (message ProtoMessage) Members() []ProtoMember {
+	output := make([]ProtoMember, 0)
+	for _, field := range message.Fields {
+		if len(field.Oneof) == 0 {
+			output = append(output, ProtoMember{Field: field})
+			continue
+		}
+		last := len(output) - 1
+		if last >= 0 && output[last].Oneof != nil && output[last].Oneof.Name == field.Oneof {
+			output[last].Oneof.Fields = append(output[last].Oneof.Fields, field)
+			continue
+		}
+		output = append(output, ProtoMember{Oneof: &ProtoOneof{Name: field.Oneof, Fields: []*ProtoField{field}}})
+	}
+	return output
+}
+
+func (field ProtoField) Options() []string {
+	output := make([]string, 0)
+	if len(field.JsonName) != 0 {
+		output = append(output, "json_name = \""+field.JsonName+"\"")
+	}
+	for _, value := range field.Behaviors {
+		output = append(output, "(google.api.field_behavior) = "+value)
+	}
+	output = append(output, field.Constraints...)
+	return append(output, field.Extensions...)
+}
+
+func (protoRange ProtoRange) String() string {
+	if protoRange.Start == protoRange.End {
+		return fmt.Sprintf("%d", protoRange.Start)
+	}
+	if protoRange.End == MAX_FIELD_NUMBER {
+		return fmt.Sprintf("%d to max", protoRange.Start)
+	}
+	return fmt.Sprintf("%d to %d", protoRange.Start, protoRange.End)
+}
+
+func (reserved ProtoReserved) ReservedNumbers() string {
+	output := make([]string, 0)
+	for _, value := range reserved.ReservedRanges {
+		output = append(output, value.String())
+	}
+	return strings.Join(output, ", ")
+}
+
+func (reserved ProtoReserved) ReservedNameList() string {
+	output := make([]string, 0)
+	for _, value := range reserved.ReservedNames {
+		output = append(output, fmt.Sprintf("\"%s\"", value))
+	}
+	return strings.Join(output, ", ")
+}
+
+func (reserved ProtoReserved) IsReserved(number int) bool {
+	for _, value := range reserved.ReservedRanges {
+		if number >= value.Start && number <= value.End {
+			return true
+		}
+	}
+	return false
+}
+
+func (reserved ProtoReserved) IsReservedName(name string) bool {
+	for _, value := range reserved.ReservedNames {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+type ProtoService struct {
+	Name    string         `json:"name,omitempty"`
+	Methods []*ProtoMethod `json:"methods,omitempty"`
+}
+
+type ProtoMethod struct {
+	Name            string         `json:"name,omitempty"`
+	Input           string         `json:"input,omitempty"`
+	Output          string         `json:"output,omitempty"`
+	ClientStreaming bool           `json:"client_streaming,omitempty"`
+	ServerStreaming bool           `json:"server_streaming,omitempty"`
+	Http            *ProtoHttpRule `json:"http,omitempty"`
+}
+
+type ProtoHttpRule struct {
+	Method       string `json:"method,omitempty"`
+	Path         string `json:"path,omitempty"`
+	Body         string `json:"body,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+func (rule ProtoHttpRule) Pattern() string {
+	switch strings.ToLower(rule.Method) {
+	case "get", "put", "post", "delete", "patch":
+		{
+			return fmt.Sprintf("%s: \"%s\"", strings.ToLower(rule.Method), rule.Path)
+		}
+	}
+	return fmt.Sprintf("custom: { kind: \"%s\" path: \"%s\" }", rule.Method, rule.Path)
+}
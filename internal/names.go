@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var RESERVED_WORDS = map[string]bool{
+	"syntax": true, "edition": true, "import": true, "weak": true, "public": true, "package": true,
+	"option": true, "repeated": true, "optional": true, "required": true, "oneof": true, "map": true,
+	"reserved": true, "extensions": true, "extend": true, "to": true, "max": true, "enum": true,
+	"message": true, "service": true, "rpc": true, "stream": true, "returns": true, "group": true,
+	"true": true, "false": true, "inf": true, "nan": true,
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bool": true, "string": true, "bytes": true,
+}
+
+func sanitizeIdentifier(str string) (string, bool) {
+	output := str
+	if len(output) == 0 {
+		output = "_"
+	}
+	if unicode.IsDigit(rune(output[0])) {
+		output = "_" + output
+	}
+	if RESERVED_WORDS[output] {
+		output = output + "_"
+	}
+	return output, output != str
+}
+
+func toFieldName(propertyName string, diagnosticHandler DiagnosticHandler) (string, *string) {
+	fieldName, _ := sanitizeIdentifier(*toCamelCase(propertyName))
+	if fieldName != lowerFirst(propertyName) {
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: propertyName, Message: fmt.Sprintf("field renamed to %s", fieldName)})
+		return fieldName, &propertyName
+	}
+	snakeCasePropertyName, ok := toSnakeCase(propertyName)
+	if ok {
+		return fieldName, snakeCasePropertyName
+	}
+	return fieldName, nil
+}
+
+type EnumShape struct {
+	Values []string
+	Names  []string
+}
+
+func pinnedTypeName(value any) (string, bool) {
+	properties, ok := value.(Properties)
+	if !ok {
+		return "", false
+	}
+	if properties.XProtoName != nil && len(*properties.XProtoName) != 0 {
+		return *properties.XProtoName, true
+	}
+	if properties.XGoName != nil && len(*properties.XGoName) != 0 {
+		return *properties.XGoName, true
+	}
+	return "", false
+}
+
+func isDefinitionPointer(pointer string) bool {
+	segments := strings.Split(pointer, "/")
+	for index := 1; index < len(segments); index += 2 {
+		if segments[index] != "definitions" && segments[index] != "$defs" {
+			return false
+		}
+	}
+	return len(segments)%2 == 1
+}
+
+func pinnedFieldName(properties Properties) (string, bool) {
+	if properties.XProtoName != nil && len(*properties.XProtoName) != 0 {
+		return *properties.XProtoName, true
+	}
+	if properties.XGoName != nil && len(*properties.XGoName) != 0 {
+		return lowerFirst(*properties.XGoName), true
+	}
+	return "", false
+}
+
+func pinEnumValueNames(enum *ProtoEnum, shape EnumShape, diagnosticHandler DiagnosticHandler) {
+	if len(shape.Names) != len(shape.Values) {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: enum.Name, Message: "x-enum-varnames does not have one name per enum value and was ignored"})
+		return
+	}
+	offset := len(enum.Values) - len(shape.Values)
+	for index, name := range shape.Names {
+		enum.Values[index+offset].Name = name
+	}
+}
+
+func toInlineTypeName(options Options, parentName string, propertyName string, pointer string) string {
+	if options.InlineNaming == PATH_NAMING && len(parentName) != 0 && pointer != "#/properties/"+escapePointer(propertyName) {
+		return *toPascalCase(parentName) + *toPascalCase(propertyName)
+	}
+	return propertyName
+}
+
+func toTypeName(name string, diagnosticHandler DiagnosticHandler) *string {
+	typeName := toPascalCase(name)
+	if *typeName != upperFirst(name) {
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: name, Message: fmt.Sprintf("type renamed to %s", *typeName)})
+	}
+	return typeName
+}
+
+func lowerFirst(str string) string {
+	first, size := utf8.DecodeRuneInString(str)
+	if size == 0 {
+		return str
+	}
+	return string(unicode.ToLower(first)) + str[size:]
+}
+
+func upperFirst(str string) string {
+	first, size := utf8.DecodeRuneInString(str)
+	if size == 0 {
+		return str
+	}
+	return string(unicode.ToUpper(first)) + str[size:]
+}
+
+var TRANSLITERATIONS = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "ae", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "oe", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'ţ': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "ue", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'α': "a", 'β': "b", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+func transliterate(r rune) string {
+	if r <= unicode.MaxASCII {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return string(r)
+		}
+		return "_"
+	}
+	lower := unicode.ToLower(r)
+	if value, ok := TRANSLITERATIONS[lower]; ok {
+		if lower != r && len(value) != 0 {
+			return strings.ToUpper(value[:1]) + value[1:]
+		}
+		return value
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return fmt.Sprintf("u%04x", r)
+	}
+	return "_"
+}
+
+func fixString(str string) *string {
+	var output strings.Builder
+	for _, value := range str {
+		output.WriteString(transliterate(value))
+	}
+	outputStr := output.String()
+	return &outputStr
+}
+
+func toCamelCase(str string) *string {
+	output := lowerFirst(*fixString(str))
+	return &output
+}
+
+func toPascalCase(str string) *string {
+	if strings.Contains(str, ".") {
+		return &str
+	}
+	output := upperFirst(*fixString(str))
+	if len(output) != 0 && unicode.IsDigit(rune(output[0])) {
+		output = "_" + output
+	}
+	return &output
+}
+
+func toSnakeCase(str string) (*string, bool) {
+	fixedStr := fixString(str)
+	var output strings.Builder
+	isConverted := false
+	isPrevNum := false
+	for index, value := range []rune(*fixedStr) {
+		if value == '_' {
+			isConverted = true
+			isPrevNum = false
+			output.WriteRune(value)
+			continue
+		}
+		if unicode.IsUpper(value) {
+			if index != 0 {
+				output.WriteString("_")
+				isConverted = true
+			}
+			isPrevNum = false
+			output.WriteRune(unicode.ToLower(value))
+			continue
+		}
+		if unicode.IsDigit(value) {
+			if !isPrevNum && index != 0 {
+				output.WriteString("_")
+				isConverted = true
+			}
+			isPrevNum = true
+			output.WriteRune(value)
+			continue
+		}
+		if isPrevNum {
+			output.WriteString("_")
+			isConverted = true
+			isPrevNum = false
+		}
+		output.WriteRune(value)
+	}
+	outputStr := output.String()
+	return &outputStr, isConverted
+}
+
+func sourceName(field *ProtoField) string {
+	if len(field.Original) == 0 {
+		return field.Name
+	}
+	return field.Original
+}
+
+func sourceMemberName(field *ProtoField) string {
+	if len(field.Original) == 0 || len(field.Oneof) == 0 {
+		return sourceName(field)
+	}
+	typeName := strings.TrimPrefix(field.Type, ".")
+	return field.Original + "_" + typeName[strings.LastIndex(typeName, ".")+1:]
+}
+
+func toSnakeName(str string) string {
+	output := strings.ToLower(strings.Join(nameWords(str), "_"))
+	if len(output) == 0 || unicode.IsDigit(rune(output[0])) {
+		return "_" + output
+	}
+	return output
+}
@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const ENUM_TEST_SCHEMA = `{"definitions": {"Order": {"type": "object", "properties": {"region": {"enum": ["us-east", "us east", "eu"]}}}}}`
+
+func buildTestEnum(t *testing.T, schema string, options Options, name string) (*ProtoEnum, []Diagnostic) {
+	t.Helper()
+	parser := NewWithOptions([]byte(schema), options)
+	file := parser.Build("test")
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil && definition.Enum.Name == name {
+			return definition.Enum, parser.Diagnostics()
+		}
+	}
+	t.Fatalf("enum %s was not generated", name)
+	return nil, nil
+}
+
+func buildTestMessage(t *testing.T, schema string, options Options, name string) *ProtoMessage {
+	t.Helper()
+	file := NewWithOptions([]byte(schema), options).Build("test")
+	for _, definition := range file.Definitions {
+		if definition.Message != nil && definition.Message.Name == name {
+			return definition.Message
+		}
+	}
+	t.Fatalf("message %s was not generated", name)
+	return nil
+}
+
+func findField(message *ProtoMessage, name string) *ProtoField {
+	for _, field := range message.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func fieldNumbers(message *ProtoMessage) map[string]int {
+	output := make(map[string]int)
+	for _, field := range message.Fields {
+		output[field.Name] = field.Number
+	}
+	return output
+}
+
+func enumValues(enum *ProtoEnum) string {
+	values := make([]string, 0)
+	for _, value := range enum.Values {
+		values = append(values, fmt.Sprintf("%s = %d", value.Name, value.Number))
+	}
+	return strings.Join(values, ", ")
+}
+
+func TestEnumZeroValue(t *testing.T) {
+	tests := []struct {
+		zero   string
+		values string
+	}{
+		{"UNSPECIFIED", "REGION_UNSPECIFIED = 0, REGION_US_EAST = 1, REGION_US_EAST_2 = 2, REGION_EU = 3"},
+		{"UNKNOWN", "REGION_UNKNOWN = 0, REGION_US_EAST = 1, REGION_US_EAST_2 = 2, REGION_EU = 3"},
+		{"", "REGION_US_EAST = 0, REGION_US_EAST_2 = 1, REGION_EU = 2"},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.EnumZeroValue = test.zero
+		enum, _ := buildTestEnum(t, ENUM_TEST_SCHEMA, options, "Region")
+		if values := enumValues(enum); values != test.values {
+			t.Fatalf("%q: expected %s, got %s", test.zero, test.values, values)
+		}
+	}
+}
+
+func TestEnumValueCollisions(t *testing.T) {
+	tests := []struct {
+		alias   bool
+		values  string
+		message string
+	}{
+		{false, "REGION_UNSPECIFIED = 0, REGION_US_EAST = 1, REGION_US_EAST_2 = 2, REGION_EU = 3", "renamed to REGION_US_EAST_2"},
+		{true, "REGION_UNSPECIFIED = 0, REGION_US_EAST = 1, REGION_US_EAST_2 = 1, REGION_EU = 3", "emitted as alias REGION_US_EAST_2 = 1"},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.EnumAllowAlias = test.alias
+		enum, diagnostics := buildTestEnum(t, ENUM_TEST_SCHEMA, options, "Region")
+		if values := enumValues(enum); values != test.values || enum.AllowAlias != test.alias {
+			t.Fatalf("alias %v: expected %s, got %s (allow_alias %v)", test.alias, test.values, values, enum.AllowAlias)
+		}
+		if len(diagnostics) != 1 || diagnostics[0].Severity != WARNING || !strings.Contains(diagnostics[0].Message, test.message) {
+			t.Fatalf("alias %v: expected a warning with %q, got %v", test.alias, test.message, diagnostics)
+		}
+	}
+}
+
+func TestOneofNames(t *testing.T) {
+	schema := `{"definitions": {"Order": {"type": "object", "properties": {"payload": {"anyOf": [{"type": "string"}, {"type": "integer"}]}}}}}`
+	tests := []struct {
+		group   string
+		member  string
+		oneof   string
+		members string
+	}{
+		{DefaultOptions().OneofNameTemplate, DefaultOptions().OneofMemberNameTemplate, "payload_union", "payload_string, payload_integer"},
+		{"_$NAME$_", "_$NAME$__as__$TYPE$_", "payload", "payload_as_string, payload_as_integer"},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.OneofNameTemplate = test.group
+		options.OneofMemberNameTemplate = test.member
+		message := buildTestMessage(t, schema, options, "Order")
+		members := make([]string, 0)
+		for _, field := range message.Fields {
+			if field.Oneof != test.oneof {
+				t.Fatalf("%s: expected the oneof %s, got %s", test.group, test.oneof, field.Oneof)
+			}
+			members = append(members, field.Name)
+		}
+		if strings.Join(members, ", ") != test.members {
+			t.Fatalf("%s: expected the members %s, got %v", test.member, test.members, members)
+		}
+	}
+}
+
+func TestSanitizeIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		changed  bool
+	}{
+		{"name", "name", false},
+		{"message", "message_", true},
+		{"reserved", "reserved_", true},
+		{"1st", "_1st", true},
+		{"", "_", true},
+	}
+	for _, test := range tests {
+		if output, changed := sanitizeIdentifier(test.name); output != test.expected || changed != test.changed {
+			t.Fatalf("%q: expected %s (%v), got %s (%v)", test.name, test.expected, test.changed, output, changed)
+		}
+	}
+	schema := `{"definitions": {"Order": {"type": "object", "properties": {"message": {"type": "string"}, "1st": {"type": "string"}, "a-b": {"type": "string"}}}}}`
+	message := buildTestMessage(t, schema, DefaultOptions(), "Order")
+	for name, jsonName := range map[string]string{"message_": "message", "_1st": "1st", "a_b": "a-b"} {
+		if field := findField(message, name); field == nil || field.JsonName != jsonName {
+			t.Fatalf("expected %s with the json name %s, got %+v", name, jsonName, field)
+		}
+	}
+}
+
+func TestFieldNameCollisions(t *testing.T) {
+	schema := `{"title": "Order", "type": "object", "properties": {"région": {"type": "string"}, "region": {"type": "integer"}, "a-b": {"type": "string"}, "a_b": {"type": "string"}, "a_b_2": {"type": "string"}}}`
+	parser := NewWithOptions([]byte(schema), DefaultOptions())
+	file := parser.Build("test")
+	message := file.Definitions[0].Message
+	names := make(map[string]string)
+	for _, field := range message.Fields {
+		if _, ok := names[field.Name]; ok {
+			t.Fatalf("%s was assigned twice", field.Name)
+		}
+		names[field.Name] = protoJsonName(field)
+	}
+	if len(names) != 5 || names["region_2"] != "région" || names["a_b"] != "a-b" || names["a_b_3"] != "a_b" {
+		t.Fatalf("expected region_2 and a_b_3 keeping their original json names, got %v", names)
+	}
+	warnings := 0
+	for _, diagnostic := range parser.Diagnostics() {
+		if diagnostic.Severity == WARNING && strings.Contains(diagnostic.Message, "collides with another field") {
+			warnings++
+		}
+	}
+	if warnings != 2 {
+		t.Fatalf("expected a warning per renamed field, got %v", parser.Diagnostics())
+	}
+	if _, err := CompileSource("order.proto", RenderTarget(file, DefaultOptions())); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnicodeNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		camel  string
+		pascal string
+		snake  string
+	}{
+		{"größe", "groesse", "Groesse", "groesse"},
+		{"имя", "imya", "Imya", "imya"},
+		{"Élan", "elan", "Elan", "elan"},
+		{"userId", "userId", "UserId", "user_id"},
+	}
+	for _, test := range tests {
+		snake, _ := toSnakeCase(test.name)
+		if *toCamelCase(test.name) != test.camel || *toPascalCase(test.name) != test.pascal || *snake != test.snake {
+			t.Fatalf("%s: expected %s, %s and %s, got %s, %s and %s", test.name, test.camel, test.pascal, test.snake, *toCamelCase(test.name), *toPascalCase(test.name), *snake)
+		}
+	}
+	schema := `{"definitions": {"Order": {"type": "object", "properties": {"日付": {"type": "string"}, "größe": {"type": "string"}}}}}`
+	message := buildTestMessage(t, schema, DefaultOptions(), "Order")
+	for name, jsonName := range map[string]string{"u65e5u4ed8": "日付", "groesse": "größe"} {
+		if field := findField(message, name); field == nil || field.JsonName != jsonName {
+			t.Fatalf("expected %s with the json name %s, got %+v", name, jsonName, field)
+		}
+	}
+}
+
+func TestTypeNameCollisions(t *testing.T) {
+	schema := `{"title": "Order", "type": "object", "properties": {"a": {"$ref": "#/definitions/user_profile"}, "b": {"$ref": "#/definitions/user-profile"}, "c": {"$ref": "#/definitions/user_profile/properties/address"}},
+"definitions": {"user_profile": {"type": "object", "properties": {"x": {"type": "string"}, "address": {"type": "object", "properties": {"city": {"type": "string"}}}}}, "user-profile": {"type": "object", "properties": {"y": {"type": "string"}}}}}`
+	parser := NewWithOptions([]byte(schema), DefaultOptions())
+	file := parser.Build("test")
+	messages := make(map[string]*ProtoMessage)
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		if _, ok := messages[definition.Message.Name]; ok {
+			t.Fatalf("%s was generated twice", definition.Message.Name)
+		}
+		messages[definition.Message.Name] = definition.Message
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected Order, Address and a message per user profile definition, got %v", messages)
+	}
+	order := messages["Order"]
+	a, b, c := findField(order, "a"), findField(order, "b"), findField(order, "c")
+	if a.Type == b.Type || messages[a.Type] == nil || messages[b.Type] == nil || findField(messages[a.Type], "x") == nil || findField(messages[b.Type], "y") == nil {
+		t.Fatalf("the colliding definitions were not kept apart: %+v, %+v", a, b)
+	}
+	if address := findField(messages[a.Type], "address"); address == nil || address.Type != c.Type {
+		t.Fatalf("the nested schema reached through two paths was generated twice: %+v, %+v", address, c)
+	}
+	warnings := 0
+	for _, diagnostic := range parser.Diagnostics() {
+		if diagnostic.Severity == WARNING && strings.Contains(diagnostic.Message, "collides with a different definition") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Fatalf("expected one collision warning, got %v", parser.Diagnostics())
+	}
+}
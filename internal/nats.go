@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const SERVICE_FILE = "schema.proto"
+
+type ConversionRequest struct {
+	Schema     json.RawMessage `json:"schema"`
+	Package    string          `json:"package,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	Options    json.RawMessage `json:"options,omitempty"`
+	Descriptor bool            `json:"descriptor,omitempty"`
+}
+
+type ConversionResponse struct {
+	Files       map[string]string `json:"files,omitempty"`
+	Descriptor  []byte            `json:"descriptor,omitempty"`
+	Diagnostics []Diagnostic      `json:"diagnostics,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+var REQUEST_DENIED_OPTIONS = map[string]bool{"template_files": true, "source": true, "merge_file": true}
+
+const (
+	MAX_REQUEST_INDENT_WIDTH     = 16
+	MAX_REQUEST_EXAMPLE_COMMENTS = 4096
+)
+
+func ParseConversionOptions(data []byte, options *Options) error {
+	keys := make(map[string]any)
+	err := json.Unmarshal(data, &keys)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(keys) {
+		if REQUEST_DENIED_OPTIONS[key] {
+			return fmt.Errorf("%s cannot be set in a request", key)
+		}
+	}
+	output := *options
+	// the decoder reuses slices and merges maps, requested ones must not alias the caller's options
+	if _, ok := keys["reserved_ranges"]; ok {
+		output.ReservedRanges = nil
+	}
+	if _, ok := keys["roots"]; ok {
+		output.Roots = nil
+	}
+	if _, ok := keys["import_map"]; ok {
+		output.ImportMap = nil
+	}
+	if _, ok := keys["update_requests"]; ok {
+		output.UpdateRequests = nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&output)
+	if err != nil {
+		return err
+	}
+	if output.IndentWidth < 0 || output.IndentWidth > MAX_REQUEST_INDENT_WIDTH {
+		return fmt.Errorf("indent_width must be between 0 and %d", MAX_REQUEST_INDENT_WIDTH)
+	}
+	if output.ExampleComments < 0 || output.ExampleComments > MAX_REQUEST_EXAMPLE_COMMENTS {
+		return fmt.Errorf("example_comments must be between 0 and %d", MAX_REQUEST_EXAMPLE_COMMENTS)
+	}
+	*options = output
+	return nil
+}
+
+func ParseConversionRequest(data []byte) (ConversionRequest, error) {
+	request := ConversionRequest{}
+	envelope := struct {
+		Schema json.RawMessage `json:"schema"`
+	}{}
+	if json.Unmarshal(data, &envelope) == nil && len(envelope.Schema) != 0 && envelope.Schema[0] == '{' {
+		err := json.Unmarshal(data, &request)
+		return request, err
+	}
+	request.Schema = data
+	return request, nil
+}
+
+func ConvertMessage(data []byte, options Options, store *DescriptorStore) []byte {
+	request, err := ParseConversionRequest(data)
+	response := ConversionResponse{}
+	if err != nil {
+		response.Error = fmt.Sprintf("invalid request: %s", err)
+	} else {
+		response = Convert(request, options, store)
+	}
+	output, err := json.Marshal(response)
+	if err != nil {
+		panic(err)
+	}
+	return output
+}
+
+func Convert(request ConversionRequest, options Options, store *DescriptorStore) (response ConversionResponse) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			response = ConversionResponse{Error: fmt.Sprintf("%v", recovered)}
+		}
+	}()
+	return convert(request, options, store)
+}
+
+func convert(request ConversionRequest, options Options, store *DescriptorStore) ConversionResponse {
+	response := ConversionResponse{Diagnostics: make([]Diagnostic, 0)}
+	if len(request.Options) != 0 {
+		err := ParseConversionOptions(request.Options, &options)
+		if err != nil {
+			response.Error = fmt.Sprintf("invalid options: %s", err)
+			return response
+		}
+	}
+	packageName := request.Package
+	if len(packageName) == 0 {
+		packageName = "test"
+	}
+	name := request.Name
+	if len(name) == 0 {
+		name = SERVICE_FILE
+	}
+	if path.IsAbs(name) || strings.HasPrefix(path.Clean(name), "..") {
+		response.Error = fmt.Sprintf("file name %s is outside of the output", name)
+		return response
+	}
+	file, err := ConvertSchema(request.Schema, packageName, options, func(diagnostic Diagnostic) {
+		response.Diagnostics = append(response.Diagnostics, diagnostic)
+	})
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+	response.Files = map[string]string{name: RenderTarget(file, options)}
+	if UsesJ2pOptions(file) {
+		response.Files[J2P_OPTIONS_PROTO] = J2P_OPTIONS
+	}
+	if !request.Descriptor && (store == nil || options.Target != PROTO_TARGET) {
+		return response
+	}
+	if options.Target != PROTO_TARGET {
+		response.Error = "descriptors can only be built for the proto target"
+		return response
+	}
+	files, err := CompileSource(name, response.Files[name])
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+	set := NewFileDescriptorSet(files)
+	descriptor, err := proto.Marshal(set)
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+	if store != nil {
+		if len(request.Name) == 0 {
+			set = proto.Clone(set).(*descriptorpb.FileDescriptorSet)
+			set.File[len(set.File)-1].Name = proto.String(path.Join(strings.ReplaceAll(packageName, ".", "/"), name))
+		}
+		err = store.Add(set)
+		if err != nil {
+			response.Diagnostics = append(response.Diagnostics, Diagnostic{Severity: WARNING, Subject: name, Message: fmt.Sprintf("not served over reflection: %s", err)})
+		}
+	}
+	if request.Descriptor {
+		response.Descriptor = descriptor
+	}
+	return response
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConvertOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		error   string
+		content string
+	}{
+		{name: "defaults", content: "message Order {"},
+		{name: "allowed option", options: `{"enum_zero_value": "UNKNOWN"}`, content: "STATUS_UNKNOWN = 0;"},
+		{name: "template files", options: `{"template_files": ["/nonexistent"]}`, error: "template_files"},
+		{name: "merge file", options: `{"merge_file": "/etc/passwd"}`, error: "merge_file"},
+		{name: "source", options: `{"source": "/etc/passwd"}`, error: "source"},
+		{name: "malformed", options: `{"enum_zero_value": 1}`, error: "enum_zero_value"},
+		{name: "unknown", options: `{"bogus": true}`, error: "bogus"},
+		{name: "not an object", options: `["indent_width"]`, error: "cannot unmarshal array"},
+		{name: "indent width", options: `{"indent_width": 4}`, content: "\n    string id = 1;"},
+		{name: "unbounded indent width", options: `{"indent_width": 100000000}`, error: "indent_width must be between 0 and 16"},
+		{name: "negative example comments", options: `{"example_comments": -1}`, error: "example_comments must be between 0 and 4096"},
+		{name: "unbounded example comments", options: `{"example_comments": 100000000}`, error: "example_comments must be between 0 and 4096"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := ConversionRequest{Schema: []byte(SERVICE_TEST_SCHEMA)}
+			if len(test.options) != 0 {
+				request.Options = []byte(test.options)
+			}
+			response := Convert(request, DefaultOptions(), nil)
+			if len(test.error) != 0 {
+				if !strings.Contains(response.Error, test.error) {
+					t.Fatalf("expected an error mentioning %s, got %q", test.error, response.Error)
+				}
+				return
+			}
+			if len(response.Error) != 0 {
+				t.Fatalf("unexpected error %s", response.Error)
+			}
+			if !strings.Contains(response.Files[SERVICE_FILE], test.content) {
+				t.Fatalf("expected %q in\n%s", test.content, response.Files[SERVICE_FILE])
+			}
+		})
+	}
+}
+
+func TestParseConversionOptionsCopies(t *testing.T) {
+	base := DefaultOptions()
+	base.Roots = []string{"Order", "Item"}
+	base.ImportMap = map[string]ImportMapping{"https://acme.com/money.json": {}}
+	options := base
+	err := ParseConversionOptions([]byte(`{"roots": ["Invoice"], "import_map": {"https://acme.com/date.json": {}}, "prune": true}`), &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(options.Roots, ",") != "Invoice" || len(options.ImportMap) != 1 || !options.Prune || options.IndentWidth != 2 {
+		t.Fatalf("the requested options were not applied over the defaults: %+v", options)
+	}
+	if strings.Join(base.Roots, ",") != "Order,Item" || len(base.ImportMap) != 1 || base.ImportMap["https://acme.com/money.json"] != (ImportMapping{}) || base.Prune {
+		t.Fatalf("the shared options were modified: %+v", base)
+	}
+	options = base
+	if err := ParseConversionOptions([]byte(`{"prune": true, "indent_width": -1}`), &options); err == nil || options.Prune {
+		t.Fatalf("rejected options were applied: %v", err)
+	}
+}
+
+func TestConvertRecoversPanics(t *testing.T) {
+	options := DefaultOptions()
+	options.TemplateFiles = []string{"/nonexistent"}
+	response := Convert(ConversionRequest{Schema: []byte(SERVICE_TEST_SCHEMA)}, options, nil)
+	if len(response.Error) == 0 {
+		t.Fatal("expected the panic to be reported as an error")
+	}
+}
+
+func TestConvertDescriptor(t *testing.T) {
+	store := NewDescriptorStore()
+	response := Convert(ConversionRequest{Schema: []byte(SERVICE_TEST_SCHEMA), Package: "acme.v1"}, DefaultOptions(), store)
+	if len(response.Error) != 0 {
+		t.Fatal(response.Error)
+	}
+	if len(response.Descriptor) != 0 {
+		t.Fatal("the descriptor was returned without being requested")
+	}
+	if _, err := store.FindDescriptorByName("acme.v1.Order"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.FindFileByPath("acme/v1/schema.proto"); err != nil {
+		t.Fatal(err)
+	}
+	response = Convert(ConversionRequest{Schema: []byte(SERVICE_TEST_SCHEMA), Descriptor: true}, DefaultOptions(), nil)
+	if len(response.Descriptor) == 0 {
+		t.Fatal("the requested descriptor is missing")
+	}
+}
+
+func TestParseConversionRequest(t *testing.T) {
+	request, err := ParseConversionRequest([]byte(`{"schema": {"type": "object"}, "package": "acme"}`))
+	if err != nil || request.Package != "acme" || string(request.Schema) != `{"type": "object"}` {
+		t.Fatalf("unexpected envelope %+v, %v", request, err)
+	}
+	request, err = ParseConversionRequest([]byte(`{"type": "object", "properties": {"schema": {"type": "string"}}}`))
+	if err != nil || len(request.Package) != 0 || !strings.HasPrefix(string(request.Schema), `{"type"`) {
+		t.Fatalf("unexpected raw schema %+v, %v", request, err)
+	}
+}
+
+func TestConvertMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		content string
+		error   string
+	}{
+		{"envelope", `{"schema": ` + SERVICE_TEST_SCHEMA + `, "package": "acme.v1"}`, "package acme.v1;", ""},
+		{"raw schema", SERVICE_TEST_SCHEMA, "message Order", ""},
+		{"invalid request", `{"schema": {"type": "object"}, "package": 1}`, "", "invalid request: "},
+		{"invalid options", `{"schema": ` + SERVICE_TEST_SCHEMA + `, "options": {"template_files": ["/etc/passwd"]}}`, "", "invalid options: "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := ConversionResponse{}
+			if err := json.Unmarshal(ConvertMessage([]byte(test.data), DefaultOptions(), nil), &response); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(response.Error, test.error) || (len(test.error) == 0) != (len(response.Error) == 0) {
+				t.Fatalf("expected the error %q, got %q", test.error, response.Error)
+			}
+			if !strings.Contains(response.Files[SERVICE_FILE], test.content) {
+				t.Fatalf("expected %q in\n%s", test.content, response.Files[SERVICE_FILE])
+			}
+		})
+	}
+}
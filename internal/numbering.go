@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+type Numbering string
+
+const (
+	SEQUENTIAL_NUMBERING Numbering = "sequential"
+	HASH_NUMBERING       Numbering = "hash"
+)
+
+const (
+	IMPLEMENTATION_RESERVED_START = 19000
+	IMPLEMENTATION_RESERVED_END   = 19999
+)
+
+func NumberFields(message *ProtoMessage, numbering Numbering, fixed map[string]bool) {
+	numberFieldsFrom(message, numbering, fixed, 1)
+}
+
+func numberFieldsFrom(message *ProtoMessage, numbering Numbering, fixed map[string]bool, start int) {
+	used := make(map[int]bool)
+	pending := make([]*ProtoField, 0)
+	for _, field := range append(append(make([]*ProtoField, 0), message.Fields...), message.Omitted...) {
+		if fixed[field.Name] {
+			used[field.Number] = true
+			continue
+		}
+		pending = append(pending, field)
+	}
+	if numbering == HASH_NUMBERING {
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].Name < pending[j].Name
+		})
+		for _, field := range pending {
+			field.Number = NextFieldNumber(HashFieldNumber(field.Name), used, message.ProtoReserved)
+			used[field.Number] = true
+		}
+		return
+	}
+	next := start
+	for _, field := range pending {
+		next = NextFieldNumber(next, used, message.ProtoReserved)
+		field.Number = next
+		used[next] = true
+	}
+}
+
+func HashFieldNumber(name string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	return int(hash.Sum32()%MAX_FIELD_NUMBER) + 1
+}
+
+func NextFieldNumber(start int, used map[int]bool, reserved ProtoReserved) int {
+	next := start
+	for used[next] || reserved.IsReserved(next) || (next >= IMPLEMENTATION_RESERVED_START && next <= IMPLEMENTATION_RESERVED_END) {
+		next++
+		if next > MAX_FIELD_NUMBER {
+			next = 1
+		}
+		if next == start {
+			panic("field numbers exhausted")
+		}
+	}
+	return next
+}
+
+func ReserveOmitted(file *ProtoFile) {
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Omitted {
+			definition.Message.ReservedRanges = append(definition.Message.ReservedRanges, ProtoRange{Start: field.Number, End: field.Number})
+			definition.Message.ReservedNames = append(definition.Message.ReservedNames, field.Name)
+		}
+		definition.Message.Omitted = nil
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const OMIT_TEST_SCHEMA = `{"definitions": {"User": {"type": "object", "properties": {"name": {"type": "string"}, "id": {"type": "string", "readOnly": true}, "email": {"type": "string"}}}}}`
+
+func TestNumberFields(t *testing.T) {
+	options := DefaultOptions()
+	message := buildTestMessage(t, OMIT_TEST_SCHEMA, options, "User")
+	used := make(map[int]bool)
+	for _, field := range message.Fields {
+		if field.Number < 1 || used[field.Number] {
+			t.Fatalf("field %s has the invalid or duplicate number %d", field.Name, field.Number)
+		}
+		used[field.Number] = true
+	}
+	options.Numbering = HASH_NUMBERING
+	message = buildTestMessage(t, OMIT_TEST_SCHEMA, options, "User")
+	for _, field := range message.Fields {
+		if field.Number != HashFieldNumber(field.Name) {
+			t.Fatalf("field %s is numbered %d instead of its hash %d", field.Name, field.Number, HashFieldNumber(field.Name))
+		}
+	}
+}
+
+func TestOmittedFieldsReserveTheirNumber(t *testing.T) {
+	options := DefaultOptions()
+	options.Omit = READ_ONLY_OMIT
+	options.Numbering = HASH_NUMBERING
+	message := buildTestMessage(t, OMIT_TEST_SCHEMA, options, "User")
+	if _, ok := fieldNumbers(message)["id"]; ok {
+		t.Fatal("the read only field was not omitted")
+	}
+	if !message.IsReserved(HashFieldNumber("id")) || !message.IsReservedName("id") {
+		t.Fatalf("the hash number of the omitted field is not reserved: %+v", message.ProtoReserved)
+	}
+
+	existing := filepath.Join(t.TempDir(), "user.proto")
+	err := os.WriteFile(existing, []byte("syntax = \"proto3\";\npackage test;\nmessage User {\n  string name = 7;\n  string id = 3;\n  string email = 9;\n}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options = DefaultOptions()
+	options.Omit = READ_ONLY_OMIT
+	options.MergeFile = existing
+	message = buildTestMessage(t, OMIT_TEST_SCHEMA, options, "User")
+	numbers := fieldNumbers(message)
+	if numbers["name"] != 7 || numbers["email"] != 9 {
+		t.Fatalf("merged numbers were not preserved: %v", numbers)
+	}
+	if len(message.ReservedRanges) != 1 || message.ReservedRanges[0] != (ProtoRange{Start: 3, End: 3}) {
+		t.Fatalf("expected the merged number 3 of the omitted field to be reserved, got %v", message.ReservedRanges)
+	}
+}
+
+func TestReservedRanges(t *testing.T) {
+	for _, numbering := range []Numbering{DefaultOptions().Numbering, HASH_NUMBERING} {
+		options := DefaultOptions()
+		options.Numbering = numbering
+		options.ReservedRanges = []ProtoRange{{Start: 1, End: 2}, {Start: HashFieldNumber("name"), End: HashFieldNumber("name")}}
+		message := buildTestMessage(t, OMIT_TEST_SCHEMA, options, "User")
+		for _, field := range message.Fields {
+			if message.IsReserved(field.Number) {
+				t.Fatalf("%s: field %s was given the reserved number %d", numbering, field.Name, field.Number)
+			}
+		}
+		if !message.IsReserved(1) || !message.IsReserved(2) {
+			t.Fatalf("%s: the configured ranges are not reserved: %+v", numbering, message.ProtoReserved)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const OPENAPI_SCHEMAS = "#/components/schemas/"
+
+func IsOpenApi(data []byte) bool {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return false
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return false
+	}
+	version, ok := document.Get("openapi")
+	if !ok {
+		return false
+	}
+	_version, ok := version.(string)
+	return ok && strings.HasPrefix(_version, "3.")
+}
+
+func FromOpenApi(data []byte, services bool, diagnosticHandler DiagnosticHandler) ([]byte, error) {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return nil, fmt.Errorf("openapi documents must be objects")
+	}
+	output := NewJsonObject()
+	output.Set("$schema", "http://json-schema.org/draft-07/schema#")
+	output.Set("$id", openApiID(document))
+	definitions := NewJsonObject()
+	if components, ok := document.Get("components"); ok {
+		if _components, ok := components.(*JsonObject); ok {
+			if schemas, ok := _components.Get("schemas"); ok {
+				if _schemas, ok := schemas.(*JsonObject); ok {
+					definitions = _schemas
+				}
+			}
+		}
+	}
+	if len(definitions.Keys) == 0 && !services {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: "#/components/schemas", Message: "the openapi document has no component schemas"})
+	}
+	for _, key := range definitions.Keys {
+		definitions.Set(key, fromOpenApiSchema(definitions.Values[key], OPENAPI_SCHEMAS+escapePointer(key), diagnosticHandler))
+	}
+	if services {
+		fromOpenApiPaths(document, definitions, diagnosticHandler)
+	}
+	output.Set("definitions", definitions)
+	return EncodeJson(output), nil
+}
+
+func openApiID(document *JsonObject) string {
+	title, version := "openapi", ""
+	if info, ok := document.Get("info"); ok {
+		if _info, ok := info.(*JsonObject); ok {
+			if value, ok := _info.Get("title"); ok {
+				if _value, ok := value.(string); ok && len(strings.TrimSpace(_value)) != 0 {
+					title = _value
+				}
+			}
+			if value, ok := _info.Get("version"); ok {
+				version = fmt.Sprint(value)
+			}
+		}
+	}
+	name := strings.Trim(strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_"), "_")
+	major := strings.TrimPrefix(strings.SplitN(strings.TrimSpace(version), ".", 2)[0], "v")
+	if len(major) == 0 || strings.Trim(major, "0123456789") != "" {
+		return path.Join(name, name+".json")
+	}
+	return path.Join(name, "v"+major, name+".json")
+}
+
+func fromOpenApiRef(ref string) string {
+	index := strings.Index(ref, OPENAPI_SCHEMAS)
+	if index < 0 {
+		return ref
+	}
+	return ref[:index] + "#/definitions/" + ref[index+len(OPENAPI_SCHEMAS):]
+}
+
+func fromOpenApiSchema(value any, pointer string, diagnosticHandler DiagnosticHandler) any {
+	switch _value := value.(type) {
+	case []any:
+		{
+			for index, item := range _value {
+				_value[index] = fromOpenApiSchema(item, fmt.Sprintf("%s/%d", pointer, index), diagnosticHandler)
+			}
+			return _value
+		}
+	case *JsonObject:
+		{
+			for _, key := range _value.Keys {
+				if key == "example" || key == "examples" || key == "xml" || key == "externalDocs" {
+					_value.Delete(key)
+				}
+			}
+			for _, key := range _value.Keys {
+				item, ok := _value.Get(key)
+				if !ok {
+					continue
+				}
+				switch key {
+				case "$ref":
+					{
+						if ref, ok := item.(string); ok {
+							_value.Set(key, fromOpenApiRef(ref))
+						}
+					}
+				case "x-nullable":
+					{
+						_value.Delete(key)
+						if nullable, ok := item.(bool); ok {
+							_value.Set("nullable", nullable)
+						}
+					}
+				case "collectionFormat":
+					{
+						_value.Delete(key)
+						if format, ok := item.(string); ok && format != "multi" {
+							diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("%s collections are serialized as a delimited string and were typed as string", format)})
+							_value.Delete("items")
+							_value.Set("type", string(STRING))
+						}
+					}
+				case "discriminator":
+					{
+						if propertyName, ok := item.(string); ok {
+							discriminator := NewJsonObject()
+							discriminator.Set("propertyName", propertyName)
+							_value.Set(key, discriminator)
+						}
+						if discriminator, ok := item.(*JsonObject); ok {
+							if mapping, ok := discriminator.Get("mapping"); ok {
+								if _mapping, ok := mapping.(*JsonObject); ok {
+									for _, name := range _mapping.Keys {
+										if ref, ok := _mapping.Values[name].(string); ok {
+											_mapping.Set(name, fromOpenApiRef(ref))
+										}
+									}
+								}
+							}
+						}
+					}
+				case "properties", "definitions", "$defs", "patternProperties":
+					{
+						if properties, ok := item.(*JsonObject); ok {
+							for _, name := range properties.Keys {
+								properties.Set(name, fromOpenApiSchema(properties.Values[name], pointer+"/"+key+"/"+escapePointer(name), diagnosticHandler))
+							}
+						}
+					}
+				case "enum", "required", "default", "const", "x-enum-varnames":
+					{
+					}
+				default:
+					{
+						_value.Set(key, fromOpenApiSchema(item, pointer+"/"+escapePointer(key), diagnosticHandler))
+					}
+				}
+			}
+			if _type, ok := _value.Get("type"); ok && _type == "file" {
+				diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: "file was typed as string"})
+				_value.Set("type", string(STRING))
+			}
+			fromOpenApiBounds(_value, "minimum", "exclusiveMinimum")
+			fromOpenApiBounds(_value, "maximum", "exclusiveMaximum")
+			fromOpenApiTypes(_value, pointer, diagnosticHandler)
+			return _value
+		}
+	}
+	return value
+}
+
+func fromOpenApiBounds(schema *JsonObject, bound string, exclusive string) {
+	value, ok := schema.Get(exclusive)
+	if !ok {
+		return
+	}
+	_value, ok := value.(bool)
+	if !ok {
+		return
+	}
+	schema.Delete(exclusive)
+	if limit, ok := schema.Get(bound); ok && _value {
+		schema.Delete(bound)
+		schema.Set(exclusive, limit)
+	}
+}
+
+func fromOpenApiTypes(schema *JsonObject, pointer string, diagnosticHandler DiagnosticHandler) {
+	value, ok := schema.Get("type")
+	if !ok {
+		return
+	}
+	types, ok := value.([]any)
+	if !ok {
+		return
+	}
+	members := make([]any, 0)
+	for _, item := range types {
+		if item == string(NULL) {
+			schema.Set("nullable", true)
+			continue
+		}
+		members = append(members, item)
+	}
+	switch len(members) {
+	case 0:
+		{
+			schema.Set("type", string(NULL))
+			schema.Delete("nullable")
+		}
+	case 1:
+		{
+			schema.Set("type", members[0])
+		}
+	default:
+		{
+			diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: "a type list was converted to a union"})
+			schema.Delete("type")
+			anyOf := make([]any, 0, len(members))
+			for _, member := range members {
+				_member := NewJsonObject()
+				_member.Set("type", member)
+				anyOf = append(anyOf, _member)
+			}
+			schema.Set("anyOf", anyOf)
+		}
+	}
+}
+
+var OPENAPI_METHODS = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func fromOpenApiPaths(document *JsonObject, definitions *JsonObject, diagnosticHandler DiagnosticHandler) {
+	paths := jsonObject(document, "paths")
+	components := jsonObject(document, "components")
+	for _, route := range paths.Keys {
+		item, ok := paths.Values[route].(*JsonObject)
+		if !ok {
+			continue
+		}
+		shared, _ := item.Get("parameters")
+		for _, method := range OPENAPI_METHODS {
+			operation := jsonObject(item, method)
+			if len(operation.Keys) == 0 {
+				continue
+			}
+			pointer := "#/paths/" + escapePointer(route) + "/" + method
+			rpc := openApiOperationName(operation, method, route, pointer, diagnosticHandler)
+			request := NewJsonObject()
+			request.Set("type", string(OBJECT))
+			properties := NewJsonObject()
+			required := make([]any, 0)
+			parameters, _ := operation.Get("parameters")
+			for _, parameter := range openApiParameters(shared, parameters, components) {
+				name := fmt.Sprint(parameter.Values["name"])
+				location := fmt.Sprint(parameter.Values["in"])
+				if location != "path" && location != "query" {
+					diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: fmt.Sprintf("%s parameter %s has no place in the request message and was skipped", location, name)})
+					continue
+				}
+				schema, ok := parameter.Get("schema")
+				if !ok {
+					schema = NewJsonObject()
+				}
+				properties.Set(name, fromOpenApiSchema(schema, pointer+"/parameters/"+escapePointer(name), diagnosticHandler))
+				if value, ok := parameter.Get("required"); ok && value == true {
+					required = append(required, name)
+				}
+			}
+			body := ""
+			if schema, ok := openApiContentSchema(jsonObject(openApiResolve(jsonObject(operation, "requestBody"), components, "requestBodies"), "content")); ok {
+				body = "body"
+				if _, ok := properties.Get(body); ok {
+					body = "request_body"
+				}
+				properties.Set(body, fromOpenApiSchema(schema, pointer+"/requestBody", diagnosticHandler))
+				if value, ok := jsonObject(operation, "requestBody").Get("required"); ok && value == true {
+					required = append(required, body)
+				}
+			}
+			request.Set("properties", properties)
+			if len(required) != 0 {
+				request.Set("required", required)
+			}
+			extension := NewJsonObject()
+			extension.Set("method", method)
+			extension.Set("path", route)
+			if len(body) != 0 {
+				extension.Set("body", body)
+			}
+			extension.Set("rpc", rpc)
+			if tags, ok := operation.Get("tags"); ok {
+				if _tags, ok := tags.([]any); ok && len(_tags) != 0 {
+					extension.Set("service", *toPascalCase(strings.Join(strings.Fields(fmt.Sprint(_tags[0])), ""))+"Service")
+				}
+			}
+			if schema, ok := openApiResponseSchema(jsonObject(operation, "responses"), components); ok {
+				schema = fromOpenApiSchema(schema, pointer+"/responses", diagnosticHandler)
+				_schema, _ := schema.(*JsonObject)
+				ref, isRef := _schema.Get("$ref")
+				switch {
+				case isRef && len(_schema.Keys) == 1 && strings.HasPrefix(fmt.Sprint(ref), "#/definitions/"):
+					{
+						extension.Set("response", ref)
+					}
+				case _schema.Values["type"] == string(OBJECT) || _schema.Values["properties"] != nil:
+					{
+						name := openApiDefinitionName(definitions, rpc+"Response", pointer, diagnosticHandler)
+						definitions.Set(name, _schema)
+						extension.Set("response", "#/definitions/"+escapePointer(name))
+					}
+				default:
+					{
+						wrapper := NewJsonObject()
+						wrapper.Set("type", string(OBJECT))
+						wrapperProperties := NewJsonObject()
+						wrapperProperties.Set("value", _schema)
+						wrapper.Set("properties", wrapperProperties)
+						name := openApiDefinitionName(definitions, rpc+"Response", pointer, diagnosticHandler)
+						definitions.Set(name, wrapper)
+						extension.Set("response", "#/definitions/"+escapePointer(name))
+						extension.Set("response_body", "value")
+					}
+				}
+			}
+			request.Set("x-http", extension)
+			definitions.Set(openApiDefinitionName(definitions, rpc+"Request", pointer, diagnosticHandler), request)
+		}
+	}
+}
+
+func openApiOperationName(operation *JsonObject, method string, route string, pointer string, diagnosticHandler DiagnosticHandler) string {
+	if operationId, ok := operation.Get("operationId"); ok {
+		if _operationId, ok := operationId.(string); ok && len(_operationId) != 0 {
+			var output strings.Builder
+			for _, segment := range strings.FieldsFunc(_operationId, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+			}) {
+				output.WriteString(upperFirst(segment))
+			}
+			return output.String()
+		}
+	}
+	var output strings.Builder
+	output.WriteString(upperFirst(method))
+	for _, segment := range strings.FieldsFunc(route, func(r rune) bool { return r == '/' || r == '-' || r == '.' || r == '_' }) {
+		if strings.HasPrefix(segment, "{") {
+			output.WriteString("By" + upperFirst(strings.Trim(segment, "{}")))
+			continue
+		}
+		output.WriteString(upperFirst(segment))
+	}
+	diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer, Message: fmt.Sprintf("the operation has no operationId and was named %s", output.String())})
+	return output.String()
+}
+
+func openApiDefinitionName(definitions *JsonObject, name string, pointer string, diagnosticHandler DiagnosticHandler) string {
+	if _, ok := definitions.Get(name); !ok {
+		return name
+	}
+	for suffix := 2; ; suffix++ {
+		candidate := fmt.Sprintf("%s%d", name, suffix)
+		if _, ok := definitions.Get(candidate); !ok {
+			diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer, Message: fmt.Sprintf("%s collides with a component schema and was renamed to %s", name, candidate)})
+			return candidate
+		}
+	}
+}
+
+func openApiResolve(object *JsonObject, components *JsonObject, section string) *JsonObject {
+	ref, ok := object.Get("$ref")
+	if !ok {
+		return object
+	}
+	prefix := "#/components/" + section + "/"
+	_ref := fmt.Sprint(ref)
+	if !strings.HasPrefix(_ref, prefix) {
+		return object
+	}
+	return jsonObject(jsonObject(components, section), strings.ReplaceAll(strings.ReplaceAll(_ref[len(prefix):], "~1", "/"), "~0", "~"))
+}
+
+func openApiParameters(shared any, parameters any, components *JsonObject) []*JsonObject {
+	output := make([]*JsonObject, 0)
+	positions := make(map[string]int)
+	for _, list := range []any{shared, parameters} {
+		_list, _ := list.([]any)
+		for _, parameter := range _list {
+			_parameter, ok := parameter.(*JsonObject)
+			if !ok {
+				continue
+			}
+			_parameter = openApiResolve(_parameter, components, "parameters")
+			key := fmt.Sprintf("%v:%v", _parameter.Values["in"], _parameter.Values["name"])
+			if position, ok := positions[key]; ok {
+				output[position] = _parameter
+				continue
+			}
+			positions[key] = len(output)
+			output = append(output, _parameter)
+		}
+	}
+	return output
+}
+
+func openApiContentSchema(content *JsonObject) (any, bool) {
+	if len(content.Keys) == 0 {
+		return nil, false
+	}
+	keys := append([]string{}, content.Keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return strings.Contains(keys[i], "json") && !strings.Contains(keys[j], "json")
+	})
+	schema, ok := jsonObject(content, keys[0]).Get("schema")
+	return schema, ok
+}
+
+func openApiResponseSchema(responses *JsonObject, components *JsonObject) (any, bool) {
+	for _, code := range responses.Keys {
+		if strings.HasPrefix(code, "2") {
+			return openApiContentSchema(jsonObject(openApiResolve(jsonObject(responses, code), components, "responses"), "content"))
+		}
+	}
+	return nil, false
+}
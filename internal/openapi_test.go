@@ -0,0 +1,93 @@
+package internal
+
+import "testing"
+
+const OPENAPI_TEST_DOCUMENT = `{"openapi": "3.0.3", "info": {"title": "Pets", "version": "1.2.0"}, "paths": {}, "components": {"schemas": {
+	"Pet": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "tag": {"type": "string", "nullable": true}, "owner": {"$ref": "#/components/schemas/Owner"}}},
+	"Owner": {"type": "object", "properties": {"id": {"type": "integer"}}}
+}}}`
+
+func convertTestDocument(t *testing.T, document string, options Options) (string, []Diagnostic) {
+	t.Helper()
+	diagnostics := make([]Diagnostic, 0)
+	diagnosticHandler := func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	schema, packageName, err := PrepareSchema("schema.json", []byte(document), options, diagnosticHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := NewWithOptions(schema, options)
+	file := parser.Build(packageName)
+	return RenderTarget(file, options), append(diagnostics, parser.Diagnostics()...)
+}
+
+func TestFromOpenApi(t *testing.T) {
+	if !IsOpenApi([]byte(OPENAPI_TEST_DOCUMENT)) {
+		t.Fatal("the document was not detected as openapi")
+	}
+	output, _ := convertTestDocument(t, OPENAPI_TEST_DOCUMENT, DefaultOptions())
+	expected := `syntax = "proto3";
+
+package pets.v1;
+
+message Owner {
+  int32 id = 1;
+}
+
+message Pet {
+  optional string tag = 1;
+  string name = 2;
+  Owner owner = 3;
+}
+`
+	if output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
+
+const OPENAPI_SERVICES_TEST_DOCUMENT = `{"openapi": "3.0.3", "info": {"title": "Pets", "version": "1.0.0"}, "paths": {
+	"/pets/{petId}": {"get": {"operationId": "getPet", "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "string"}}], "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}}},
+	"/pets": {"post": {"operationId": "createPet", "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}, "responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}}}
+}, "components": {"schemas": {"Pet": {"type": "object", "properties": {"name": {"type": "string"}}}}}}`
+
+func TestOpenApiServices(t *testing.T) {
+	options := DefaultOptions()
+	options.OpenApiServices = true
+	output, _ := convertTestDocument(t, OPENAPI_SERVICES_TEST_DOCUMENT, options)
+	expected := `syntax = "proto3";
+
+package pets.v1;
+
+import "google/api/annotations.proto";
+
+message Pet {
+  string name = 1;
+}
+
+message CreatePetRequest {
+  Pet body = 1;
+}
+
+message GetPetRequest {
+  string petId = 1 [json_name = "pet_id"];
+}
+
+service PetsService {
+  rpc GetPet(GetPetRequest) returns (Pet) {
+    option (google.api.http) = {
+      get: "/pets/{petId}"
+    };
+  }
+  rpc CreatePet(CreatePetRequest) returns (Pet) {
+    option (google.api.http) = {
+      post: "/pets"
+      body: "body"
+    };
+  }
+}
+`
+	if output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
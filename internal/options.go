@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+)
+
+type InlineNaming string
+
+const (
+	PROPERTY_NAMING InlineNaming = "property"
+	PATH_NAMING     InlineNaming = "path"
+)
+
+type IndentStyle string
+
+const (
+	SPACE_INDENT IndentStyle = "space"
+	TAB_INDENT   IndentStyle = "tab"
+)
+
+type NewlineStyle string
+
+const (
+	LF_NEWLINE   NewlineStyle = "lf"
+	CRLF_NEWLINE NewlineStyle = "crlf"
+)
+
+type OmitMode string
+
+const (
+	NO_OMIT         OmitMode = "none"
+	READ_ONLY_OMIT  OmitMode = "readOnly"
+	WRITE_ONLY_OMIT OmitMode = "writeOnly"
+)
+
+type Options struct {
+	InputFormat             InputFormat                                    `json:"input_format"`
+	Draft                   Draft                                          `json:"draft"`
+	EnumZeroValue           string                                         `json:"enum_zero_value"`
+	EnumAllowAlias          bool                                           `json:"enum_allow_alias"`
+	OneofNameTemplate       string                                         `json:"oneof_name_template"`
+	OneofMemberNameTemplate string                                         `json:"oneof_member_name_template"`
+	OneofNamer              func(unionName string) string                  `json:"-"`
+	OneofMemberNamer        func(unionName string, typeName string) string `json:"-"`
+	InlineNaming            InlineNaming                                   `json:"inline_naming"`
+	Disambiguation          Disambiguation                                 `json:"disambiguation"`
+	FieldOrder              FieldOrder                                     `json:"field_order"`
+	TemplateFiles           []string                                       `json:"template_files"`
+	IndentStyle             IndentStyle                                    `json:"indent_style"`
+	IndentWidth             int                                            `json:"indent_width"`
+	Newline                 NewlineStyle                                   `json:"newline"`
+	Metadata                bool                                           `json:"metadata"`
+	MetadataTimestamp       bool                                           `json:"metadata_timestamp"`
+	Source                  string                                         `json:"source"`
+	MergeFile               string                                         `json:"merge_file"`
+	Numbering               Numbering                                      `json:"numbering"`
+	ReservedRanges          []ProtoRange                                   `json:"reserved_ranges"`
+	Roots                   []string                                       `json:"roots"`
+	ExtractCommon           bool                                           `json:"extract_common"`
+	CommonPackage           string                                         `json:"common_package"`
+	ImportPrefix            string                                         `json:"import_prefix"`
+	SplitEnums              bool                                           `json:"split_enums"`
+	Envelope                string                                         `json:"envelope"`
+	Prune                   bool                                           `json:"prune"`
+	WellKnownTypes          bool                                           `json:"well_known_types"`
+	ImportMap               map[string]ImportMapping                       `json:"import_map"`
+	UpdateRequests          []string                                       `json:"update_requests"`
+	Provenance              bool                                           `json:"provenance"`
+	FieldBehavior           bool                                           `json:"field_behavior"`
+	Validation              Validation                                     `json:"validation"`
+	Defaults                DefaultMode                                    `json:"defaults"`
+	Omit                    OmitMode                                       `json:"omit"`
+	ExampleComments         int                                            `json:"example_comments"`
+	OpenApiServices         bool                                           `json:"openapi_services"`
+	AsyncApiServices        bool                                           `json:"asyncapi_services"`
+	Target                  Target                                         `json:"target"`
+	GoPackage               string                                         `json:"go_package"`
+	GoImportPath            string                                         `json:"go_import_path"`
+	GoValidateTags          bool                                           `json:"go_validate_tags"`
+	SqlDialect              SqlDialect                                     `json:"sql_dialect"`
+	SqlNesting              SqlNesting                                     `json:"sql_nesting"`
+	ExternalRefResolver     func(ref string) (string, bool)                `json:"-"`
+}
+
+func DefaultOptions() Options {
+	return Options{
+		InputFormat:             AUTO_INPUT,
+		Draft:                   AUTO_DRAFT,
+		Target:                  PROTO_TARGET,
+		Validation:              NO_VALIDATION,
+		Defaults:                NO_DEFAULTS,
+		Omit:                    NO_OMIT,
+		SqlDialect:              POSTGRES_DIALECT,
+		SqlNesting:              JSON_NESTING,
+		EnumZeroValue:           "UNSPECIFIED",
+		OneofNameTemplate:       "_$NAME$__union",
+		OneofMemberNameTemplate: "_$NAME$___$TYPE$_",
+		InlineNaming:            PROPERTY_NAMING,
+		Disambiguation:          SUFFIX_DISAMBIGUATION,
+		FieldOrder:              LENGTH_ORDER,
+		IndentStyle:             SPACE_INDENT,
+		IndentWidth:             2,
+		Newline:                 LF_NEWLINE,
+		Numbering:               SEQUENTIAL_NUMBERING,
+		WellKnownTypes:          true,
+		CommonPackage:           "common",
+	}
+}
+
+func (options Options) ImportPath(file string) string {
+	if len(options.ImportPrefix) == 0 {
+		return file
+	}
+	return path.Join(options.ImportPrefix, file)
+}
+
+func LoadOptions(path string, options *Options) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	err = json.Unmarshal(data, options)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,111 @@
+package internal
+
+import "sort"
+
+func TopologicalOrder[T any](types map[string]T, dependencies map[string][]string, roots []string) []string {
+	names := make([]string, 0)
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	visited := make(map[string]bool)
+	output := make([]string, 0)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		_dependencies := append([]string{}, dependencies[name]...)
+		sort.Strings(_dependencies)
+		for _, dependency := range _dependencies {
+			if _, ok := types[dependency]; ok {
+				visit(dependency)
+			}
+		}
+		output = append(output, name)
+	}
+	for _, name := range roots {
+		if _, ok := types[name]; ok {
+			visit(name)
+		}
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return output
+}
+
+func Reachable(roots []string, dependencies map[string][]string) map[string]bool {
+	output := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if output[name] {
+			return
+		}
+		output[name] = true
+		for _, dependency := range dependencies[name] {
+			visit(dependency)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return output
+}
+
+type FieldOrder string
+
+const (
+	LENGTH_ORDER         FieldOrder = "length"
+	SCHEMA_ORDER         FieldOrder = "schema"
+	ALPHABETICAL_ORDER   FieldOrder = "alphabetical"
+	REQUIRED_FIRST_ORDER FieldOrder = "required"
+)
+
+func OrderFields(message Properties, fieldOrder FieldOrder) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, key := range message.PropertyOrder {
+		if _, ok := message.Properties[key]; ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	rest := make([]string, 0)
+	for key := range message.Properties {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+	switch fieldOrder {
+	case SCHEMA_ORDER:
+		{
+			break
+		}
+	case ALPHABETICAL_ORDER:
+		{
+			sort.Strings(keys)
+		}
+	case REQUIRED_FIRST_ORDER:
+		{
+			required := make(map[string]bool)
+			for _, key := range message.Required {
+				required[key] = true
+			}
+			sort.SliceStable(keys, func(i, j int) bool {
+				return required[keys[i]] && !required[keys[j]]
+			})
+		}
+	default:
+		{
+			sort.Strings(keys)
+			sort.SliceStable(keys, func(i, j int) bool {
+				return len(keys[i]) < len(keys[j])
+			})
+		}
+	}
+	return keys
+}
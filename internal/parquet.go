@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+var PARQUET_SCALARS = map[string]string{
+	"double":   "double",
+	"float":    "float",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"uint32":   "int32 (INTEGER(32,false))",
+	"fixed32":  "int32 (INTEGER(32,false))",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint64":   "int64 (INTEGER(64,false))",
+	"fixed64":  "int64 (INTEGER(64,false))",
+	"bool":     "boolean",
+	"string":   "binary (STRING)",
+	"bytes":    "binary",
+}
+
+var PARQUET_WELL_KNOWN_TYPES = map[string]string{
+	"google.protobuf.Timestamp":   "int64 (TIMESTAMP(MILLIS,true))",
+	"google.protobuf.Duration":    "binary (STRING)",
+	"google.protobuf.FieldMask":   "binary (STRING)",
+	"google.protobuf.Struct":      "binary (JSON)",
+	"google.protobuf.Value":       "binary (JSON)",
+	"google.protobuf.ListValue":   "binary (JSON)",
+	"google.protobuf.Any":         "binary (JSON)",
+	"google.protobuf.Empty":       "binary (JSON)",
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.UInt64Value": "int64 (INTEGER(64,false))",
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.UInt32Value": "int32 (INTEGER(32,false))",
+	"google.protobuf.BoolValue":   "boolean",
+	"google.protobuf.StringValue": "binary (STRING)",
+	"google.protobuf.BytesValue":  "binary",
+	"google.type.Date":            "int32 (DATE)",
+	"google.type.TimeOfDay":       "int64 (TIME(MICROS,false))",
+	"google.rpc.Status":           "binary (JSON)",
+}
+
+type parquetWriter struct {
+	file        *ProtoFile
+	definitions map[string]ProtoDefinition
+	path        map[string]bool
+	builder     strings.Builder
+}
+
+func RenderParquet(file *ProtoFile, options Options) string {
+	writer := parquetWriter{file: file, definitions: make(map[string]ProtoDefinition), path: make(map[string]bool)}
+	referenced := referencedTypes(file)
+	for _, definition := range file.Definitions {
+		writer.definitions[definitionName(definition)] = definition
+	}
+	for _, definition := range file.Definitions {
+		if definition.Message != nil && !referenced[definition.Message.Name] {
+			writer.builder.WriteString(fmt.Sprintf("message %s {\n", toGoTypeName(file.Package, definition.Message.Name)))
+			writer.fields(definition.Message, 1)
+			writer.builder.WriteString("}\n")
+			break
+		}
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(writer.builder.String(), "\n", "\r\n")
+	}
+	return writer.builder.String()
+}
+
+func (rcvr *parquetWriter) fields(message *ProtoMessage, depth int) {
+	rcvr.path[message.Name] = true
+	defer delete(rcvr.path, message.Name)
+	for _, field := range message.Fields {
+		repetition := "optional"
+		if field.Required && len(field.Oneof) == 0 {
+			repetition = "required"
+		}
+		name := bigQueryName(field)
+		if strings.HasPrefix(field.Type, "map<") {
+			index := strings.Index(field.Type, ",")
+			rcvr.line(depth, "%s group %s (MAP) {", repetition, name)
+			rcvr.line(depth+1, "repeated group key_value {")
+			rcvr.column(depth+2, "required", "key", strings.TrimPrefix(field.Type[:index], "map<"))
+			rcvr.column(depth+2, "optional", "value", strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")))
+			rcvr.line(depth+1, "}")
+			rcvr.line(depth, "}")
+			continue
+		}
+		if field.Label == REPEATED_LABEL {
+			rcvr.line(depth, "%s group %s (LIST) {", repetition, name)
+			rcvr.line(depth+1, "repeated group list {")
+			rcvr.column(depth+2, "required", "element", field.Type)
+			rcvr.line(depth+1, "}")
+			rcvr.line(depth, "}")
+			continue
+		}
+		rcvr.column(depth, repetition, name, field.Type)
+	}
+}
+
+func (rcvr *parquetWriter) column(depth int, repetition string, name string, typeName string) {
+	if scalar, ok := PARQUET_SCALARS[typeName]; ok {
+		rcvr.primitive(depth, repetition, name, scalar)
+		return
+	}
+	if wellKnown, ok := PARQUET_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		rcvr.primitive(depth, repetition, name, wellKnown)
+		return
+	}
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(rcvr.file.Package) != 0 {
+		typeName = strings.TrimPrefix(typeName, rcvr.file.Package+".")
+	}
+	definition, ok := rcvr.definitions[typeName]
+	if !ok {
+		rcvr.primitive(depth, repetition, name, "binary (JSON)")
+		return
+	}
+	if definition.Enum != nil {
+		rcvr.primitive(depth, repetition, name, "binary (ENUM)")
+		return
+	}
+	if rcvr.path[typeName] || len(definition.Message.Fields) == 0 {
+		rcvr.primitive(depth, repetition, name, "binary (JSON)")
+		return
+	}
+	rcvr.line(depth, "%s group %s {", repetition, name)
+	rcvr.fields(definition.Message, depth+1)
+	rcvr.line(depth, "}")
+}
+
+func (rcvr *parquetWriter) primitive(depth int, repetition string, name string, typeName string) {
+	physical, annotation, _ := strings.Cut(typeName, " ")
+	if len(annotation) != 0 {
+		rcvr.line(depth, "%s %s %s %s;", repetition, physical, name, annotation)
+		return
+	}
+	rcvr.line(depth, "%s %s %s;", repetition, physical, name)
+}
+
+func (rcvr *parquetWriter) line(depth int, format string, args ...any) {
+	rcvr.builder.WriteString(strings.Repeat("  ", depth))
+	rcvr.builder.WriteString(fmt.Sprintf(format, args...))
+	rcvr.builder.WriteString("\n")
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const PLUGIN_PREFIX = "j2p-gen-"
+
+type PluginRequest struct {
+	Version   string     `json:"version"`
+	Parameter string     `json:"parameter,omitempty"`
+	Options   Options    `json:"options"`
+	File      *ProtoFile `json:"file"`
+}
+
+type PluginResponse struct {
+	Error       string       `json:"error,omitempty"`
+	Files       []PluginFile `json:"files"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+type PluginFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+func PluginCommand(plugin string) (string, string) {
+	name, parameter, _ := strings.Cut(plugin, ":")
+	if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		return name, parameter
+	}
+	return PLUGIN_PREFIX + name, parameter
+}
+
+func RunEmitterPlugin(plugin string, file *ProtoFile, options Options) (*PluginResponse, error) {
+	name, parameter := PluginCommand(plugin)
+	data, err := json.Marshal(PluginRequest{Version: VERSION, Parameter: parameter, Options: options, File: file})
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(name)
+	command.Stdin = bytes.NewReader(data)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	err = command.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	response := PluginResponse{}
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid response: %w", name, err)
+	}
+	if len(response.Error) != 0 {
+		return nil, fmt.Errorf("%s: %s", name, response.Error)
+	}
+	return &response, nil
+}
+
+func WritePluginFiles(response *PluginResponse, outputDirectory string) error {
+	for _, file := range response.Files {
+		name := filepath.Clean(filepath.FromSlash(file.Name))
+		if filepath.IsAbs(name) || strings.HasPrefix(name, ".."+string(filepath.Separator)) || name == ".." {
+			return fmt.Errorf("plugin output %s is outside of the output directory", file.Name)
+		}
+		_path := filepath.Join(outputDirectory, name)
+		err := os.MkdirAll(filepath.Dir(_path), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(_path, []byte(file.Content), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
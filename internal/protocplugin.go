@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+type PluginParameters map[string]string
+
+func ParsePluginParameters(parameter string) PluginParameters {
+	output := make(PluginParameters)
+	for _, value := range strings.Split(parameter, ",") {
+		if len(value) == 0 {
+			continue
+		}
+		key, _value, ok := strings.Cut(value, "=")
+		if !ok {
+			_value = "true"
+		}
+		output[strings.TrimSpace(key)] = strings.TrimSpace(_value)
+	}
+	return output
+}
+
+func GenerateJsonSchemas(request *pluginpb.CodeGeneratorRequest, diagnosticHandler DiagnosticHandler) *pluginpb.CodeGeneratorResponse {
+	response := pluginpb.CodeGeneratorResponse{SupportedFeatures: proto.Uint64(uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL))}
+	parameters := ParsePluginParameters(request.GetParameter())
+	for key := range parameters {
+		if key != "root" && key != "id_prefix" && key != "lint" && key != "strict" {
+			response.Error = proto.String(fmt.Sprintf("unknown parameter %s, expected root, id_prefix, lint or strict", key))
+			return &response
+		}
+	}
+	descriptors := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, file := range request.ProtoFile {
+		descriptors[file.GetName()] = file
+	}
+	for _, name := range request.FileToGenerate {
+		descriptor, ok := descriptors[name]
+		if !ok {
+			response.Error = proto.String(fmt.Sprintf("%s is not part of the request", name))
+			return &response
+		}
+		files := []*ProtoFile{FromFileDescriptor(descriptor)}
+		seen := map[string]bool{name: true}
+		queue := append([]string{}, descriptor.Dependency...)
+		for len(queue) != 0 {
+			dependency := queue[0]
+			queue = queue[1:]
+			if seen[dependency] || descriptors[dependency] == nil {
+				continue
+			}
+			seen[dependency] = true
+			files = append(files, FromFileDescriptor(descriptors[dependency]))
+			queue = append(queue, descriptors[dependency].Dependency...)
+		}
+		root := parameters["root"]
+		if len(root) == 0 {
+			root = RootMessage(files[0])
+		}
+		output := strings.TrimSuffix(name, ".proto") + ".schema.json"
+		id := ""
+		if prefix, ok := parameters["id_prefix"]; ok {
+			id = strings.TrimSuffix(prefix, "/") + "/" + output
+		}
+		schema := NewProtoReverser(files, func(diagnostic Diagnostic) {
+			diagnostic.Subject = name + ": " + diagnostic.Subject
+			diagnosticHandler(diagnostic)
+		}).Schema(id, root)
+		response.File = append(response.File, &pluginpb.CodeGeneratorResponse_File{Name: proto.String(output), Content: proto.String(string(schema) + "\n")})
+		if parameters["lint"] != "true" && parameters["strict"] != "true" {
+			continue
+		}
+		diagnostics, err := LintRoundTrip(files[0], schema)
+		if err != nil {
+			response.Error = proto.String(fmt.Sprintf("%s: %s", name, err))
+			return &response
+		}
+		failures := 0
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Severity == ERROR || (parameters["strict"] == "true" && diagnostic.Severity == WARNING) {
+				failures++
+			}
+			diagnostic.Subject = name + ": " + diagnostic.Subject
+			diagnosticHandler(diagnostic)
+		}
+		if parameters["lint"] == "true" {
+			response.File = append(response.File, &pluginpb.CodeGeneratorResponse_File{Name: proto.String(strings.TrimSuffix(name, ".proto") + ".lint.json"), Content: proto.String(string(EncodeJson(diagnostics)) + "\n")})
+		}
+		if failures != 0 && parameters["strict"] == "true" {
+			response.Error = proto.String(fmt.Sprintf("%s: %d lint failures", name, failures))
+			return &response
+		}
+	}
+	return &response
+}
+
+func LintRoundTrip(file *ProtoFile, schema []byte) ([]Diagnostic, error) {
+	diagnostics, _, err := LintSchema(schema, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := ConvertSchema(schema, file.Package, DefaultOptions(), func(diagnostic Diagnostic) {})
+	if err != nil {
+		return append(diagnostics, Diagnostic{Severity: ERROR, Subject: file.Package, Message: fmt.Sprintf("the json schema does not convert back: %s", err)}), nil
+	}
+	messages, enums := protoDefinitions(converted)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			enum, ok := enums[definition.Enum.Name]
+			if !ok {
+				diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: definition.Enum.Name, Message: "enum does not survive the round trip through json schema"})
+				continue
+			}
+			values := make(map[string]bool)
+			for _, value := range enum.Values {
+				values[value.Name] = true
+			}
+			for _, value := range definition.Enum.Values {
+				if !values[value.Name] {
+					diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: definition.Enum.Name + "." + value.Name, Message: "enum value does not survive the round trip through json schema"})
+				}
+			}
+			continue
+		}
+		message, ok := messages[definition.Message.Name]
+		if !ok {
+			diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: definition.Message.Name, Message: "message does not survive the round trip through json schema"})
+			continue
+		}
+		fields := make(map[string]*ProtoField)
+		for _, field := range message.Fields {
+			fields[field.Name] = field
+		}
+		for _, field := range definition.Message.Fields {
+			subject := definition.Message.Name + "." + field.Name
+			_field, ok := fields[field.Name]
+			if !ok {
+				diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: subject, Message: "field does not survive the round trip through json schema"})
+				continue
+			}
+			typeName := strings.TrimPrefix(strings.TrimPrefix(field.Type, "."+file.Package+"."), ".")
+			if typeName != strings.TrimPrefix(_field.Type, ".") {
+				diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("type changes from %s to %s in the round trip through json schema", typeName, _field.Type)})
+			}
+			if protoCardinality(field) != protoCardinality(_field) {
+				diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("cardinality changes from %s to %s in the round trip through json schema", protoCardinality(field), protoCardinality(_field))})
+			}
+			if field.Oneof != _field.Oneof {
+				diagnostics = append(diagnostics, Diagnostic{Severity: WARNING, Subject: subject, Message: fmt.Sprintf("oneof changes from %q to %q in the round trip through json schema", field.Oneof, _field.Oneof)})
+			}
+		}
+	}
+	return diagnostics, nil
+}
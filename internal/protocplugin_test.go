@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const PLUGIN_TEST_PROTO = `syntax = "proto3";
+
+package acme.orders.v1;
+
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  string id = 1;
+  google.protobuf.Timestamp created = 2;
+  repeated Item items = 3;
+}
+
+message Item {
+  string sku = 1;
+  %s
+}
+`
+
+func newTestPluginRequest(t *testing.T, field string, parameter string) *pluginpb.CodeGeneratorRequest {
+	t.Helper()
+	files, err := CompileSource("acme/orders.proto", fmt.Sprintf(PLUGIN_TEST_PROTO, field))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCodeGeneratorRequest(files, parameter)
+}
+
+func TestParsePluginParameters(t *testing.T) {
+	tests := []struct {
+		parameter string
+		expected  string
+	}{
+		{"", "map[]"},
+		{"lint", "map[lint:true]"},
+		{"root=Item, id_prefix=https://acme.com/a=b,,strict", "map[id_prefix:https://acme.com/a=b root:Item strict:true]"},
+	}
+	for _, test := range tests {
+		if output := fmt.Sprint(ParsePluginParameters(test.parameter)); output != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.parameter, test.expected, output)
+		}
+	}
+}
+
+func TestGenerateJsonSchemas(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		parameter string
+		files     string
+		id        string
+		ref       string
+		lint      string
+		failures  int
+		error     string
+	}{
+		{name: "default", parameter: "", files: "acme/orders.schema.json", ref: "#/$defs/Order"},
+		{name: "root and id", parameter: "root=Item,id_prefix=https://acme.com/schemas/", files: "acme/orders.schema.json", id: "https://acme.com/schemas/acme/orders.schema.json", ref: "#/$defs/Item"},
+		{name: "lint", parameter: "lint", files: "acme/orders.schema.json acme/orders.lint.json", ref: "#/$defs/Order", lint: "[warning: Order.created: type changes from google.protobuf.Timestamp to string in the round trip through json schema]"},
+		{name: "lint errors", field: "uint64 total = 2;", parameter: "lint", files: "acme/orders.schema.json acme/orders.lint.json", ref: "#/$defs/Order", lint: "[error: #/$defs/Item/properties/total/type: type lists [integer string] are not supported, declare a single type or an anyOf of typed schemas error: acme.orders.v1: the json schema does not convert back: json: cannot unmarshal array into Go struct field alias.type of type internal.Types]", failures: 2},
+		{name: "strict", field: "uint64 total = 2;", parameter: "strict", files: "acme/orders.schema.json", failures: 2, error: "acme/orders.proto: 2 lint failures"},
+		{name: "unknown parameter", parameter: "lint,pretty", error: "unknown parameter pretty, expected root, id_prefix, lint or strict"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			failures := 0
+			response := GenerateJsonSchemas(newTestPluginRequest(t, test.field, test.parameter), func(diagnostic Diagnostic) {
+				if !strings.HasPrefix(diagnostic.Subject, "acme/orders.proto: ") {
+					t.Errorf("the diagnostic is not attributed to its file: %s", diagnostic.String())
+				}
+				if diagnostic.Severity == ERROR {
+					failures++
+				}
+			})
+			if response.GetError() != test.error || failures != test.failures {
+				t.Fatalf("expected the error %q and %d failures, got %q and %d", test.error, test.failures, response.GetError(), failures)
+			}
+			if response.GetSupportedFeatures() != uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) {
+				t.Fatalf("proto3 optional is not advertised")
+			}
+			names := make([]string, 0)
+			for _, file := range response.File {
+				names = append(names, file.GetName())
+			}
+			if strings.Join(names, " ") != test.files {
+				t.Fatalf("expected the files %q, got %q", test.files, names)
+			}
+			if len(response.File) == 0 || len(test.error) != 0 {
+				return
+			}
+			schema := struct {
+				Id   string                     `json:"$id"`
+				Ref  string                     `json:"$ref"`
+				Defs map[string]json.RawMessage `json:"$defs"`
+			}{}
+			if err := json.Unmarshal([]byte(response.File[0].GetContent()), &schema); err != nil {
+				t.Fatal(err)
+			}
+			if schema.Id != test.id || schema.Ref != test.ref || len(schema.Defs) != 2 {
+				t.Fatalf("unexpected schema:\n%s", response.File[0].GetContent())
+			}
+			if len(test.lint) == 0 {
+				return
+			}
+			diagnostics := make([]Diagnostic, 0)
+			if err := json.Unmarshal([]byte(response.File[1].GetContent()), &diagnostics); err != nil {
+				t.Fatal(err)
+			}
+			if output := fmt.Sprint(diagnostics); output != test.lint {
+				t.Fatalf("expected the lint findings %s, got %s", test.lint, output)
+			}
+		})
+	}
+}
+
+func TestGenerateJsonSchemasMissingFile(t *testing.T) {
+	request := newTestPluginRequest(t, "", "")
+	request.FileToGenerate = append(request.FileToGenerate, "acme/missing.proto")
+	response := GenerateJsonSchemas(request, func(Diagnostic) {})
+	if response.GetError() != "acme/missing.proto is not part of the request" {
+		t.Fatalf("unexpected error %q", response.GetError())
+	}
+}
@@ -0,0 +1,347 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type protoTokenizer struct {
+	tokens   []string
+	position int
+}
+
+func tokenizeProto(data []byte) []string {
+	input := []rune(string(data))
+	tokens := make([]string, 0)
+	for i := 0; i < len(input); {
+		value := input[i]
+		if unicode.IsSpace(value) {
+			i++
+			continue
+		}
+		if value == '/' && i+1 < len(input) && input[i+1] == '/' {
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		if value == '/' && i+1 < len(input) && input[i+1] == '*' {
+			i += 2
+			for i+1 < len(input) && !(input[i] == '*' && input[i+1] == '/') {
+				i++
+			}
+			i += 2
+			continue
+		}
+		if value == '"' || value == '\'' {
+			start := i
+			i++
+			for i < len(input) && input[i] != value {
+				if input[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			if i > len(input) {
+				i = len(input)
+			}
+			tokens = append(tokens, string(input[start:i]))
+			continue
+		}
+		if value == '_' || value == '.' || unicode.IsLetter(value) || unicode.IsDigit(value) {
+			start := i
+			for i < len(input) && (input[i] == '_' || input[i] == '.' || unicode.IsLetter(input[i]) || unicode.IsDigit(input[i])) {
+				i++
+			}
+			tokens = append(tokens, string(input[start:i]))
+			continue
+		}
+		tokens = append(tokens, string(value))
+		i++
+	}
+	return tokens
+}
+
+func (tokenizer *protoTokenizer) peek() string {
+	if tokenizer.position >= len(tokenizer.tokens) {
+		return ""
+	}
+	return tokenizer.tokens[tokenizer.position]
+}
+
+func (tokenizer *protoTokenizer) next() string {
+	token := tokenizer.peek()
+	if tokenizer.position >= len(tokenizer.tokens) {
+		panic("unexpected end of proto file")
+	}
+	tokenizer.position++
+	return token
+}
+
+func (tokenizer *protoTokenizer) expect(expected string) {
+	token := tokenizer.next()
+	if token != expected {
+		panic(fmt.Sprintf("expected %s but found %s in proto file", expected, token))
+	}
+}
+
+func (tokenizer *protoTokenizer) number() int {
+	token := tokenizer.next()
+	sign := 1
+	if token == "-" {
+		sign = -1
+		token = tokenizer.next()
+	}
+	value, err := strconv.ParseInt(token, 0, 64)
+	if err != nil {
+		panic(fmt.Sprintf("expected a number but found %s in proto file", token))
+	}
+	return sign * int(value)
+}
+
+func (tokenizer *protoTokenizer) skipStatement() {
+	depth := 0
+	for {
+		token := tokenizer.next()
+		switch token {
+		case "{", "[", "(":
+			{
+				depth++
+			}
+		case "}", "]", ")":
+			{
+				depth--
+				if depth == 0 && token == "}" {
+					return
+				}
+			}
+		case ";":
+			{
+				if depth == 0 {
+					return
+				}
+			}
+		}
+	}
+}
+
+func ParseProto(data []byte) *ProtoFile {
+	tokenizer := protoTokenizer{tokens: tokenizeProto(data)}
+	output := ProtoFile{}
+	for tokenizer.peek() != "" {
+		switch tokenizer.next() {
+		case "package":
+			{
+				output.Package = tokenizer.next()
+				tokenizer.expect(";")
+			}
+		case "import":
+			{
+				token := tokenizer.next()
+				if token == "public" || token == "weak" {
+					token = tokenizer.next()
+				}
+				output.Imports = append(output.Imports, unquote(token))
+				tokenizer.expect(";")
+			}
+		case "message":
+			{
+				output.Definitions = append(output.Definitions, tokenizer.parseMessage("")...)
+			}
+		case "enum":
+			{
+				output.Definitions = append(output.Definitions, ProtoDefinition{Enum: tokenizer.parseEnum("")})
+			}
+		case ";":
+			{
+				continue
+			}
+		default:
+			{
+				tokenizer.skipStatement()
+			}
+		}
+	}
+	return &output
+}
+
+func (tokenizer *protoTokenizer) parseMessage(prefix string) []ProtoDefinition {
+	message := ProtoMessage{Name: prefix + tokenizer.next()}
+	output := []ProtoDefinition{{Message: &message}}
+	tokenizer.expect("{")
+	for {
+		token := tokenizer.next()
+		switch token {
+		case "}":
+			{
+				return output
+			}
+		case ";":
+			{
+				continue
+			}
+		case "message":
+			{
+				output = append(output, tokenizer.parseMessage(message.Name+".")...)
+			}
+		case "enum":
+			{
+				output = append(output, ProtoDefinition{Enum: tokenizer.parseEnum(message.Name + ".")})
+			}
+		case "oneof":
+			{
+				name := tokenizer.next()
+				tokenizer.expect("{")
+				for tokenizer.peek() != "}" {
+					token := tokenizer.next()
+					if token == "option" {
+						tokenizer.skipStatement()
+						continue
+					}
+					if token == ";" {
+						continue
+					}
+					field := tokenizer.parseField(token)
+					field.Oneof = name
+					message.Fields = append(message.Fields, field)
+				}
+				tokenizer.expect("}")
+			}
+		case "reserved":
+			{
+				tokenizer.parseReserved(&message.ProtoReserved)
+			}
+		case "option", "extensions", "extend":
+			{
+				tokenizer.skipStatement()
+			}
+		default:
+			{
+				message.Fields = append(message.Fields, tokenizer.parseField(token))
+			}
+		}
+	}
+}
+
+func (tokenizer *protoTokenizer) parseField(token string) *ProtoField {
+	field := ProtoField{}
+	if token == string(OPTIONAL_LABEL) || token == string(REPEATED_LABEL) || token == "required" {
+		if token != "required" {
+			field.Label = Label(token)
+		}
+		token = tokenizer.next()
+	}
+	if token == "map" {
+		tokenizer.expect("<")
+		key := tokenizer.next()
+		tokenizer.expect(",")
+		value := tokenizer.next()
+		tokenizer.expect(">")
+		token = fmt.Sprintf("map<%s, %s>", key, value)
+	}
+	field.Type = token
+	field.Name = tokenizer.next()
+	tokenizer.expect("=")
+	field.Number = tokenizer.number()
+	if tokenizer.peek() == "[" {
+		tokenizer.next()
+		for tokenizer.peek() != "]" {
+			name := tokenizer.next()
+			if name == "," {
+				continue
+			}
+			tokenizer.expect("=")
+			value := tokenizer.next()
+			if name == "json_name" {
+				field.JsonName = unquote(value)
+			}
+		}
+		tokenizer.expect("]")
+	}
+	tokenizer.expect(";")
+	return &field
+}
+
+func (tokenizer *protoTokenizer) parseEnum(prefix string) *ProtoEnum {
+	enum := ProtoEnum{Name: prefix + tokenizer.next()}
+	tokenizer.expect("{")
+	for {
+		token := tokenizer.next()
+		switch token {
+		case "}":
+			{
+				return &enum
+			}
+		case ";":
+			{
+				continue
+			}
+		case "reserved":
+			{
+				tokenizer.parseReserved(&enum.ProtoReserved)
+			}
+		case "option":
+			{
+				if tokenizer.peek() == "allow_alias" {
+					enum.AllowAlias = true
+				}
+				tokenizer.skipStatement()
+			}
+		default:
+			{
+				tokenizer.expect("=")
+				value := ProtoEnumValue{Name: token, Number: tokenizer.number()}
+				if tokenizer.peek() == "[" {
+					tokenizer.skipStatement()
+				} else {
+					tokenizer.expect(";")
+				}
+				enum.Values = append(enum.Values, &value)
+			}
+		}
+	}
+}
+
+func (tokenizer *protoTokenizer) parseReserved(reserved *ProtoReserved) {
+	for {
+		token := tokenizer.peek()
+		if token == ";" {
+			tokenizer.next()
+			return
+		}
+		if token == "," {
+			tokenizer.next()
+			continue
+		}
+		if strings.HasPrefix(token, "\"") || strings.HasPrefix(token, "'") {
+			reserved.ReservedNames = append(reserved.ReservedNames, unquote(tokenizer.next()))
+			continue
+		}
+		start := tokenizer.number()
+		end := start
+		if tokenizer.peek() == "to" {
+			tokenizer.next()
+			if tokenizer.peek() == "max" {
+				tokenizer.next()
+				end = MAX_FIELD_NUMBER
+			} else {
+				end = tokenizer.number()
+			}
+		}
+		reserved.ReservedRanges = append(reserved.ReservedRanges, ProtoRange{Start: start, End: end})
+	}
+}
+
+func unquote(str string) string {
+	if len(str) >= 2 && (str[0] == '"' || str[0] == '\'') {
+		value, err := strconv.Unquote("\"" + strings.ReplaceAll(str[1:len(str)-1], "\"", "\\\"") + "\"")
+		if err == nil {
+			return value
+		}
+		return str[1 : len(str)-1]
+	}
+	return str
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var BUF_DEPENDENCIES = map[string]string{
+	"google/api/":       "buf.build/googleapis/googleapis",
+	"google/type/":      "buf.build/googleapis/googleapis",
+	"google/rpc/":       "buf.build/googleapis/googleapis",
+	"buf/validate/":     "buf.build/bufbuild/protovalidate",
+	PGV_VALIDATE_IMPORT: "buf.build/envoyproxy/protoc-gen-validate",
+}
+
+func BufDependencies(files map[string]string) []string {
+	dependencies := make(map[string]bool)
+	for _, content := range files {
+		for _, line := range strings.Split(content, "\n") {
+			if !strings.HasPrefix(line, "import \"") {
+				continue
+			}
+			importPath := strings.TrimSuffix(strings.TrimPrefix(line, "import \""), "\";")
+			for prefix, dependency := range BUF_DEPENDENCIES {
+				if strings.HasPrefix(importPath, prefix) {
+					dependencies[dependency] = true
+				}
+			}
+		}
+	}
+	output := make([]string, 0)
+	for dependency := range dependencies {
+		output = append(output, dependency)
+	}
+	sort.Strings(output)
+	return output
+}
+
+func BufYaml(module string, dependencies []string) string {
+	var builder strings.Builder
+	builder.WriteString("version: v1\n")
+	builder.WriteString(fmt.Sprintf("name: %s\n", module))
+	if len(dependencies) != 0 {
+		builder.WriteString("deps:\n")
+		for _, dependency := range dependencies {
+			builder.WriteString(fmt.Sprintf("  - %s\n", dependency))
+		}
+	}
+	return builder.String()
+}
+
+func RunBuf(buf string, directory string, args ...string) error {
+	if strings.ContainsRune(buf, filepath.Separator) || strings.ContainsRune(buf, '/') {
+		if absolute, err := filepath.Abs(buf); err == nil {
+			buf = absolute
+		}
+	}
+	command := exec.Command(buf, args...)
+	command.Dir = directory
+	command.Stdout = os.Stderr
+	command.Stderr = os.Stderr
+	err := command.Run()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", buf, strings.Join(args, " "), err)
+	}
+	return nil
+}
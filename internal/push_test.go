@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBufDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{"none", map[string]string{"a.proto": "syntax = \"proto3\";\n\nimport \"google/protobuf/timestamp.proto\";\nimport \"b.proto\";\n"}, ""},
+		{"googleapis", map[string]string{"a.proto": "import \"google/api/annotations.proto\";\n", "b.proto": "import \"google/type/money.proto\";\nimport \"google/rpc/status.proto\";\n"}, "buf.build/googleapis/googleapis"},
+		{"validate", map[string]string{"a.proto": "import \"buf/validate/validate.proto\";\n", "b.proto": "import \"validate/validate.proto\";\n"}, "buf.build/bufbuild/protovalidate,buf.build/envoyproxy/protoc-gen-validate"},
+		{"comment", map[string]string{"a.proto": "// import \"google/api/annotations.proto\";\n"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if output := strings.Join(BufDependencies(test.files), ","); output != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestBufYaml(t *testing.T) {
+	if output := BufYaml("buf.build/acme/orders", nil); output != "version: v1\nname: buf.build/acme/orders\n" {
+		t.Fatalf("unexpected buf.yaml:\n%s", output)
+	}
+	expected := "version: v1\nname: buf.build/acme/orders\ndeps:\n  - buf.build/bufbuild/protovalidate\n  - buf.build/googleapis/googleapis\n"
+	if output := BufYaml("buf.build/acme/orders", []string{"buf.build/bufbuild/protovalidate", "buf.build/googleapis/googleapis"}); output != expected {
+		t.Fatalf("unexpected buf.yaml:\n%s", output)
+	}
+}
+
+func TestRunBuf(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake buf is a shell script")
+	}
+	root := t.TempDir()
+	directory := filepath.Join(root, "module")
+	if err := os.Mkdir(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$(pwd -P) $*\" > args.txt\n[ \"$1\" != fail ]\n"
+	if err := os.WriteFile(filepath.Join(root, "buf"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	working, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(working) })
+	if err := RunBuf("./buf", directory, "push", "--label", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	args, err := os.ReadFile(filepath.Join(directory, "args.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := filepath.EvalSymlinks(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(args) != resolved+" push --label v1\n" {
+		t.Fatalf("buf ran with unexpected arguments %q", args)
+	}
+	if err := RunBuf("./buf", directory, "fail"); err == nil || !strings.Contains(err.Error(), "buf fail: exit status 1") {
+		t.Fatalf("expected the buf failure, got %v", err)
+	}
+}
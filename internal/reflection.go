@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type DescriptorStore struct {
+	mutex    sync.RWMutex
+	files    map[string]*descriptorpb.FileDescriptorProto
+	registry *protoregistry.Files
+	types    *dynamicpb.Types
+}
+
+func NewDescriptorStore() *DescriptorStore {
+	registry := new(protoregistry.Files)
+	return &DescriptorStore{files: make(map[string]*descriptorpb.FileDescriptorProto), registry: registry, types: dynamicpb.NewTypes(registry)}
+}
+
+func (rcvr *DescriptorStore) Add(set *descriptorpb.FileDescriptorSet) error {
+	rcvr.mutex.Lock()
+	defer rcvr.mutex.Unlock()
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	for name, file := range rcvr.files {
+		files[name] = file
+	}
+	for _, file := range set.File {
+		files[file.GetName()] = file
+	}
+	_set := descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		_set.File = append(_set.File, file)
+	}
+	registry, err := protodesc.NewFiles(&_set)
+	if err != nil {
+		return fmt.Errorf("descriptors conflict with earlier conversions: %w", err)
+	}
+	rcvr.files = files
+	rcvr.registry = registry
+	rcvr.types = dynamicpb.NewTypes(registry)
+	return nil
+}
+
+func (rcvr *DescriptorStore) Services() []string {
+	rcvr.mutex.RLock()
+	defer rcvr.mutex.RUnlock()
+	output := make([]string, 0)
+	rcvr.registry.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			output = append(output, string(services.Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(output)
+	return output
+}
+
+func (rcvr *DescriptorStore) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	rcvr.mutex.RLock()
+	defer rcvr.mutex.RUnlock()
+	return rcvr.registry.FindFileByPath(path)
+}
+
+func (rcvr *DescriptorStore) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	rcvr.mutex.RLock()
+	defer rcvr.mutex.RUnlock()
+	return rcvr.registry.FindDescriptorByName(name)
+}
+
+func (rcvr *DescriptorStore) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	rcvr.mutex.RLock()
+	defer rcvr.mutex.RUnlock()
+	return rcvr.types.FindExtensionByName(field)
+}
+
+func (rcvr *DescriptorStore) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	rcvr.mutex.RLock()
+	defer rcvr.mutex.RUnlock()
+	return rcvr.types.FindExtensionByNumber(message, field)
+}
+
+func (rcvr *DescriptorStore) RangeExtensionsByMessage(message protoreflect.FullName, f func(protoreflect.ExtensionType) bool) {
+	rcvr.mutex.RLock()
+	registry := rcvr.registry
+	rcvr.mutex.RUnlock()
+	var visit func(extensions protoreflect.ExtensionDescriptors, messages protoreflect.MessageDescriptors) bool
+	visit = func(extensions protoreflect.ExtensionDescriptors, messages protoreflect.MessageDescriptors) bool {
+		for i := 0; i < extensions.Len(); i++ {
+			if extensions.Get(i).ContainingMessage().FullName() == message && !f(dynamicpb.NewExtensionType(extensions.Get(i))) {
+				return false
+			}
+		}
+		for i := 0; i < messages.Len(); i++ {
+			if !visit(messages.Get(i).Extensions(), messages.Get(i).Messages()) {
+				return false
+			}
+		}
+		return true
+	}
+	registry.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		return visit(file.Extensions(), file.Messages())
+	})
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const SERVICE_TEST_SCHEMA = `{"title": "Order", "type": "object", "properties": {"id": {"type": "string"}, "status": {"enum": ["open", "closed"]}}}`
+
+func TestDescriptorStoreConcurrency(t *testing.T) {
+	store := NewDescriptorStore()
+	var group sync.WaitGroup
+	errors := make(chan error, 32)
+	for index := 0; index < 16; index++ {
+		group.Add(2)
+		go func(index int) {
+			defer group.Done()
+			packageName := fmt.Sprintf("acme.v%d", index)
+			response := Convert(ConversionRequest{Schema: []byte(SERVICE_TEST_SCHEMA), Package: packageName}, DefaultOptions(), store)
+			if len(response.Error) != 0 {
+				errors <- fmt.Errorf("%s: %s", packageName, response.Error)
+			}
+		}(index)
+		go func() {
+			defer group.Done()
+			store.Services()
+			store.FindDescriptorByName("acme.v0.Order")
+			store.RangeExtensionsByMessage("acme.v0.Order", func(protoreflect.ExtensionType) bool {
+				return true
+			})
+		}()
+	}
+	group.Wait()
+	close(errors)
+	for err := range errors {
+		t.Fatal(err)
+	}
+	for index := 0; index < 16; index++ {
+		if _, err := store.FindDescriptorByName(protoreflect.FullName(fmt.Sprintf("acme.v%d.Order", index))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDescriptorStoreConflicts(t *testing.T) {
+	store := NewDescriptorStore()
+	if response := Convert(ConversionRequest{Schema: []byte(SERVICE_TEST_SCHEMA), Package: "acme.v1"}, DefaultOptions(), store); len(response.Error) != 0 {
+		t.Fatal(response.Error)
+	}
+	other := `{"title": "Order", "type": "object", "properties": {"sku": {"type": "string"}}}`
+	response := Convert(ConversionRequest{Schema: []byte(other), Package: "acme.v1", Name: "other.proto"}, DefaultOptions(), store)
+	if len(response.Error) != 0 || len(response.Diagnostics) == 0 || response.Diagnostics[len(response.Diagnostics)-1].Severity != WARNING {
+		t.Fatalf("a conflicting message was not reported: %+v", response)
+	}
+	if _, err := store.FindFileByPath("other.proto"); err == nil {
+		t.Fatal("a conflicting file was added to the store")
+	}
+	if _, err := store.FindFileByPath("acme/v1/schema.proto"); err != nil {
+		t.Fatalf("the store lost its earlier files after a conflict: %v", err)
+	}
+}
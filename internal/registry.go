@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const REGISTRY_CONTENT_TYPE = "application/vnd.schemaregistry.v1+json"
+
+type RegistryClient struct {
+	Url      string
+	Username string
+	Password string
+	client   http.Client
+}
+
+type RegistrySchema struct {
+	Subject    string              `json:"subject,omitempty"`
+	ID         int                 `json:"id,omitempty"`
+	Version    int                 `json:"version,omitempty"`
+	SchemaType string              `json:"schemaType,omitempty"`
+	Schema     string              `json:"schema"`
+	References []RegistryReference `json:"references,omitempty"`
+}
+
+type RegistryReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+type RegistryError struct {
+	Status    int    `json:"-"`
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (err *RegistryError) Error() string {
+	return fmt.Sprintf("schema registry error %d: %s", err.ErrorCode, err.Message)
+}
+
+func NewRegistryClient(registryUrl string, username string, password string) *RegistryClient {
+	return &RegistryClient{Url: strings.TrimSuffix(registryUrl, "/"), Username: username, Password: password, client: http.Client{Timeout: 30 * time.Second}}
+}
+
+func (client *RegistryClient) do(method string, endpoint string, body any, output any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(method, client.Url+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", REGISTRY_CONTENT_TYPE)
+	if body != nil {
+		request.Header.Set("Content-Type", REGISTRY_CONTENT_TYPE)
+	}
+	if len(client.Username) != 0 {
+		request.SetBasicAuth(client.Username, client.Password)
+	}
+	response, err := client.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode/100 != 2 {
+		registryError := RegistryError{Status: response.StatusCode}
+		if json.Unmarshal(data, &registryError) != nil || len(registryError.Message) == 0 {
+			registryError.ErrorCode = response.StatusCode
+			registryError.Message = strings.TrimSpace(string(data))
+		}
+		return &registryError
+	}
+	return json.Unmarshal(data, output)
+}
+
+func (client *RegistryClient) Schema(subject string, version string) (*RegistrySchema, error) {
+	output := RegistrySchema{}
+	err := client.do(http.MethodGet, fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version)), nil, &output)
+	if err != nil {
+		return nil, fmt.Errorf("%s version %s: %w", subject, version, err)
+	}
+	return &output, nil
+}
+
+func (client *RegistryClient) Compatibility(subject string) (string, error) {
+	output := struct {
+		CompatibilityLevel string `json:"compatibilityLevel"`
+	}{}
+	err := client.do(http.MethodGet, fmt.Sprintf("/config/%s?defaultToGlobal=true", url.PathEscape(subject)), nil, &output)
+	if registryError, ok := err.(*RegistryError); ok && registryError.Status == http.StatusNotFound {
+		err = client.do(http.MethodGet, "/config", nil, &output)
+	}
+	return output.CompatibilityLevel, err
+}
+
+func (client *RegistryClient) CheckCompatibility(subject string, schema RegistrySchema) (bool, []string, error) {
+	output := struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}{}
+	err := client.do(http.MethodPost, fmt.Sprintf("/compatibility/subjects/%s/versions/latest?verbose=true", url.PathEscape(subject)), schema, &output)
+	if registryError, ok := err.(*RegistryError); ok && registryError.Status == http.StatusNotFound {
+		return true, nil, nil
+	}
+	return output.IsCompatible, output.Messages, err
+}
+
+func (client *RegistryClient) Register(subject string, schema RegistrySchema) (int, error) {
+	output := struct {
+		ID int `json:"id"`
+	}{}
+	err := client.do(http.MethodPost, fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject)), schema, &output)
+	return output.ID, err
+}
+
+func (client *RegistryClient) Resolve(schema *RegistrySchema, diagnosticHandler DiagnosticHandler) ([]byte, error) {
+	if len(schema.SchemaType) != 0 && schema.SchemaType != "JSON" {
+		return nil, fmt.Errorf("%s version %d is a %s schema, not a json schema", schema.Subject, schema.Version, schema.SchemaType)
+	}
+	documents := make(map[string][]byte)
+	locations := make(map[string]string)
+	var collect func(schema *RegistrySchema) error
+	collect = func(schema *RegistrySchema) error {
+		for _, reference := range schema.References {
+			location := fmt.Sprintf("%s/subjects/%s/versions/%d/%s", client.Url, url.PathEscape(reference.Subject), reference.Version, path.Base(reference.Name))
+			if _, ok := documents[location]; ok {
+				locations[reference.Name] = location
+				continue
+			}
+			referenced, err := client.Schema(reference.Subject, fmt.Sprint(reference.Version))
+			if err != nil {
+				return err
+			}
+			documents[location] = nil
+			err = collect(referenced)
+			if err != nil {
+				return err
+			}
+			locations[reference.Name] = location
+			documents[location] = []byte(referenced.Schema)
+		}
+		return nil
+	}
+	err := collect(schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return []byte(schema.Schema), nil
+	}
+	rewrite := func(data []byte) []byte {
+		document, err := DecodeJson(data)
+		if err != nil {
+			return data
+		}
+		rewriteRegistryRefs(document, locations)
+		return EncodeJson(document)
+	}
+	fetch := func(source string) ([]byte, error) {
+		if data, ok := documents[source]; ok {
+			return rewrite(data), nil
+		}
+		return DefaultFetcher(source)
+	}
+	return Vendor(rewrite([]byte(schema.Schema)), "", false, &VendorLock{Sources: make(map[string]string)}, fetch, diagnosticHandler), nil
+}
+
+func rewriteRegistryRefs(node any, locations map[string]string) {
+	switch value := node.(type) {
+	case *JsonObject:
+		{
+			for _, key := range value.Keys {
+				if ref, ok := value.Values[key].(string); ok && key == "$ref" {
+					name, fragment, _ := strings.Cut(ref, "#")
+					if location, ok := locations[name]; ok {
+						value.Values[key] = location + "#" + fragment
+					}
+					continue
+				}
+				rewriteRegistryRefs(value.Values[key], locations)
+			}
+		}
+	case []any:
+		{
+			for _, item := range value {
+				rewriteRegistryRefs(item, locations)
+			}
+		}
+	}
+}
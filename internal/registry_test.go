@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	schemas := map[string]RegistrySchema{
+		"/subjects/order-value/versions/latest": {Subject: "order-value", Version: 3, Schema: `{"title": "Order", "type": "object", "properties": {"ship": {"$ref": "address.json"}, "bill": {"$ref": "address.json#"}}}`, References: []RegistryReference{{Name: "address.json", Subject: "address", Version: 1}}},
+		"/subjects/address/versions/1":          {Subject: "address", Version: 1, Schema: `{"type": "object", "properties": {"country": {"$ref": "country.json"}}}`, References: []RegistryReference{{Name: "country.json", Subject: "country", Version: 2}}},
+		"/subjects/country/versions/2":          {Subject: "country", Version: 2, Schema: `{"type": "string", "enum": ["de", "fr"]}`},
+		"/subjects/order-avro/versions/1":       {Subject: "order-avro", Version: 1, SchemaType: "AVRO", Schema: `{"type": "record"}`},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Accept") != REGISTRY_CONTENT_TYPE {
+			t.Errorf("unexpected accept header %q", request.Header.Get("Accept"))
+		}
+		writer.Header().Set("Content-Type", REGISTRY_CONTENT_TYPE)
+		switch {
+		case request.Method == http.MethodGet && strings.HasPrefix(request.URL.Path, "/subjects/"):
+			{
+				schema, ok := schemas[request.URL.EscapedPath()]
+				if !ok {
+					writer.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(writer, `{"error_code": 40401, "message": "Subject not found"}`)
+					return
+				}
+				json.NewEncoder(writer).Encode(schema)
+			}
+		case request.URL.Path == "/config/order-value":
+			{
+				writer.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(writer, `{"error_code": 40401, "message": "Subject not found"}`)
+			}
+		case request.URL.Path == "/config":
+			{
+				fmt.Fprint(writer, `{"compatibilityLevel": "BACKWARD"}`)
+			}
+		case request.URL.Path == "/compatibility/subjects/order-value/versions/latest":
+			{
+				if request.URL.Query().Get("verbose") != "true" || request.Header.Get("Content-Type") != REGISTRY_CONTENT_TYPE {
+					t.Errorf("unexpected compatibility request %s", request.URL)
+				}
+				fmt.Fprint(writer, `{"is_compatible": false, "messages": ["property ship removed"]}`)
+			}
+		case request.URL.Path == "/compatibility/subjects/new-value/versions/latest":
+			{
+				writer.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(writer, `{"error_code": 40401, "message": "Subject not found"}`)
+			}
+		case request.Method == http.MethodPost && request.URL.Path == "/subjects/order-value/versions":
+			{
+				if username, password, ok := request.BasicAuth(); !ok || username != "key" || password != "secret" {
+					writer.WriteHeader(http.StatusUnauthorized)
+					fmt.Fprint(writer, "Unauthorized")
+					return
+				}
+				schema := RegistrySchema{}
+				if err := json.NewDecoder(request.Body).Decode(&schema); err != nil || schema.SchemaType != "JSON" {
+					t.Errorf("unexpected registration %+v, %v", schema, err)
+				}
+				fmt.Fprint(writer, `{"id": 7}`)
+			}
+		default:
+			{
+				t.Errorf("unexpected request %s %s", request.Method, request.URL)
+				writer.WriteHeader(http.StatusBadRequest)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRegistryResolve(t *testing.T) {
+	server := newTestRegistry(t)
+	client := NewRegistryClient(server.URL+"/", "", "")
+	schema, err := client.Schema("order-value", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := client.Resolve(schema, func(Diagnostic) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := struct {
+		Properties map[string]struct {
+			Ref string `json:"$ref"`
+		} `json:"properties"`
+		Defs map[string]struct {
+			Properties map[string]struct {
+				Ref string `json:"$ref"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}{}
+	if err := json.Unmarshal(data, &resolved); err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Properties["ship"].Ref != "#/$defs/address" || resolved.Properties["bill"].Ref != "#/$defs/address" || len(resolved.Defs) != 2 {
+		t.Fatalf("the references were not vendored:\n%s", data)
+	}
+	if resolved.Defs["address"].Properties["country"].Ref != "#/$defs/country" {
+		t.Fatalf("the nested reference was not vendored:\n%s", data)
+	}
+	output := RenderTarget(NewWithOptions(data, DefaultOptions()).Build("test"), DefaultOptions())
+	if _, err := CompileSource("order.proto", output); err != nil {
+		t.Fatalf("%v:\n%s", err, output)
+	}
+	avro, err := client.Schema("order-avro", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Resolve(avro, func(Diagnostic) {}); err == nil || !strings.Contains(err.Error(), "not a json schema") {
+		t.Fatalf("expected an error for an avro schema, got %v", err)
+	}
+	if _, err := client.Schema("missing", "1"); err == nil || !strings.Contains(err.Error(), "Subject not found") {
+		t.Fatalf("expected the registry error, got %v", err)
+	}
+}
+
+func TestRegistryCompatibility(t *testing.T) {
+	server := newTestRegistry(t)
+	client := NewRegistryClient(server.URL, "key", "secret")
+	level, err := client.Compatibility("order-value")
+	if err != nil || level != "BACKWARD" {
+		t.Fatalf("expected the global level, got %q, %v", level, err)
+	}
+	schema := RegistrySchema{SchemaType: "JSON", Schema: `{"type": "object"}`}
+	compatible, messages, err := client.CheckCompatibility("order-value", schema)
+	if err != nil || compatible || fmt.Sprint(messages) != "[property ship removed]" {
+		t.Fatalf("unexpected compatibility %v %v %v", compatible, messages, err)
+	}
+	if compatible, _, err := client.CheckCompatibility("new-value", schema); err != nil || !compatible {
+		t.Fatalf("a new subject is compatible, got %v %v", compatible, err)
+	}
+	if id, err := client.Register("order-value", schema); err != nil || id != 7 {
+		t.Fatalf("unexpected registration %d, %v", id, err)
+	}
+	_, err = NewRegistryClient(server.URL, "key", "wrong").Register("order-value", schema)
+	if registryError, ok := err.(*RegistryError); !ok || registryError.Status != http.StatusUnauthorized || registryError.Message != "Unauthorized" {
+		t.Fatalf("expected an unauthorized registry error, got %v", err)
+	}
+}
+
+func TestRewriteRegistryRefs(t *testing.T) {
+	locations := map[string]string{"address.json": "https://registry/subjects/address/versions/1/address.json"}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"$ref": "address.json"}`, `{"$ref":"https://registry/subjects/address/versions/1/address.json#"}`},
+		{`{"$ref": "address.json#/definitions/Street"}`, `{"$ref":"https://registry/subjects/address/versions/1/address.json#/definitions/Street"}`},
+		{`{"$ref": "#/definitions/Local"}`, `{"$ref":"#/definitions/Local"}`},
+		{`{"$ref": "other.json"}`, `{"$ref":"other.json"}`},
+		{`{"items": [{"$ref": "address.json"}], "properties": {"$ref": {"type": "string"}}}`, `{"items":[{"$ref":"https://registry/subjects/address/versions/1/address.json#"}],"properties":{"$ref":{"type":"string"}}}`},
+	}
+	for _, test := range tests {
+		document, err := DecodeJson([]byte(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rewriteRegistryRefs(document, locations)
+		output := bytes.Buffer{}
+		if err := json.Compact(&output, EncodeJson(document)); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.input, test.expected, output.String())
+		}
+	}
+}
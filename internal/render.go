@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+const FILE_TEMPLATE = `{{define "file"}}{{template "header" .}}{{range .Definitions}}
+{{if .Message}}{{template "message" .Message}}{{else if .Enum}}{{template "enum" .Enum}}{{end}}{{end}}{{range .Services}}
+{{template "service" .}}{{end}}{{end}}`
+
+const HEADER_TEMPLATE = `{{define "header"}}{{with .Metadata}}// Code generated by j2p. DO NOT EDIT.
+// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}
+{{end}}syntax = "proto3";
+
+package {{.Package}};
+{{if .Imports}}
+{{range .Imports}}import "{{.}}";
+{{end}}{{end}}{{end}}`
+
+const MESSAGE_TEMPLATE = `{{define "message"}}{{with .Comment}}// {{.}}
+{{end}}message {{.Name}} {{"{"}}{{if or .Fields .ReservedRanges .ReservedNames}}
+{{template "reserved" .}}{{range .Members}}{{if .Oneof}}{{template "oneof" .Oneof}}{{else}}{{indent 1}}{{template "field" .Field}}
+{{end}}{{end}}{{end}}}
+{{end}}`
+
+const ONEOF_TEMPLATE = `{{define "oneof"}}{{indent 1}}oneof {{.Name}} {
+{{range .Fields}}{{indent 2}}{{template "field" .}}
+{{end}}{{indent 1}}}
+{{end}}`
+
+const FIELD_TEMPLATE = `{{define "field"}}{{if .Label}}{{.Label}} {{end}}{{.Type}} {{.Name}} = {{.Number}}{{with .Options}} [{{join . ", "}}]{{end}};{{with .Comment}} // {{.}}{{end}}{{end}}`
+
+const ENUM_TEMPLATE = `{{define "enum"}}enum {{.Name}} {{"{"}}{{if or .AllowAlias .Values .ReservedRanges .ReservedNames}}
+{{if .AllowAlias}}{{indent 1}}option allow_alias = true;
+{{end}}{{template "reserved" .}}{{range .Values}}{{indent 1}}{{.Name}} = {{.Number}};
+{{end}}{{end}}}
+{{end}}`
+
+const SERVICE_TEMPLATE = `{{define "service"}}service {{.Name}} {{"{"}}{{if .Methods}}
+{{range .Methods}}{{indent 1}}{{template "method" .}}
+{{end}}{{end}}}
+{{end}}{{define "method"}}rpc {{.Name}}({{if .ClientStreaming}}stream {{end}}{{.Input}}) returns ({{if .ServerStreaming}}stream {{end}}{{.Output}}){{with .Http}} {
+{{indent 2}}option (google.api.http) = {
+{{indent 3}}{{.Pattern}}{{if .Body}}
+{{indent 3}}body: "{{.Body}}"{{end}}{{if .ResponseBody}}
+{{indent 3}}response_body: "{{.ResponseBody}}"{{end}}
+{{indent 2}}};
+{{indent 1}}}{{else}};{{end}}{{end}}`
+
+const RESERVED_TEMPLATE = `{{define "reserved"}}{{with .ReservedNumbers}}{{indent 1}}reserved {{.}};
+{{end}}{{with .ReservedNameList}}{{indent 1}}reserved {{.}};
+{{end}}{{end}}`
+
+func NewTemplate(options Options) *template.Template {
+	indent := strings.Repeat(" ", options.IndentWidth)
+	if options.IndentStyle == TAB_INDENT {
+		indent = "\t"
+	}
+	funcs := template.FuncMap{
+		"join": strings.Join,
+		"indent": func(level int) string {
+			return strings.Repeat(indent, level)
+		},
+	}
+	output := template.New("file").Funcs(funcs)
+	for _, value := range []string{FILE_TEMPLATE, HEADER_TEMPLATE, MESSAGE_TEMPLATE, ONEOF_TEMPLATE, FIELD_TEMPLATE, ENUM_TEMPLATE, SERVICE_TEMPLATE, RESERVED_TEMPLATE} {
+		template.Must(output.Parse(value))
+	}
+	if len(options.TemplateFiles) != 0 {
+		template.Must(output.ParseFiles(options.TemplateFiles...))
+	}
+	return output
+}
+
+func Render(templates *template.Template, file *ProtoFile, options Options) string {
+	var buffer bytes.Buffer
+	err := templates.ExecuteTemplate(&buffer, "file", file)
+	if err != nil {
+		panic(err)
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(buffer.String(), "\n", "\r\n")
+	}
+	return buffer.String()
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderProto(t *testing.T) {
+	options := DefaultOptions()
+	file := NewWithOptions([]byte(REQUIRED_TEST_SCHEMA), options).Build("test")
+	expected := `syntax = "proto3";
+
+package test;
+
+message Item {
+  string sku = 1;
+}
+
+message Order {
+  string id = 1;
+  Item item = 2;
+  string note = 3;
+}
+`
+	if output := RenderTarget(file, options); output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+}
+
+func TestAnyImport(t *testing.T) {
+	options := DefaultOptions()
+	build := map[string]func(string) (*ProtoFile, error){
+		"json schema": func(schema string) (*ProtoFile, error) {
+			return NewWithOptions([]byte(schema), options).Build("test"), nil
+		},
+		"avro": func(schema string) (*ProtoFile, error) {
+			parser, err := NewAvroParser([]byte(schema), options)
+			if err != nil {
+				return nil, err
+			}
+			return parser.Build("test"), nil
+		},
+		"jtd": func(schema string) (*ProtoFile, error) {
+			parser, err := NewJtdParser([]byte(schema), "Bag", options)
+			if err != nil {
+				return nil, err
+			}
+			return parser.Build("test"), nil
+		},
+	}
+	tests := []struct {
+		format string
+		schema string
+		uses   bool
+	}{
+		{"json schema", `{"title": "Bag", "type": "object", "properties": {"name": {"type": "string"}}}`, false},
+		{"json schema", `{"title": "Bag", "type": "object", "properties": {"items": {"type": "array", "items": {}}}}`, true},
+		{"avro", `{"type": "record", "name": "Bag", "fields": [{"name": "name", "type": "string"}]}`, false},
+		{"jtd", `{"properties": {"name": {"type": "string"}}}`, false},
+		{"jtd", `{"properties": {"value": {}}}`, true},
+	}
+	for _, test := range tests {
+		file, err := build[test.format](test.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := Render(NewTemplate(options), file, options)
+		if strings.Contains(output, `import "google/protobuf/any.proto";`) != test.uses {
+			t.Fatalf("%s %s: expected the any import to be %v:\n%s", test.format, test.schema, test.uses, output)
+		}
+	}
+}
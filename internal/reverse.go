@@ -0,0 +1,416 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var PROTO_SCALARS = map[string][2]string{
+	"double":   {"number", "double"},
+	"float":    {"number", "float"},
+	"int32":    {"integer", "int32"},
+	"sint32":   {"integer", "int32"},
+	"sfixed32": {"integer", "int32"},
+	"uint32":   {"integer", "uint32"},
+	"fixed32":  {"integer", "uint32"},
+	"int64":    {"integer", "int64"},
+	"sint64":   {"integer", "int64"},
+	"sfixed64": {"integer", "int64"},
+	"uint64":   {"integer", "uint64"},
+	"fixed64":  {"integer", "uint64"},
+	"bool":     {"boolean", ""},
+	"string":   {"string", ""},
+	"bytes":    {"string", "byte"},
+}
+
+var PROTO_WRAPPERS = map[string]string{
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.UInt64Value": "uint64",
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.UInt32Value": "uint32",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue":  "bytes",
+}
+
+type protoType struct {
+	file       *ProtoFile
+	definition ProtoDefinition
+	key        string
+}
+
+type ProtoReverser struct {
+	files             []*ProtoFile
+	types             map[string]protoType
+	diagnosticHandler DiagnosticHandler
+}
+
+func IsDescriptorSet(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pb", ".binpb", ".desc", ".protoset":
+		{
+			return true
+		}
+	}
+	return false
+}
+
+func ReadDescriptorSet(data []byte) ([]*ProtoFile, error) {
+	set := descriptorpb.FileDescriptorSet{}
+	err := proto.Unmarshal(data, &set)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]*ProtoFile, 0)
+	for _, file := range set.File {
+		output = append(output, FromFileDescriptor(file))
+	}
+	return output, nil
+}
+
+func FromFileDescriptor(file *descriptorpb.FileDescriptorProto) *ProtoFile {
+	protoFile := ProtoFile{Package: file.GetPackage(), Imports: file.Dependency}
+	for _, message := range file.MessageType {
+		protoFile.Definitions = append(protoFile.Definitions, fromDescriptor(message, "")...)
+	}
+	for _, enum := range file.EnumType {
+		protoFile.Definitions = append(protoFile.Definitions, ProtoDefinition{Enum: fromEnumDescriptor(enum, "")})
+	}
+	for _, service := range file.Service {
+		protoFile.Services = append(protoFile.Services, &ProtoService{Name: service.GetName()})
+	}
+	return &protoFile
+}
+
+func fromDescriptor(descriptor *descriptorpb.DescriptorProto, prefix string) []ProtoDefinition {
+	message := ProtoMessage{Name: prefix + descriptor.GetName()}
+	output := []ProtoDefinition{{Message: &message}}
+	entries := make(map[string]*descriptorpb.DescriptorProto)
+	for _, nested := range descriptor.NestedType {
+		if nested.GetOptions().GetMapEntry() {
+			entries[nested.GetName()] = nested
+			continue
+		}
+		output = append(output, fromDescriptor(nested, message.Name+".")...)
+	}
+	for _, enum := range descriptor.EnumType {
+		output = append(output, ProtoDefinition{Enum: fromEnumDescriptor(enum, message.Name+".")})
+	}
+	for _, field := range descriptor.Field {
+		_field := ProtoField{Name: field.GetName(), Number: int(field.GetNumber()), JsonName: field.GetJsonName(), Type: descriptorType(field)}
+		if _field.JsonName == toProtoJsonName(_field.Name) {
+			_field.JsonName = ""
+		}
+		if field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+			_field.Label = REPEATED_LABEL
+			typeNames := strings.Split(field.GetTypeName(), ".")
+			if entry, ok := entries[typeNames[len(typeNames)-1]]; ok && len(entry.Field) == 2 {
+				_field.Label = NO_LABEL
+				_field.Type = fmt.Sprintf("map<%s, %s>", descriptorType(entry.Field[0]), descriptorType(entry.Field[1]))
+			}
+		}
+		if field.OneofIndex != nil {
+			if field.GetProto3Optional() {
+				_field.Label = OPTIONAL_LABEL
+			} else {
+				_field.Oneof = descriptor.OneofDecl[field.GetOneofIndex()].GetName()
+			}
+		}
+		message.Fields = append(message.Fields, &_field)
+	}
+	for _, reserved := range descriptor.ReservedRange {
+		message.ReservedRanges = append(message.ReservedRanges, ProtoRange{Start: int(reserved.GetStart()), End: int(reserved.GetEnd()) - 1})
+	}
+	message.ReservedNames = descriptor.ReservedName
+	return output
+}
+
+func fromEnumDescriptor(descriptor *descriptorpb.EnumDescriptorProto, prefix string) *ProtoEnum {
+	enum := ProtoEnum{Name: prefix + descriptor.GetName(), AllowAlias: descriptor.GetOptions().GetAllowAlias()}
+	for _, value := range descriptor.Value {
+		enum.Values = append(enum.Values, &ProtoEnumValue{Name: value.GetName(), Number: int(value.GetNumber())})
+	}
+	return &enum
+}
+
+func descriptorType(field *descriptorpb.FieldDescriptorProto) string {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		{
+			return field.GetTypeName()
+		}
+	}
+	return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+}
+
+func toProtoJsonName(name string) string {
+	var output strings.Builder
+	upper := false
+	for _, value := range name {
+		if value == '_' {
+			upper = true
+			continue
+		}
+		if upper && value >= 'a' && value <= 'z' {
+			value -= 'a' - 'A'
+		}
+		upper = false
+		output.WriteRune(value)
+	}
+	return output.String()
+}
+
+func NewProtoReverser(files []*ProtoFile, diagnosticHandler DiagnosticHandler) *ProtoReverser {
+	output := ProtoReverser{files: files, types: make(map[string]protoType), diagnosticHandler: diagnosticHandler}
+	keys := make(map[string]int)
+	for _, file := range files {
+		for _, definition := range file.Definitions {
+			keys[definitionName(definition)]++
+		}
+	}
+	for _, file := range files {
+		for _, definition := range file.Definitions {
+			name := definitionName(definition)
+			fullName := name
+			if len(file.Package) != 0 {
+				fullName = file.Package + "." + name
+			}
+			key := name
+			if keys[name] > 1 {
+				key = fullName
+			}
+			output.types[fullName] = protoType{file: file, definition: definition, key: strings.ReplaceAll(key, ".", "_")}
+		}
+	}
+	return &output
+}
+
+func (rcvr *ProtoReverser) Schema(id string, root string) []byte {
+	output := NewJsonObject()
+	output.Set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	if len(id) != 0 {
+		output.Set("$id", id)
+	}
+	definitions := NewJsonObject()
+	for _, file := range rcvr.files {
+		if file.Package == "google.protobuf" {
+			continue
+		}
+		for _, service := range file.Services {
+			rcvr.diagnosticHandler(Diagnostic{Severity: INFO, Subject: file.Package, Message: fmt.Sprintf("service %s has no json schema equivalent and was skipped", service.Name)})
+		}
+		for _, definition := range file.Definitions {
+			fullName := definitionName(definition)
+			if len(file.Package) != 0 {
+				fullName = file.Package + "." + fullName
+			}
+			key := rcvr.types[fullName].key
+			if definition.Enum != nil {
+				definitions.Set(key, rcvr.enum(definition.Enum))
+				continue
+			}
+			definitions.Set(key, rcvr.message(file, definition.Message))
+		}
+	}
+	if len(root) != 0 {
+		if _type, ok := rcvr.lookup(root, ""); ok {
+			output.Set("$ref", "#/$defs/"+escapePointer(_type.key))
+		} else {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: "#", Message: fmt.Sprintf("root message %s was not found", root)})
+		}
+	}
+	output.Set("$defs", definitions)
+	return EncodeJson(output)
+}
+
+func (rcvr *ProtoReverser) enum(enum *ProtoEnum) *JsonObject {
+	output := NewJsonObject()
+	output.Set("type", string(STRING))
+	values := make([]any, 0)
+	for _, value := range enum.Values {
+		values = append(values, value.Name)
+	}
+	output.Set("enum", values)
+	return output
+}
+
+func (rcvr *ProtoReverser) message(file *ProtoFile, message *ProtoMessage) *JsonObject {
+	output := NewJsonObject()
+	output.Set("type", string(OBJECT))
+	properties := NewJsonObject()
+	scope := message.Name
+	if len(file.Package) != 0 {
+		scope = file.Package + "." + scope
+	}
+	oneofs := make([]any, 0)
+	for _, member := range message.Members() {
+		if member.Field != nil {
+			properties.Set(jsonPropertyName(member.Field), rcvr.field(member.Field, scope))
+			continue
+		}
+		branches := make([]any, 0)
+		none := NewJsonObject()
+		excluded := NewJsonObject()
+		for _, field := range member.Oneof.Fields {
+			name := jsonPropertyName(field)
+			properties.Set(name, rcvr.field(field, scope))
+			branch := NewJsonObject()
+			branch.Set("required", []any{name})
+			branches = append(branches, branch)
+			never := NewJsonObject()
+			never.Set("not", NewJsonObject())
+			excluded.Set(name, never)
+		}
+		none.Set("properties", excluded)
+		oneof := NewJsonObject()
+		oneof.Set("oneOf", append(branches, none))
+		oneofs = append(oneofs, oneof)
+	}
+	output.Set("properties", properties)
+	if len(oneofs) != 0 {
+		output.Set("allOf", oneofs)
+	}
+	return output
+}
+
+func jsonPropertyName(field *ProtoField) string {
+	if len(field.JsonName) != 0 {
+		return field.JsonName
+	}
+	return toProtoJsonName(field.Name)
+}
+
+func (rcvr *ProtoReverser) field(field *ProtoField, scope string) *JsonObject {
+	if strings.HasPrefix(field.Type, "map<") {
+		output := NewJsonObject()
+		output.Set("type", string(OBJECT))
+		value := strings.TrimSpace(strings.TrimSuffix(field.Type[strings.Index(field.Type, ",")+1:], ">"))
+		output.Set("additionalProperties", rcvr.typeSchema(value, scope))
+		return output
+	}
+	if field.Label == REPEATED_LABEL {
+		output := NewJsonObject()
+		output.Set("type", string(ARRAY))
+		output.Set("items", rcvr.typeSchema(field.Type, scope))
+		return output
+	}
+	return rcvr.typeSchema(field.Type, scope)
+}
+
+func (rcvr *ProtoReverser) typeSchema(typeName string, scope string) *JsonObject {
+	output := NewJsonObject()
+	if scalar, ok := PROTO_SCALARS[typeName]; ok {
+		output.Set("type", scalar[0])
+		if len(scalar[1]) != 0 {
+			output.Set("format", scalar[1])
+		}
+		switch scalar[1] {
+		case "int64":
+			{
+				output.Set("type", []any{string(INTEGER), string(STRING)})
+				output.Set("pattern", "^-?[0-9]+$")
+			}
+		case "uint64":
+			{
+				output.Set("type", []any{string(INTEGER), string(STRING)})
+				output.Set("pattern", "^[0-9]+$")
+			}
+		}
+		return output
+	}
+	fullName := strings.TrimPrefix(typeName, ".")
+	if wrapped, ok := PROTO_WRAPPERS[fullName]; ok {
+		null := NewJsonObject()
+		null.Set("type", string(NULL))
+		output.Set("anyOf", []any{rcvr.typeSchema(wrapped, scope), null})
+		return output
+	}
+	switch fullName {
+	case "google.protobuf.Timestamp":
+		{
+			output.Set("type", string(STRING))
+			output.Set("format", "date-time")
+			return output
+		}
+	case "google.protobuf.Duration":
+		{
+			output.Set("type", string(STRING))
+			output.Set("format", "duration")
+			return output
+		}
+	case "google.protobuf.FieldMask":
+		{
+			output.Set("type", string(STRING))
+			return output
+		}
+	case "google.protobuf.Struct", "google.protobuf.Empty":
+		{
+			output.Set("type", string(OBJECT))
+			return output
+		}
+	case "google.protobuf.ListValue":
+		{
+			output.Set("type", string(ARRAY))
+			return output
+		}
+	case "google.protobuf.Value":
+		{
+			return output
+		}
+	case "google.protobuf.Any":
+		{
+			output.Set("type", string(OBJECT))
+			properties := NewJsonObject()
+			_type := NewJsonObject()
+			_type.Set("type", string(STRING))
+			properties.Set("@type", _type)
+			output.Set("properties", properties)
+			output.Set("required", []any{"@type"})
+			return output
+		}
+	}
+	_type, ok := rcvr.lookup(typeName, scope)
+	if !ok {
+		rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: scope, Message: fmt.Sprintf("type %s was not found and accepts any value", typeName)})
+		return output
+	}
+	output.Set("$ref", "#/$defs/"+escapePointer(_type.key))
+	return output
+}
+
+func (rcvr *ProtoReverser) lookup(typeName string, scope string) (protoType, bool) {
+	if strings.HasPrefix(typeName, ".") {
+		_type, ok := rcvr.types[typeName[1:]]
+		return _type, ok
+	}
+	for {
+		candidate := typeName
+		if len(scope) != 0 {
+			candidate = scope + "." + typeName
+		}
+		if _type, ok := rcvr.types[candidate]; ok {
+			return _type, true
+		}
+		if len(scope) == 0 {
+			break
+		}
+		index := strings.LastIndex(scope, ".")
+		if index < 0 {
+			scope = ""
+			continue
+		}
+		scope = scope[:index]
+	}
+	for _, file := range rcvr.files {
+		if _type, ok := rcvr.types[file.Package+"."+typeName]; ok {
+			return _type, true
+		}
+	}
+	return protoType{}, false
+}
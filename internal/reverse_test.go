@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const REVERSE_TEST_PROTO = `syntax = "proto3";
+
+package acme.v1;
+
+message Counter {
+  int64 total = 1;
+  uint64 size = 2;
+  int32 count = 3;
+  repeated sint64 samples = 4;
+}
+`
+
+func TestReverseScalars(t *testing.T) {
+	reverser := NewProtoReverser([]*ProtoFile{ParseProto([]byte(REVERSE_TEST_PROTO))}, func(Diagnostic) {})
+	schema := struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+		} `json:"$defs"`
+	}{}
+	if err := json.Unmarshal(reverser.Schema("", "Counter"), &schema); err != nil {
+		t.Fatal(err)
+	}
+	properties := schema.Defs["Counter"].Properties
+	tests := map[string]map[string]any{
+		"total": {"type": []any{"integer", "string"}, "format": "int64", "pattern": "^-?[0-9]+$"},
+		"size":  {"type": []any{"integer", "string"}, "format": "uint64", "pattern": "^[0-9]+$"},
+		"count": {"type": "integer", "format": "int32"},
+	}
+	for name, expected := range tests {
+		if !reflect.DeepEqual(properties[name], expected) {
+			t.Fatalf("%s: expected %v, got %v", name, expected, properties[name])
+		}
+	}
+	if items := properties["samples"]["items"]; !reflect.DeepEqual(items, tests["total"]) {
+		t.Fatalf("samples: expected 64-bit items to accept strings, got %v", items)
+	}
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type ArtifactFormat string
+
+const (
+	PROTO_ARTIFACT      ArtifactFormat = "proto"
+	DESCRIPTOR_ARTIFACT ArtifactFormat = "descriptor"
+	ZIP_ARTIFACT        ArtifactFormat = "zip"
+)
+
+type ConvertHandler struct {
+	Options Options
+	Store   *DescriptorStore
+	Fetch   Fetcher
+}
+
+func NewConvertHandler(options Options, store *DescriptorStore) *ConvertHandler {
+	return &ConvertHandler{Options: options, Store: store, Fetch: PublicHttpFetcher}
+}
+
+func PublicHttpFetcher(url string) ([]byte, error) {
+	dialer := net.Dialer{Timeout: 30 * time.Second, Control: rejectPrivateAddress}
+	transport := http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second}
+	return fetchHttp(&http.Client{Timeout: 30 * time.Second, Transport: &transport}, url)
+}
+
+func rejectPrivateAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%s is a loopback, private or link-local address and cannot be fetched", host)
+	}
+	return nil
+}
+
+func (rcvr *ConvertHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.Header().Set("Allow", "GET, HEAD")
+		http.Error(writer, "only GET and HEAD are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	query := request.URL.Query()
+	source, err := url.Parse(query.Get("url"))
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") {
+		http.Error(writer, "url must be an http or https url", http.StatusBadRequest)
+		return
+	}
+	format := ArtifactFormat(query.Get("format"))
+	if len(format) == 0 {
+		format = PROTO_ARTIFACT
+	}
+	if format != PROTO_ARTIFACT && format != DESCRIPTOR_ARTIFACT && format != ZIP_ARTIFACT {
+		http.Error(writer, fmt.Sprintf("unknown format %s, expected proto, descriptor or zip", format), http.StatusBadRequest)
+		return
+	}
+	data, err := rcvr.Fetch(source.String())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadGateway)
+		return
+	}
+	options, _ := json.Marshal(rcvr.Options)
+	hash := sha256.New()
+	hash.Write(options)
+	for _, value := range []string{VERSION, string(format), query.Get("package"), query.Get("name")} {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	hash.Write(data)
+	etag := fmt.Sprintf("\"%x\"", hash.Sum(nil))
+	writer.Header().Set("ETag", etag)
+	writer.Header().Set("Cache-Control", "no-cache")
+	if matchesETag(request.Header.Get("If-None-Match"), etag) {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+	data, err = ToJsonSchema(source.Path, data, rcvr.Options.InputFormat)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	conversion := ConversionRequest{Schema: data, Package: query.Get("package"), Name: query.Get("name"), Descriptor: format == DESCRIPTOR_ARTIFACT}
+	response := Convert(conversion, rcvr.Options, rcvr.Store)
+	if len(response.Error) != 0 {
+		http.Error(writer, response.Error, http.StatusUnprocessableEntity)
+		return
+	}
+	name := conversion.Name
+	if len(name) == 0 {
+		name = SERVICE_FILE
+	}
+	var content []byte
+	switch format {
+	case PROTO_ARTIFACT:
+		{
+			writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			content = []byte(response.Files[name])
+		}
+	case DESCRIPTOR_ARTIFACT:
+		{
+			writer.Header().Set("Content-Type", "application/x-protobuf")
+			name = strings.TrimSuffix(name, path.Ext(name)) + ".binpb"
+			content = response.Descriptor
+		}
+	case ZIP_ARTIFACT:
+		{
+			writer.Header().Set("Content-Type", "application/zip")
+			name = strings.TrimSuffix(name, path.Ext(name)) + ".zip"
+			content, err = zipFiles(response.Files)
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(name)))
+	writer.Header().Set("Content-Length", fmt.Sprint(len(content)))
+	if request.Method == http.MethodHead {
+		return
+	}
+	writer.Write(content)
+}
+
+func matchesETag(header string, etag string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == etag || value == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func zipFiles(files map[string]string) ([]byte, error) {
+	names := make([]string, 0)
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, name := range names {
+		file, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = file.Write([]byte(files[name]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := archive.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertHandlerETag(t *testing.T) {
+	fetches := 0
+	handler := NewConvertHandler(DefaultOptions(), nil)
+	handler.Fetch = func(url string) ([]byte, error) {
+		fetches++
+		return []byte(SERVICE_TEST_SCHEMA), nil
+	}
+	get := func(query string, etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/convert?"+query, nil)
+		if len(etag) != 0 {
+			request.Header.Set("If-None-Match", etag)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	response := get("url=https://example.com/order.json", "")
+	etag := response.Header().Get("ETag")
+	if response.Code != http.StatusOK || len(etag) == 0 || !strings.Contains(response.Body.String(), "message Order") {
+		t.Fatalf("unexpected response %d %q:\n%s", response.Code, etag, response.Body.String())
+	}
+	if response = get("url=https://example.com/order.json", etag); response.Code != http.StatusNotModified || response.Body.Len() != 0 {
+		t.Fatalf("a matching If-None-Match returned %d", response.Code)
+	}
+	if response = get("url=https://example.com/order.json", "\"other\", W/"+etag); response.Code != http.StatusNotModified {
+		t.Fatalf("a weak etag in a list returned %d", response.Code)
+	}
+	if response = get("url=https://example.com/order.json&format=zip", etag); response.Code != http.StatusOK || response.Header().Get("ETag") == etag {
+		t.Fatalf("another format reused the etag %q", response.Header().Get("ETag"))
+	}
+	if response = get("url=https://example.com/order.json&package=acme.v2", etag); response.Code != http.StatusOK || response.Header().Get("ETag") == etag {
+		t.Fatalf("another package reused the etag %q", response.Header().Get("ETag"))
+	}
+	if fetches != 5 {
+		t.Fatalf("expected every request to fetch the schema, got %d fetches", fetches)
+	}
+}
+
+func TestConvertHandlerRejectsRequests(t *testing.T) {
+	handler := NewConvertHandler(DefaultOptions(), nil)
+	tests := []struct {
+		method string
+		query  string
+		code   int
+	}{
+		{http.MethodPost, "url=https://example.com/schema.json", http.StatusMethodNotAllowed},
+		{http.MethodGet, "url=file:///etc/passwd", http.StatusBadRequest},
+		{http.MethodGet, "url=https://example.com/schema.json&format=yaml", http.StatusBadRequest},
+		{http.MethodGet, "url=http://127.0.0.1/schema.json", http.StatusBadGateway},
+		{http.MethodGet, "url=http://169.254.169.254/latest/meta-data", http.StatusBadGateway},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, "/convert?"+test.query, nil))
+		if recorder.Code != test.code {
+			t.Fatalf("%s %s: expected %d, got %d", test.method, test.query, test.code, recorder.Code)
+		}
+	}
+}
+
+func TestRejectPrivateAddress(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:80":       false,
+		"[::1]:443":          false,
+		"10.1.2.3:80":        false,
+		"192.168.0.10:80":    false,
+		"169.254.169.254:80": false,
+		"[fe80::1]:80":       false,
+		"0.0.0.0:80":         false,
+		"93.184.216.34:443":  true,
+		"[2606:4700::1]:443": true,
+	}
+	for address, allowed := range tests {
+		if err := rejectPrivateAddress("tcp", address, nil); (err == nil) != allowed {
+			t.Fatalf("%s: expected allowed to be %v, got %v", address, allowed, err)
+		}
+	}
+}
+
+func TestFetchLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		size := MAX_FETCH_SIZE
+		if request.URL.Path == "/large" {
+			size++
+		}
+		fmt.Fprint(writer, strings.Repeat(" ", size))
+	}))
+	defer server.Close()
+	if _, err := PublicHttpFetcher(server.URL + "/small"); err == nil {
+		t.Fatal("a loopback url was fetched")
+	}
+	if data, err := HttpFetcher(server.URL + "/small"); err != nil || len(data) != MAX_FETCH_SIZE {
+		t.Fatalf("fetching the limit failed: %v", err)
+	}
+	if _, err := HttpFetcher(server.URL + "/large"); err == nil {
+		t.Fatal("a response over the limit was read")
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+const SOURCE_MAP_VERSION = 1
+
+var (
+	DECLARATION_LINE = regexp.MustCompile(`^\s*(message|enum|service)\s+([A-Za-z_][\w.]*)`)
+	FIELD_LINE       = regexp.MustCompile(`^\s*(?:(?:optional|repeated)\s+)?(?:map\s*<[^>]*>|[A-Za-z_.][\w.]*)\s+([A-Za-z_]\w*)\s*=\s*\d+`)
+	ENUM_VALUE_LINE  = regexp.MustCompile(`^\s*([A-Za-z_]\w*)\s*=\s*-?\d+`)
+)
+
+type SourceMapEntry struct {
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Pointer string `json:"pointer"`
+}
+
+type SourceMap struct {
+	Version int              `json:"version"`
+	Proto   string           `json:"proto"`
+	Source  string           `json:"source"`
+	Entries []SourceMapEntry `json:"entries"`
+}
+
+func NewSourceMap(file *ProtoFile, rendered string, proto string, source string) SourceMap {
+	output := SourceMap{Version: SOURCE_MAP_VERSION, Proto: proto, Source: source, Entries: make([]SourceMapEntry, 0)}
+	messages := make(map[string]*ProtoMessage)
+	enums := make(map[string]*ProtoEnum)
+	for _, definition := range file.Definitions {
+		if definition.Message != nil {
+			messages[definition.Message.Name] = definition.Message
+		}
+		if definition.Enum != nil {
+			enums[definition.Enum.Name] = definition.Enum
+		}
+	}
+	var message *ProtoMessage
+	var enum *ProtoEnum
+	for index, line := range strings.Split(rendered, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+		if match := DECLARATION_LINE.FindStringSubmatch(line); match != nil {
+			message, enum = messages[match[2]], enums[match[2]]
+			if message != nil && len(message.Pointer) != 0 {
+				output.Entries = append(output.Entries, SourceMapEntry{Line: index + 1, Kind: "message", Name: message.Name, Pointer: message.Pointer})
+			}
+			if enum != nil && len(enum.Pointer) != 0 {
+				output.Entries = append(output.Entries, SourceMapEntry{Line: index + 1, Kind: "enum", Name: enum.Name, Pointer: enum.Pointer})
+			}
+			continue
+		}
+		if message != nil {
+			if match := FIELD_LINE.FindStringSubmatch(line); match != nil {
+				for _, field := range message.Fields {
+					if field.Name == match[1] && len(field.Pointer) != 0 {
+						output.Entries = append(output.Entries, SourceMapEntry{Line: index + 1, Kind: "field", Name: message.Name + "." + field.Name, Pointer: field.Pointer})
+						break
+					}
+				}
+			}
+			continue
+		}
+		if enum != nil {
+			if match := ENUM_VALUE_LINE.FindStringSubmatch(line); match != nil {
+				for _, value := range enum.Values {
+					if value.Name == match[1] && len(value.Pointer) != 0 {
+						output.Entries = append(output.Entries, SourceMapEntry{Line: index + 1, Kind: "enum_value", Name: enum.Name + "." + value.Name, Pointer: value.Pointer})
+						break
+					}
+				}
+			}
+		}
+	}
+	return output
+}
+
+func (sourceMap SourceMap) Lookup(line int) (SourceMapEntry, bool) {
+	output, ok := SourceMapEntry{}, false
+	for _, entry := range sourceMap.Entries {
+		if entry.Line <= line && entry.Line >= output.Line {
+			output, ok = entry, true
+		}
+	}
+	return output, ok
+}
+
+func SourceMapPath(proto string) string {
+	return strings.TrimSuffix(proto, ".proto") + ".map.json"
+}
+
+func (sourceMap SourceMap) String() string {
+	return string(EncodeJson(sourceMap)) + "\n"
+}
@@ -0,0 +1,264 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SqlDialect string
+
+const (
+	POSTGRES_DIALECT SqlDialect = "postgres"
+	MYSQL_DIALECT    SqlDialect = "mysql"
+)
+
+type SqlNesting string
+
+const (
+	JSON_NESTING  SqlNesting = "json"
+	TABLE_NESTING SqlNesting = "table"
+)
+
+var SQL_SCALARS = map[SqlDialect]map[string]string{
+	POSTGRES_DIALECT: {
+		"double":   "DOUBLE PRECISION",
+		"float":    "REAL",
+		"int32":    "INTEGER",
+		"sint32":   "INTEGER",
+		"sfixed32": "INTEGER",
+		"uint32":   "BIGINT",
+		"fixed32":  "BIGINT",
+		"int64":    "BIGINT",
+		"sint64":   "BIGINT",
+		"sfixed64": "BIGINT",
+		"uint64":   "NUMERIC(20)",
+		"fixed64":  "NUMERIC(20)",
+		"bool":     "BOOLEAN",
+		"string":   "TEXT",
+		"bytes":    "BYTEA",
+		"json":     "JSONB",
+	},
+	MYSQL_DIALECT: {
+		"double":   "DOUBLE",
+		"float":    "FLOAT",
+		"int32":    "INT",
+		"sint32":   "INT",
+		"sfixed32": "INT",
+		"uint32":   "INT UNSIGNED",
+		"fixed32":  "INT UNSIGNED",
+		"int64":    "BIGINT",
+		"sint64":   "BIGINT",
+		"sfixed64": "BIGINT",
+		"uint64":   "BIGINT UNSIGNED",
+		"fixed64":  "BIGINT UNSIGNED",
+		"bool":     "BOOLEAN",
+		"string":   "TEXT",
+		"bytes":    "LONGBLOB",
+		"json":     "JSON",
+	},
+}
+
+var SQL_WELL_KNOWN_TYPES = map[string]string{
+	"google.protobuf.Timestamp":   "timestamp",
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.Struct":      "json",
+	"google.protobuf.Value":       "json",
+	"google.protobuf.ListValue":   "json",
+	"google.protobuf.Any":         "json",
+	"google.protobuf.Empty":       "json",
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.UInt64Value": "uint64",
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.UInt32Value": "uint32",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue":  "bytes",
+	"google.type.Date":            "date",
+	"google.type.TimeOfDay":       "time",
+	"google.rpc.Status":           "json",
+}
+
+var SQL_TEMPORAL_TYPES = map[SqlDialect]map[string]string{
+	POSTGRES_DIALECT: {"timestamp": "TIMESTAMPTZ", "date": "DATE", "time": "TIME"},
+	MYSQL_DIALECT:    {"timestamp": "DATETIME(6)", "date": "DATE", "time": "TIME(6)"},
+}
+
+type sqlWriter struct {
+	file        *ProtoFile
+	options     Options
+	definitions map[string]ProtoDefinition
+	builder     strings.Builder
+}
+
+func RenderSql(file *ProtoFile, options Options) string {
+	writer := sqlWriter{file: file, options: options, definitions: make(map[string]ProtoDefinition)}
+	referenced := referencedTypes(file)
+	for _, definition := range file.Definitions {
+		writer.definitions[definitionName(definition)] = definition
+	}
+	if file.Metadata != nil {
+		writer.builder.WriteString("-- Code generated by j2p. DO NOT EDIT.\n")
+		writer.builder.WriteString(fmt.Sprintf("-- versions:\n--   j2p %s\n", file.Metadata.Version))
+		if len(file.Metadata.Source) != 0 {
+			writer.builder.WriteString(fmt.Sprintf("-- source: %s\n", file.Metadata.Source))
+		}
+		writer.builder.WriteString(fmt.Sprintf("-- sha256: %s\n", file.Metadata.Hash))
+		writer.builder.WriteString("\n")
+	}
+	if options.SqlDialect == POSTGRES_DIALECT {
+		for _, definition := range file.Definitions {
+			if definition.Enum != nil {
+				writer.builder.WriteString(fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);\n\n", writer.quote(toSqlName(definition.Enum.Name)), strings.Join(sqlEnumValues(definition.Enum, options), ", ")))
+			}
+		}
+	}
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		if options.SqlNesting == TABLE_NESTING || !referenced[definition.Message.Name] {
+			writer.table(definition.Message)
+		}
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(writer.builder.String(), "\n", "\r\n")
+	}
+	return writer.builder.String()
+}
+
+func (rcvr *sqlWriter) table(message *ProtoMessage) {
+	name := toSqlName(message.Name)
+	columns := make([]string, 0)
+	children := make([]*ProtoField, 0)
+	if rcvr.options.SqlNesting == TABLE_NESTING {
+		columns = append(columns, rcvr.primaryKey())
+	}
+	for _, field := range message.Fields {
+		column := bigQueryName(field)
+		notNull := ""
+		if field.Required && len(field.Oneof) == 0 {
+			notNull = " NOT NULL"
+		}
+		if field.Label == REPEATED_LABEL || strings.HasPrefix(field.Type, "map<") {
+			if rcvr.options.SqlNesting == TABLE_NESTING {
+				children = append(children, field)
+				continue
+			}
+			columns = append(columns, fmt.Sprintf("%s %s%s", rcvr.quote(column), SQL_SCALARS[rcvr.options.SqlDialect]["json"], notNull))
+			continue
+		}
+		typeName, reference := rcvr.typeOf(field.Type)
+		if len(reference) != 0 {
+			columns = append(columns, fmt.Sprintf("%s %s%s REFERENCES %s (%s)", rcvr.quote(column+"_id"), typeName, notNull, rcvr.quote(reference), rcvr.quote("id")))
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s %s%s", rcvr.quote(column), typeName, notNull))
+	}
+	rcvr.create(name, columns)
+	for _, field := range children {
+		rcvr.child(name, field)
+	}
+}
+
+func (rcvr *sqlWriter) child(parent string, field *ProtoField) {
+	name := parent + "_" + toSqlName(field.Name)
+	parentKey := parent + "_id"
+	columns := []string{fmt.Sprintf("%s BIGINT NOT NULL REFERENCES %s (%s)", rcvr.quote(parentKey), rcvr.quote(parent), rcvr.quote("id"))}
+	key := "position"
+	typeName := field.Type
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		keyType, _ := rcvr.typeOf(strings.TrimPrefix(field.Type[:index], "map<"))
+		if rcvr.options.SqlDialect == MYSQL_DIALECT && keyType == "TEXT" {
+			keyType = "VARCHAR(255)"
+		}
+		key = "key"
+		columns = append(columns, fmt.Sprintf("%s %s NOT NULL", rcvr.quote(key), keyType))
+		typeName = strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">"))
+	} else {
+		columns = append(columns, fmt.Sprintf("%s INTEGER NOT NULL", rcvr.quote(key)))
+	}
+	valueType, reference := rcvr.typeOf(typeName)
+	if len(reference) != 0 {
+		columns = append(columns, fmt.Sprintf("%s %s REFERENCES %s (%s)", rcvr.quote("value_id"), valueType, rcvr.quote(reference), rcvr.quote("id")))
+	} else {
+		columns = append(columns, fmt.Sprintf("%s %s", rcvr.quote("value"), valueType))
+	}
+	columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s, %s)", rcvr.quote(parentKey), rcvr.quote(key)))
+	rcvr.create(name, columns)
+}
+
+func (rcvr *sqlWriter) create(name string, columns []string) {
+	rcvr.builder.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", rcvr.quote(name)))
+	rcvr.builder.WriteString("  " + strings.Join(columns, ",\n  "))
+	rcvr.builder.WriteString("\n);\n\n")
+}
+
+func (rcvr *sqlWriter) primaryKey() string {
+	if rcvr.options.SqlDialect == MYSQL_DIALECT {
+		return fmt.Sprintf("%s BIGINT AUTO_INCREMENT PRIMARY KEY", rcvr.quote("id"))
+	}
+	return fmt.Sprintf("%s BIGSERIAL PRIMARY KEY", rcvr.quote("id"))
+}
+
+func (rcvr *sqlWriter) typeOf(typeName string) (string, string) {
+	scalars := SQL_SCALARS[rcvr.options.SqlDialect]
+	if scalar, ok := scalars[typeName]; ok {
+		return scalar, ""
+	}
+	if wellKnown, ok := SQL_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		if temporal, ok := SQL_TEMPORAL_TYPES[rcvr.options.SqlDialect][wellKnown]; ok {
+			return temporal, ""
+		}
+		return scalars[wellKnown], ""
+	}
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(rcvr.file.Package) != 0 {
+		typeName = strings.TrimPrefix(typeName, rcvr.file.Package+".")
+	}
+	definition, ok := rcvr.definitions[typeName]
+	if !ok {
+		return scalars["json"], ""
+	}
+	if definition.Enum != nil {
+		if rcvr.options.SqlDialect == MYSQL_DIALECT {
+			return fmt.Sprintf("ENUM(%s)", strings.Join(sqlEnumValues(definition.Enum, rcvr.options), ", ")), ""
+		}
+		return rcvr.quote(toSqlName(definition.Enum.Name)), ""
+	}
+	if rcvr.options.SqlNesting == TABLE_NESTING {
+		return "BIGINT", toSqlName(typeName)
+	}
+	return scalars["json"], ""
+}
+
+func (rcvr *sqlWriter) quote(name string) string {
+	if rcvr.options.SqlDialect == MYSQL_DIALECT {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
+
+func sqlEnumValues(enum *ProtoEnum, options Options) []string {
+	output := make([]string, 0)
+	prefix := strings.ToUpper(enum.Name) + "_"
+	for _, value := range enum.Values {
+		_value := value.Original
+		if len(_value) == 0 {
+			if strings.TrimPrefix(value.Name, prefix) == strings.ToUpper(*fixString(options.EnumZeroValue)) {
+				continue
+			}
+			_value = value.Name
+		}
+		output = append(output, "'"+strings.ReplaceAll(_value, "'", "''")+"'")
+	}
+	return output
+}
+
+func toSqlName(name string) string {
+	output, _ := toSnakeCase(strings.ReplaceAll(name, ".", "_"))
+	return strings.ReplaceAll(*output, "__", "_")
+}
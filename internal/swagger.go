@@ -0,0 +1,44 @@
+package internal
+
+import "fmt"
+
+func IsSwagger(data []byte) bool {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return false
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return false
+	}
+	version, ok := document.Get("swagger")
+	return ok && version == "2.0"
+}
+
+func FromSwagger(data []byte, diagnosticHandler DiagnosticHandler) ([]byte, error) {
+	value, err := DecodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+	document, ok := value.(*JsonObject)
+	if !ok {
+		return nil, fmt.Errorf("swagger documents must be objects")
+	}
+	output := NewJsonObject()
+	output.Set("$schema", "http://json-schema.org/draft-04/schema#")
+	output.Set("$id", openApiID(document))
+	definitions := NewJsonObject()
+	if value, ok := document.Get("definitions"); ok {
+		if _definitions, ok := value.(*JsonObject); ok {
+			definitions = _definitions
+		}
+	}
+	if len(definitions.Keys) == 0 {
+		diagnosticHandler(Diagnostic{Severity: WARNING, Subject: "#/definitions", Message: "the swagger document has no definitions"})
+	}
+	for _, key := range definitions.Keys {
+		definitions.Set(key, fromOpenApiSchema(definitions.Values[key], "#/definitions/"+escapePointer(key), diagnosticHandler))
+	}
+	output.Set("definitions", definitions)
+	return EncodeJson(output), nil
+}
@@ -0,0 +1,30 @@
+package internal
+
+import "testing"
+
+const SWAGGER_TEST_DOCUMENT = `{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0.0"}, "paths": {}, "definitions": {
+	"Pet": {"type": "object", "properties": {"name": {"type": "string"}, "tag": {"type": "string", "x-nullable": true}, "photos": {"type": "array", "collectionFormat": "csv", "items": {"type": "string"}}}}
+}}`
+
+func TestFromSwagger(t *testing.T) {
+	if !IsSwagger([]byte(SWAGGER_TEST_DOCUMENT)) || IsOpenApi([]byte(SWAGGER_TEST_DOCUMENT)) {
+		t.Fatal("the document was not detected as swagger")
+	}
+	output, diagnostics := convertTestDocument(t, SWAGGER_TEST_DOCUMENT, DefaultOptions())
+	expected := `syntax = "proto3";
+
+package pets.v1;
+
+message Pet {
+  optional string tag = 1;
+  string name = 2;
+  string photos = 3;
+}
+`
+	if output != expected {
+		t.Fatalf("unexpected proto:\n%s", output)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Subject != "#/definitions/Pet/properties/photos" {
+		t.Fatalf("expected a warning for the csv collection, got %+v", diagnostics)
+	}
+}
@@ -0,0 +1,98 @@
+package internal
+
+import "strings"
+
+type Target string
+
+const (
+	PROTO_TARGET    Target = "proto"
+	GO_TARGET       Target = "go"
+	AVRO_TARGET     Target = "avro"
+	THRIFT_TARGET   Target = "thrift"
+	FBS_TARGET      Target = "fbs"
+	CAPNP_TARGET    Target = "capnp"
+	BIGQUERY_TARGET Target = "bigquery"
+	PARQUET_TARGET  Target = "parquet"
+	SQL_TARGET      Target = "sql"
+	CONNECT_TARGET  Target = "connect"
+	CUE_TARGET      Target = "cue"
+	TS_TARGET       Target = "ts"
+)
+
+func RenderTarget(file *ProtoFile, options Options) string {
+	switch options.Target {
+	case GO_TARGET:
+		{
+			return RenderGo(file, options)
+		}
+	case AVRO_TARGET:
+		{
+			return RenderAvro(file, options)
+		}
+	case THRIFT_TARGET:
+		{
+			return RenderThrift(file, options)
+		}
+	case FBS_TARGET:
+		{
+			return RenderFlatBuffers(file, options)
+		}
+	case CAPNP_TARGET:
+		{
+			return RenderCapnp(file, options)
+		}
+	case BIGQUERY_TARGET:
+		{
+			return RenderBigQuery(file, options)
+		}
+	case PARQUET_TARGET:
+		{
+			return RenderParquet(file, options)
+		}
+	case SQL_TARGET:
+		{
+			return RenderSql(file, options)
+		}
+	case CONNECT_TARGET:
+		{
+			return RenderConnect(file, options)
+		}
+	case CUE_TARGET:
+		{
+			return RenderCue(file, options)
+		}
+	case TS_TARGET:
+		{
+			return RenderTypeScript(file, options)
+		}
+	}
+	return Render(NewTemplate(options), file, options)
+}
+
+func RootMessage(file *ProtoFile) string {
+	referenced := referencedTypes(file)
+	for _, definition := range file.Definitions {
+		if definition.Message != nil && !referenced[definition.Message.Name] {
+			return definition.Message.Name
+		}
+	}
+	return ""
+}
+
+func referencedTypes(file *ProtoFile) map[string]bool {
+	output := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			typeName := field.Type
+			if index := strings.Index(typeName, ","); strings.HasPrefix(typeName, "map<") && index >= 0 {
+				typeName = strings.TrimSpace(strings.TrimSuffix(typeName[index+1:], ">"))
+			}
+			output[typeName] = true
+			output[strings.TrimPrefix(typeName, "."+file.Package+".")] = true
+		}
+	}
+	return output
+}
@@ -0,0 +1,201 @@
+package internal
+
+import "testing"
+
+const REQUIRED_TEST_SCHEMA = `{"title":"Order","type":"object","required":["id","item"],"properties":{"id":{"type":"string"},"note":{"type":"string"},"item":{"$ref":"#/definitions/Item"}},"definitions":{"Item":{"type":"object","properties":{"sku":{"type":"string"}}}}}`
+
+func TestRenderTargets(t *testing.T) {
+	tests := []struct {
+		target   Target
+		expected string
+	}{
+		{GO_TARGET,
+			"// Code generated by j2p. DO NOT EDIT.\n" +
+				"\n" +
+				"package test\n" +
+				"\n" +
+				"type Item struct {\n" +
+				"\tSku string `json:\"sku,omitempty\"`\n" +
+				"}\n" +
+				"\n" +
+				"type Order struct {\n" +
+				"\tID   string `json:\"id\"`\n" +
+				"\tItem *Item  `json:\"item\"`\n" +
+				"\tNote string `json:\"note,omitempty\"`\n" +
+				"}\n"},
+		{AVRO_TARGET, `{
+  "type": "record",
+  "name": "Order",
+  "namespace": "test",
+  "fields": [
+    {
+      "name": "id",
+      "type": "string"
+    },
+    {
+      "name": "item",
+      "type": {
+        "type": "record",
+        "name": "Item",
+        "namespace": "test",
+        "fields": [
+          {
+            "name": "sku",
+            "type": [
+              "null",
+              "string"
+            ],
+            "default": null
+          }
+        ]
+      }
+    },
+    {
+      "name": "note",
+      "type": [
+        "null",
+        "string"
+      ],
+      "default": null
+    }
+  ]
+}
+`},
+		{THRIFT_TARGET, `// Code generated by j2p. DO NOT EDIT.
+
+namespace * test
+
+struct Item {
+  1: optional string sku
+}
+
+struct Order {
+  1: required string id
+  2: required Item item
+  3: optional string note
+}
+`},
+		{BIGQUERY_TARGET, `[
+  {
+    "name": "id",
+    "type": "STRING",
+    "mode": "REQUIRED"
+  },
+  {
+    "name": "item",
+    "type": "RECORD",
+    "mode": "REQUIRED",
+    "fields": [
+      {
+        "name": "sku",
+        "type": "STRING",
+        "mode": "NULLABLE"
+      }
+    ]
+  },
+  {
+    "name": "note",
+    "type": "STRING",
+    "mode": "NULLABLE"
+  }
+]
+`},
+		{PARQUET_TARGET, `message Order {
+  required binary id (STRING);
+  required group item {
+    optional binary sku (STRING);
+  }
+  optional binary note (STRING);
+}
+`},
+		{SQL_TARGET, `CREATE TABLE "order" (
+  "id" TEXT NOT NULL,
+  "item" JSONB NOT NULL,
+  "note" TEXT
+);
+
+`},
+		{CONNECT_TARGET, `{
+  "type": "struct",
+  "fields": [
+    {
+      "field": "id",
+      "type": "string",
+      "optional": false
+    },
+    {
+      "field": "item",
+      "type": "struct",
+      "fields": [
+        {
+          "field": "sku",
+          "type": "string",
+          "optional": true
+        }
+      ],
+      "optional": false,
+      "name": "test.Item"
+    },
+    {
+      "field": "note",
+      "type": "string",
+      "optional": true
+    }
+  ],
+  "optional": false,
+  "name": "test.Order"
+}
+`},
+		{CUE_TARGET, `// Code generated by j2p. DO NOT EDIT.
+
+package test
+
+#Item: {
+	sku?: string
+}
+
+#Order: {
+	id: string
+	item: #Item
+	note?: string
+}
+`},
+		{TS_TARGET, `// Code generated by j2p. DO NOT EDIT.
+
+export interface Item {
+  sku?: string;
+}
+
+export interface Order {
+  id: string;
+  item: Item;
+  note?: string;
+}
+`},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.Target = test.target
+		file := NewWithOptions([]byte(REQUIRED_TEST_SCHEMA), options).Build("test")
+		if output := RenderTarget(file, options); output != test.expected {
+			t.Fatalf("%s: unexpected output:\n%s", test.target, output)
+		}
+	}
+}
+
+func TestFieldBehaviorsAreOptIn(t *testing.T) {
+	options := DefaultOptions()
+	message := buildTestMessage(t, REQUIRED_TEST_SCHEMA, options, "Order")
+	for _, field := range message.Fields {
+		if len(field.Behaviors) != 0 {
+			t.Fatalf("%s carries field behaviors without -field-behavior: %v", field.Name, field.Behaviors)
+		}
+	}
+	options.FieldBehavior = true
+	message = buildTestMessage(t, REQUIRED_TEST_SCHEMA, options, "Order")
+	for _, field := range message.Fields {
+		if field.Required != (len(field.Behaviors) != 0 && field.Behaviors[0] == "REQUIRED") {
+			t.Fatalf("%s has the behaviors %v but required is %v", field.Name, field.Behaviors, field.Required)
+		}
+	}
+}
@@ -0,0 +1,568 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var TEXTPROTO_INTEGERS = map[string]bool{"int32": true, "sint32": true, "sfixed32": true, "uint32": true, "fixed32": true, "int64": true, "sint64": true, "sfixed64": true, "uint64": true, "fixed64": true}
+
+type textprotoWriter struct {
+	file              *ProtoFile
+	definitions       map[string]ProtoDefinition
+	builder           strings.Builder
+	diagnosticHandler DiagnosticHandler
+}
+
+func SchemaExamples(schema []byte, pointer string) ([]any, []string) {
+	var node any
+	err := json.Unmarshal(schema, &node)
+	if err != nil {
+		return nil, nil
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(pointer, "#"), "/"), "/") {
+		if len(segment) == 0 {
+			continue
+		}
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		switch value := node.(type) {
+		case map[string]any:
+			{
+				node = value[segment]
+			}
+		case []any:
+			{
+				index, err := strconv.Atoi(segment)
+				if err != nil || index < 0 || index >= len(value) {
+					return nil, nil
+				}
+				node = value[index]
+			}
+		default:
+			{
+				return nil, nil
+			}
+		}
+	}
+	object, ok := node.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	if examples, ok := object["examples"].([]any); ok && len(examples) != 0 {
+		pointers := make([]string, 0)
+		for i := range examples {
+			pointers = append(pointers, fmt.Sprintf("%s/examples/%d", pointer, i))
+		}
+		return examples, pointers
+	}
+	if example, ok := object["example"]; ok {
+		return []any{example}, []string{pointer + "/example"}
+	}
+	if value, ok := object["default"]; ok {
+		return []any{value}, []string{pointer + "/default"}
+	}
+	if value, ok := schemaDefaults(object); ok {
+		return []any{value}, []string{pointer + "/properties"}
+	}
+	return nil, nil
+}
+
+func schemaDefaults(object map[string]any) (any, bool) {
+	if value, ok := object["default"]; ok {
+		return value, true
+	}
+	properties, ok := object["properties"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	output := make(map[string]any)
+	for key, property := range properties {
+		if _property, ok := property.(map[string]any); ok {
+			if value, ok := schemaDefaults(_property); ok {
+				output[key] = value
+			}
+		}
+	}
+	return output, len(output) != 0
+}
+
+func RenderTextproto(file *ProtoFile, protoFile string, messageName string, value any, pointer string, options Options, diagnosticHandler DiagnosticHandler) string {
+	writer := textprotoWriter{file: file, definitions: make(map[string]ProtoDefinition), diagnosticHandler: diagnosticHandler}
+	for _, definition := range file.Definitions {
+		writer.definitions[definitionName(definition)] = definition
+	}
+	fullName := messageName
+	if len(file.Package) != 0 {
+		fullName = file.Package + "." + messageName
+	}
+	fmt.Fprintf(&writer.builder, "# proto-file: %s\n# proto-message: %s\n\n", protoFile, fullName)
+	writer.message(messageName, value, "", pointer)
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(writer.builder.String(), "\n", "\r\n")
+	}
+	return writer.builder.String()
+}
+
+func WriteTextprotos(file *ProtoFile, schema []byte, protoFile string, outputDirectory string, options Options, diagnosticHandler DiagnosticHandler) error {
+	for _, definition := range file.Definitions {
+		if definition.Message == nil || len(definition.Message.Pointer) == 0 {
+			continue
+		}
+		examples, pointers := SchemaExamples(schema, definition.Message.Pointer)
+		for i, example := range examples {
+			name := definition.Message.Name + ".textproto"
+			if len(examples) > 1 {
+				name = fmt.Sprintf("%s_%d.textproto", definition.Message.Name, i+1)
+			}
+			content := RenderTextproto(file, protoFile, definition.Message.Name, example, pointers[i], options, diagnosticHandler)
+			err := os.MkdirAll(outputDirectory, 0755)
+			if err != nil {
+				return err
+			}
+			err = os.WriteFile(filepath.Join(outputDirectory, name), []byte(content), 0644)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (rcvr *textprotoWriter) lookup(typeName string) (ProtoDefinition, bool) {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(rcvr.file.Package) != 0 {
+		typeName = strings.TrimPrefix(typeName, rcvr.file.Package+".")
+	}
+	definition, ok := rcvr.definitions[typeName]
+	return definition, ok
+}
+
+func (rcvr *textprotoWriter) message(typeName string, value any, indent string, pointer string) bool {
+	object, ok := value.(map[string]any)
+	definition, found := rcvr.lookup(typeName)
+	if !ok || !found || definition.Message == nil {
+		return false
+	}
+	keys := make(map[string]bool)
+	for _, field := range definition.Message.Fields {
+		if keys[textprotoKey(field)] {
+			continue
+		}
+		_value, ok := object[textprotoKey(field)]
+		if !ok {
+			continue
+		}
+		keys[textprotoKey(field)] = true
+		_pointer := pointer + "/" + escapePointer(textprotoKey(field))
+		if _value == nil {
+			continue
+		}
+		if !rcvr.member(definition.Message, field, _value, indent, _pointer) {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: _pointer, Message: fmt.Sprintf("example value does not match %s.%s and was skipped", definition.Message.Name, field.Name)})
+		}
+	}
+	for key := range object {
+		if !keys[key] {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer + "/" + escapePointer(key), Message: fmt.Sprintf("example property has no matching field in %s and was skipped", definition.Message.Name)})
+		}
+	}
+	return true
+}
+
+func (rcvr *textprotoWriter) member(message *ProtoMessage, field *ProtoField, value any, indent string, pointer string) bool {
+	if len(field.Oneof) == 0 {
+		return rcvr.field(field, value, indent, pointer)
+	}
+	for _, _field := range message.Fields {
+		if _field.Oneof == field.Oneof && textprotoKey(_field) == textprotoKey(field) && rcvr.field(_field, value, indent, pointer) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rcvr *textprotoWriter) field(field *ProtoField, value any, indent string, pointer string) bool {
+	if strings.HasPrefix(field.Type, "map<") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return false
+		}
+		index := strings.Index(field.Type, ",")
+		keyType := strings.TrimPrefix(field.Type[:index], "map<")
+		valueType := strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">"))
+		mark := rcvr.builder.Len()
+		for _, key := range sortedKeys(object) {
+			rcvr.builder.WriteString(indent + field.Name + " {\n")
+			if !rcvr.value("key", keyType, key, indent+"  ", pointer) || !rcvr.value("value", valueType, object[key], indent+"  ", pointer+"/"+escapePointer(key)) {
+				rcvr.truncate(mark)
+				return false
+			}
+			rcvr.builder.WriteString(indent + "}\n")
+		}
+		return true
+	}
+	if field.Label == REPEATED_LABEL {
+		array, ok := value.([]any)
+		if !ok {
+			return false
+		}
+		mark := rcvr.builder.Len()
+		for i, item := range array {
+			if !rcvr.value(field.Name, field.Type, item, indent, fmt.Sprintf("%s/%d", pointer, i)) {
+				rcvr.truncate(mark)
+				return false
+			}
+		}
+		return true
+	}
+	return rcvr.value(field.Name, field.Type, value, indent, pointer)
+}
+
+func (rcvr *textprotoWriter) value(name string, typeName string, value any, indent string, pointer string) bool {
+	if scalar, ok := textprotoScalar(typeName, value); ok {
+		rcvr.builder.WriteString(indent + name + ": " + scalar + "\n")
+		return true
+	}
+	mark := rcvr.builder.Len()
+	rcvr.builder.WriteString(indent + name + " {\n")
+	ok := rcvr.wellKnown(strings.TrimPrefix(typeName, "."), value, indent+"  ")
+	if !ok {
+		definition, found := rcvr.lookup(typeName)
+		if found && definition.Enum != nil {
+			rcvr.truncate(mark)
+			if _value, ok := textprotoEnum(definition.Enum, value); ok {
+				rcvr.builder.WriteString(indent + name + ": " + _value + "\n")
+				return true
+			}
+			return false
+		}
+		ok = rcvr.message(typeName, value, indent+"  ", pointer)
+	}
+	if !ok {
+		rcvr.truncate(mark)
+		return false
+	}
+	rcvr.builder.WriteString(indent + "}\n")
+	return true
+}
+
+func (rcvr *textprotoWriter) wellKnown(typeName string, value any, indent string) bool {
+	switch typeName {
+	case "google.protobuf.Timestamp":
+		{
+			str, ok := value.(string)
+			if !ok {
+				return false
+			}
+			timestamp, err := time.Parse(time.RFC3339Nano, str)
+			if err != nil {
+				return false
+			}
+			rcvr.seconds(timestamp.Unix(), int64(timestamp.Nanosecond()), indent)
+			return true
+		}
+	case "google.protobuf.Duration":
+		{
+			str, ok := value.(string)
+			if !ok {
+				return false
+			}
+			duration, err := time.ParseDuration(str)
+			if err != nil {
+				return false
+			}
+			rcvr.seconds(int64(duration/time.Second), int64(duration%time.Second), indent)
+			return true
+		}
+	case "google.protobuf.FieldMask":
+		{
+			str, ok := value.(string)
+			if !ok {
+				return false
+			}
+			for _, path := range strings.Split(str, ",") {
+				if snake, ok := toSnakeCase(strings.TrimSpace(path)); ok {
+					path = *snake
+				}
+				rcvr.builder.WriteString(indent + "paths: " + textprotoString(path, false) + "\n")
+			}
+			return true
+		}
+	case "google.type.Date":
+		{
+			str, ok := value.(string)
+			if !ok {
+				return false
+			}
+			date, err := time.Parse("2006-01-02", str)
+			if err != nil {
+				return false
+			}
+			fmt.Fprintf(&rcvr.builder, "%syear: %d\n%smonth: %d\n%sday: %d\n", indent, date.Year(), indent, date.Month(), indent, date.Day())
+			return true
+		}
+	case "google.type.TimeOfDay":
+		{
+			str, ok := value.(string)
+			if !ok {
+				return false
+			}
+			timeOfDay, err := time.Parse("15:04:05.999999999", strings.TrimSuffix(str, "Z"))
+			if err != nil {
+				return false
+			}
+			fmt.Fprintf(&rcvr.builder, "%shours: %d\n%sminutes: %d\n%sseconds: %d\n", indent, timeOfDay.Hour(), indent, timeOfDay.Minute(), indent, timeOfDay.Second())
+			if timeOfDay.Nanosecond() != 0 {
+				fmt.Fprintf(&rcvr.builder, "%snanos: %d\n", indent, timeOfDay.Nanosecond())
+			}
+			return true
+		}
+	case "google.protobuf.Empty":
+		{
+			object, ok := value.(map[string]any)
+			return ok && len(object) == 0
+		}
+	case "google.protobuf.Struct":
+		{
+			object, ok := value.(map[string]any)
+			if !ok {
+				return false
+			}
+			for _, key := range sortedKeys(object) {
+				rcvr.builder.WriteString(indent + "fields {\n" + indent + "  key: " + textprotoString(key, false) + "\n" + indent + "  value {\n")
+				rcvr.structValue(object[key], indent+"    ")
+				rcvr.builder.WriteString(indent + "  }\n" + indent + "}\n")
+			}
+			return true
+		}
+	case "google.protobuf.Value":
+		{
+			rcvr.structValue(value, indent)
+			return true
+		}
+	case "google.protobuf.ListValue":
+		{
+			array, ok := value.([]any)
+			if !ok {
+				return false
+			}
+			for _, item := range array {
+				rcvr.builder.WriteString(indent + "values {\n")
+				rcvr.structValue(item, indent+"  ")
+				rcvr.builder.WriteString(indent + "}\n")
+			}
+			return true
+		}
+	}
+	if wrapper, ok := PROTO_WRAPPERS[typeName]; ok {
+		scalar, ok := textprotoScalar(wrapper, value)
+		if ok {
+			rcvr.builder.WriteString(indent + "value: " + scalar + "\n")
+		}
+		return ok
+	}
+	return false
+}
+
+func (rcvr *textprotoWriter) seconds(seconds int64, nanos int64, indent string) {
+	fmt.Fprintf(&rcvr.builder, "%sseconds: %d\n", indent, seconds)
+	if nanos != 0 {
+		fmt.Fprintf(&rcvr.builder, "%snanos: %d\n", indent, nanos)
+	}
+}
+
+func (rcvr *textprotoWriter) structValue(value any, indent string) {
+	switch _value := value.(type) {
+	case nil:
+		{
+			rcvr.builder.WriteString(indent + "null_value: NULL_VALUE\n")
+		}
+	case bool:
+		{
+			rcvr.builder.WriteString(indent + "bool_value: " + strconv.FormatBool(_value) + "\n")
+		}
+	case float64:
+		{
+			rcvr.builder.WriteString(indent + "number_value: " + textprotoFloat(_value) + "\n")
+		}
+	case string:
+		{
+			rcvr.builder.WriteString(indent + "string_value: " + textprotoString(_value, false) + "\n")
+		}
+	case []any:
+		{
+			rcvr.builder.WriteString(indent + "list_value {\n")
+			rcvr.wellKnown("google.protobuf.ListValue", _value, indent+"  ")
+			rcvr.builder.WriteString(indent + "}\n")
+		}
+	case map[string]any:
+		{
+			rcvr.builder.WriteString(indent + "struct_value {\n")
+			rcvr.wellKnown("google.protobuf.Struct", _value, indent+"  ")
+			rcvr.builder.WriteString(indent + "}\n")
+		}
+	}
+}
+
+func (rcvr *textprotoWriter) truncate(length int) {
+	content := rcvr.builder.String()[:length]
+	rcvr.builder.Reset()
+	rcvr.builder.WriteString(content)
+}
+
+func textprotoKey(field *ProtoField) string {
+	if len(field.Original) != 0 {
+		return field.Original
+	}
+	return field.Name
+}
+
+func textprotoScalar(typeName string, value any) (string, bool) {
+	switch typeName {
+	case "bool":
+		{
+			_value, ok := value.(bool)
+			return strconv.FormatBool(_value), ok
+		}
+	case "string":
+		{
+			_value, ok := value.(string)
+			return textprotoString(_value, false), ok
+		}
+	case "bytes":
+		{
+			_value, ok := value.(string)
+			if data, err := base64.StdEncoding.DecodeString(_value); ok && err == nil {
+				return textprotoString(string(data), true), true
+			}
+			return textprotoString(_value, true), ok
+		}
+	case "double", "float":
+		{
+			_value, ok := value.(float64)
+			return textprotoFloat(_value), ok
+		}
+	}
+	if !TEXTPROTO_INTEGERS[typeName] {
+		return "", false
+	}
+	switch _value := value.(type) {
+	case float64:
+		{
+			if _value != math.Trunc(_value) || (strings.HasPrefix(typeName, "u") || strings.HasPrefix(typeName, "fixed")) && _value < 0 {
+				return "", false
+			}
+			return strconv.FormatFloat(_value, 'f', -1, 64), true
+		}
+	case string:
+		{
+			if strings.HasSuffix(typeName, "64") {
+				if _, err := strconv.ParseInt(_value, 10, 64); err == nil {
+					return _value, true
+				}
+				if _, err := strconv.ParseUint(_value, 10, 64); err == nil {
+					return _value, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func textprotoEnum(enum *ProtoEnum, value any) (string, bool) {
+	switch _value := value.(type) {
+	case string:
+		{
+			for _, enumValue := range enum.Values {
+				if enumValue.Original == _value {
+					return enumValue.Name, true
+				}
+			}
+			for _, enumValue := range enum.Values {
+				if enumValue.Name == _value || strings.TrimPrefix(enumValue.Name, strings.ToUpper(enum.Name)+"_") == strings.ToUpper(_value) {
+					return enumValue.Name, true
+				}
+			}
+		}
+	case float64:
+		{
+			for _, enumValue := range enum.Values {
+				if enumValue.Original == strconv.FormatFloat(_value, 'f', -1, 64) {
+					return enumValue.Name, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func textprotoFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		{
+			return "inf"
+		}
+	case math.IsInf(value, -1):
+		{
+			return "-inf"
+		}
+	case math.IsNaN(value):
+		{
+			return "nan"
+		}
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func textprotoString(value string, binary bool) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"' || c == '\\':
+			{
+				builder.WriteByte('\\')
+				builder.WriteByte(c)
+			}
+		case c == '\n':
+			{
+				builder.WriteString(`\n`)
+			}
+		case c == '\r':
+			{
+				builder.WriteString(`\r`)
+			}
+		case c == '\t':
+			{
+				builder.WriteString(`\t`)
+			}
+		case c < 0x20 || c == 0x7f || binary && c >= 0x80:
+			{
+				fmt.Fprintf(&builder, `\%03o`, c)
+			}
+		default:
+			{
+				builder.WriteByte(c)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const TEXTPROTO_TEST_SCHEMA = `{"title": "Order", "type": "object", "properties": {
+	"id": {"type": "string"},
+	"count": {"type": "integer"},
+	"status": {"enum": ["open", "closed"]},
+	"tags": {"type": "array", "items": {"type": "string"}},
+	"item": {"$ref": "#/definitions/Item"}
+}, "examples": [{"id": "o-1", "count": 2, "status": "open", "tags": ["a", "b"], "item": {"sku": "s\"1"}}, {"id": "o-2"}],
+"definitions": {"Item": {"type": "object", "properties": {"sku": {"type": "string", "default": "none"}}}}}`
+
+func TestWriteTextprotos(t *testing.T) {
+	file := NewWithOptions([]byte(TEXTPROTO_TEST_SCHEMA), DefaultOptions()).Build("acme")
+	directory := t.TempDir()
+	err := WriteTextprotos(file, []byte(TEXTPROTO_TEST_SCHEMA), "order.proto", directory, DefaultOptions(), func(Diagnostic) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"Order_1.textproto": "# proto-file: order.proto\n# proto-message: acme.Order\n\nid: \"o-1\"\nitem {\n  sku: \"s\\\"1\"\n}\ntags: \"a\"\ntags: \"b\"\ncount: 2\nstatus: STATUS_OPEN\n",
+		"Order_2.textproto": "# proto-file: order.proto\n# proto-message: acme.Order\n\nid: \"o-2\"\n",
+		"Item.textproto":    "# proto-file: order.proto\n# proto-message: acme.Item\n\nsku: \"none\"\n",
+	}
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d samples, got %v", len(expected), entries)
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Fatalf("%s: expected\n%s\ngot\n%s", name, content, data)
+		}
+	}
+}
+
+func TestRenderTextprotoMismatches(t *testing.T) {
+	file := NewWithOptions([]byte(TEXTPROTO_TEST_SCHEMA), DefaultOptions()).Build("acme")
+	value := map[string]any{"id": "o-1", "count": "many", "status": "lost", "unknown": true}
+	diagnostics := make(map[string]bool)
+	output := RenderTextproto(file, "order.proto", "Order", value, "#/examples/0", DefaultOptions(), func(diagnostic Diagnostic) {
+		diagnostics[diagnostic.Subject] = diagnostic.Severity == WARNING
+	})
+	if !strings.HasSuffix(output, "\n\nid: \"o-1\"\n") {
+		t.Fatalf("mismatched values were rendered:\n%s", output)
+	}
+	for _, pointer := range []string{"#/examples/0/count", "#/examples/0/status", "#/examples/0/unknown"} {
+		if !diagnostics[pointer] {
+			t.Fatalf("expected a warning for %s, got %v", pointer, diagnostics)
+		}
+	}
+}
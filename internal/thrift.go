@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var THRIFT_SCALARS = map[string]string{
+	"double":   "double",
+	"float":    "double",
+	"int32":    "i32",
+	"sint32":   "i32",
+	"sfixed32": "i32",
+	"uint32":   "i64",
+	"fixed32":  "i64",
+	"int64":    "i64",
+	"sint64":   "i64",
+	"sfixed64": "i64",
+	"uint64":   "i64",
+	"fixed64":  "i64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "binary",
+}
+
+var THRIFT_WELL_KNOWN_TYPES = map[string][2]string{
+	"google.protobuf.Timestamp":   {"Timestamp", "string"},
+	"google.protobuf.Duration":    {"Duration", "string"},
+	"google.protobuf.FieldMask":   {"FieldMask", "string"},
+	"google.protobuf.Struct":      {"Struct", "string"},
+	"google.protobuf.Value":       {"Value", "string"},
+	"google.protobuf.ListValue":   {"ListValue", "string"},
+	"google.protobuf.Any":         {"Any", "string"},
+	"google.protobuf.DoubleValue": {"double", ""},
+	"google.protobuf.FloatValue":  {"double", ""},
+	"google.protobuf.Int64Value":  {"i64", ""},
+	"google.protobuf.UInt64Value": {"i64", ""},
+	"google.protobuf.Int32Value":  {"i32", ""},
+	"google.protobuf.UInt32Value": {"i64", ""},
+	"google.protobuf.BoolValue":   {"bool", ""},
+	"google.protobuf.StringValue": {"string", ""},
+	"google.protobuf.BytesValue":  {"binary", ""},
+	"google.type.Date":            {"Date", "string"},
+	"google.type.TimeOfDay":       {"TimeOfDay", "string"},
+	"google.rpc.Status":           {"Status", "string"},
+}
+
+var THRIFT_KEYWORDS = map[string]bool{
+	"binary": true, "bool": true, "byte": true, "const": true, "cpp_include": true, "cpp_type": true, "double": true,
+	"enum": true, "exception": true, "extends": true, "false": true, "i8": true, "i16": true, "i32": true, "i64": true,
+	"include": true, "list": true, "map": true, "namespace": true, "oneway": true, "optional": true, "required": true,
+	"senum": true, "service": true, "set": true, "slist": true, "string": true, "struct": true, "throws": true,
+	"true": true, "typedef": true, "union": true, "uuid": true, "void": true,
+}
+
+const THRIFT_TEMPLATE = `// Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}{{end}}{{if .Namespace}}
+namespace * {{.Namespace}}
+{{end}}{{range .Typedefs}}
+typedef {{index . 1}} {{index . 0}}
+{{end}}{{range .Types}}{{if .Values}}
+enum {{.Name}} {
+{{range .Values}}  {{.Name}} = {{.Value}}
+{{end}}}
+{{else}}
+{{.Kind}} {{.Name}} {
+{{range .Fields}}{{with .Comment}}  // {{.}}
+{{end}}  {{.Id}}: {{with .Qualifier}}{{.}} {{end}}{{.Type}} {{.Name}}
+{{end}}}
+{{end}}{{end}}`
+
+type ThriftFile struct {
+	Metadata  *ProtoMetadata
+	Namespace string
+	Typedefs  [][2]string
+	Types     []*ThriftType
+}
+
+type ThriftType struct {
+	Kind   string
+	Name   string
+	Fields []ThriftField
+	Values []GoValue
+}
+
+type ThriftField struct {
+	Id        int
+	Qualifier string
+	Type      string
+	Name      string
+	Comment   string
+}
+
+func RenderThrift(file *ProtoFile, options Options) string {
+	thriftFile := NewThriftFile(file, options)
+	var buffer bytes.Buffer
+	err := template.Must(template.New("thrift").Parse(THRIFT_TEMPLATE)).Execute(&buffer, thriftFile)
+	if err != nil {
+		panic(err)
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(buffer.String(), "\n", "\r\n")
+	}
+	return buffer.String()
+}
+
+func NewThriftFile(file *ProtoFile, options Options) *ThriftFile {
+	output := ThriftFile{Metadata: file.Metadata, Namespace: file.Package}
+	typedefs := make(map[string]string)
+	unions := make([]*ThriftType, 0)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			output.Types = append(output.Types, thriftEnum(definition.Enum, options))
+			continue
+		}
+		message := definition.Message
+		_type := ThriftType{Kind: "struct", Name: toThriftTypeName(file.Package, message.Name)}
+		for _, member := range message.Members() {
+			if member.Oneof == nil {
+				field := member.Field
+				_type.Fields = append(_type.Fields, ThriftField{Id: field.Number, Qualifier: thriftQualifier(field), Type: thriftFieldType(file.Package, field, typedefs), Name: toThriftName(toSnakeName(sourceName(field))), Comment: field.Comment})
+				continue
+			}
+			name := member.Oneof.Fields[0].Original
+			union := ThriftType{Kind: "union"}
+			for _, field := range member.Oneof.Fields {
+				if field.Original != name {
+					name = member.Oneof.Name
+				}
+				union.Fields = append(union.Fields, ThriftField{Id: field.Number, Type: thriftType(file.Package, field.Type, typedefs), Name: toThriftName(toSnakeName(sourceMemberName(field))), Comment: field.Comment})
+			}
+			if len(name) == 0 {
+				name = member.Oneof.Name
+			}
+			union.Name = _type.Name + toGoName(name)
+			unions = append(unions, &union)
+			_type.Fields = append(_type.Fields, ThriftField{Id: member.Oneof.Fields[0].Number, Qualifier: "optional", Type: union.Name, Name: toThriftName(toSnakeName(name))})
+		}
+		output.Types = append(output.Types, &_type)
+	}
+	output.Types = append(unions, output.Types...)
+	for name, typeName := range typedefs {
+		output.Typedefs = append(output.Typedefs, [2]string{name, typeName})
+	}
+	sort.Slice(output.Typedefs, func(i, j int) bool {
+		return output.Typedefs[i][0] < output.Typedefs[j][0]
+	})
+	return &output
+}
+
+func thriftEnum(enum *ProtoEnum, options Options) *ThriftType {
+	output := ThriftType{Name: toThriftTypeName("", enum.Name)}
+	prefix := strings.ToUpper(enum.Name) + "_"
+	for _, value := range enum.Values {
+		name := strings.TrimPrefix(value.Name, prefix)
+		if len(value.Original) == 0 && name == strings.ToUpper(*fixString(options.EnumZeroValue)) {
+			continue
+		}
+		if len(name) == 0 || unicode.IsDigit(rune(name[0])) {
+			name = value.Name
+		}
+		output.Values = append(output.Values, GoValue{Name: toThriftName(name), Value: fmt.Sprint(value.Number)})
+	}
+	return &output
+}
+
+func thriftQualifier(field *ProtoField) string {
+	if field.Required {
+		return "required"
+	}
+	return "optional"
+}
+
+func thriftFieldType(packageName string, field *ProtoField, typedefs map[string]string) string {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		key := thriftType(packageName, strings.TrimPrefix(field.Type[:index], "map<"), typedefs)
+		value := thriftType(packageName, strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">")), typedefs)
+		return fmt.Sprintf("map<%s, %s>", key, value)
+	}
+	typeName := thriftType(packageName, field.Type, typedefs)
+	if field.Label == REPEATED_LABEL {
+		return fmt.Sprintf("list<%s>", typeName)
+	}
+	return typeName
+}
+
+func thriftType(packageName string, typeName string, typedefs map[string]string) string {
+	if scalar, ok := THRIFT_SCALARS[typeName]; ok {
+		return scalar
+	}
+	if wellKnown, ok := THRIFT_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		if len(wellKnown[1]) != 0 {
+			typedefs[wellKnown[0]] = wellKnown[1]
+		}
+		return wellKnown[0]
+	}
+	return toThriftTypeName(packageName, typeName)
+}
+
+func toThriftTypeName(packageName string, typeName string) string {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if len(packageName) != 0 {
+		typeName = strings.TrimPrefix(typeName, packageName+".")
+	}
+	return toThriftName(strings.ReplaceAll(typeName, ".", "_"))
+}
+
+func toThriftName(name string) string {
+	if THRIFT_KEYWORDS[name] {
+		return name + "_"
+	}
+	return name
+}
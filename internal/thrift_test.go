@@ -0,0 +1,16 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThriftFieldNames(t *testing.T) {
+	file, options := initialismTestFile(t)
+	output := RenderThrift(file, options)
+	for _, expected := range []string{" i32 http_code\n", " string user_id\n", " string first_name\n", " RespNote note\n", " string note_string\n", " i32 note_int32\n"} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("expected %q in\n%s", expected, output)
+		}
+	}
+}
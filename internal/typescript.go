@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var TS_SCALARS = map[string]string{
+	"double":   "number",
+	"float":    "number",
+	"int32":    "number",
+	"sint32":   "number",
+	"sfixed32": "number",
+	"uint32":   "number",
+	"fixed32":  "number",
+	"int64":    "number",
+	"sint64":   "number",
+	"sfixed64": "number",
+	"uint64":   "number",
+	"fixed64":  "number",
+	"bool":     "boolean",
+	"string":   "string",
+	"bytes":    "string",
+}
+
+var TS_WELL_KNOWN_TYPES = map[string]string{
+	"google.protobuf.Timestamp":   "string",
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.Struct":      "{ [key: string]: unknown }",
+	"google.protobuf.Value":       "unknown",
+	"google.protobuf.ListValue":   "unknown[]",
+	"google.protobuf.Any":         "{ [key: string]: unknown }",
+	"google.protobuf.Empty":       "Record<string, never>",
+	"google.protobuf.DoubleValue": "number | null",
+	"google.protobuf.FloatValue":  "number | null",
+	"google.protobuf.Int64Value":  "number | null",
+	"google.protobuf.UInt64Value": "number | null",
+	"google.protobuf.Int32Value":  "number | null",
+	"google.protobuf.UInt32Value": "number | null",
+	"google.protobuf.BoolValue":   "boolean | null",
+	"google.protobuf.StringValue": "string | null",
+	"google.protobuf.BytesValue":  "string | null",
+	"google.type.Date":            "string",
+	"google.type.TimeOfDay":       "string",
+	"google.rpc.Status":           "{ [key: string]: unknown }",
+}
+
+var TS_IDENTIFIER_PATTERN = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+const TS_TEMPLATE = `// Code generated by j2p. DO NOT EDIT.
+{{with .Metadata}}// versions:
+//   j2p {{.Version}}
+{{if .Source}}// source: {{.Source}}
+{{end}}// sha256: {{.Hash}}
+{{if .Timestamp}}// generated: {{.Timestamp}}
+{{end}}{{end}}{{range .Definitions}}
+{{if .Values}}export type {{.Name}} = {{.Values}};
+{{else}}export interface {{.Name}} {
+{{range .Fields}}{{with .Comment}}  /** {{.}} */
+{{end}}  {{.Label}}: {{.Type}};
+{{end}}}
+{{end}}{{end}}`
+
+type TsFile struct {
+	Metadata    *ProtoMetadata
+	Definitions []TsDefinition
+}
+
+type TsDefinition struct {
+	Name   string
+	Fields []TsField
+	Values string
+}
+
+type TsField struct {
+	Label   string
+	Type    string
+	Comment string
+}
+
+func RenderTypeScript(file *ProtoFile, options Options) string {
+	tsFile := NewTsFile(file, options)
+	var buffer bytes.Buffer
+	err := template.Must(template.New("ts").Parse(TS_TEMPLATE)).Execute(&buffer, tsFile)
+	if err != nil {
+		panic(err)
+	}
+	if options.Newline == CRLF_NEWLINE {
+		return strings.ReplaceAll(buffer.String(), "\n", "\r\n")
+	}
+	return buffer.String()
+}
+
+func NewTsFile(file *ProtoFile, options Options) *TsFile {
+	output := TsFile{Metadata: file.Metadata}
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			output.Definitions = append(output.Definitions, TsDefinition{Name: toTsTypeName(file.Package, definition.Enum.Name), Values: tsEnum(definition.Enum, options)})
+			continue
+		}
+		_definition := TsDefinition{Name: toTsTypeName(file.Package, definition.Message.Name)}
+		for _, member := range definition.Message.Members() {
+			if member.Oneof != nil {
+				name := member.Oneof.Fields[0].Original
+				types := make([]string, 0)
+				for _, field := range member.Oneof.Fields {
+					if field.Original != name {
+						name = member.Oneof.Name
+					}
+					types = append(types, tsType(file.Package, field.Type))
+				}
+				if len(name) == 0 {
+					name = member.Oneof.Name
+				}
+				_definition.Fields = append(_definition.Fields, TsField{Label: toTsLabel(name) + "?", Type: strings.Join(types, " | ")})
+				continue
+			}
+			field := member.Field
+			name := field.Original
+			if len(name) == 0 {
+				name = field.Name
+			}
+			label := toTsLabel(name)
+			if !field.Required {
+				label += "?"
+			}
+			_definition.Fields = append(_definition.Fields, TsField{Label: label, Type: tsFieldType(file.Package, field), Comment: strings.ReplaceAll(field.Comment, "*/", "* /")})
+		}
+		output.Definitions = append(output.Definitions, _definition)
+	}
+	return &output
+}
+
+func tsEnum(enum *ProtoEnum, options Options) string {
+	values := make([]string, 0)
+	prefix := strings.ToUpper(enum.Name) + "_"
+	for _, value := range enum.Values {
+		_value := value.Original
+		if len(_value) == 0 {
+			if strings.TrimPrefix(value.Name, prefix) == strings.ToUpper(*fixString(options.EnumZeroValue)) {
+				continue
+			}
+			_value = value.Name
+		}
+		data, _ := json.Marshal(_value)
+		values = append(values, string(data))
+	}
+	if len(values) == 0 {
+		return "string"
+	}
+	return strings.Join(values, " | ")
+}
+
+func tsFieldType(packageName string, field *ProtoField) string {
+	if strings.HasPrefix(field.Type, "map<") {
+		index := strings.Index(field.Type, ",")
+		return fmt.Sprintf("{ [key: string]: %s }", tsType(packageName, strings.TrimSpace(strings.TrimSuffix(field.Type[index+1:], ">"))))
+	}
+	typeName := tsType(packageName, field.Type)
+	if field.Label == REPEATED_LABEL {
+		if strings.Contains(typeName, "|") {
+			typeName = "(" + typeName + ")"
+		}
+		return typeName + "[]"
+	}
+	return typeName
+}
+
+func tsType(packageName string, typeName string) string {
+	if scalar, ok := TS_SCALARS[typeName]; ok {
+		return scalar
+	}
+	if wellKnown, ok := TS_WELL_KNOWN_TYPES[strings.TrimPrefix(typeName, ".")]; ok {
+		return wellKnown
+	}
+	return toTsTypeName(packageName, typeName)
+}
+
+func toTsTypeName(packageName string, typeName string) string {
+	return strings.ReplaceAll(toGoTypeName(packageName, typeName), "_", "")
+}
+
+func toTsLabel(name string) string {
+	if TS_IDENTIFIER_PATTERN.MatchString(name) {
+		return name
+	}
+	data, _ := json.Marshal(name)
+	return string(data)
+}
@@ -0,0 +1,40 @@
+package internal
+
+import "fmt"
+
+const FIELD_MASK_IMPORT = "google/protobuf/field_mask.proto"
+
+func (rcvr DefaultJsonSchemaParser) registerUpdateRequests() []string {
+	output := make([]string, 0)
+	for _, value := range rcvr.options.UpdateRequests {
+		name, pointer, resource, ok := rcvr.resolveRoot(value)
+		if !ok || len(resource.Properties) == 0 {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: value, Message: "update request target does not match any object definition in the schema"})
+			continue
+		}
+		if len(resource.Required) != 0 {
+			rcvr.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: value, Message: "update request target has required properties, partial updates usually mirror an all-optional resource"})
+		}
+		output = append(output, rcvr.nestedObjectHander("", name, pointer, resource))
+	}
+	return output
+}
+
+func (rcvr DefaultJsonSchemaParser) buildUpdateRequests(resources []string, definitions map[string]ProtoDefinition) []string {
+	output := make([]string, 0)
+	for _, resource := range resources {
+		name := fmt.Sprintf("Update%sRequest", resource)
+		if _, ok := definitions[name]; ok || rcvr.duplicateCheck(name) {
+			rcvr.diagnosticHandler(Diagnostic{Severity: ERROR, Subject: resource, Message: fmt.Sprintf("%s already exists and was not generated", name)})
+			continue
+		}
+		index := 1
+		message := ProtoMessage{Name: name}
+		message.Fields = append(message.Fields, toField(NO_LABEL, resource, lowerFirst(resource), &index, rcvr.diagnosticHandler))
+		message.Fields = append(message.Fields, toField(NO_LABEL, "google.protobuf.FieldMask", "updateMask", &index, rcvr.diagnosticHandler))
+		definitions[name] = ProtoDefinition{Message: &message}
+		rcvr.dependencies[name] = append(rcvr.dependencies[name], resource)
+		output = append(output, name)
+	}
+	return output
+}
@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Validation string
+
+const (
+	NO_VALIDATION  Validation = "none"
+	BUF_VALIDATION Validation = "buf"
+	PGV_VALIDATION Validation = "pgv"
+)
+
+const (
+	BUF_VALIDATE_IMPORT = "buf/validate/validate.proto"
+	PGV_VALIDATE_IMPORT = "validate/validate.proto"
+)
+
+func ApplyConstraints(property Properties, field *ProtoField, options Options, diagnosticHandler DiagnosticHandler) {
+	validate := options.Validation == BUF_VALIDATION || options.Validation == PGV_VALIDATION
+	rules := make([]string, 0)
+	notes := make([]string, 0)
+	if field.Label == REPEATED_LABEL {
+		if property.MinItems != nil {
+			rules = append(rules, fmt.Sprintf("repeated.min_items = %d", *property.MinItems))
+		}
+		if property.MaxItems != nil {
+			rules = append(rules, fmt.Sprintf("repeated.max_items = %d", *property.MaxItems))
+		}
+		if property.UniqueItems != nil && *property.UniqueItems {
+			if _, ok := PROTO_SCALARS[field.Type]; ok && validate {
+				rules = append(rules, "repeated.unique = true")
+			} else {
+				notes = append(notes, "items must be unique")
+			}
+		}
+		if property.Items != nil {
+			_rules, _notes := scalarConstraints(*property.Items, field.Type, field.Pointer+"/items", options, diagnosticHandler)
+			for _, rule := range _rules {
+				rules = append(rules, "repeated.items."+rule)
+			}
+			for _, note := range _notes {
+				notes = append(notes, "items "+note)
+			}
+		}
+	} else if strings.HasPrefix(field.Type, "map<") {
+		if property.MinProperties != nil {
+			rules = append(rules, fmt.Sprintf("map.min_pairs = %d", *property.MinProperties))
+		}
+		if property.MaxProperties != nil {
+			rules = append(rules, fmt.Sprintf("map.max_pairs = %d", *property.MaxProperties))
+		}
+	} else {
+		_rules, _notes := scalarConstraints(property, field.Type, field.Pointer, options, diagnosticHandler)
+		rules = append(rules, _rules...)
+		notes = append(notes, _notes...)
+		if property.MinProperties != nil {
+			notes = append(notes, fmt.Sprintf("minProperties: %d", *property.MinProperties))
+		}
+		if property.MaxProperties != nil {
+			notes = append(notes, fmt.Sprintf("maxProperties: %d", *property.MaxProperties))
+		}
+	}
+	if validate {
+		for _, rule := range rules {
+			field.Constraints = append(field.Constraints, validationOption(options, rule))
+		}
+	}
+	for _, note := range notes {
+		field.Comment = strings.TrimPrefix(field.Comment+"; "+note, "; ")
+	}
+}
+
+func ApplyRequired(file *ProtoFile, options Options, diagnosticHandler DiagnosticHandler) {
+	if options.Validation != BUF_VALIDATION && options.Validation != PGV_VALIDATION {
+		return
+	}
+	enums := make(map[string]bool)
+	for _, definition := range file.Definitions {
+		if definition.Enum != nil {
+			enums[definitionName(definition)] = true
+		}
+	}
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			if !field.Required || len(field.Oneof) != 0 || field.Label == REPEATED_LABEL || strings.HasPrefix(field.Type, "map<") {
+				continue
+			}
+			_, scalar := PROTO_SCALARS[field.Type]
+			if scalar || enums[strings.TrimPrefix(strings.TrimPrefix(field.Type, "."), file.Package+".")] {
+				if options.Validation == PGV_VALIDATION {
+					diagnosticHandler(Diagnostic{Severity: WARNING, Subject: field.Pointer, Message: fmt.Sprintf("%s is required but protoc-gen-validate cannot require scalars or enums, a missing value reads as its default; use -validate buf to enforce it", field.Name)})
+					continue
+				}
+				field.Label = OPTIONAL_LABEL
+				field.Constraints = append([]string{validationOption(options, "required = true")}, field.Constraints...)
+				continue
+			}
+			if options.Validation == PGV_VALIDATION {
+				field.Constraints = append([]string{validationOption(options, "message.required = true")}, field.Constraints...)
+				continue
+			}
+			field.Constraints = append([]string{validationOption(options, "required = true")}, field.Constraints...)
+		}
+	}
+}
+
+func MessageConstraints(message Properties, pointer string, diagnosticHandler DiagnosticHandler) {
+	if message.MinProperties != nil {
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer + "/minProperties", Message: fmt.Sprintf("minProperties %d has no equivalent in a proto message and was not enforced", *message.MinProperties)})
+	}
+	if message.MaxProperties != nil {
+		diagnosticHandler(Diagnostic{Severity: INFO, Subject: pointer + "/maxProperties", Message: fmt.Sprintf("maxProperties %d has no equivalent in a proto message and was not enforced", *message.MaxProperties)})
+	}
+}
+
+func scalarConstraints(property Properties, typeName string, pointer string, options Options, diagnosticHandler DiagnosticHandler) ([]string, []string) {
+	output := make([]string, 0)
+	notes := make([]string, 0)
+	if typeName == "string" {
+		if property.MinLength != nil {
+			output = append(output, fmt.Sprintf("string.min_len = %d", *property.MinLength))
+		}
+		if property.MaxLength != nil {
+			output = append(output, fmt.Sprintf("string.max_len = %d", *property.MaxLength))
+		}
+		if property.Pattern != nil && options.Validation != NO_VALIDATION {
+			if _, err := regexp.Compile(*property.Pattern); err != nil {
+				diagnosticHandler(Diagnostic{Severity: WARNING, Subject: pointer + "/pattern", Message: fmt.Sprintf("pattern is not a valid RE2 expression and was not emitted as a validation rule: %s", err.Error())})
+			} else {
+				output = append(output, "string.pattern = "+strconv.Quote(*property.Pattern))
+			}
+		}
+	}
+	output = append(output, rangeConstraints(property, typeName)...)
+	if property.MultipleOf != nil {
+		value := strconv.FormatFloat(*property.MultipleOf, 'g', -1, 64)
+		notes = append(notes, "multipleOf: "+value)
+		if expression, ok := multipleOfExpression(typeName, *property.MultipleOf); ok && options.Validation == BUF_VALIDATION {
+			output = append(output, fmt.Sprintf("cel = {id: \"multiple_of\", message: %s, expression: %s}", strconv.Quote("value must be a multiple of "+value), strconv.Quote(expression)))
+		}
+	}
+	return output, notes
+}
+
+func rangeConstraints(property Properties, typeName string) []string {
+	output := make([]string, 0)
+	bounds := []struct {
+		value *float64
+		rule  string
+	}{{property.ExclusiveMinimum, "gt"}, {property.Minimum, "gte"}, {property.ExclusiveMaximum, "lt"}, {property.Maximum, "lte"}}
+	for _, bound := range bounds {
+		if bound.value == nil {
+			continue
+		}
+		value, rule := *bound.value, bound.rule
+		switch typeName {
+		case "double", "float":
+			{
+				output = append(output, fmt.Sprintf("%s.%s = %s", typeName, rule, strconv.FormatFloat(value, 'g', -1, 64)))
+			}
+		case "int32", "sint32", "sfixed32", "int64", "sint64", "sfixed64", "uint32", "fixed32", "uint64", "fixed64":
+			{
+				if value != math.Trunc(value) {
+					if strings.HasPrefix(rule, "g") {
+						value, rule = math.Ceil(value), "gte"
+					} else {
+						value, rule = math.Floor(value), "lte"
+					}
+				}
+				if strings.Contains(typeName, "u") || strings.HasPrefix(typeName, "fixed") {
+					if value < 0 {
+						continue
+					}
+				}
+				output = append(output, fmt.Sprintf("%s.%s = %d", typeName, rule, int64(value)))
+			}
+		}
+	}
+	return output
+}
+
+func multipleOfExpression(typeName string, multipleOf float64) (string, bool) {
+	if multipleOf <= 0 {
+		return "", false
+	}
+	switch typeName {
+	case "int32", "sint32", "sfixed32", "int64", "sint64", "sfixed64":
+		{
+			if multipleOf != math.Trunc(multipleOf) {
+				return "", false
+			}
+			return fmt.Sprintf("this %% %d == 0", int64(multipleOf)), true
+		}
+	case "uint32", "fixed32", "uint64", "fixed64":
+		{
+			if multipleOf != math.Trunc(multipleOf) {
+				return "", false
+			}
+			return fmt.Sprintf("this %% %du == 0", uint64(multipleOf)), true
+		}
+	case "double", "float":
+		{
+			value := strconv.FormatFloat(multipleOf, 'f', -1, 64)
+			if !strings.Contains(value, ".") {
+				value += ".0"
+			}
+			return fmt.Sprintf("double(int(this / %s)) == this / %s", value, value), true
+		}
+	}
+	return "", false
+}
+
+func validationOption(options Options, rule string) string {
+	if options.Validation == PGV_VALIDATION {
+		return "(validate.rules)." + rule
+	}
+	return "(buf.validate.field)." + rule
+}
+
+func validationImport(options Options, file *ProtoFile) (string, bool) {
+	for _, definition := range file.Definitions {
+		if definition.Message == nil {
+			continue
+		}
+		for _, field := range definition.Message.Fields {
+			if len(field.Constraints) == 0 {
+				continue
+			}
+			if options.Validation == PGV_VALIDATION {
+				return PGV_VALIDATE_IMPORT, true
+			}
+			return BUF_VALIDATE_IMPORT, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+const MAP_TEST_SCHEMA = `{"title": "Resource", "type": "object", "properties": {
+	"labels": {"type": "object", "minProperties": 1, "maxProperties": 8, "additionalProperties": {"type": "string"}},
+	"ports": {"type": "object", "additionalProperties": {"type": "object", "properties": {"number": {"type": "integer"}}}},
+	"open": {"type": "object", "additionalProperties": true}
+}}`
+
+func TestMapConstraints(t *testing.T) {
+	tests := []struct {
+		validation  Validation
+		constraints []string
+	}{
+		{NO_VALIDATION, nil},
+		{BUF_VALIDATION, []string{"(buf.validate.field).map.min_pairs = 1", "(buf.validate.field).map.max_pairs = 8"}},
+		{PGV_VALIDATION, []string{"(validate.rules).map.min_pairs = 1", "(validate.rules).map.max_pairs = 8"}},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.Validation = test.validation
+		message := buildTestMessage(t, MAP_TEST_SCHEMA, options, "Resource")
+		labels := findField(message, "labels")
+		if labels == nil || labels.Type != "map<string, string>" {
+			t.Fatalf("%s: labels was not typed as a map: %+v", test.validation, labels)
+		}
+		if strings.Join(labels.Constraints, ", ") != strings.Join(test.constraints, ", ") {
+			t.Fatalf("%s: expected the constraints %v, got %v", test.validation, test.constraints, labels.Constraints)
+		}
+	}
+}
+
+func TestMapValueTypes(t *testing.T) {
+	message := buildTestMessage(t, MAP_TEST_SCHEMA, DefaultOptions(), "Resource")
+	if ports := findField(message, "ports"); ports == nil || !strings.HasPrefix(ports.Type, "map<string, ") || ports.Label != NO_LABEL {
+		t.Fatalf("ports was not typed as a map of messages: %+v", ports)
+	}
+	if open := findField(message, "open"); open != nil && strings.HasPrefix(open.Type, "map<") {
+		t.Fatalf("a boolean additionalProperties was typed as a map: %+v", open)
+	}
+}
+
+func TestRequiredFields(t *testing.T) {
+	schema := `{"title": "Order", "type": "object", "required": ["id", "item"], "properties": {"id": {"type": "string"}, "item": {"$ref": "#/definitions/Item"}}, "definitions": {"Item": {"type": "object", "properties": {"sku": {"type": "string"}}}}}`
+	tests := []struct {
+		validation Validation
+		id         []string
+		item       []string
+		warns      bool
+	}{
+		{BUF_VALIDATION, []string{"(buf.validate.field).required = true"}, []string{"(buf.validate.field).required = true"}, false},
+		{PGV_VALIDATION, nil, []string{"(validate.rules).message.required = true"}, true},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.Validation = test.validation
+		parser := NewWithOptions([]byte(schema), options)
+		file := parser.Build("test")
+		var message *ProtoMessage
+		for _, definition := range file.Definitions {
+			if definition.Message != nil && definition.Message.Name == "Order" {
+				message = definition.Message
+			}
+		}
+		if id := findField(message, "id"); strings.Join(id.Constraints, ", ") != strings.Join(test.id, ", ") {
+			t.Fatalf("%s: unexpected id constraints %v", test.validation, id.Constraints)
+		}
+		if item := findField(message, "item"); strings.Join(item.Constraints, ", ") != strings.Join(test.item, ", ") {
+			t.Fatalf("%s: unexpected item constraints %v", test.validation, item.Constraints)
+		}
+		warned := false
+		for _, diagnostic := range parser.Diagnostics() {
+			warned = warned || diagnostic.Severity == WARNING && diagnostic.Subject == "#/properties/id"
+		}
+		if warned != test.warns {
+			t.Fatalf("%s: expected a warning for the required scalar to be %v, got %+v", test.validation, test.warns, parser.Diagnostics())
+		}
+	}
+}
+
+func TestStringLengthConstraints(t *testing.T) {
+	schema := `{"title": "User", "type": "object", "properties": {"name": {"type": "string", "minLength": 1, "maxLength": 64}}}`
+	tests := []struct {
+		validation  Validation
+		constraints []string
+	}{
+		{NO_VALIDATION, nil},
+		{BUF_VALIDATION, []string{"(buf.validate.field).string.min_len = 1", "(buf.validate.field).string.max_len = 64"}},
+		{PGV_VALIDATION, []string{"(validate.rules).string.min_len = 1", "(validate.rules).string.max_len = 64"}},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.Validation = test.validation
+		name := findField(buildTestMessage(t, schema, options, "User"), "name")
+		if name == nil || strings.Join(name.Constraints, ", ") != strings.Join(test.constraints, ", ") {
+			t.Fatalf("%s: expected the constraints %v, got %+v", test.validation, test.constraints, name)
+		}
+	}
+}
+
+func TestPatternConstraints(t *testing.T) {
+	options := DefaultOptions()
+	options.Validation = BUF_VALIDATION
+	schema := `{"title": "User", "type": "object", "properties": {"email": {"type": "string", "pattern": "^[a-z]+@[a-z]+$"}}}`
+	email := findField(buildTestMessage(t, schema, options, "User"), "email")
+	if email == nil || strings.Join(email.Constraints, ", ") != `(buf.validate.field).string.pattern = "^[a-z]+@[a-z]+$"` {
+		t.Fatalf("the pattern was not emitted as a rule: %+v", email)
+	}
+	invalid := "^(?!admin).*$"
+	diagnostics := make([]Diagnostic, 0)
+	rules, _ := scalarConstraints(Properties{Pattern: &invalid}, "string", "#/properties/name", options, func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	if len(rules) != 0 {
+		t.Fatalf("a non RE2 pattern was emitted as a rule: %v", rules)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Severity != WARNING || diagnostics[0].Subject != "#/properties/name/pattern" {
+		t.Fatalf("expected one warning for the non RE2 pattern, got %+v", diagnostics)
+	}
+}
+
+func TestUniqueItems(t *testing.T) {
+	schema := `{"title": "Post", "type": "object", "properties": {
+	"tags": {"type": "array", "uniqueItems": true, "items": {"type": "string"}},
+	"authors": {"type": "array", "uniqueItems": true, "items": {"$ref": "#/definitions/Author"}}
+}, "definitions": {"Author": {"type": "object", "properties": {"name": {"type": "string"}}}}}`
+	options := DefaultOptions()
+	options.Validation = BUF_VALIDATION
+	message := buildTestMessage(t, schema, options, "Post")
+	if tags := findField(message, "tags"); tags == nil || strings.Join(tags.Constraints, ", ") != "(buf.validate.field).repeated.unique = true" {
+		t.Fatalf("uniqueItems on scalars was not emitted as a rule: %+v", tags)
+	}
+	if authors := findField(message, "authors"); authors == nil || len(authors.Constraints) != 0 || !strings.Contains(authors.Comment, "items must be unique") {
+		t.Fatalf("uniqueItems on messages was not carried as a comment: %+v", authors)
+	}
+	tags := findField(buildTestMessage(t, schema, DefaultOptions(), "Post"), "tags")
+	if tags == nil || len(tags.Constraints) != 0 || !strings.Contains(tags.Comment, "items must be unique") {
+		t.Fatalf("uniqueItems without -validate was not carried as a comment: %+v", tags)
+	}
+}
+
+func TestItemCountConstraints(t *testing.T) {
+	schema := `{"title": "Post", "type": "object", "properties": {"tags": {"type": "array", "minItems": 1, "maxItems": 10, "items": {"type": "string", "maxLength": 32}}}}`
+	tests := []struct {
+		validation  Validation
+		constraints []string
+	}{
+		{NO_VALIDATION, nil},
+		{BUF_VALIDATION, []string{"(buf.validate.field).repeated.min_items = 1", "(buf.validate.field).repeated.max_items = 10", "(buf.validate.field).repeated.items.string.max_len = 32"}},
+		{PGV_VALIDATION, []string{"(validate.rules).repeated.min_items = 1", "(validate.rules).repeated.max_items = 10", "(validate.rules).repeated.items.string.max_len = 32"}},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.Validation = test.validation
+		tags := findField(buildTestMessage(t, schema, options, "Post"), "tags")
+		if tags == nil || strings.Join(tags.Constraints, ", ") != strings.Join(test.constraints, ", ") {
+			t.Fatalf("%s: expected the constraints %v, got %+v", test.validation, test.constraints, tags)
+		}
+	}
+}
+
+func TestMultipleOf(t *testing.T) {
+	tests := []struct {
+		typeName   string
+		multipleOf float64
+		expression string
+	}{
+		{"int32", 5, "this % 5 == 0"},
+		{"uint64", 8, "this % 8u == 0"},
+		{"double", 0.5, "double(int(this / 0.5)) == this / 0.5"},
+		{"double", 2, "double(int(this / 2.0)) == this / 2.0"},
+		{"int32", 0.5, ""},
+		{"int64", -2, ""},
+	}
+	for _, test := range tests {
+		expression, ok := multipleOfExpression(test.typeName, test.multipleOf)
+		if ok != (test.expression != "") || expression != test.expression {
+			t.Fatalf("%s %g: expected %q, got %q", test.typeName, test.multipleOf, test.expression, expression)
+		}
+	}
+	multipleOf := 5.0
+	for _, validation := range []Validation{NO_VALIDATION, BUF_VALIDATION, PGV_VALIDATION} {
+		options := DefaultOptions()
+		options.Validation = validation
+		rules, notes := scalarConstraints(Properties{MultipleOf: &multipleOf}, "int32", "#/properties/count", options, func(Diagnostic) {})
+		if strings.Join(notes, ", ") != "multipleOf: 5" {
+			t.Fatalf("%s: multipleOf was not carried as a comment: %v", validation, notes)
+		}
+		if (len(rules) == 1) != (validation == BUF_VALIDATION) {
+			t.Fatalf("%s: expected a CEL rule only with buf validation, got %v", validation, rules)
+		}
+	}
+}
+
+func TestRangeConstraints(t *testing.T) {
+	bound := func(value float64) *float64 {
+		return &value
+	}
+	tests := []struct {
+		property Properties
+		typeName string
+		rules    []string
+	}{
+		{Properties{Minimum: bound(0), Maximum: bound(100)}, "int32", []string{"int32.gte = 0", "int32.lte = 100"}},
+		{Properties{ExclusiveMinimum: bound(0), ExclusiveMaximum: bound(1)}, "double", []string{"double.gt = 0", "double.lt = 1"}},
+		{Properties{Minimum: bound(0.5), ExclusiveMaximum: bound(9.5)}, "int64", []string{"int64.gte = 1", "int64.lte = 9"}},
+		{Properties{Minimum: bound(-5), Maximum: bound(5)}, "uint32", []string{"uint32.lte = 5"}},
+		{Properties{Minimum: bound(1)}, "string", []string{}},
+	}
+	for _, test := range tests {
+		rules := rangeConstraints(test.property, test.typeName)
+		if strings.Join(rules, ", ") != strings.Join(test.rules, ", ") {
+			t.Fatalf("%s: expected the rules %v, got %v", test.typeName, test.rules, rules)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const MAX_FETCH_SIZE = 32 << 20
+
+type Fetcher func(url string) ([]byte, error)
+
+type VendorLock struct {
+	Sources map[string]string `json:"sources"`
+}
+
+type vendor struct {
+	fetch             Fetcher
+	local             bool
+	lock              *VendorLock
+	names             map[string]string
+	defs              *JsonObject
+	diagnosticHandler DiagnosticHandler
+}
+
+func HttpFetcher(url string) ([]byte, error) {
+	return fetchHttp(&http.Client{Timeout: 30 * time.Second}, url)
+}
+
+func fetchHttp(client *http.Client, url string) ([]byte, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s failed with status %s", url, response.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, MAX_FETCH_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MAX_FETCH_SIZE {
+		return nil, fmt.Errorf("fetching %s failed, the response is larger than %d bytes", url, MAX_FETCH_SIZE)
+	}
+	return data, nil
+}
+
+func DefaultFetcher(source string) ([]byte, error) {
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if parsed.Scheme == "file" {
+		data, err = os.ReadFile(parsed.Path)
+	} else {
+		data, err = HttpFetcher(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ToJsonSchema(parsed.Path, data, AUTO_INPUT)
+}
+
+func ReadVendorLock(path string) *VendorLock {
+	output := VendorLock{Sources: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &output
+	}
+	if err != nil {
+		panic(err)
+	}
+	err = json.Unmarshal(data, &output)
+	if err != nil {
+		panic(err)
+	}
+	if output.Sources == nil {
+		output.Sources = make(map[string]string)
+	}
+	return &output
+}
+
+func (lock *VendorLock) Write(path string) {
+	err := os.WriteFile(path, EncodeJson(lock), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func Vendor(schema []byte, source string, local bool, lock *VendorLock, fetch Fetcher, diagnosticHandler DiagnosticHandler) []byte {
+	document, err := DecodeJson(schema)
+	if err != nil {
+		panic(err)
+	}
+	root, ok := document.(*JsonObject)
+	if !ok {
+		panic("schema root must be an object")
+	}
+	base := &url.URL{}
+	if absolute, err := filepath.Abs(source); err == nil && len(source) != 0 {
+		base = &url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}
+	}
+	if id, ok := root.Get("$id"); ok {
+		if _id, ok := id.(string); ok {
+			if parsed, err := url.Parse(_id); err == nil {
+				base = parsed
+			}
+		}
+	}
+	defs := NewJsonObject()
+	if existing, ok := root.Get("$defs"); ok {
+		if _existing, ok := existing.(*JsonObject); ok {
+			defs = _existing
+		}
+	}
+	context := vendor{fetch: fetch, local: local, lock: lock, names: make(map[string]string), defs: defs, diagnosticHandler: diagnosticHandler}
+	context.rewrite(root, base, "")
+	if len(context.names) != 0 {
+		root.Set("$defs", defs)
+	}
+	return EncodeJson(root)
+}
+
+func (context *vendor) rewrite(node any, base *url.URL, prefix string) {
+	switch value := node.(type) {
+	case *JsonObject:
+		{
+			for _, key := range value.Keys {
+				if ref, ok := value.Values[key].(string); ok && key == "$ref" {
+					value.Values[key] = context.rewriteRef(ref, base, prefix)
+					continue
+				}
+				context.rewrite(value.Values[key], base, prefix)
+			}
+		}
+	case []any:
+		{
+			for _, item := range value {
+				context.rewrite(item, base, prefix)
+			}
+		}
+	}
+}
+
+func (context *vendor) rewriteRef(ref string, base *url.URL, prefix string) string {
+	if strings.HasPrefix(ref, "#") {
+		if len(prefix) == 0 {
+			return ref
+		}
+		return "#/$defs/" + prefix + ref[1:]
+	}
+	target, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	resolved := base.ResolveReference(target)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" && (!context.local || resolved.Scheme != "file") {
+		return ref
+	}
+	fragment := resolved.Fragment
+	resolved.Fragment = ""
+	return "#/$defs/" + context.vendorDocument(resolved) + fragment
+}
+
+func (context *vendor) vendorDocument(source *url.URL) string {
+	key := source.String()
+	if name, ok := context.names[key]; ok {
+		return name
+	}
+	name := toVendorName(source)
+	candidate := name
+	for suffix := 2; ; suffix++ {
+		if _, ok := context.defs.Get(candidate); !ok {
+			break
+		}
+		candidate = fmt.Sprintf("%s_%d", name, suffix)
+	}
+	context.names[key] = candidate
+	data, err := context.fetch(key)
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(data)
+	_hash := hex.EncodeToString(hash[:])
+	if locked, ok := context.lock.Sources[key]; ok && locked != _hash {
+		context.diagnosticHandler(Diagnostic{Severity: WARNING, Subject: key, Message: fmt.Sprintf("content changed since it was locked (expected sha256 %s, got %s)", locked, _hash)})
+	}
+	context.lock.Sources[key] = _hash
+	document, err := DecodeJson(data)
+	if err != nil {
+		panic(err)
+	}
+	if object, ok := document.(*JsonObject); ok {
+		draft, _ := DetectDraft(object)
+		normalizeDraft(object, draft, key, context.diagnosticHandler)
+		object.Delete("$id")
+		object.Delete("$schema")
+	}
+	context.defs.Set(candidate, document)
+	context.rewrite(document, source, candidate)
+	context.diagnosticHandler(Diagnostic{Severity: INFO, Subject: key, Message: fmt.Sprintf("vendored as #/$defs/%s", candidate)})
+	return candidate
+}
+
+func toVendorName(source *url.URL) string {
+	stem := path.Base(source.Path)
+	for _, extension := range []string{".json", ".schema", ".yaml", ".yml"} {
+		stem = strings.TrimSuffix(stem, extension)
+	}
+	if len(stem) == 0 || stem == "." || stem == "/" {
+		stem = source.Hostname()
+	}
+	return *fixString(stem)
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const VENDOR_TEST_SCHEMA = `{"$id": "https://acme.com/schemas/order.json", "title": "Order", "type": "object", "properties": {
+	"item": {"$ref": "item.json"},
+	"items": {"type": "array", "items": {"$ref": "item.json"}},
+	"other": {"$ref": "https://other.com/item.json#/definitions/Price"},
+	"local": {"$ref": "#/definitions/Note"}
+}, "definitions": {"Note": {"type": "string"}}}`
+
+var VENDOR_TEST_SOURCES = map[string]string{
+	"https://acme.com/schemas/item.json": `{"$id": "https://acme.com/schemas/item.json", "type": "object", "properties": {"sku": {"$ref": "#/definitions/Sku"}}, "definitions": {"Sku": {"type": "string"}}}`,
+	"https://other.com/item.json":        `{"definitions": {"Price": {"type": "number"}}}`,
+}
+
+func vendorTestFetcher(fetches map[string]int) Fetcher {
+	return func(url string) ([]byte, error) {
+		fetches[url]++
+		if data, ok := VENDOR_TEST_SOURCES[url]; ok {
+			return []byte(data), nil
+		}
+		return nil, fmt.Errorf("%s not found", url)
+	}
+}
+
+func vendorHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestVendor(t *testing.T) {
+	fetches := make(map[string]int)
+	lock := &VendorLock{Sources: make(map[string]string)}
+	diagnostics := make([]Diagnostic, 0)
+	output := Vendor([]byte(VENDOR_TEST_SCHEMA), "", false, lock, vendorTestFetcher(fetches), func(diagnostic Diagnostic) {
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	schema := struct {
+		Properties map[string]struct {
+			Ref   string `json:"$ref"`
+			Items struct {
+				Ref string `json:"$ref"`
+			} `json:"items"`
+		} `json:"properties"`
+		Defs map[string]struct {
+			ID         *string `json:"$id"`
+			Properties map[string]struct {
+				Ref string `json:"$ref"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}{}
+	if err := json.Unmarshal(output, &schema); err != nil {
+		t.Fatal(err)
+	}
+	refs := map[string]string{
+		"item":  schema.Properties["item"].Ref,
+		"items": schema.Properties["items"].Items.Ref,
+		"other": schema.Properties["other"].Ref,
+		"local": schema.Properties["local"].Ref,
+		"sku":   schema.Defs["item"].Properties["sku"].Ref,
+	}
+	expected := map[string]string{
+		"item":  "#/$defs/item",
+		"items": "#/$defs/item",
+		"other": "#/$defs/item_2/definitions/Price",
+		"local": "#/definitions/Note",
+		"sku":   "#/$defs/item/definitions/Sku",
+	}
+	if fmt.Sprint(refs) != fmt.Sprint(expected) {
+		t.Fatalf("expected the refs %v, got %v", expected, refs)
+	}
+	if len(schema.Defs) != 2 || schema.Defs["item"].ID != nil {
+		t.Fatalf("expected item and item_2 without their $id under $defs, got %s", output)
+	}
+	for url, count := range fetches {
+		if count != 1 {
+			t.Fatalf("%s was fetched %d times", url, count)
+		}
+	}
+	for url, data := range VENDOR_TEST_SOURCES {
+		if lock.Sources[url] != vendorHash(data) {
+			t.Fatalf("the lock hash of %s was not recorded: %v", url, lock.Sources)
+		}
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity != INFO {
+			t.Fatalf("unexpected diagnostic %v", diagnostic)
+		}
+	}
+}
+
+func TestVendorLockMismatch(t *testing.T) {
+	lock := &VendorLock{Sources: map[string]string{"https://acme.com/schemas/item.json": vendorHash("{}"), "https://other.com/item.json": vendorHash(VENDOR_TEST_SOURCES["https://other.com/item.json"])}}
+	warnings := make([]Diagnostic, 0)
+	Vendor([]byte(VENDOR_TEST_SCHEMA), "", false, lock, vendorTestFetcher(make(map[string]int)), func(diagnostic Diagnostic) {
+		if diagnostic.Severity == WARNING {
+			warnings = append(warnings, diagnostic)
+		}
+	})
+	if len(warnings) != 1 || warnings[0].Subject != "https://acme.com/schemas/item.json" || !strings.Contains(warnings[0].Message, "content changed") {
+		t.Fatalf("expected one content changed warning for item.json, got %v", warnings)
+	}
+	if lock.Sources["https://acme.com/schemas/item.json"] != vendorHash(VENDOR_TEST_SOURCES["https://acme.com/schemas/item.json"]) {
+		t.Fatalf("the lock was not updated to the fetched content: %v", lock.Sources)
+	}
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type VerifySample struct {
+	Message string
+	Pointer string
+	Value   any
+}
+
+func SchemaSamples(file *ProtoFile, schema []byte) []VerifySample {
+	output := make([]VerifySample, 0)
+	for _, definition := range file.Definitions {
+		if definition.Message == nil || len(definition.Message.Pointer) == 0 {
+			continue
+		}
+		examples, pointers := SchemaExamples(schema, definition.Message.Pointer)
+		for i, example := range examples {
+			output = append(output, VerifySample{Message: definition.Message.Name, Pointer: pointers[i], Value: example})
+		}
+	}
+	return output
+}
+
+func FileSamples(file *ProtoFile, paths []string) ([]VerifySample, error) {
+	output := make([]VerifySample, 0)
+	root := RootMessage(file)
+	if len(root) == 0 {
+		return nil, fmt.Errorf("no root message to verify the samples against")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		err = json.Unmarshal(data, &value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		output = append(output, VerifySample{Message: root, Pointer: path + "#", Value: value})
+	}
+	return output, nil
+}
+
+func VerifyProto(files linker.Files, samples []VerifySample, diagnosticHandler DiagnosticHandler) int {
+	failures := 0
+	for _, sample := range samples {
+		name := protoreflect.FullName(sample.Message)
+		if _package := files[0].Package(); len(_package) != 0 {
+			name = _package.Append(protoreflect.Name(sample.Message))
+		}
+		descriptor, ok := files[0].FindDescriptorByName(name).(protoreflect.MessageDescriptor)
+		if !ok {
+			diagnosticHandler(Diagnostic{Severity: ERROR, Subject: sample.Pointer, Message: fmt.Sprintf("message %s was not found in the compiled proto", name)})
+			failures++
+			continue
+		}
+		failures += verifyValue(descriptor, sample.Value, sample.Pointer, diagnosticHandler)
+	}
+	return failures
+}
+
+func verifyValue(descriptor protoreflect.MessageDescriptor, value any, pointer string, diagnosticHandler DiagnosticHandler) int {
+	object, ok := value.(map[string]any)
+	if !ok || strings.HasPrefix(string(descriptor.FullName()), "google.protobuf.") {
+		return verifyJson(descriptor, value, pointer, diagnosticHandler)
+	}
+	failures := 0
+	for _, key := range sortedKeys(object) {
+		_pointer := pointer + "/" + escapePointer(key)
+		field := descriptor.Fields().ByJSONName(key)
+		if field == nil {
+			field = descriptor.Fields().ByTextName(key)
+		}
+		if field == nil {
+			diagnosticHandler(Diagnostic{Severity: ERROR, Subject: _pointer, Message: fmt.Sprintf("property does not map to any field of %s", descriptor.FullName())})
+			failures++
+			continue
+		}
+		if field.Message() != nil && !field.IsMap() {
+			if array, ok := object[key].([]any); ok && field.IsList() {
+				for i, item := range array {
+					failures += verifyValue(field.Message(), item, fmt.Sprintf("%s/%d", _pointer, i), diagnosticHandler)
+				}
+				continue
+			}
+			if _, ok := object[key].(map[string]any); ok && !field.IsList() {
+				failures += verifyValue(field.Message(), object[key], _pointer, diagnosticHandler)
+				continue
+			}
+		}
+		data, _ := json.Marshal(map[string]any{key: object[key]})
+		err := protojson.Unmarshal(data, dynamicpb.NewMessage(descriptor))
+		if err != nil {
+			diagnosticHandler(Diagnostic{Severity: ERROR, Subject: _pointer, Message: fmt.Sprintf("%s.%s: %s", descriptor.FullName(), field.Name(), err.Error())})
+			failures++
+		}
+	}
+	return failures
+}
+
+func verifyJson(descriptor protoreflect.MessageDescriptor, value any, pointer string, diagnosticHandler DiagnosticHandler) int {
+	data, _ := json.Marshal(value)
+	err := protojson.Unmarshal(data, dynamicpb.NewMessage(descriptor))
+	if err != nil {
+		diagnosticHandler(Diagnostic{Severity: ERROR, Subject: pointer, Message: fmt.Sprintf("%s: %s", descriptor.FullName(), err.Error())})
+		return 1
+	}
+	return 0
+}
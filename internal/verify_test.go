@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const VERIFY_TEST_SCHEMA = `{
+	"title": "Order",
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"quantity": {"type": "integer"},
+		"created": {"$ref": "#/definitions/timestamp"},
+		"items": {"type": "array", "items": {"$ref": "#/definitions/Item"}},
+		"owner": {"$ref": "#/definitions/Item"}
+	},
+	"definitions": {
+		"timestamp": {"$id": "https://schema.org/DateTime", "type": "string"},
+		"Item": {"type": "object", "properties": {"sku": {"type": "string"}}, "examples": [{"sku": 7}]}
+	},
+	"examples": [
+		{"id": "a", "quantity": 2, "created": "2024-01-01T00:00:00Z", "items": [{"sku": "x"}], "owner": {"sku": "y"}},
+		{"id": "b", "quantity": "many", "colour": "red", "created": "yesterday", "items": [{"sku": "x"}, {"sku": 1}]}
+	]
+}`
+
+func TestVerifyProto(t *testing.T) {
+	file := NewWithOptions([]byte(VERIFY_TEST_SCHEMA), DefaultOptions()).Build("test")
+	files, err := CompileSource("order.proto", RenderTarget(file, DefaultOptions()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "valid.json"), []byte(`{"id": "c", "items": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(directory, "invalid.json"), []byte(`{"owner": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	samples, err := FileSamples(file, []string{filepath.Join(directory, "valid.json"), filepath.Join(directory, "invalid.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples = append(SchemaSamples(file, []byte(VERIFY_TEST_SCHEMA)), samples...)
+	samples = append(samples, VerifySample{Message: "Missing", Pointer: "missing.json#", Value: map[string]any{}})
+	expected := []struct {
+		subject string
+		message string
+	}{
+		{"#/definitions/Item/examples/0/sku", "test.Item.sku: "},
+		{"#/examples/1/colour", "property does not map to any field of test.Order"},
+		{"#/examples/1/created", `invalid google.protobuf.Timestamp value "yesterday"`},
+		{"#/examples/1/items/1/sku", "test.Item.sku: "},
+		{"#/examples/1/quantity", "test.Order.quantity: "},
+		{"DIR/invalid.json#/owner", "test.Order.owner: "},
+		{"missing.json#", "message test.Missing was not found in the compiled proto"},
+	}
+	diagnostics := make([]Diagnostic, 0)
+	failures := VerifyProto(files, samples, func(diagnostic Diagnostic) {
+		diagnostic.Subject = strings.ReplaceAll(diagnostic.Subject, directory, "DIR")
+		diagnostics = append(diagnostics, diagnostic)
+	})
+	if failures != len(expected) || len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d failures, got %d: %v", len(expected), failures, diagnostics)
+	}
+	for i, diagnostic := range diagnostics {
+		if diagnostic.Severity != ERROR || diagnostic.Subject != expected[i].subject || !strings.Contains(diagnostic.Message, expected[i].message) {
+			t.Errorf("expected an error at %s containing %q, got %s", expected[i].subject, expected[i].message, diagnostic.String())
+		}
+	}
+}
+
+func TestFileSamples(t *testing.T) {
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "broken.json"), []byte(`{"id": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := NewWithOptions([]byte(VERIFY_TEST_SCHEMA), DefaultOptions()).Build("test")
+	if _, err := FileSamples(file, []string{filepath.Join(directory, "broken.json")}); err == nil || !strings.HasPrefix(err.Error(), filepath.Join(directory, "broken.json")+": ") {
+		t.Fatalf("expected the sample path in the error, got %v", err)
+	}
+	if _, err := FileSamples(file, []string{filepath.Join(directory, "missing.json")}); err == nil {
+		t.Fatalf("expected an error for a missing sample")
+	}
+	if _, err := FileSamples(&ProtoFile{}, nil); err == nil || err.Error() != "no root message to verify the samples against" {
+		t.Fatalf("expected the missing root error, got %v", err)
+	}
+}
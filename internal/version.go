@@ -0,0 +1,3 @@
+package internal
+
+const VERSION = "v0.1.0"
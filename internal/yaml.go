@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type InputFormat string
+
+const (
+	AUTO_INPUT    InputFormat = "auto"
+	JSON_INPUT    InputFormat = "json"
+	YAML_INPUT    InputFormat = "yaml"
+	AVRO_INPUT    InputFormat = "avro"
+	JTD_INPUT     InputFormat = "jtd"
+	GRAPHQL_INPUT InputFormat = "graphql"
+)
+
+func IsYaml(path string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		{
+			return true
+		}
+	case ".json":
+		{
+			return false
+		}
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) != 0 && trimmed[0] != '{' && trimmed[0] != '['
+}
+
+func ToJsonSchema(path string, data []byte, format InputFormat) ([]byte, error) {
+	if format == JSON_INPUT || format == AVRO_INPUT || (format != YAML_INPUT && !IsYaml(path, data)) {
+		return data, nil
+	}
+	output, err := YamlToJson(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return output, nil
+}
+
+func YamlToJson(data []byte) ([]byte, error) {
+	var document yaml.Node
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+	if document.Kind == 0 {
+		return []byte("{}"), nil
+	}
+	value, err := yamlNodeValue(&document)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeJson(value), nil
+}
+
+func yamlNodeValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		{
+			return yamlNodeValue(node.Content[0])
+		}
+	case yaml.AliasNode:
+		{
+			return yamlNodeValue(node.Alias)
+		}
+	case yaml.MappingNode:
+		{
+			object := NewJsonObject()
+			err := yamlMapping(node, object)
+			return object, err
+		}
+	case yaml.SequenceNode:
+		{
+			array := make([]any, 0, len(node.Content))
+			for _, item := range node.Content {
+				value, err := yamlNodeValue(item)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			return array, nil
+		}
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		{
+			return nil, nil
+		}
+	case "!!bool":
+		{
+			var value bool
+			err := node.Decode(&value)
+			return value, err
+		}
+	case "!!int":
+		{
+			var value int64
+			if err := node.Decode(&value); err == nil {
+				return json.Number(strconv.FormatInt(value, 10)), nil
+			}
+			var unsigned uint64
+			if err := node.Decode(&unsigned); err == nil {
+				return json.Number(strconv.FormatUint(unsigned, 10)), nil
+			}
+			var float float64
+			err := node.Decode(&float)
+			return json.Number(strconv.FormatFloat(float, 'g', -1, 64)), err
+		}
+	case "!!float":
+		{
+			var value float64
+			err := node.Decode(&value)
+			if err != nil {
+				return nil, err
+			}
+			if math.IsInf(value, 0) || math.IsNaN(value) {
+				return nil, fmt.Errorf("line %d: %s has no json representation", node.Line, node.Value)
+			}
+			return json.Number(strconv.FormatFloat(value, 'g', -1, 64)), nil
+		}
+	}
+	return node.Value, nil
+}
+
+func yamlMapping(node *yaml.Node, object *JsonObject) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() == "!!merge" {
+			err := yamlMerge(value, object)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		_value, err := yamlNodeValue(value)
+		if err != nil {
+			return err
+		}
+		object.Set(key.Value, _value)
+	}
+	return nil
+}
+
+func yamlMerge(node *yaml.Node, object *JsonObject) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			err := yamlMerge(item, object)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: merge keys must reference mappings", node.Line)
+	}
+	merged := NewJsonObject()
+	err := yamlMapping(node, merged)
+	if err != nil {
+		return err
+	}
+	for _, key := range merged.Keys {
+		if _, ok := object.Get(key); !ok {
+			object.Set(key, merged.Values[key])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestYamlToJson(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"type: object\nproperties:\n  name: {type: string}\n", `{"type":"object","properties":{"name":{"type":"string"}}}`},
+		{"base: &base\n  type: string\nname:\n  <<: *base\n  minLength: 1\n", `{"base":{"type":"string"},"name":{"type":"string","minLength":1}}`},
+		{"count: 10\nhex: 0x1F\nratio: 1.5\nflag: true\nempty:\n", `{"count":10,"hex":31,"ratio":1.5,"flag":true,"empty":null}`},
+		{"maximum: 18446744073709551615\n", `{"maximum":18446744073709551615}`},
+		{"", `{}`},
+	}
+	for _, test := range tests {
+		output, err := YamlToJson([]byte(test.yaml))
+		if err != nil {
+			t.Fatalf("%q: %v", test.yaml, err)
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, output); err != nil {
+			t.Fatalf("%q: %v", test.yaml, err)
+		}
+		if compact.String() != test.expected {
+			t.Fatalf("%q: expected %s, got %s", test.yaml, test.expected, output)
+		}
+	}
+	if _, err := YamlToJson([]byte("value: .inf\n")); err == nil {
+		t.Fatal("infinity was converted to json")
+	}
+}
+
+func TestIsYaml(t *testing.T) {
+	tests := []struct {
+		path     string
+		data     string
+		expected bool
+	}{
+		{"schema.yaml", `{"type": "object"}`, true},
+		{"schema.json", "type: object", false},
+		{"-", "type: object", true},
+		{"-", `  {"type": "object"}`, false},
+	}
+	for _, test := range tests {
+		if IsYaml(test.path, []byte(test.data)) != test.expected {
+			t.Fatalf("%s %q: expected %v", test.path, test.data, test.expected)
+		}
+	}
+}